@@ -2,30 +2,43 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/joho/godotenv"
 	"github.com/abelclopes/nomad-iabot/internal/agent"
 	"github.com/abelclopes/nomad-iabot/internal/channels"
 	"github.com/abelclopes/nomad-iabot/internal/config"
+	"github.com/abelclopes/nomad-iabot/internal/devops"
+	"github.com/abelclopes/nomad-iabot/internal/feedback"
 	"github.com/abelclopes/nomad-iabot/internal/gateway"
+	"github.com/abelclopes/nomad-iabot/internal/httpx"
+	"github.com/abelclopes/nomad-iabot/internal/i18n"
+	"github.com/abelclopes/nomad-iabot/internal/redact"
+	"github.com/abelclopes/nomad-iabot/internal/shutdown"
+	"github.com/abelclopes/nomad-iabot/internal/stream"
+	"github.com/abelclopes/nomad-iabot/internal/trello"
+	"github.com/joho/godotenv"
 )
 
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "0.1.0"
+
 func main() {
 	// Load .env file if exists
 	_ = godotenv.Load()
 
 	// Setup structured logging
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	logger := slog.New(redact.NewHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
-	}))
+	})))
 	slog.SetDefault(logger)
 
-	slog.Info("🚀 Starting Nomad Agent", "version", "0.1.0")
+	slog.Info("🚀 Starting Nomad Agent", "version", version)
 
 	// Load configuration
 	cfg, err := config.Load()
@@ -34,6 +47,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	logSetup(cfg)
+
+	// Mask credentials in any log line or error that echoes them
+	redact.Register(cfg.Secrets()...)
+
+	userAgent := cfg.HTTP.UserAgent
+	if userAgent == "" {
+		userAgent = "nomad-iabot/" + version
+	}
+	httpx.SetUserAgent(userAgent)
+
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -49,9 +73,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Make sure the configured model is available before accepting traffic
+	if err := aiAgent.EnsureModel(ctx); err != nil {
+		slog.Error("LLM model check failed", "error", err)
+		os.Exit(1)
+	}
+
 	// Message handler using the agent
 	messageHandler := func(ctx context.Context, msg channels.IncomingMessage) (string, error) {
-		return aiAgent.ProcessMessage(ctx, msg.UserID, msg.Channel, msg.Text)
+		if msg.Metadata[channels.MetadataPlan] != "true" {
+			return aiAgent.ProcessMessage(ctx, msg.UserID, msg.Channel, msg.Text)
+		}
+		res, err := aiAgent.ProcessMessageWithAttachments(agent.WithPlanMode(ctx, agent.PlanShow), msg.UserID, msg.Channel, msg.Text, nil)
+		if err != nil || res.Plan == "" {
+			return res.Response, err
+		}
+		return i18n.T(cfg.I18n.Locale, "plan.header", res.Plan) + res.Response, nil
 	}
 
 	// Create and start gateway
@@ -63,27 +100,93 @@ func main() {
 
 	// Setup WebChat channel
 	webchat := channels.NewWebChatChannel(logger, messageHandler)
+	webchat.SetInputLimits(cfg.InputLimits())
+	streamHandler := func(ctx context.Context, msg channels.IncomingMessage, emit func(stream.Event)) (string, error) {
+		return aiAgent.ProcessMessageStream(ctx, msg.UserID, msg.Channel, msg.Text, emit)
+	}
+	webchat.SetStreamHandler(streamHandler)
+	webchat.SetIdentities(aiAgent.GetIdentities())
 	gw.RegisterWebChat(webchat)
 
 	// Start webchat session cleanup routine
 	go webchat.StartCleanupRoutine(ctx, 5*time.Minute, 1*time.Hour)
 
 	// Start Telegram bot if configured
+	var telegramBot *channels.TelegramChannel
 	if cfg.Telegram.BotToken != "" {
-		telegramBot, err := channels.NewTelegramChannel(&cfg.Telegram, logger, messageHandler)
+		telegramBot, err = channels.NewTelegramChannel(&cfg.Telegram, logger, messageHandler)
 		if err != nil {
 			slog.Error("Failed to create Telegram bot", "error", err)
 		} else {
+			telegramBot.SetInputLimits(cfg.InputLimits())
+			telegramBot.SetUsageTracker(aiAgent.GetUsageTracker())
+			telegramBot.SetLocale(cfg.I18n.Locale)
+			telegramBot.SetGreeting(cfg.Agent.Greeting, aiAgent.Capabilities())
+			telegramBot.SetPlanModeEnabled(true)
+			telegramBot.SetStreamHandler(streamHandler)
+			telegramBot.SetIdentities(aiAgent.GetIdentities())
+			telegramBot.SetStatusHandler(aiAgent.Health)
+			if fb := aiAgent.GetFeedbackService(); fb != nil {
+				telegramBot.SetFeedbackHandler(func(ctx context.Context, msg channels.IncomingMessage) (string, error) {
+					result, err := fb.Submit(ctx, feedback.Report{
+						UserID:  msg.UserID,
+						Channel: msg.Channel,
+						Text:    msg.Text,
+					})
+					if err != nil {
+						return "", err
+					}
+					return result.URL, nil
+				})
+			}
+			if aiAgent.GetConversationStore() != nil {
+				telegramBot.SetExportHandler(func(ctx context.Context, msg channels.IncomingMessage, format string) (string, error) {
+					return aiAgent.ExportConversation(ctx, aiAgent.GetIdentities().Resolve(msg.Channel, msg.UserID), format)
+				})
+			}
+			// /newitem creates work items, which read-only mode forbids
+			if dc := aiAgent.GetDevOpsClient(); dc != nil && !cfg.Security.ReadOnly {
+				telegramBot.SetNewItemHandler(func(ctx context.Context, msg channels.IncomingMessage, form channels.WorkItemForm) (string, error) {
+					item, err := dc.CreateWorkItem(ctx, devops.WorkItemCreateRequest{
+						Type:        form.Type,
+						Title:       form.Title,
+						Description: form.Description,
+					})
+					if err != nil {
+						return "", err
+					}
+					return dc.WorkItemWebURL(item.ID), nil
+				})
+			}
+			if chatID := cfg.Trello.WebhookNotifyChat; chatID != "" {
+				gw.OnTrelloEvent(func(ctx context.Context, event trello.WebhookEvent) {
+					text := trello.FormatWebhookEvent(event)
+					if text == "" {
+						return
+					}
+					if err := telegramBot.SendMessage(chatID, text); err != nil {
+						slog.Error("Failed to send Trello notification", "error", err)
+					}
+				})
+			}
+			if chatID := cfg.AzureDevOps.WebhookNotifyChat; chatID != "" {
+				gw.OnDevOpsEvent(func(ctx context.Context, event devops.ServiceHookEvent) {
+					if err := telegramBot.SendMessage(chatID, devops.FormatServiceHookEvent(event)); err != nil {
+						slog.Error("Failed to send Azure DevOps notification", "error", err)
+					}
+				})
+			}
 			go telegramBot.Start(ctx)
 			slog.Info("Telegram bot started")
 		}
 	}
 
 	// Start gateway in goroutine
+	gatewayFailed := make(chan struct{})
 	go func() {
-		if err := gw.Start(ctx); err != nil {
+		if err := gw.Start(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			slog.Error("Gateway error", "error", err)
-			cancel()
+			close(gatewayFailed)
 		}
 	}()
 
@@ -92,13 +195,50 @@ func main() {
 	)
 
 	// Wait for shutdown signal
-	<-sigChan
+	select {
+	case <-sigChan:
+	case <-gatewayFailed:
+	}
 	slog.Info("Shutting down gracefully...")
-	cancel()
 
-	if err := gw.Shutdown(ctx); err != nil {
+	// Stop taking work first and let what is in flight finish; cancelling
+	// ctx earlier would abort the messages being answered
+	stages := []shutdown.Stage{
+		{Name: "gateway", Stop: gw.Shutdown},
+		{Name: "agent", Stop: aiAgent.Drain},
+		{Name: "background", Stop: func(context.Context) error {
+			cancel()
+			return nil
+		}},
+	}
+	if telegramBot != nil {
+		stages = append(stages, shutdown.Stage{Name: "telegram", Stop: telegramBot.Shutdown})
+	}
+	stages = append(stages, shutdown.Stage{Name: "stores", Stop: func(context.Context) error {
+		return aiAgent.Close()
+	}})
+
+	timeout := time.Duration(cfg.Gateway.ShutdownTimeoutSec) * time.Second
+	if err := shutdown.Run(context.Background(), logger, timeout, stages...); err != nil {
 		slog.Error("Error during shutdown", "error", err)
 	}
 
 	slog.Info("Nomad Agent stopped")
 }
+
+// logSetup reports which integrations are active and what enables the
+// others, so a first run without configuration explains itself
+func logSetup(cfg *config.Config) {
+	for _, st := range cfg.Integrations() {
+		if st.Enabled {
+			slog.Info("integration enabled", "integration", st.Name)
+		} else {
+			slog.Info("integration disabled", "integration", st.Name, "set", st.Missing)
+		}
+	}
+	if !cfg.HasToolIntegrations() {
+		slog.Warn("no integrations configured: the agent can only chat. " +
+			"Set AZURE_DEVOPS_ORGANIZATION and AZURE_DEVOPS_PAT for Azure DevOps, " +
+			"or TRELLO_ENABLED, TRELLO_API_KEY and TRELLO_TOKEN for Trello (see .env.example)")
+	}
+}
@@ -6,14 +6,31 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/abelclopes/nomad-iabot/internal/breaker"
+	"github.com/abelclopes/nomad-iabot/internal/caller"
+	"github.com/abelclopes/nomad-iabot/internal/clock"
 	"github.com/abelclopes/nomad-iabot/internal/config"
 	"github.com/abelclopes/nomad-iabot/internal/devops"
+	"github.com/abelclopes/nomad-iabot/internal/feedback"
+	"github.com/abelclopes/nomad-iabot/internal/i18n"
+	"github.com/abelclopes/nomad-iabot/internal/identity"
 	"github.com/abelclopes/nomad-iabot/internal/llm"
 	"github.com/abelclopes/nomad-iabot/internal/skills"
+	"github.com/abelclopes/nomad-iabot/internal/stream"
 	"github.com/abelclopes/nomad-iabot/internal/trello"
+	"github.com/abelclopes/nomad-iabot/internal/usage"
 )
 
+// ToolProvider supplies a group of tools to the agent. Execute reports
+// handled=false for names the provider does not own.
+type ToolProvider interface {
+	GetToolDefinitions() []llm.Tool
+	Execute(ctx context.Context, name string, args map[string]interface{}) (string, bool, error)
+}
+
 // Agent is the core AI agent that processes messages and executes tools
 type Agent struct {
 	config          *config.Config
@@ -24,12 +41,54 @@ type Agent struct {
 	skillsValidator *skills.Validator
 	trelloClient *trello.Client
 	trelloTool   *trello.Tool
+	tools        []ToolProvider
+	toolTimeout  time.Duration
+	feedback     *feedback.Service
+	usage        *usage.Tracker
+
+	llmBreaker    *breaker.Breaker
+	devopsBreaker *breaker.Breaker
+	trelloBreaker *breaker.Breaker
+
+	clock    clock.Clock
+	started  time.Time      // reported as uptime by Health
+	location *time.Location // zone for the date given to the model
+
+	conversations ConversationStore // nil when memory is disabled
+
+	overridesMu sync.Mutex // serializes tool toggles and their persistence
+
+	limiter *limiter // caps concurrent message processing
+
+	toolStats toolStats // tool call outcomes per model, see ToolStats
+
+	credentialsMu      sync.Mutex
+	expiredCredentials map[string]CredentialStatus // by integration, see noteCredentials
+
+	pingMu  sync.Mutex // serializes LLM pings, see pingLLM
+	pingAt  time.Time  // when the last ping ran
+	pingErr error      // its outcome
+
+	identities *identity.Store // links channel user IDs to one user
 }
 
 // New creates a new Agent instance
-func New(cfg *config.Config, logger *slog.Logger) (*Agent, error) {
+func New(cfg *config.Config, logger *slog.Logger, opts ...Option) (*Agent, error) {
 	// Create LLM client
 	llmClient := llm.NewClient(cfg.LLM.BaseURL, cfg.LLM.Model, cfg.LLM.APIKey, cfg.LLM.TimeoutSec)
+	llmClient.SetConnectionPool(cfg.LLM.MaxIdleConns, cfg.LLM.MaxIdleConnsPerHost, time.Duration(cfg.LLM.IdleConnTimeoutSec)*time.Second)
+	llmClient.SetLatencyWindow(time.Duration(cfg.LLM.LatencyWindowSec) * time.Second)
+	if cfg.LLM.CacheEnabled {
+		llmClient.SetCache(llm.NewResponseCache(time.Duration(cfg.LLM.CacheTTLSec)*time.Second, cfg.LLM.CacheMaxTemperature))
+	}
+
+	if cfg.LLM.Debug {
+		llmClient.SetDebugLogger(logger)
+	}
+
+	cooldown := time.Duration(cfg.Breaker.CooldownSec) * time.Second
+	llmBreaker := breaker.New("llm", cfg.Breaker.FailureThreshold, cooldown)
+	llmClient.SetBreaker(llmBreaker)
 
 	// Initialize skills validator
 	skillsValidator := skills.NewValidator()
@@ -39,6 +98,34 @@ func New(cfg *config.Config, logger *slog.Logger) (*Agent, error) {
 		logger:          logger,
 		llmClient:       llmClient,
 		skillsValidator: skillsValidator,
+		toolTimeout:     time.Duration(cfg.Tools.CallTimeoutSec) * time.Second,
+		usage:           usage.NewTracker(usage.NewMemoryStore(), cfg.Usage.DailyTokenLimit),
+		llmBreaker:      llmBreaker,
+		clock:           clock.Real(),
+		started:         time.Now(),
+		location:        time.Local,
+		limiter:         newLimiter(cfg.Agent.MaxConcurrency, time.Duration(cfg.Agent.QueueWaitSec)*time.Second),
+	}
+
+	if cfg.Agent.MemoryMessages > 0 {
+		agent.conversations = NewMemoryConversationStore(cfg.Agent.MemoryMessages)
+	}
+
+	if tz := cfg.I18n.Timezone; tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", tz, err)
+		}
+		agent.location = loc
+	}
+
+	links, err := identity.ParseLinks(cfg.Agent.IdentityLinks)
+	if err != nil {
+		return nil, err
+	}
+	agent.identities, err = identity.NewStore(links, cfg.Agent.IdentityLinksFile)
+	if err != nil {
+		return nil, err
 	}
 
 	// Initialize Azure DevOps client if configured
@@ -49,8 +136,20 @@ func New(cfg *config.Config, logger *slog.Logger) (*Agent, error) {
 			cfg.AzureDevOps.PAT,
 			cfg.AzureDevOps.APIVersion,
 		)
+		devopsClient.SetPipelineBranches(cfg.AzureDevOps.PipelineBranches)
+		agent.devopsBreaker = breaker.New("azure_devops", cfg.Breaker.FailureThreshold, cooldown)
+		devopsClient.SetBreaker(agent.devopsBreaker)
 		agent.devopsClient = devopsClient
 		agent.devopsTool = devops.NewTool(devopsClient)
+		agent.devopsTool.SetLocation(agent.location)
+		agent.devopsTool.SetSnippetChars(cfg.Tools.Limits.SnippetChars)
+		agent.devopsTool.SetCreateDefaults(cfg.AzureDevOps.DefaultWorkItemType, cfg.AzureDevOps.DefaultPriority)
+		templates, err := devops.LoadQueryTemplates(cfg.AzureDevOps.QueryTemplates)
+		if err != nil {
+			return nil, err
+		}
+		agent.devopsTool.SetQueryTemplates(templates)
+		agent.tools = append(agent.tools, agent.devopsTool)
 		
 		// Register allowed DevOps commands
 		skillsValidator.RegisterCommands(skills.GetAllowedDevOpsCommands())
@@ -64,24 +163,157 @@ func New(cfg *config.Config, logger *slog.Logger) (*Agent, error) {
 	// Initialize Trello client if configured
 	if cfg.Trello.Enabled && cfg.Trello.APIKey != "" && cfg.Trello.Token != "" {
 		trelloClient := trello.NewClient(cfg.Trello.APIKey, cfg.Trello.Token)
+		trelloClient.SetRateLimit(cfg.Trello.RateLimit, time.Duration(cfg.Trello.RateWindowSec)*time.Second)
+		agent.trelloBreaker = breaker.New("trello", cfg.Breaker.FailureThreshold, cooldown)
+		trelloClient.SetBreaker(agent.trelloBreaker)
 		agent.trelloClient = trelloClient
 		agent.trelloTool = trello.NewTool(trelloClient)
+		agent.trelloTool.SetLocation(agent.location)
+		agent.trelloTool.SetWIPLimit(cfg.Trello.WIPLimit)
+		agent.tools = append(agent.tools, agent.trelloTool)
+
+		// Register allowed Trello commands
+		skillsValidator.RegisterCommands(skills.GetAllowedTrelloCommands())
+
 		logger.Info("Trello integration enabled")
 	}
 
+	// Cross-integration tools, offered only when both sides are configured
+	if agent.devopsClient != nil && agent.trelloClient != nil {
+		agent.tools = append(agent.tools, &bridgeTool{devops: agent.devopsClient, trello: agent.trelloClient})
+		skillsValidator.RegisterCommands(skills.GetAllowedBridgeCommands())
+	}
+
+	if err := agent.loadToolOverrides(); err != nil {
+		return nil, err
+	}
+	agent.warnUnknownToolConfig()
+
+	agent.setupFeedback()
+
+	for _, opt := range opts {
+		opt(agent)
+	}
+
 	return agent, nil
 }
 
+// setupFeedback wires the feedback service to the configured integration,
+// leaving it disabled when that integration is not available
+func (a *Agent) setupFeedback() {
+	switch a.config.Feedback.Target {
+	case "devops":
+		if a.devopsClient == nil {
+			a.logger.Warn("feedback target is devops but Azure DevOps is not configured; feedback disabled")
+			return
+		}
+		client := a.devopsClient
+		if p := a.config.Feedback.Project; p != "" && p != a.config.AzureDevOps.Project {
+			client = devops.NewClient(
+				a.config.AzureDevOps.Organization,
+				p,
+				a.config.AzureDevOps.PAT,
+				a.config.AzureDevOps.APIVersion,
+			)
+			client.SetBreaker(a.devopsBreaker)
+		}
+		a.feedback = feedback.NewDevOpsService(client, a.config.Feedback.WorkItemType)
+	case "trello":
+		if a.trelloClient == nil {
+			a.logger.Warn("feedback target is trello but Trello is not configured; feedback disabled")
+			return
+		}
+		a.feedback = feedback.NewTrelloService(a.trelloClient, a.config.Feedback.TrelloListID)
+	default:
+		return
+	}
+	a.logger.Info("feedback enabled", "target", a.config.Feedback.Target)
+}
+
 // ProcessMessage processes an incoming message and returns a response
 func (a *Agent) ProcessMessage(ctx context.Context, userID, channel, message string) (string, error) {
+	res, err := a.process(ctx, userID, channel, message, nil, nil)
+	return res.Response, err
+}
+
+// ProcessMessageWithUsage processes a message and also returns the tokens
+// consumed across every LLM call it made
+func (a *Agent) ProcessMessageWithUsage(ctx context.Context, userID, channel, message string) (string, llm.Usage, error) {
+	res, err := a.process(ctx, userID, channel, message, nil, nil)
+	return res.Response, res.Usage, err
+}
+
+// Result is the outcome of processing one message
+type Result struct {
+	Response  string
+	Usage     llm.Usage       // tokens consumed across every LLM call
+	ToolCalls []ToolCallTrace // tools executed, in order
+	Plan      string          // intended actions, when plan mode was requested
+}
+
+// ToolCallTrace records one tool execution
+type ToolCallTrace struct {
+	Name       string `json:"name"`
+	Arguments  string `json:"arguments,omitempty"` // JSON as produced by the LLM
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"` // generic; the details are only logged
+}
+
+// process runs the LLM/tool loop, reporting tool progress and the streamed
+// content to emit when set, and records the accumulated token usage for
+// userID. The returned Result
+// carries the usage and tool trace even when err is set.
+func (a *Agent) process(ctx context.Context, userID, channel, message string, attachments []Attachment, emit func(stream.Event)) (Result, error) {
+	var res Result
+
+	// Linked identities share memory, usage and limits from here on
+	userID = a.identities.Resolve(channel, userID)
+
+	// The LLM sees the normalized text; the original is kept for the audit log
+	normalized := skills.NormalizeInput(message)
+
 	a.logger.Info("processing message",
 		"user_id", userID,
 		"channel", channel,
 		"message_length", len(message),
+		"normalized_length", len(normalized),
+		"attachments", len(attachments),
 	)
+	if normalized != message {
+		a.logger.Debug("message normalized", "user_id", userID, "channel", channel, "original", message)
+	}
+
+	// Nothing left to ask (e.g. only a bot mention or invisible characters)
+	if normalized == "" && len(attachments) == 0 {
+		res.Response = i18n.T(a.config.I18n.Locale, "error.empty")
+		return res, nil
+	}
+
+	release, err := a.limiter.acquire(ctx)
+	if err != nil {
+		a.logger.Warn("rejected message, agent is at capacity",
+			"user_id", userID,
+			"channel", channel,
+			"error", err,
+		)
+		return res, err
+	}
+	defer release()
+
+	// Tools read the caller to attribute the actions they take
+	ctx = caller.With(ctx, caller.Caller{UserID: userID, Channel: channel})
+
+	if a.usage.LimitReached(userID) {
+		a.logger.Warn("daily usage limit reached", "user_id", userID, "channel", channel)
+		res.Response = i18n.T(a.config.I18n.Locale, "usage.daily_limit")
+		return res, nil
+	}
+	defer func() {
+		a.usage.Record(userID, res.Usage)
+	}()
 
 	// Detect prompt injection attempts
-	if skills.DetectPromptInjection(message) {
+	if skills.DetectPromptInjection(normalized) {
 		a.logger.Warn("potential prompt injection detected",
 			"user_id", userID,
 			"channel", channel,
@@ -90,36 +322,61 @@ func (a *Agent) ProcessMessage(ctx context.Context, userID, channel, message str
 	}
 
 	// Sanitize input to prevent prompt injection
-	sanitizedMessage := skills.SanitizeInput(message)
+	sanitizedMessage := skills.SanitizeInput(normalized)
 
 	// Build system prompt
-	systemPrompt := a.buildSystemPrompt()
+	systemPrompt := a.buildSystemPrompt(channel)
 
-	// Build messages - use sanitized message
-	messages := []llm.Message{
-		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: sanitizedMessage},
-	}
+	// Build messages - use sanitized message, after any remembered turns
+	convKey := conversationKeyFor(ctx, userID)
+	userMsg := a.userMessage(sanitizedMessage, attachments)
+	messages := []llm.Message{{Role: "system", Content: systemPrompt}}
+	messages = append(messages, a.loadHistory(ctx, convKey)...)
+	messages = append(messages, userMsg)
 
 	// Get available tools
-	tools := a.getAvailableTools()
+	tools := a.getAvailableTools(channel)
 
 	// Build chat options
 	var opts []llm.ChatOption
 	if len(tools) > 0 {
 		opts = append(opts, llm.WithTools(tools))
 	}
+	opts = append(opts, a.chatOptions(ctx)...)
+	if emit != nil {
+		opts = append(opts, llm.WithStreamHandler(func(delta string) {
+			emit(stream.Event{Type: stream.Content, Content: delta})
+		}))
+	}
+
+	// Explain the intended tool calls first when asked to
+	if mode := planMode(ctx); mode != PlanOff && len(tools) > 0 {
+		plan, u, err := a.explainPlan(ctx, messages, tools)
+		addUsage(&res.Usage, u)
+		if err != nil {
+			a.logger.Error("LLM plan request failed", "error", err)
+			return res, fmt.Errorf("failed to process message: %w", err)
+		}
+		res.Plan = plan
+		if mode == PlanOnly {
+			res.Response = plan
+			return res, nil
+		}
+	}
 
 	// Get initial response
 	resp, err := a.llmClient.Chat(ctx, messages, opts...)
+	if resp != nil {
+		addUsage(&res.Usage, resp.Usage)
+	}
 	if err != nil {
 		a.logger.Error("LLM request failed", "error", err)
-		return "", fmt.Errorf("failed to process message: %w", err)
+		return res, fmt.Errorf("failed to process message: %w", err)
 	}
 
 	// Check if we have choices
 	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response from LLM")
+		return res, fmt.Errorf("no response from LLM")
 	}
 
 	choice := resp.Choices[0]
@@ -135,81 +392,239 @@ func (a *Agent) ProcessMessage(ctx context.Context, userID, channel, message str
 			Content: choice.Message.Content,
 		})
 
-		// Execute each tool call
+		// Execute each tool call. Identical calls in one turn run once and
+		// share the result.
+		results := make(map[string]string)
 		for _, tc := range choice.ToolCalls {
+			key := toolCallKey(tc)
+			if result, ok := results[key]; ok {
+				a.logger.Info("skipping duplicate tool call", "name", tc.Function.Name, "iteration", i+1)
+				messages = append(messages, llm.Message{
+					Role:    "tool",
+					Content: result,
+				})
+				continue
+			}
+
+			if emit != nil {
+				emit(stream.Event{Type: stream.ToolStart, Tool: tc.Function.Name})
+			}
+			start := time.Now()
+
 			result, err := a.executeTool(ctx, tc.Function.Name, tc.Function.Arguments)
+			a.recordToolCall(resp, tc.Function.Name, err)
 			if err != nil {
 				result = fmt.Sprintf("Error executing tool: %s", err.Error())
 			}
 
+			trace := ToolCallTrace{
+				Name:       tc.Function.Name,
+				Arguments:  tc.Function.Arguments,
+				DurationMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				// The LLM gets the details; API and chat clients a generic message
+				a.logger.Warn("tool call failed", "name", tc.Function.Name, "error", err)
+				trace.Error = toolErrorMessage(err)
+			}
+			res.ToolCalls = append(res.ToolCalls, trace)
+
+			if emit != nil {
+				emit(stream.Event{Type: stream.ToolEnd, Tool: trace.Name, DurationMs: trace.DurationMs, Error: trace.Error})
+			}
+
 			// Add tool result
+			results[key] = result
 			messages = append(messages, llm.Message{
 				Role:    "tool",
 				Content: result,
 			})
 		}
 
-		// Get next response
-		resp, err = a.llmClient.Chat(ctx, messages, opts...)
+		// Get next response. On the last iteration the model must answer
+		// with what it has instead of asking for more tools.
+		nextOpts := opts
+		if i == maxIterations-1 {
+			nextOpts = append(opts[:len(opts):len(opts)], llm.WithToolChoice(llm.ToolChoiceNone))
+		}
+		// The nudge only goes with this request, so it does not pile up
+		// over iterations
+		next := messages
+		if nudge := a.postToolNudge(); nudge != "" {
+			next = append(messages[:len(messages):len(messages)], llm.Message{Role: "system", Content: nudge})
+		}
+		resp, err = a.llmClient.Chat(ctx, next, nextOpts...)
+		if resp != nil {
+			addUsage(&res.Usage, resp.Usage)
+		}
 		if err != nil {
 			a.logger.Error("LLM request failed during tool processing", "error", err)
-			return "", fmt.Errorf("failed to process tool results: %w", err)
+			return res, fmt.Errorf("failed to process tool results: %w", err)
 		}
 
 		if len(resp.Choices) == 0 {
-			return "", fmt.Errorf("no response from LLM")
+			return res, fmt.Errorf("no response from LLM")
 		}
 		choice = resp.Choices[0]
 	}
 
-	return choice.Message.Content, nil
+	res.Response = choice.Message.Content
+
+	// A caller that went away mid-response must not leave its turn in the
+	// history
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	a.remember(ctx, convKey, userMsg, res.Response)
+	return res, nil
+}
+
+// postToolNudge returns the system message sent after tool results, or ""
+// when AGENT_POST_TOOL_NUDGE is off
+func (a *Agent) postToolNudge() string {
+	if !a.config.Agent.PostToolNudge {
+		return ""
+	}
+	if a.config.Agent.PostToolPrompt != "" {
+		return a.config.Agent.PostToolPrompt
+	}
+	return i18n.T(a.config.I18n.Locale, "agent.post_tool")
+}
+
+// addUsage accumulates the token counts of one LLM call into total
+func addUsage(total *llm.Usage, u llm.Usage) {
+	total.PromptTokens += u.PromptTokens
+	total.CompletionTokens += u.CompletionTokens
+	if u.TotalTokens > 0 {
+		total.TotalTokens += u.TotalTokens
+	} else {
+		total.TotalTokens += u.PromptTokens + u.CompletionTokens
+	}
+}
+
+// weekdaysPtBR names the weekday in the language of the system prompt
+var weekdaysPtBR = [...]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"}
+
+// Capability keys reported by Capabilities; channels localize them as "capability.<key>"
+const (
+	CapabilityChat   = "chat"
+	CapabilityCode   = "code"
+	CapabilityDevOps = "devops"
+	CapabilityTrello = "trello"
+)
+
+// capabilityPrompts describes each capability to the model
+var capabilityPrompts = map[string]string{
+	CapabilityChat:   "Responder perguntas de forma clara e objetiva",
+	CapabilityCode:   "Ajudar com tarefas de programação e desenvolvimento",
+	CapabilityDevOps: "Gerenciar projetos no Azure DevOps (work items, pipelines, repositórios)",
+	CapabilityTrello: "Gerenciar boards, listas e cards no Trello",
+}
+
+// Capabilities lists what the agent can do with the tools it actually has,
+// in the order they are presented to the model and to users
+func (a *Agent) Capabilities() []string {
+	// Go by the tools the model is offered, so integrations whose tools are
+	// all turned off by TOOLS_DISABLED or READ_ONLY are not advertised
+	var devops, trello bool
+	for _, def := range a.toolDefinitions() {
+		switch {
+		case strings.HasPrefix(def.Function.Name, "devops_"):
+			devops = true
+		case strings.HasPrefix(def.Function.Name, "trello_"):
+			trello = true
+		}
+	}
+
+	caps := []string{CapabilityChat, CapabilityCode}
+	if devops {
+		caps = append(caps, CapabilityDevOps)
+	}
+	if trello {
+		caps = append(caps, CapabilityTrello)
+	}
+	return caps
+}
+
+// defaultPersona and defaultGuidelines frame the system prompt unless
+// AGENT_SYSTEM_PROMPT or a per-channel prompt replaces them
+const (
+	defaultPersona    = "Você é o Nomad Agent, um assistente AI inteligente e prestativo."
+	defaultGuidelines = `## Diretrizes
+- Seja conciso e direto nas respostas
+- Use formatação Markdown quando apropriado
+- Quando usar ferramentas, explique o que está fazendo
+- Responda no idioma do usuário
+`
+)
+
+// customPrompt returns the configured prompt for channel, falling back to
+// the global AGENT_SYSTEM_PROMPT ("" when neither is set)
+func (a *Agent) customPrompt(channel string) string {
+	if p := a.config.Agent.ChannelPrompts[channel]; p != "" {
+		return p
+	}
+	return a.config.Agent.SystemPrompt
 }
 
-// buildSystemPrompt creates the system prompt for the agent
-func (a *Agent) buildSystemPrompt() string {
+// buildSystemPrompt creates the system prompt for a message received on
+// channel. The integration sections are always included.
+func (a *Agent) buildSystemPrompt(channel string) string {
 	var sb strings.Builder
 
-	sb.WriteString("Você é o Nomad Agent, um assistente AI inteligente e prestativo.\n\n")
+	persona, guidelines := defaultPersona, defaultGuidelines
+	if custom := strings.TrimSpace(a.customPrompt(channel)); custom != "" {
+		persona, guidelines = custom, ""
+	}
+
+	sb.WriteString(persona + "\n\n")
 	sb.WriteString("## Suas Capacidades\n")
-	sb.WriteString("- Responder perguntas de forma clara e objetiva\n")
-	sb.WriteString("- Ajudar com tarefas de programação e desenvolvimento\n")
+	for _, c := range a.Capabilities() {
+		sb.WriteString("- " + capabilityPrompts[c] + "\n")
+	}
 
 	if a.devopsClient != nil {
-		sb.WriteString("- Gerenciar projetos no Azure DevOps (work items, pipelines, repositórios)\n")
 		sb.WriteString("\n## Azure DevOps\n")
 		sb.WriteString(fmt.Sprintf("Organização: %s\n", a.config.AzureDevOps.Organization))
 		sb.WriteString(fmt.Sprintf("Projeto padrão: %s\n", a.config.AzureDevOps.Project))
 	}
 
 	if a.trelloClient != nil {
-		sb.WriteString("- Gerenciar boards, listas e cards no Trello\n")
 		sb.WriteString("\n## Trello\n")
 		sb.WriteString("Você pode criar, atualizar e consultar boards, listas e cards do Trello.\n")
 	}
 
-	sb.WriteString("\n## Diretrizes\n")
-	sb.WriteString("- Seja conciso e direto nas respostas\n")
-	sb.WriteString("- Use formatação Markdown quando apropriado\n")
-	sb.WriteString("- Quando usar ferramentas, explique o que está fazendo\n")
-	sb.WriteString("- Responda no idioma do usuário\n")
+	sb.WriteString("\n## Data e Hora\n")
+	now := a.clock.Now().In(a.location)
+	// Only the date: a prompt that changed every minute would defeat the
+	// response cache, which keys on the whole request
+	sb.WriteString(fmt.Sprintf("Hoje: %s (%s), fuso horário %s (UTC%s)\n",
+		now.Format("2006-01-02"), weekdaysPtBR[now.Weekday()], a.location, now.Format("-07:00")))
+	sb.WriteString("Use esta data para interpretar \"hoje\", \"ontem\", \"esta semana\" e para calcular datas de entrega em ISO 8601.\n")
+
+	if guidelines != "" {
+		sb.WriteString("\n" + guidelines)
+	}
+
+	if a.config.Tools.Guidance {
+		sb.WriteString(toolGuidance(a.getAvailableTools(channel)))
+	}
 
 	return sb.String()
 }
 
-// getAvailableTools returns the list of available tools
-func (a *Agent) getAvailableTools() []llm.Tool {
+// getAvailableTools returns the list of tools available on channel
+func (a *Agent) getAvailableTools(channel string) []llm.Tool {
 	var tools []llm.Tool
-
-	// Add DevOps tools if available
-	if a.devopsTool != nil {
-		tools = append(tools, a.devopsTool.GetToolDefinitions()...)
-	}
-
-	// Add Trello tools if available
-	if a.trelloTool != nil {
-		tools = append(tools, a.trelloTool.GetToolDefinitions()...)
+	for _, def := range a.toolDefinitions() {
+		if a.skillsValidator.IsRegistered(def.Function.Name) && !a.skillsValidator.IsCommandAllowed(def.Function.Name) {
+			continue // disabled at runtime
+		}
+		if !a.allowedOnChannel(channel, def.Function.Name) {
+			continue
+		}
+		tools = append(tools, def)
 	}
-
 	return tools
 }
 
@@ -225,38 +640,73 @@ func (a *Agent) executeTool(ctx context.Context, name string, arguments string)
 		)
 		return "", fmt.Errorf("operation not permitted")
 	}
+	if a.disabledByConfig(name) {
+		a.logger.Warn("tool disabled by configuration", "command", name)
+		return "", fmt.Errorf("operation not permitted")
+	}
+
+	// The model only sees the channel's tools, but may still name others
+	if c, ok := caller.From(ctx); ok && !a.allowedOnChannel(c.Channel, name) {
+		a.logger.Warn("tool not allowed on channel", "command", name, "channel", c.Channel)
+		return "", fmt.Errorf("%s is not available on %s; the user can ask for it on another channel", name, c.Channel)
+	}
 
 	// Parse arguments
 	var args map[string]interface{}
 	if arguments != "" {
 		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
-			return "", fmt.Errorf("failed to parse arguments: %w", err)
+			return "", &malformedArgumentsError{err}
 		}
 	}
 
-	// Execute DevOps tools
-	if a.devopsTool != nil {
-		result, handled, err := a.devopsTool.Execute(ctx, name, args)
+	for _, p := range a.tools {
+		result, handled, err := a.runTool(ctx, p, name, args)
 		if handled {
+			err = a.noteCredentials(name, err)
 			if err != nil {
 				return "", err
 			}
-			return result, nil
+			return truncateResult(result, a.config.Tools.Limits.ResultBytes), nil
 		}
 	}
 
-	// Execute Trello tools
-	if a.trelloTool != nil {
-		result, handled, err := a.trelloTool.Execute(ctx, name, args)
-		if handled {
-			if err != nil {
-				return "", err
-			}
-			return result, nil
-		}
+	return "", fmt.Errorf("unknown tool: %s", name)
+}
+
+// runTool executes a tool call within the per-tool budget. A call that runs
+// past the budget is reported as handled with a timeout error, which process
+// hands back to the LLM as the tool result instead of failing the request.
+func (a *Agent) runTool(ctx context.Context, p ToolProvider, name string, args map[string]interface{}) (string, bool, error) {
+	if a.toolTimeout <= 0 {
+		return p.Execute(ctx, name, args)
 	}
 
-	return "", fmt.Errorf("unknown tool: %s", name)
+	toolCtx, cancel := context.WithTimeout(ctx, a.toolTimeout)
+	defer cancel()
+
+	type outcome struct {
+		result  string
+		handled bool
+		err     error
+	}
+	// Buffered so a tool that ignores its context can still finish and exit
+	done := make(chan outcome, 1)
+	go func() {
+		result, handled, err := p.Execute(toolCtx, name, args)
+		done <- outcome{result, handled, err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.handled, o.err
+	case <-toolCtx.Done():
+		if ctx.Err() != nil {
+			// The request itself was cancelled, not just this tool
+			return "", true, ctx.Err()
+		}
+		a.logger.Warn("tool call timed out", "name", name, "timeout", a.toolTimeout)
+		return "", true, fmt.Errorf("tool %s timed out after %s; the service may be slow, try a narrower request or tell the user", name, a.toolTimeout)
+	}
 }
 
 // GetDevOpsClient returns the Azure DevOps client
@@ -264,6 +714,23 @@ func (a *Agent) GetDevOpsClient() *devops.Client {
 	return a.devopsClient
 }
 
+// GetDevOpsBreaker returns the circuit breaker shared by Azure DevOps clients,
+// or nil when the breaker or the integration is disabled
+func (a *Agent) GetDevOpsBreaker() *breaker.Breaker {
+	return a.devopsBreaker
+}
+
+// Breakers returns the enabled circuit breakers
+func (a *Agent) Breakers() []*breaker.Breaker {
+	var breakers []*breaker.Breaker
+	for _, b := range []*breaker.Breaker{a.llmBreaker, a.devopsBreaker, a.trelloBreaker} {
+		if b != nil {
+			breakers = append(breakers, b)
+		}
+	}
+	return breakers
+}
+
 // GetDevOpsTool returns the Azure DevOps tool
 func (a *Agent) GetDevOpsTool() *devops.Tool {
 	return a.devopsTool
@@ -279,7 +746,28 @@ func (a *Agent) GetTrelloTool() *trello.Tool {
 	return a.trelloTool
 }
 
+// GetFeedbackService returns the feedback service, or nil when disabled
+func (a *Agent) GetFeedbackService() *feedback.Service {
+	return a.feedback
+}
+
 // GetLLMClient returns the LLM client
 func (a *Agent) GetLLMClient() *llm.Client {
 	return a.llmClient
 }
+
+// GetUsageTracker returns the per-user usage tracker
+func (a *Agent) GetUsageTracker() *usage.Tracker {
+	return a.usage
+}
+
+// UsageFor returns the usage of a channel's user, counted under the
+// identity that user is linked to
+func (a *Agent) UsageFor(channel, userID string) usage.Stats {
+	return a.usage.Get(a.identities.Resolve(channel, userID))
+}
+
+// GetIdentities returns the store that links channel user IDs to one user
+func (a *Agent) GetIdentities() *identity.Store {
+	return a.identities
+}
@@ -0,0 +1,553 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+	"github.com/abelclopes/nomad-iabot/internal/config"
+	"github.com/abelclopes/nomad-iabot/internal/i18n"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+	"github.com/abelclopes/nomad-iabot/internal/trello"
+	"github.com/abelclopes/nomad-iabot/internal/usage"
+)
+
+// newTestAgent returns an agent whose LLM requests are served by handler
+func newTestAgent(t *testing.T, handler http.HandlerFunc) *Agent {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		LLM: config.LLMConfig{
+			BaseURL:    srv.URL,
+			Model:      "test-model",
+			TimeoutSec: 5,
+		},
+	}
+
+	a, err := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return a
+}
+
+// respondChat writes an OpenAI-compatible chat completion with the given content
+func respondChat(w http.ResponseWriter, content string, toolCalls ...llm.ToolCall) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(llm.ChatResponse{
+		Choices: []llm.Choice{{
+			Message:   llm.Message{Role: "assistant", Content: content},
+			ToolCalls: toolCalls,
+		}},
+	})
+}
+
+func TestProcessMessageWithUsage(t *testing.T) {
+	var calls int
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(llm.ChatResponse{
+			Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "Olá!"}}},
+			Usage:   llm.Usage{PromptTokens: 40, CompletionTokens: 10, TotalTokens: 50},
+		})
+	})
+	a.usage = usage.NewTracker(usage.NewMemoryStore(), 100)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		response, u, err := a.ProcessMessageWithUsage(ctx, "alice", "test", "Oi")
+		if err != nil {
+			t.Fatalf("ProcessMessageWithUsage() error = %v", err)
+		}
+		if response != "Olá!" || u.TotalTokens != 50 {
+			t.Errorf("ProcessMessageWithUsage() = %q, %+v", response, u)
+		}
+	}
+
+	if st := a.GetUsageTracker().Get("test:alice"); st.Requests != 2 || st.TotalTokens != 100 {
+		t.Errorf("usage for alice = %+v", st)
+	}
+
+	// The cap is reached: the LLM must not be called again
+	response, err := a.ProcessMessage(ctx, "alice", "test", "Oi de novo")
+	if err != nil || response != i18n.T(i18n.Fallback, "usage.daily_limit") {
+		t.Errorf("ProcessMessage() = %q, %v; expected daily limit message", response, err)
+	}
+	if calls != 2 {
+		t.Errorf("LLM calls = %d, expected 2", calls)
+	}
+}
+
+// slowTool never returns on its own, ignoring its context like a tool stuck in a chain of calls
+type slowTool struct {
+	release chan struct{}
+}
+
+func (s *slowTool) GetToolDefinitions() []llm.Tool {
+	return []llm.Tool{{
+		Type:     "function",
+		Function: llm.ToolFunction{Name: "slow_tool", Description: "A deliberately slow tool"},
+	}}
+}
+
+func (s *slowTool) Execute(ctx context.Context, name string, args map[string]interface{}) (string, bool, error) {
+	if name != "slow_tool" {
+		return "", false, nil
+	}
+	<-s.release
+	return "too late", true, nil
+}
+
+func TestToolCallTimeout(t *testing.T) {
+	var calls int
+	var toolResult string
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			respondChat(w, "", llm.ToolCall{
+				ID:       "call_1",
+				Type:     "function",
+				Function: llm.ToolCallFunction{Name: "slow_tool", Arguments: "{}"},
+			})
+			return
+		}
+
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, m := range req.Messages {
+			if m.Role == "tool" {
+				toolResult = m.Content
+			}
+		}
+		respondChat(w, "O serviço está lento, tente novamente mais tarde.")
+	})
+
+	tool := &slowTool{release: make(chan struct{})}
+	t.Cleanup(func() { close(tool.release) })
+	a.tools = append(a.tools, tool)
+	a.skillsValidator.RegisterCommands([]string{"slow_tool"})
+	a.toolTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	response, err := a.ProcessMessage(context.Background(), "alice", "test", "Rode a ferramenta lenta")
+	if err != nil {
+		t.Fatalf("ProcessMessage() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("ProcessMessage() took %s, expected the tool to be cut off", elapsed)
+	}
+	if response != "O serviço está lento, tente novamente mais tarde." {
+		t.Errorf("ProcessMessage() = %q", response)
+	}
+	if !strings.Contains(toolResult, "slow_tool timed out") {
+		t.Errorf("tool result sent to LLM = %q, expected a timeout message", toolResult)
+	}
+}
+
+func TestAttachmentMessageShaping(t *testing.T) {
+	png := Attachment{Name: "erro.png", ContentType: "image/png", Data: []byte("\x89PNG fake")}
+	log := Attachment{Name: "app.log", ContentType: "text/plain", Data: []byte("panic: boom")}
+
+	var userContent json.RawMessage
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Role    string          `json:"role"`
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, m := range req.Messages {
+			if m.Role == "user" {
+				userContent = m.Content
+			}
+		}
+		respondChat(w, "ok")
+	})
+
+	t.Run("Vision model", func(t *testing.T) {
+		a.config.LLM.Vision = true
+		if _, err := a.ProcessMessageWithAttachments(context.Background(), "alice", "api", "Crie um bug para este erro", []Attachment{png, log}); err != nil {
+			t.Fatalf("ProcessMessageWithAttachments() error = %v", err)
+		}
+
+		var parts []llm.ContentPart
+		if err := json.Unmarshal(userContent, &parts); err != nil {
+			t.Fatalf("user content = %s, expected a content array: %v", userContent, err)
+		}
+		if len(parts) != 2 {
+			t.Fatalf("got %d parts, expected text and image: %s", len(parts), userContent)
+		}
+		if parts[0].Type != "text" || !strings.Contains(parts[0].Text, "Crie um bug") || !strings.Contains(parts[0].Text, "[attachment: app.log]") {
+			t.Errorf("text part = %+v", parts[0])
+		}
+		want := "data:image/png;base64," + base64.StdEncoding.EncodeToString(png.Data)
+		if parts[1].Type != "image_url" || parts[1].ImageURL == nil || parts[1].ImageURL.URL != want {
+			t.Errorf("image part = %+v", parts[1])
+		}
+	})
+
+	t.Run("Text-only model", func(t *testing.T) {
+		a.config.LLM.Vision = false
+		if _, err := a.ProcessMessageWithAttachments(context.Background(), "alice", "api", "Veja", []Attachment{png}); err != nil {
+			t.Fatalf("ProcessMessageWithAttachments() error = %v", err)
+		}
+
+		var content string
+		if err := json.Unmarshal(userContent, &content); err != nil {
+			t.Fatalf("user content = %s, expected a string: %v", userContent, err)
+		}
+		if content != "Veja\n\n[attachment: erro.png]" {
+			t.Errorf("user content = %q", content)
+		}
+	})
+}
+
+// fakeTools answers every call to its tools with a fixed result
+type fakeTools struct {
+	names []string
+}
+
+func (f *fakeTools) GetToolDefinitions() []llm.Tool {
+	var tools []llm.Tool
+	for _, name := range f.names {
+		tools = append(tools, llm.Tool{Type: "function", Function: llm.ToolFunction{Name: name}})
+	}
+	return tools
+}
+
+func (f *fakeTools) Execute(ctx context.Context, name string, args map[string]interface{}) (string, bool, error) {
+	for _, n := range f.names {
+		if n == name {
+			return name + " ok", true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func TestProcessReturnsToolTrace(t *testing.T) {
+	var calls int
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			respondChat(w, "",
+				llm.ToolCall{ID: "1", Type: "function", Function: llm.ToolCallFunction{Name: "list_items", Arguments: `{"state":"Active"}`}},
+				llm.ToolCall{ID: "2", Type: "function", Function: llm.ToolCallFunction{Name: "not_allowed", Arguments: "{}"}},
+			)
+		case 2:
+			respondChat(w, "", llm.ToolCall{ID: "3", Type: "function", Function: llm.ToolCallFunction{Name: "get_item", Arguments: `{"id":1}`}})
+		default:
+			respondChat(w, "Pronto")
+		}
+	})
+	a.tools = append(a.tools, &fakeTools{names: []string{"list_items", "get_item"}})
+	a.skillsValidator.RegisterCommands([]string{"list_items", "get_item"})
+
+	res, err := a.ProcessMessageWithAttachments(context.Background(), "alice", "api", "Liste e detalhe", nil)
+	if err != nil {
+		t.Fatalf("ProcessMessageWithAttachments() error = %v", err)
+	}
+	if res.Response != "Pronto" {
+		t.Errorf("Response = %q", res.Response)
+	}
+
+	want := []ToolCallTrace{
+		{Name: "list_items", Arguments: `{"state":"Active"}`},
+		{Name: "not_allowed", Arguments: "{}", Error: "tool call failed"},
+		{Name: "get_item", Arguments: `{"id":1}`},
+	}
+	if len(res.ToolCalls) != len(want) {
+		t.Fatalf("ToolCalls = %+v, expected %d entries", res.ToolCalls, len(want))
+	}
+	for i, w := range want {
+		got := res.ToolCalls[i]
+		got.DurationMs = 0
+		if got != w {
+			t.Errorf("ToolCalls[%d] = %+v, expected %+v", i, got, w)
+		}
+	}
+}
+
+func TestPostToolNudgeOnlyFollowsToolResults(t *testing.T) {
+	var requests [][]llm.Message
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req.Messages)
+		if len(requests) == 1 {
+			respondChat(w, "", llm.ToolCall{ID: "1", Type: "function", Function: llm.ToolCallFunction{Name: "list_items", Arguments: "{}"}})
+			return
+		}
+		respondChat(w, "Você tem 3 itens ativos.")
+	})
+	a.config.Agent.PostToolNudge = true
+	a.config.I18n.Locale = "en"
+	a.tools = append(a.tools, &fakeTools{names: []string{"list_items"}})
+	a.skillsValidator.RegisterCommands([]string{"list_items"})
+
+	if _, err := a.ProcessMessage(context.Background(), "alice", "api", "Liste meus itens"); err != nil {
+		t.Fatalf("ProcessMessage() error = %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("sent %d requests, expected 2", len(requests))
+	}
+
+	nudge := i18n.T("en", "agent.post_tool")
+	for _, m := range requests[0] {
+		if m.Content == nudge {
+			t.Error("initial request carries the post-tool nudge")
+		}
+	}
+	last := requests[1][len(requests[1])-1]
+	if last.Role != "system" || last.Content != nudge {
+		t.Errorf("post-tool request ends with %s %q, expected the nudge", last.Role, last.Content)
+	}
+}
+
+func TestProcessEndsRunawayToolLoop(t *testing.T) {
+	var calls int
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var req struct {
+			ToolChoice string `json:"tool_choice"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.ToolChoice == llm.ToolChoiceNone {
+			respondChat(w, "Resumo final")
+			return
+		}
+		respondChat(w, "", llm.ToolCall{ID: "1", Type: "function", Function: llm.ToolCallFunction{Name: "list_items", Arguments: "{}"}})
+	})
+	a.tools = append(a.tools, &fakeTools{names: []string{"list_items"}})
+	a.skillsValidator.RegisterCommands([]string{"list_items"})
+
+	res, err := a.ProcessMessageWithAttachments(context.Background(), "alice", "api", "Liste tudo", nil)
+	if err != nil {
+		t.Fatalf("ProcessMessageWithAttachments() error = %v", err)
+	}
+	if res.Response != "Resumo final" {
+		t.Errorf("Response = %q, want the answer forced with tool_choice none", res.Response)
+	}
+	if calls != 11 || len(res.ToolCalls) != 10 {
+		t.Errorf("LLM calls = %d, tool calls = %d; want the loop capped at 10 iterations", calls, len(res.ToolCalls))
+	}
+}
+
+func TestSystemPromptIncludesCurrentDate(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	a.location = loc
+	// 02:30 UTC on a Saturday is still Friday evening in São Paulo
+	a.clock = clock.NewFake(time.Date(2024, 3, 16, 2, 30, 0, 0, time.UTC))
+
+	prompt := a.buildSystemPrompt("api")
+	for _, want := range []string{"Hoje: 2024-03-15", "sexta-feira", "America/Sao_Paulo", "UTC-03:00"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("system prompt is missing %q:\n%s", want, prompt)
+		}
+	}
+
+	// The prompt stays the same all day, so cached responses keep matching
+	a.clock = clock.NewFake(time.Date(2024, 3, 16, 2, 47, 0, 0, time.UTC))
+	if later := a.buildSystemPrompt("api"); later != prompt {
+		t.Errorf("system prompt changed within the day:\n%s\nthen\n%s", prompt, later)
+	}
+}
+
+func TestCapabilitiesFollowEnabledTools(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{
+		LLM:    config.LLMConfig{BaseURL: "http://localhost", Model: "test-model", TimeoutSec: 5},
+		Trello: config.TrelloConfig{Enabled: true, APIKey: "key", Token: "token"},
+	}
+
+	a, err := New(cfg, logger)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if caps := a.Capabilities(); !reflect.DeepEqual(caps, []string{CapabilityChat, CapabilityCode, CapabilityTrello}) {
+		t.Errorf("Capabilities() = %v, want Trello listed", caps)
+	}
+
+	cfg.Trello.Enabled = false
+	a, err = New(cfg, logger)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if caps := a.Capabilities(); !reflect.DeepEqual(caps, []string{CapabilityChat, CapabilityCode}) {
+		t.Errorf("Capabilities() with Trello disabled = %v", caps)
+	}
+	if strings.Contains(a.buildSystemPrompt("api"), "Trello") {
+		t.Error("system prompt mentions Trello while it is disabled")
+	}
+}
+
+func TestCapabilitiesSkipIntegrationsWithoutTools(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{
+		LLM:    config.LLMConfig{BaseURL: "http://localhost", Model: "test-model", TimeoutSec: 5},
+		Trello: config.TrelloConfig{Enabled: true, APIKey: "key", Token: "token"},
+	}
+	a, err := New(cfg, logger)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// READ_ONLY keeps the Trello tools that only read
+	cfg.Security.ReadOnly = true
+	if caps := a.Capabilities(); !reflect.DeepEqual(caps, []string{CapabilityChat, CapabilityCode, CapabilityTrello}) {
+		t.Errorf("Capabilities() in read-only mode = %v, want Trello listed", caps)
+	}
+
+	for _, def := range a.toolDefinitions() {
+		if strings.HasPrefix(def.Function.Name, "trello_") {
+			cfg.Tools.Disabled = append(cfg.Tools.Disabled, def.Function.Name)
+		}
+	}
+	if caps := a.Capabilities(); !reflect.DeepEqual(caps, []string{CapabilityChat, CapabilityCode}) {
+		t.Errorf("Capabilities() with every Trello tool disabled = %v", caps)
+	}
+}
+
+func TestSystemPromptPerChannel(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {})
+	a.trelloClient = trello.NewClient("key", "token")
+	a.config.Agent.ChannelPrompts = map[string]string{
+		"telegram": "Responda em no máximo duas frases.",
+	}
+
+	telegram := a.buildSystemPrompt("telegram")
+	if !strings.HasPrefix(telegram, "Responda em no máximo duas frases.") {
+		t.Errorf("telegram prompt does not start with the channel override:\n%s", telegram)
+	}
+	if strings.Contains(telegram, "## Diretrizes") || strings.Contains(telegram, defaultPersona) {
+		t.Errorf("telegram prompt kept the default persona:\n%s", telegram)
+	}
+
+	webchat := a.buildSystemPrompt("webchat")
+	if !strings.HasPrefix(webchat, defaultPersona) || !strings.Contains(webchat, "## Diretrizes") {
+		t.Errorf("webchat prompt is not the default:\n%s", webchat)
+	}
+
+	for name, prompt := range map[string]string{"telegram": telegram, "webchat": webchat} {
+		for _, want := range []string{"## Suas Capacidades", "## Trello", "## Data e Hora"} {
+			if !strings.Contains(prompt, want) {
+				t.Errorf("%s prompt is missing %q", name, want)
+			}
+		}
+	}
+
+	a.config.Agent.SystemPrompt = "Você é um assistente formal."
+	if got := a.buildSystemPrompt("webchat"); !strings.HasPrefix(got, "Você é um assistente formal.") {
+		t.Errorf("webchat did not fall back to AGENT_SYSTEM_PROMPT:\n%s", got)
+	}
+}
+
+func TestResponseCacheHonorsConfiguredTemperature(t *testing.T) {
+	for _, tc := range []struct {
+		temperature float64
+		wantCalls   int
+	}{
+		{temperature: 0.7, wantCalls: 2},
+		{temperature: 0.2, wantCalls: 1},
+	} {
+		var calls int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req llm.ChatRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Temperature != tc.temperature {
+				t.Errorf("request temperature = %v, want %v", req.Temperature, tc.temperature)
+			}
+			calls++
+			respondChat(w, "ok")
+		}))
+		defer srv.Close()
+
+		cfg := &config.Config{LLM: config.LLMConfig{
+			BaseURL:             srv.URL,
+			Model:               "test-model",
+			TimeoutSec:          5,
+			Temperature:         tc.temperature,
+			CacheEnabled:        true,
+			CacheTTLSec:         60,
+			CacheMaxTemperature: 0.3,
+		}}
+		a, err := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		a.clock = clock.NewFake(time.Date(2024, 3, 16, 9, 0, 0, 0, time.UTC))
+
+		for i := 0; i < 2; i++ {
+			if _, err := a.ProcessMessage(context.Background(), "anonymous", "api", "Oi"); err != nil {
+				t.Fatalf("ProcessMessage() error = %v", err)
+			}
+		}
+		if calls != tc.wantCalls {
+			t.Errorf("temperature %v: LLM called %d times, want %d", tc.temperature, calls, tc.wantCalls)
+		}
+	}
+}
+
+func TestTrelloHasABreaker(t *testing.T) {
+	cfg := &config.Config{
+		LLM:     config.LLMConfig{BaseURL: "http://localhost", Model: "test-model", TimeoutSec: 5},
+		Trello:  config.TrelloConfig{Enabled: true, APIKey: "key", Token: "token"},
+		Breaker: config.BreakerConfig{FailureThreshold: 3, CooldownSec: 30},
+	}
+	a, err := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var names []string
+	for _, b := range a.Breakers() {
+		names = append(names, b.Name())
+	}
+	if !reflect.DeepEqual(names, []string{"llm", "trello"}) {
+		t.Errorf("breakers = %v, want llm and trello", names)
+	}
+}
+
+func TestEmptyMessageAfterNormalizationSkipsTheLLM(t *testing.T) {
+	var calls int
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		respondChat(w, "Olá!")
+	})
+	var logs bytes.Buffer
+	a.logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	response, err := a.ProcessMessage(context.Background(), "u1", "telegram", "@mybot \u200b")
+	if err != nil {
+		t.Fatalf("ProcessMessage() error = %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("LLM called %d times for an empty message", calls)
+	}
+	if want := i18n.T(i18n.Fallback, "error.empty"); response != want {
+		t.Errorf("response = %q, want %q", response, want)
+	}
+	// The original text is kept for the audit log
+	if !strings.Contains(logs.String(), "original=") || !strings.Contains(logs.String(), "@mybot") {
+		t.Errorf("logs = %s, want the original message", logs.String())
+	}
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+	"github.com/abelclopes/nomad-iabot/internal/skills"
+)
+
+// Attachment is a file sent along with a user message
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// ProcessMessageWithAttachments processes a message that carries files and
+// returns the full Result, including the tools that were executed. Images
+// are passed to the model when LLM_VISION is enabled; every other
+// attachment is only mentioned by name.
+func (a *Agent) ProcessMessageWithAttachments(ctx context.Context, userID, channel, message string, attachments []Attachment) (Result, error) {
+	return a.process(ctx, userID, channel, message, attachments, nil)
+}
+
+// userMessage builds the user turn, shaping attachments for the model
+func (a *Agent) userMessage(text string, attachments []Attachment) llm.Message {
+	msg := llm.Message{Role: "user", Content: text}
+	if len(attachments) == 0 {
+		return msg
+	}
+
+	var images []llm.ContentPart
+	var mentions []string
+	for _, att := range attachments {
+		if a.config.LLM.Vision && strings.HasPrefix(strings.ToLower(att.ContentType), "image/") {
+			images = append(images, llm.ImagePart(att.ContentType, base64.StdEncoding.EncodeToString(att.Data)))
+			continue
+		}
+		mentions = append(mentions, fmt.Sprintf("[attachment: %s]", skills.SanitizeInput(att.Name)))
+	}
+
+	if len(mentions) > 0 {
+		msg.Content = strings.TrimSpace(text + "\n\n" + strings.Join(mentions, "\n"))
+	}
+	if len(images) > 0 {
+		msg.Parts = append([]llm.ContentPart{llm.TextPart(msg.Content)}, images...)
+	}
+	return msg
+}
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/abelclopes/nomad-iabot/internal/devops"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+	"github.com/abelclopes/nomad-iabot/internal/trello"
+)
+
+// maxCardDescription stays under Trello's 16384-character card description
+// limit, leaving room for the traceability footer
+const maxCardDescription = 15000
+
+// bridgeFields are the work item fields copied to the card
+var bridgeFields = []string{"System.Title", "System.Description", "System.WorkItemType", "System.State"}
+
+// bridgeTool provides the tools that work across Azure DevOps and Trello.
+// Either client may be nil when its integration is not configured.
+type bridgeTool struct {
+	devops *devops.Client
+	trello *trello.Client
+}
+
+func (b *bridgeTool) GetToolDefinitions() []llm.Tool {
+	return []llm.Tool{
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "bridge_workitem_to_card",
+				Description: "Create a Trello card from an Azure DevOps work item, copying its title and description. The card links back to the work item.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"work_item_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "The Azure DevOps work item ID",
+						},
+						"list_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The Trello list to create the card on",
+						},
+					},
+					"required": []string{"work_item_id", "list_id"},
+				},
+			},
+		},
+	}
+}
+
+func (b *bridgeTool) Execute(ctx context.Context, name string, args map[string]interface{}) (string, bool, error) {
+	switch name {
+	case "bridge_workitem_to_card":
+		result, err := b.workItemToCard(ctx, args)
+		return result, true, err
+	default:
+		return "", false, nil
+	}
+}
+
+func (b *bridgeTool) workItemToCard(ctx context.Context, args map[string]interface{}) (string, error) {
+	switch {
+	case b.devops == nil:
+		return "", fmt.Errorf("Azure DevOps is not configured; bridging needs both Azure DevOps and Trello")
+	case b.trello == nil:
+		return "", fmt.Errorf("Trello is not configured; bridging needs both Azure DevOps and Trello")
+	}
+
+	id, err := devops.RequireInt(args, "work_item_id")
+	if err != nil {
+		return "", err
+	}
+	listID, _ := args["list_id"].(string)
+	if listID == "" {
+		return "", fmt.Errorf("list_id is required")
+	}
+
+	item, err := b.devops.GetWorkItemView(ctx, id, devops.WorkItemView{Fields: bridgeFields})
+	if err != nil {
+		return "", fmt.Errorf("failed to get work item %d: %w", id, err)
+	}
+	workItemURL := b.devops.WorkItemWebURL(id)
+
+	card, err := b.trello.CreateCard(ctx, trello.CreateCardRequest{
+		Name:   fieldString(item, "System.Title"),
+		Desc:   bridgeCardDescription(item, workItemURL),
+		ListID: listID,
+		// Bridging the same work item to the same list again returns the
+		// first card instead of a duplicate
+		IdempotencyKey: fmt.Sprintf("bridge-%d-%s", id, listID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create Trello card: %w", err)
+	}
+
+	cardURL := card.ShortURL
+	if cardURL == "" {
+		cardURL = card.URL
+	}
+	return fmt.Sprintf("Created Trello card %q from work item #%d.\nWork item: %s\nCard: %s\n",
+		card.Name, id, workItemURL, cardURL), nil
+}
+
+// bridgeCardDescription is the work item description as plain text,
+// followed by the work item ID and link for traceability
+func bridgeCardDescription(item *devops.WorkItem, workItemURL string) string {
+	desc := strings.TrimSpace(devops.HTMLToText(fieldString(item, "System.Description")))
+	if r := []rune(desc); len(r) > maxCardDescription {
+		desc = string(r[:maxCardDescription]) + "…"
+	}
+
+	kind := fieldString(item, "System.WorkItemType")
+	if kind == "" {
+		kind = "Work item"
+	}
+	footer := fmt.Sprintf("Azure DevOps %s #%d: %s", kind, item.ID, workItemURL)
+	if desc == "" {
+		return footer
+	}
+	return desc + "\n\n---\n" + footer
+}
+
+// fieldString returns a work item field as a string ("" when missing)
+func fieldString(item *devops.WorkItem, field string) string {
+	s, _ := item.Fields[field].(string)
+	return s
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/devops"
+	"github.com/abelclopes/nomad-iabot/internal/trello"
+)
+
+// redirectTransport sends every request to srv, keeping the path and query
+type redirectTransport struct {
+	srv *httptest.Server
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, _ := url.Parse(rt.srv.URL)
+	req.URL.Scheme = target.Scheme
+	req.URL.Host = target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestBridgeWorkItemToCard(t *testing.T) {
+	var card url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/workitems/42"):
+			if fields := r.URL.Query().Get("fields"); !strings.Contains(fields, "System.Description") {
+				t.Errorf("fields = %q, want the description", fields)
+			}
+			w.Write([]byte(`{"id":42,"fields":{"System.Title":"Login fails on Safari","System.WorkItemType":"Bug",
+				"System.State":"Active","System.Description":"<div>Steps:<br>1. Open <b>login</b></div><p>2. Submit</p>"}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/1/cards":
+			card = r.URL.Query()
+			w.Write([]byte(`{"id":"c1","name":"Login fails on Safari","shortUrl":"https://trello.com/c/AbCd1234"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	httpClient := &http.Client{Transport: redirectTransport{srv}}
+
+	dc := devops.NewClient("contoso", "Web", "pat", "7.0")
+	dc.SetHTTPClient(httpClient)
+	tc := trello.NewClient("key", "token")
+	tc.SetHTTPClient(httpClient)
+	bridge := &bridgeTool{devops: dc, trello: tc}
+
+	result, handled, err := bridge.Execute(context.Background(), "bridge_workitem_to_card",
+		map[string]interface{}{"work_item_id": float64(42), "list_id": "list1"})
+	if err != nil || !handled {
+		t.Fatalf("Execute() = %v, %v", handled, err)
+	}
+
+	if got := card.Get("name"); got != "Login fails on Safari" {
+		t.Errorf("card name = %q", got)
+	}
+	if got := card.Get("idList"); got != "list1" {
+		t.Errorf("card list = %q", got)
+	}
+	desc := card.Get("desc")
+	for _, want := range []string{"Steps:", "1. Open login", "2. Submit", "Azure DevOps Bug #42", "https://dev.azure.com/contoso/Web/_workitems/edit/42"} {
+		if !strings.Contains(desc, want) {
+			t.Errorf("card description missing %q:\n%s", want, desc)
+		}
+	}
+	if strings.Contains(desc, "<") {
+		t.Errorf("card description still has HTML:\n%s", desc)
+	}
+	for _, want := range []string{"https://dev.azure.com/contoso/Web/_workitems/edit/42", "https://trello.com/c/AbCd1234"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestBridgeNeedsBothIntegrations(t *testing.T) {
+	args := map[string]interface{}{"work_item_id": float64(42), "list_id": "list1"}
+
+	bridge := &bridgeTool{trello: trello.NewClient("key", "token")}
+	if _, _, err := bridge.Execute(context.Background(), "bridge_workitem_to_card", args); err == nil || !strings.Contains(err.Error(), "Azure DevOps is not configured") {
+		t.Errorf("without Azure DevOps error = %v", err)
+	}
+
+	bridge = &bridgeTool{devops: devops.NewClient("contoso", "Web", "pat", "7.0")}
+	if _, _, err := bridge.Execute(context.Background(), "bridge_workitem_to_card", args); err == nil || !strings.Contains(err.Error(), "Trello is not configured") {
+		t.Errorf("without Trello error = %v", err)
+	}
+}
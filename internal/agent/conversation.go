@@ -0,0 +1,245 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/abelclopes/nomad-iabot/internal/i18n"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+	"github.com/abelclopes/nomad-iabot/internal/skills"
+)
+
+// ConversationStore persists conversation turns per user identity so the
+// agent remembers earlier messages. MemoryConversationStore is the default;
+// deployments can inject their own backend with WithConversationStore.
+// Keys are canonical user identities: "channel:id" for a user of one
+// channel, so a conversation only follows the user to channels they linked.
+type ConversationStore interface {
+	// Append adds messages to the end of the conversation for key
+	Append(ctx context.Context, key string, msgs ...llm.Message) error
+	// Load returns the conversation for key, oldest first (nil when unknown)
+	Load(ctx context.Context, key string) ([]llm.Message, error)
+	// Reset forgets the conversation for key
+	Reset(ctx context.Context, key string) error
+	// List returns the keys that have a conversation
+	List(ctx context.Context) ([]string, error)
+}
+
+// MemoryConversationStore keeps conversations in memory
+type MemoryConversationStore struct {
+	mu          sync.Mutex
+	maxMessages int
+	convs       map[string][]llm.Message
+}
+
+// NewMemoryConversationStore creates an empty store that keeps the last
+// maxMessages messages per conversation (0 keeps everything)
+func NewMemoryConversationStore(maxMessages int) *MemoryConversationStore {
+	return &MemoryConversationStore{
+		maxMessages: maxMessages,
+		convs:       make(map[string][]llm.Message),
+	}
+}
+
+// Append implements ConversationStore
+func (s *MemoryConversationStore) Append(ctx context.Context, key string, msgs ...llm.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conv := append(s.convs[key], msgs...)
+	if s.maxMessages > 0 && len(conv) > s.maxMessages {
+		conv = append([]llm.Message(nil), conv[len(conv)-s.maxMessages:]...)
+	}
+	s.convs[key] = conv
+	return nil
+}
+
+// Load implements ConversationStore
+func (s *MemoryConversationStore) Load(ctx context.Context, key string) ([]llm.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]llm.Message(nil), s.convs[key]...), nil
+}
+
+// Reset implements ConversationStore
+func (s *MemoryConversationStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.convs, key)
+	return nil
+}
+
+// List implements ConversationStore
+func (s *MemoryConversationStore) List(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.convs))
+	for k := range s.convs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Option customizes an Agent created by New
+type Option func(*Agent)
+
+// WithConversationStore makes the agent remember conversations in store
+// instead of the default in-memory store
+func WithConversationStore(store ConversationStore) Option {
+	return func(a *Agent) {
+		a.conversations = store
+	}
+}
+
+// conversationKey returns the key a conversation is stored under for the
+// canonical user returned by identity.Store.Resolve, or "" when the user
+// cannot be told apart from others
+func conversationKey(userID string) string {
+	if userID == "" || userID == "anonymous" {
+		return ""
+	}
+	return userID
+}
+
+type conversationCtxKey struct{}
+
+// WithConversation keeps the turns of messages processed with the returned
+// context in a conversation of the user's named id, apart from their main
+// one. An empty id processes them without memory, so concurrent messages
+// do not interleave in a shared history.
+func WithConversation(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, conversationCtxKey{}, id)
+}
+
+// conversationKeyFor is conversationKey, narrowed to the conversation
+// attached to ctx with WithConversation
+func conversationKeyFor(ctx context.Context, userID string) string {
+	key := conversationKey(userID)
+	id, ok := ctx.Value(conversationCtxKey{}).(string)
+	if !ok || key == "" {
+		return key
+	}
+	if id == "" {
+		return ""
+	}
+	return key + "#" + id
+}
+
+// loadHistory returns the remembered turns for key, capped to the
+// configured window. Failures are logged and treated as an empty history.
+func (a *Agent) loadHistory(ctx context.Context, key string) []llm.Message {
+	if a.conversations == nil || key == "" {
+		return nil
+	}
+	history, err := a.conversations.Load(ctx, key)
+	if err != nil {
+		a.logger.Warn("failed to load conversation", "key", key, "error", err)
+		return nil
+	}
+	if n := a.config.Agent.MemoryMessages; n > 0 && len(history) > n {
+		history = history[len(history)-n:]
+	}
+	return historyWindow(history, a.config.Agent.HistoryTurns, a.config.Agent.HistoryTokens)
+}
+
+// historyWindow returns the most recent turns of history that fit within
+// turns and an estimated tokens budget (0 = no limit). A turn starts at a
+// user message, so the window never opens with a dangling reply.
+func historyWindow(history []llm.Message, turns, tokens int) []llm.Message {
+	if turns <= 0 && tokens <= 0 {
+		return history
+	}
+
+	start, used, kept := len(history), 0, 0
+	for i := len(history) - 1; i >= 0; i-- {
+		used += skills.EstimateTokens(history[i].Content)
+		if tokens > 0 && used > tokens {
+			break
+		}
+		if history[i].Role != "user" {
+			continue
+		}
+		if turns > 0 && kept == turns {
+			break
+		}
+		kept++
+		start = i
+	}
+	return history[start:]
+}
+
+// remember stores a completed exchange for key
+func (a *Agent) remember(ctx context.Context, key string, user llm.Message, response string) {
+	if a.conversations == nil || key == "" {
+		return
+	}
+	// Attachments are not persisted; only the text of the turn is remembered
+	user.Parts = nil
+	if err := a.conversations.Append(ctx, key, user, llm.Message{Role: "assistant", Content: response}); err != nil {
+		a.logger.Warn("failed to store conversation", "key", key, "error", err)
+	}
+}
+
+// ResetConversation forgets everything the agent remembers about the
+// canonical user userID
+func (a *Agent) ResetConversation(ctx context.Context, userID string) error {
+	key := conversationKey(userID)
+	if a.conversations == nil || key == "" {
+		return nil
+	}
+	return a.conversations.Reset(ctx, key)
+}
+
+// Close releases the conversation store when it holds resources, e.g. a
+// file or database connection
+func (a *Agent) Close() error {
+	if closer, ok := a.conversations.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// GetConversationStore returns the conversation store, or nil when memory is disabled
+func (a *Agent) GetConversationStore() ConversationStore {
+	return a.conversations
+}
+
+// ExportConversation renders the remembered conversation of the canonical
+// user userID as a Markdown document, or as plain text when format is
+// "txt", in the configured locale. Only the turns kept by the conversation
+// window are exported; an empty string means there is nothing to export.
+func (a *Agent) ExportConversation(ctx context.Context, userID, format string) (string, error) {
+	history := a.loadHistory(ctx, conversationKey(userID))
+	if len(history) == 0 {
+		return "", nil
+	}
+
+	locale := a.config.I18n.Locale
+	markdown := format != "txt"
+	var sb strings.Builder
+	title := i18n.T(locale, "export.title", a.clock.Now().In(a.location).Format("2006-01-02 15:04"))
+	if markdown {
+		sb.WriteString("# " + title + "\n")
+	} else {
+		sb.WriteString(title + "\n")
+	}
+
+	for _, msg := range history {
+		speaker := i18n.T(locale, "export.user")
+		if msg.Role == "assistant" {
+			speaker = i18n.T(locale, "export.assistant")
+		}
+		if markdown {
+			fmt.Fprintf(&sb, "\n**%s:**\n\n%s\n", speaker, msg.Content)
+		} else {
+			fmt.Fprintf(&sb, "\n%s:\n%s\n", speaker, msg.Content)
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,310 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/config"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+)
+
+func TestMemoryConversationStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryConversationStore(3)
+
+	s.Append(ctx, "42", llm.Message{Role: "user", Content: "1"}, llm.Message{Role: "assistant", Content: "2"})
+	s.Append(ctx, "42", llm.Message{Role: "user", Content: "3"}, llm.Message{Role: "assistant", Content: "4"})
+	s.Append(ctx, "7", llm.Message{Role: "user", Content: "x"})
+
+	got, _ := s.Load(ctx, "42")
+	var contents []string
+	for _, m := range got {
+		contents = append(contents, m.Content)
+	}
+	if !reflect.DeepEqual(contents, []string{"2", "3", "4"}) {
+		t.Errorf("Load() = %v, want the last 3 messages", contents)
+	}
+
+	// Callers must not be able to mutate the stored conversation
+	got[0].Content = "changed"
+	if again, _ := s.Load(ctx, "42"); again[0].Content != "2" {
+		t.Error("Load() returned the internal slice")
+	}
+
+	if keys, _ := s.List(ctx); !reflect.DeepEqual(keys, []string{"42", "7"}) {
+		t.Errorf("List() = %v", keys)
+	}
+
+	s.Reset(ctx, "42")
+	if got, _ := s.Load(ctx, "42"); len(got) != 0 {
+		t.Errorf("Load() after Reset = %v", got)
+	}
+}
+
+func TestConversationIsPerChannel(t *testing.T) {
+	var requests [][]llm.Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req.Messages)
+		respondChat(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		LLM:   config.LLMConfig{BaseURL: srv.URL, Model: "test-model", TimeoutSec: 5},
+		Agent: config.AgentConfig{MemoryMessages: 10},
+	}
+	store := NewMemoryConversationStore(0)
+	a, err := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), WithConversationStore(store))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// The same ID on another channel is another user until they are linked
+	ctx := context.Background()
+	if _, err := a.ProcessMessage(ctx, "42", "telegram", "meu nome é Ana"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ProcessMessage(ctx, "42", "webchat", "qual é o meu nome?"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ProcessMessage(ctx, "42", "telegram", "e agora?"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ProcessMessage(ctx, "anonymous", "api", "oi"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests[1]) != 2 {
+		t.Errorf("webchat request = %+v, want no history from Telegram", requests[1])
+	}
+	if third := requests[2]; len(third) != 4 || third[1].Content != "meu nome é Ana" {
+		t.Errorf("second telegram request = %+v, want its own earlier turn", third)
+	}
+	if len(requests[3]) != 2 {
+		t.Errorf("anonymous request has %d messages, want no shared memory", len(requests[3]))
+	}
+	if keys, _ := store.List(ctx); !reflect.DeepEqual(keys, []string{"telegram:42", "webchat:42"}) {
+		t.Errorf("stored keys = %v, want one conversation per channel", keys)
+	}
+}
+
+func TestLinkedIdentitiesShareConversation(t *testing.T) {
+	var requests [][]llm.Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req.Messages)
+		respondChat(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		LLM:   config.LLMConfig{BaseURL: srv.URL, Model: "test-model", TimeoutSec: 5},
+		Agent: config.AgentConfig{MemoryMessages: 10},
+	}
+	store := NewMemoryConversationStore(0)
+	a, err := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), WithConversationStore(store))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// The Telegram user asks for a code with /link and redeems it on WebChat
+	code, err := a.GetIdentities().NewCode("telegram", "42")
+	if err != nil {
+		t.Fatalf("NewCode() error = %v", err)
+	}
+	if _, err := a.GetIdentities().Redeem(code, "webchat", "ana.silva"); err != nil {
+		t.Fatalf("Redeem() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := a.ProcessMessage(ctx, "42", "telegram", "meu nome é Ana"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ProcessMessage(ctx, "ana.silva", "webchat", "qual é o meu nome?"); err != nil {
+		t.Fatal(err)
+	}
+
+	second := requests[1]
+	if len(second) != 4 || second[1].Content != "meu nome é Ana" {
+		t.Errorf("webchat request = %+v, want the Telegram turn remembered", second)
+	}
+	if keys, _ := store.List(ctx); !reflect.DeepEqual(keys, []string{"telegram:42"}) {
+		t.Errorf("stored keys = %v, want one conversation for the linked user", keys)
+	}
+	if st := a.GetUsageTracker().Get("telegram:42"); st.Requests != 2 {
+		t.Errorf("usage for the linked user = %+v, want both channels counted", st)
+	}
+}
+
+func TestExportConversationContainsPriorTurns(t *testing.T) {
+	var n int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		respondChat(w, fmt.Sprintf("resposta %d", n))
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		LLM:   config.LLMConfig{BaseURL: srv.URL, Model: "test-model", TimeoutSec: 5},
+		Agent: config.AgentConfig{MemoryMessages: 2},
+	}
+	a, err := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if got, err := a.ExportConversation(ctx, "telegram:42", "md"); err != nil || got != "" {
+		t.Fatalf("ExportConversation() with no history = %q, %v; want empty", got, err)
+	}
+
+	for _, text := range []string{"primeira pergunta", "segunda pergunta"} {
+		if _, err := a.ProcessMessage(ctx, "42", "telegram", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	md, err := a.ExportConversation(ctx, "telegram:42", "md")
+	if err != nil {
+		t.Fatalf("ExportConversation() error = %v", err)
+	}
+	for _, want := range []string{"# Conversa", "**Você:**\n\nsegunda pergunta", "**Nomad Agent:**\n\nresposta 2"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("export missing %q:\n%s", want, md)
+		}
+	}
+	// Only the retained window is exported
+	if strings.Contains(md, "primeira pergunta") {
+		t.Errorf("export includes turns beyond the history window:\n%s", md)
+	}
+
+	txt, _ := a.ExportConversation(ctx, "telegram:42", "txt")
+	if strings.Contains(txt, "**") || !strings.Contains(txt, "Você:\nsegunda pergunta") {
+		t.Errorf("text export = %q", txt)
+	}
+
+	a.config.I18n.Locale = "en"
+	en, _ := a.ExportConversation(ctx, "telegram:42", "md")
+	for _, want := range []string{"# Conversation with Nomad Agent", "**You:**\n\nsegunda pergunta"} {
+		if !strings.Contains(en, want) {
+			t.Errorf("English export missing %q:\n%s", want, en)
+		}
+	}
+}
+
+func TestHistoryWindowSendsLastTurns(t *testing.T) {
+	var last []llm.Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		last = req.Messages
+		respondChat(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		LLM:   config.LLMConfig{BaseURL: srv.URL, Model: "test-model", TimeoutSec: 5},
+		Agent: config.AgentConfig{MemoryMessages: 20, HistoryTurns: 2},
+	}
+	a, err := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		if _, err := a.ProcessMessage(ctx, "42", "telegram", fmt.Sprintf("turn %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	for _, m := range last {
+		got = append(got, m.Role+":"+m.Content)
+	}
+	want := []string{"user:turn 2", "assistant:ok", "user:turn 3", "assistant:ok", "user:turn 4"}
+	if len(got) != 6 || last[0].Role != "system" || !reflect.DeepEqual(got[1:], want) {
+		t.Errorf("messages = %v, want the system prompt and %v", got, want)
+	}
+
+	// The full history is still remembered
+	if stored, _ := a.GetConversationStore().Load(ctx, "telegram:42"); len(stored) != 8 {
+		t.Errorf("stored %d messages, want 8", len(stored))
+	}
+}
+
+func TestHistoryWindowTokenBudget(t *testing.T) {
+	history := []llm.Message{
+		{Role: "user", Content: strings.Repeat("a", 400)},
+		{Role: "assistant", Content: "ok"},
+		{Role: "user", Content: "short"},
+		{Role: "assistant", Content: "ok"},
+	}
+	if got := historyWindow(history, 0, 50); len(got) != 2 || got[0].Content != "short" {
+		t.Errorf("historyWindow() = %+v, want only the turn within budget", got)
+	}
+	if got := historyWindow(history, 0, 0); len(got) != 4 {
+		t.Errorf("historyWindow() without limits = %d messages, want 4", len(got))
+	}
+}
+
+func TestWithConversationKeepsMemoryApart(t *testing.T) {
+	var requests [][]llm.Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req.Messages)
+		respondChat(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		LLM:   config.LLMConfig{BaseURL: srv.URL, Model: "test-model", TimeoutSec: 5},
+		Agent: config.AgentConfig{MemoryMessages: 10},
+	}
+	store := NewMemoryConversationStore(0)
+	a, err := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), WithConversationStore(store))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := a.ProcessMessage(ctx, "ana", "api", "main"); err != nil {
+		t.Fatal(err)
+	}
+	// Without a conversation id nothing is loaded or remembered
+	for _, text := range []string{"item 1", "item 2"} {
+		if _, err := a.ProcessMessage(WithConversation(ctx, ""), "ana", "api", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, text := range []string{"first", "second"} {
+		if _, err := a.ProcessMessage(WithConversation(ctx, "s1"), "ana", "api", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(requests[1]) != 2 || len(requests[2]) != 2 {
+		t.Errorf("memoryless requests = %d and %d messages, want no history", len(requests[1]), len(requests[2]))
+	}
+	if len(requests[3]) != 2 {
+		t.Errorf("first session request = %+v, want the main conversation left out", requests[3])
+	}
+	if fourth := requests[4]; len(fourth) != 4 || fourth[1].Content != "first" {
+		t.Errorf("second session request = %+v, want the session's earlier turn", fourth)
+	}
+	if keys, _ := store.List(ctx); !reflect.DeepEqual(keys, []string{"api:ana", "api:ana#s1"}) {
+		t.Errorf("stored keys = %v, want the main conversation and the session", keys)
+	}
+}
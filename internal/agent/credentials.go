@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/devops"
+	"github.com/abelclopes/nomad-iabot/internal/trello"
+)
+
+// CredentialStatus reports an integration whose credentials were rejected
+type CredentialStatus struct {
+	Integration string    `json:"integration"`
+	Since       time.Time `json:"since"` // first rejection since the last success
+	Message     string    `json:"message"`
+}
+
+// credentialMessages are shown to the user instead of the raw API error
+var credentialMessages = map[string]string{
+	"azure_devops": "the Azure DevOps credentials appear to be expired or invalid; please update the PAT.",
+	"trello":       "the Trello credentials appear to be expired or invalid; please update the API token.",
+}
+
+// rejectedIntegration returns the integration whose credentials err says
+// were rejected, or "". It goes by the error rather than the tool, since
+// tools such as bridge_workitem_to_card call more than one integration.
+func rejectedIntegration(err error) string {
+	switch {
+	case errors.Is(err, devops.ErrAuthExpired):
+		return "azure_devops"
+	case errors.Is(err, trello.ErrAuthExpired):
+		return "trello"
+	}
+	return ""
+}
+
+// toolIntegrations returns the integrations a tool calls, whose
+// credentials a successful call vouches for
+func toolIntegrations(tool string) []string {
+	switch {
+	case strings.HasPrefix(tool, "devops_"):
+		return []string{"azure_devops"}
+	case strings.HasPrefix(tool, "trello_"):
+		return []string{"trello"}
+	case strings.HasPrefix(tool, "bridge_"):
+		return []string{"azure_devops", "trello"}
+	}
+	return nil
+}
+
+// noteCredentials records whether a tool call's credentials were accepted
+// and returns err, replaced by a friendly message when they were rejected
+func (a *Agent) noteCredentials(tool string, err error) error {
+	if err != nil {
+		integration := rejectedIntegration(err)
+		if integration == "" {
+			// Unrelated failures say nothing about the credentials
+			return err
+		}
+
+		a.credentialsMu.Lock()
+		defer a.credentialsMu.Unlock()
+		if _, ok := a.expiredCredentials[integration]; !ok {
+			if a.expiredCredentials == nil {
+				a.expiredCredentials = make(map[string]CredentialStatus)
+			}
+			a.expiredCredentials[integration] = CredentialStatus{
+				Integration: integration,
+				Since:       a.clock.Now(),
+				Message:     credentialMessages[integration],
+			}
+			a.logger.Warn("integration credentials rejected", "integration", integration, "tool", tool, "error", err)
+		}
+		return errors.New(credentialMessages[integration])
+	}
+
+	a.credentialsMu.Lock()
+	defer a.credentialsMu.Unlock()
+	for _, integration := range toolIntegrations(tool) {
+		delete(a.expiredCredentials, integration)
+	}
+	return nil
+}
+
+// ExpiredCredentials lists the integrations whose credentials were rejected
+// by their most recent tool call, sorted by name
+func (a *Agent) ExpiredCredentials() []CredentialStatus {
+	a.credentialsMu.Lock()
+	defer a.credentialsMu.Unlock()
+
+	statuses := make([]CredentialStatus, 0, len(a.expiredCredentials))
+	for _, st := range a.expiredCredentials {
+		statuses = append(statuses, st)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Integration < statuses[j].Integration })
+	return statuses
+}
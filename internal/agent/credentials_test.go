@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/devops"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+	"github.com/abelclopes/nomad-iabot/internal/trello"
+)
+
+// authTools fails its one tool with err
+type authTools struct {
+	name string
+	err  error
+}
+
+func (f *authTools) GetToolDefinitions() []llm.Tool {
+	return []llm.Tool{{Type: "function", Function: llm.ToolFunction{Name: f.name}}}
+}
+
+func (f *authTools) Execute(ctx context.Context, name string, args map[string]interface{}) (string, bool, error) {
+	if name != f.name {
+		return "", false, nil
+	}
+	if f.err != nil {
+		return "", true, f.err
+	}
+	return "ok", true, nil
+}
+
+func TestExpiredCredentialsAreReported(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {})
+	tool := &authTools{name: "devops_fake", err: fmt.Errorf("request failed: %w", devops.ErrAuthExpired)}
+	a.tools = append(a.tools, tool)
+	a.skillsValidator.RegisterCommands([]string{tool.name})
+
+	_, err := a.executeTool(context.Background(), tool.name, "{}")
+	want := "the Azure DevOps credentials appear to be expired or invalid; please update the PAT."
+	if err == nil || err.Error() != want {
+		t.Fatalf("executeTool() error = %v, want %q", err, want)
+	}
+
+	expired := a.ExpiredCredentials()
+	if len(expired) != 1 || expired[0].Integration != "azure_devops" {
+		t.Fatalf("ExpiredCredentials() = %+v, want azure_devops", expired)
+	}
+
+	// Other failures leave the status alone; a success clears it
+	tool.err = fmt.Errorf("API error (status 500)")
+	a.executeTool(context.Background(), tool.name, "{}")
+	if len(a.ExpiredCredentials()) != 1 {
+		t.Errorf("unrelated failure cleared the expired credentials")
+	}
+
+	tool.err = nil
+	if _, err := a.executeTool(context.Background(), tool.name, "{}"); err != nil {
+		t.Fatalf("executeTool() error = %v", err)
+	}
+	if got := a.ExpiredCredentials(); len(got) != 0 {
+		t.Errorf("ExpiredCredentials() after success = %+v, want none", got)
+	}
+}
+
+func TestBridgeToolReportsTheIntegrationThatRejectedIt(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {})
+	tool := &authTools{name: "bridge_fake", err: fmt.Errorf("create card: %w", trello.ErrAuthExpired)}
+	a.tools = append(a.tools, tool)
+	a.skillsValidator.RegisterCommands([]string{tool.name})
+
+	_, err := a.executeTool(context.Background(), tool.name, "{}")
+	want := "the Trello credentials appear to be expired or invalid; please update the API token."
+	if err == nil || err.Error() != want {
+		t.Fatalf("executeTool() error = %v, want %q", err, want)
+	}
+	expired := a.ExpiredCredentials()
+	if len(expired) != 1 || expired[0].Integration != "trello" {
+		t.Fatalf("ExpiredCredentials() = %+v, want trello", expired)
+	}
+
+	tool.err = nil
+	if _, err := a.executeTool(context.Background(), tool.name, "{}"); err != nil {
+		t.Fatalf("executeTool() error = %v", err)
+	}
+	if expired := a.ExpiredCredentials(); len(expired) != 0 {
+		t.Errorf("ExpiredCredentials() = %+v after a successful bridge call, want none", expired)
+	}
+}
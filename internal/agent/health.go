@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/breaker"
+)
+
+// healthPingTimeout bounds the LLM reachability check made by Health
+const healthPingTimeout = 3 * time.Second
+
+// healthPingTTL is how long Health reuses a ping result, so polling
+// /health/detail does not turn into load on the LLM server
+const healthPingTTL = 5 * time.Second
+
+// minLatencySamples is how many recent chat requests Ready needs before it
+// trusts their p95, so one slow request after a quiet spell does not fail it
+const minLatencySamples = 5
+
+// Health is a snapshot of the agent's state, reported by /health/detail and
+// Telegram's /status
+type Health struct {
+	Status       string // "healthy", or "degraded" when the LLM or an integration is unhealthy
+	Model        string
+	Uptime       time.Duration
+	LLM          LLMHealth
+	Integrations []IntegrationHealth
+	Breakers     []breaker.Status
+	Credentials  []CredentialStatus
+	Concurrency  ConcurrencyStats
+}
+
+// LLMHealth reports whether the LLM server answered a ping and how fast it
+// has been answering chat requests
+type LLMHealth struct {
+	Reachable      bool   `json:"reachable"`
+	Error          string `json:"error,omitempty"`
+	P95LatencyMs   int64  `json:"p95_latency_ms"`
+	LatencySamples int    `json:"latency_samples"`
+}
+
+// IntegrationHealth reports an enabled integration
+type IntegrationHealth struct {
+	Name    string `json:"name"` // "azure_devops" or "trello"
+	Healthy bool   `json:"healthy"`
+	Problem string `json:"problem,omitempty"` // "credentials" or "circuit_open" when unhealthy
+}
+
+// Health pings the LLM and gathers the state of the breakers and of each
+// enabled integration's credentials
+func (a *Agent) Health(ctx context.Context) Health {
+	h := Health{
+		Status:      "healthy",
+		Model:       a.config.LLM.Model,
+		Uptime:      a.clock.Now().Sub(a.started),
+		Breakers:    []breaker.Status{},
+		Credentials: a.ExpiredCredentials(),
+		Concurrency: a.Concurrency(),
+	}
+
+	if err := a.pingLLM(ctx); err != nil {
+		h.LLM.Error = err.Error()
+		h.Status = "degraded"
+	} else {
+		h.LLM.Reachable = true
+	}
+	p95, samples := a.llmClient.LatencyP95()
+	h.LLM.P95LatencyMs = p95.Milliseconds()
+	h.LLM.LatencySamples = samples
+
+	open := make(map[string]bool)
+	for _, b := range a.Breakers() {
+		st := b.Status()
+		if st.State != breaker.Closed.String() {
+			open[st.Name] = true
+			h.Status = "degraded"
+		}
+		h.Breakers = append(h.Breakers, st)
+	}
+
+	rejected := make(map[string]bool, len(h.Credentials))
+	for _, cred := range h.Credentials {
+		rejected[cred.Integration] = true
+		h.Status = "degraded"
+	}
+
+	enabled := map[string]bool{
+		"azure_devops": a.devopsClient != nil,
+		"trello":       a.trelloClient != nil,
+	}
+	for _, name := range []string{"azure_devops", "trello"} {
+		if !enabled[name] {
+			continue
+		}
+		ih := IntegrationHealth{Name: name, Healthy: true}
+		switch {
+		case rejected[name]:
+			ih.Healthy = false
+			ih.Problem = "credentials"
+		case open[name]:
+			ih.Healthy = false
+			ih.Problem = "circuit_open"
+		}
+		h.Integrations = append(h.Integrations, ih)
+	}
+	return h
+}
+
+// pingLLM pings the LLM server, reusing the previous result for
+// healthPingTTL. Concurrent callers wait for one ping instead of each
+// sending their own.
+func (a *Agent) pingLLM(ctx context.Context) error {
+	a.pingMu.Lock()
+	defer a.pingMu.Unlock()
+
+	if !a.pingAt.IsZero() && a.clock.Now().Sub(a.pingAt) < healthPingTTL {
+		return a.pingErr
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+	defer cancel()
+	err := a.llmClient.Ping(pingCtx)
+	if ctx.Err() != nil {
+		// The caller gave up, which says nothing about the LLM
+		return err
+	}
+	a.pingAt, a.pingErr = a.clock.Now(), err
+	return err
+}
+
+// Ready reports whether the agent should receive traffic. It is not ready
+// while the p95 latency of recent chat requests exceeds LLM_READY_MAX_P95_MS,
+// and becomes ready again once the slow requests leave the latency window.
+func (a *Agent) Ready() (bool, string) {
+	limit := time.Duration(a.config.LLM.ReadyMaxP95Ms) * time.Millisecond
+	if limit <= 0 {
+		return true, ""
+	}
+	p95, samples := a.llmClient.LatencyP95()
+	if samples < minLatencySamples || p95 <= limit {
+		return true, ""
+	}
+	return false, fmt.Sprintf("LLM p95 latency %dms exceeds %dms", p95.Milliseconds(), limit.Milliseconds())
+}
@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+	"github.com/abelclopes/nomad-iabot/internal/trello"
+)
+
+func TestHealthReportsUnreachableLLM(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	a.started = time.Now().Add(-time.Hour)
+
+	h := a.Health(context.Background())
+	if h.Status != "degraded" {
+		t.Errorf("Status = %q, want degraded", h.Status)
+	}
+	if h.LLM.Reachable || !strings.Contains(h.LLM.Error, "502") {
+		t.Errorf("LLM = %+v, want unreachable with the status code", h.LLM)
+	}
+	if h.Model != "test-model" {
+		t.Errorf("Model = %q, want test-model", h.Model)
+	}
+	if h.Uptime < time.Hour {
+		t.Errorf("Uptime = %v, want at least an hour", h.Uptime)
+	}
+}
+
+func TestHealthReportsRejectedCredentials(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {})
+	a.trelloClient = trello.NewClient("key", "token")
+	a.noteCredentials("trello_get_boards", trello.ErrAuthExpired)
+
+	h := a.Health(context.Background())
+	if !h.LLM.Reachable {
+		t.Errorf("LLM = %+v, want reachable", h.LLM)
+	}
+	if h.Status != "degraded" {
+		t.Errorf("Status = %q, want degraded", h.Status)
+	}
+	if len(h.Integrations) != 1 || h.Integrations[0].Name != "trello" || h.Integrations[0].Problem != "credentials" {
+		t.Errorf("Integrations = %+v, want trello with rejected credentials", h.Integrations)
+	}
+}
+
+func TestSlowLLMFlipsReadiness(t *testing.T) {
+	var slow atomic.Bool
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		if slow.Load() {
+			time.Sleep(50 * time.Millisecond)
+		}
+		respondChat(w, "ok")
+	})
+	a.config.LLM.ReadyMaxP95Ms = 20
+
+	ctx := context.Background()
+	for i := 0; i < minLatencySamples; i++ {
+		if _, err := a.ProcessMessage(ctx, "alice", "test", "Oi"); err != nil {
+			t.Fatalf("ProcessMessage() error = %v", err)
+		}
+	}
+	if ready, reason := a.Ready(); !ready {
+		t.Fatalf("Ready() = false (%s) with fast responses", reason)
+	}
+
+	slow.Store(true)
+	for i := 0; i < minLatencySamples; i++ {
+		if _, err := a.ProcessMessage(ctx, "alice", "test", "Oi"); err != nil {
+			t.Fatalf("ProcessMessage() error = %v", err)
+		}
+	}
+	ready, reason := a.Ready()
+	if ready || !strings.Contains(reason, "exceeds 20ms") {
+		t.Errorf("Ready() = %v, %q, want not ready over the 20ms threshold", ready, reason)
+	}
+	if h := a.Health(ctx); h.LLM.P95LatencyMs < 50 || h.LLM.LatencySamples != 2*minLatencySamples {
+		t.Errorf("LLM = %+v, want the slow p95 over %d samples", h.LLM, 2*minLatencySamples)
+	}
+}
+
+func TestHealthReusesRecentPing(t *testing.T) {
+	var pings atomic.Int32
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		pings.Add(1)
+	})
+	clk := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	a.clock = clk
+
+	a.Health(context.Background())
+	a.Health(context.Background())
+	if n := pings.Load(); n != 1 {
+		t.Errorf("pings = %d within the TTL, want 1", n)
+	}
+
+	clk.Advance(healthPingTTL)
+	a.Health(context.Background())
+	if n := pings.Load(); n != 2 {
+		t.Errorf("pings = %d after the TTL, want 2", n)
+	}
+}
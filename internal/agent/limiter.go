@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBusy is returned when every processing slot stayed taken for the whole
+// queue wait. Callers should answer with "server busy" (HTTP 503).
+var ErrBusy = errors.New("server busy")
+
+// ErrShuttingDown is returned for messages that arrive while the agent
+// drains. It wraps ErrBusy, so callers answer it the same way.
+var ErrShuttingDown = fmt.Errorf("%w: shutting down", ErrBusy)
+
+// ConcurrencyStats reports how many messages are being processed
+type ConcurrencyStats struct {
+	Active   int64 `json:"active"`   // messages being processed now
+	Waiting  int64 `json:"waiting"`  // messages queued for a free slot
+	Limit    int   `json:"limit"`    // AGENT_MAX_CONCURRENCY (0 = unlimited)
+	Rejected int64 `json:"rejected"` // messages turned away with ErrBusy since start
+}
+
+// limiter caps concurrent message processing across every channel. A nil
+// slots channel means unlimited.
+type limiter struct {
+	slots chan struct{}
+	wait  time.Duration
+
+	active   atomic.Int64
+	waiting  atomic.Int64
+	rejected atomic.Int64
+
+	mu       sync.Mutex
+	draining bool
+	inflight int           // messages holding a slot, counted under mu for drain
+	stop     chan struct{} // closed when draining starts, releasing queued messages
+	idle     chan struct{} // closed once draining and nothing is in flight
+}
+
+func newLimiter(max int, wait time.Duration) *limiter {
+	l := &limiter{wait: wait, stop: make(chan struct{}), idle: make(chan struct{})}
+	if max > 0 {
+		l.slots = make(chan struct{}, max)
+	}
+	return l
+}
+
+// acquire takes a processing slot, waiting up to the queue wait for one to
+// free up. The returned release must be called when processing ends.
+func (l *limiter) acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case <-l.stop:
+		return nil, ErrShuttingDown
+	default:
+	}
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			if err := l.queue(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+	freeSlot := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	l.mu.Lock()
+	if l.draining {
+		l.mu.Unlock()
+		freeSlot()
+		return nil, ErrShuttingDown
+	}
+	l.inflight++
+	l.mu.Unlock()
+
+	l.active.Add(1)
+	return func() {
+		l.active.Add(-1)
+		freeSlot()
+
+		l.mu.Lock()
+		l.inflight--
+		if l.draining && l.inflight == 0 {
+			close(l.idle)
+		}
+		l.mu.Unlock()
+	}, nil
+}
+
+// drain turns new messages away with ErrShuttingDown and waits for the ones
+// in flight, or for ctx to end
+func (l *limiter) drain(ctx context.Context) error {
+	l.mu.Lock()
+	if !l.draining {
+		l.draining = true
+		close(l.stop)
+		if l.inflight == 0 {
+			close(l.idle)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-l.idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d message(s) still processing: %w", l.active.Load(), ctx.Err())
+	}
+}
+
+// queue waits for a slot
+func (l *limiter) queue(ctx context.Context) error {
+	l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		l.rejected.Add(1)
+		return ErrBusy
+	case <-l.stop:
+		return ErrShuttingDown
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *limiter) stats() ConcurrencyStats {
+	return ConcurrencyStats{
+		Active:   l.active.Load(),
+		Waiting:  l.waiting.Load(),
+		Limit:    cap(l.slots),
+		Rejected: l.rejected.Load(),
+	}
+}
+
+// Concurrency reports current message processing load
+func (a *Agent) Concurrency() ConcurrencyStats {
+	return a.limiter.stats()
+}
+
+// Drain stops the agent taking new messages, which fail with
+// ErrShuttingDown, and waits until the ones being processed finish or ctx
+// ends
+func (a *Agent) Drain(ctx context.Context) error {
+	return a.limiter.drain(ctx)
+}
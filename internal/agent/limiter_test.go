@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimitRejectsExtraRequests(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		respondChat(w, "ok")
+	})
+	a.limiter = newLimiter(1, 50*time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.ProcessMessage(context.Background(), "alice", "api", "first")
+		done <- err
+	}()
+	<-started
+
+	if _, err := a.ProcessMessage(context.Background(), "bob", "telegram", "second"); !errors.Is(err, ErrBusy) {
+		t.Errorf("second request error = %v, want ErrBusy", err)
+	}
+	if stats := a.Concurrency(); stats.Active != 1 || stats.Limit != 1 || stats.Rejected != 1 {
+		t.Errorf("Concurrency() = %+v", stats)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("first request error = %v", err)
+	}
+	if stats := a.Concurrency(); stats.Active != 0 {
+		t.Errorf("slot was not released: %+v", stats)
+	}
+	if _, err := a.ProcessMessage(context.Background(), "bob", "telegram", "third"); err != nil {
+		t.Errorf("request after release error = %v", err)
+	}
+}
+
+func TestConcurrencyLimitQueuesWithinWait(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		respondChat(w, "ok")
+	})
+	a.limiter = newLimiter(1, 5*time.Second)
+
+	errs := make(chan error, 2)
+	for _, msg := range []string{"first", "second"} {
+		go func(msg string) {
+			_, err := a.ProcessMessage(context.Background(), "alice", "api", msg)
+			errs <- err
+		}(msg)
+	}
+	<-started
+
+	// The second request queues for the slot instead of failing
+	deadline := time.Now().Add(time.Second)
+	for a.Concurrency().Waiting != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if stats := a.Concurrency(); stats.Waiting != 1 || stats.Active != 1 {
+		t.Fatalf("Concurrency() = %+v, want one active and one waiting", stats)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("request error = %v", err)
+		}
+	}
+}
+
+func TestDrainWaitsForInFlightMessages(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		respondChat(w, "ok")
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.ProcessMessage(context.Background(), "alice", "api", "first")
+		done <- err
+	}()
+	<-started
+
+	drained := make(chan error, 1)
+	go func() { drained <- a.Drain(context.Background()) }()
+
+	select {
+	case <-a.limiter.stop:
+	case <-time.After(time.Second):
+		t.Fatal("Drain() did not start")
+	}
+	_, err := a.ProcessMessage(context.Background(), "bob", "telegram", "second")
+	if !errors.Is(err, ErrShuttingDown) || !errors.Is(err, ErrBusy) {
+		t.Errorf("message during drain error = %v, want ErrShuttingDown answered like ErrBusy", err)
+	}
+
+	select {
+	case err := <-drained:
+		t.Fatalf("Drain() returned %v with a message in flight", err)
+	default:
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("in-flight message error = %v", err)
+	}
+	if err := <-drained; err != nil {
+		t.Errorf("Drain() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := a.Drain(ctx); err != nil {
+		t.Errorf("second Drain() error = %v", err)
+	}
+}
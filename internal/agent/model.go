@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+)
+
+// EnsureModel verifies at startup that the configured Ollama model is
+// installed, pulling it when OLLAMA_AUTO_PULL is enabled. Other providers
+// are not checked.
+func (a *Agent) EnsureModel(ctx context.Context) error {
+	if a.config.LLM.Provider != "ollama" {
+		return nil
+	}
+
+	model := a.config.LLM.Model
+	found, err := a.llmClient.HasModel(ctx, model)
+	if err != nil {
+		// The server may still be starting; chat requests will surface the error
+		a.logger.Warn("could not list Ollama models", "error", err)
+		return nil
+	}
+	if found {
+		return nil
+	}
+
+	if !a.config.LLM.AutoPull {
+		return fmt.Errorf("model %q is not available on Ollama at %s; run 'ollama pull %s' or set OLLAMA_AUTO_PULL=true",
+			model, a.config.LLM.BaseURL, model)
+	}
+
+	a.logger.Info("pulling Ollama model", "model", model)
+
+	lastStatus := ""
+	lastPercent := int64(-1)
+	err = a.llmClient.PullModel(ctx, model, func(p llm.PullProgress) {
+		percent := int64(-1)
+		if p.Total > 0 {
+			percent = p.Completed * 100 / p.Total
+		}
+		// Log status changes and every 10% of download progress
+		if p.Status == lastStatus && (percent < 0 || percent/10 == lastPercent/10) {
+			return
+		}
+		lastStatus, lastPercent = p.Status, percent
+		if percent >= 0 {
+			a.logger.Info("pull progress", "model", model, "status", p.Status, "percent", percent)
+		} else {
+			a.logger.Info("pull progress", "model", model, "status", p.Status)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pull model %q: %w", model, err)
+	}
+
+	a.logger.Info("Ollama model ready", "model", model)
+	return nil
+}
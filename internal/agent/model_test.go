@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEnsureModel(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    string
+		autoPull    bool
+		shouldError bool
+		expectPull  bool
+	}{
+		{"Missing model without auto-pull", "ollama", false, true, false},
+		{"Missing model with auto-pull", "ollama", true, false, true},
+		{"Other providers are not checked", "openrouter", false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pulled := false
+			a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/tags":
+					w.Write([]byte(`{"models":[{"name":"other:latest"}]}`))
+				case "/api/pull":
+					pulled = true
+					w.Write([]byte(`{"status":"success"}`))
+				}
+			})
+			a.config.LLM.Provider = tt.provider
+			a.config.LLM.AutoPull = tt.autoPull
+
+			err := a.EnsureModel(context.Background())
+			if (err != nil) != tt.shouldError {
+				t.Fatalf("EnsureModel() error = %v, shouldError = %v", err, tt.shouldError)
+			}
+			if err != nil && !strings.Contains(err.Error(), "OLLAMA_AUTO_PULL") {
+				t.Errorf("error should explain how to fix it: %v", err)
+			}
+			if pulled != tt.expectPull {
+				t.Errorf("pulled = %v, expected %v", pulled, tt.expectPull)
+			}
+		})
+	}
+}
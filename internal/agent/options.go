@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+)
+
+type llmOptionsKey struct{}
+
+// WithLLMOptions attaches extra chat options, such as llm.WithStop, to every
+// model call made while processing a message with the returned context
+func WithLLMOptions(ctx context.Context, opts ...llm.ChatOption) context.Context {
+	existing, _ := ctx.Value(llmOptionsKey{}).([]llm.ChatOption)
+	combined := append(append([]llm.ChatOption(nil), existing...), opts...)
+	return context.WithValue(ctx, llmOptionsKey{}, combined)
+}
+
+// llmOptions returns the chat options attached to ctx
+func llmOptions(ctx context.Context) []llm.ChatOption {
+	opts, _ := ctx.Value(llmOptionsKey{}).([]llm.ChatOption)
+	return opts
+}
+
+// chatOptions returns the options of every model call made while processing
+// a message: the configured temperature, then the options attached to ctx.
+// The temperature is always sent so the response cache can tell whether a
+// request is deterministic enough to cache.
+func (a *Agent) chatOptions(ctx context.Context) []llm.ChatOption {
+	return append([]llm.ChatOption{llm.WithTemperature(a.config.LLM.Temperature)}, llmOptions(ctx)...)
+}
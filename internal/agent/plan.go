@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+)
+
+// PlanMode controls whether the agent explains what it intends to do
+// before calling any tool
+type PlanMode int
+
+const (
+	// PlanOff executes tools without explaining first (the default)
+	PlanOff PlanMode = iota
+	// PlanShow describes the plan and then carries it out
+	PlanShow
+	// PlanOnly describes the plan and stops, so the user can approve it by
+	// sending the message again without plan mode
+	PlanOnly
+)
+
+type planModeKey struct{}
+
+// WithPlanMode makes messages processed with the returned context start by
+// describing the intended tool calls
+func WithPlanMode(ctx context.Context, mode PlanMode) context.Context {
+	return context.WithValue(ctx, planModeKey{}, mode)
+}
+
+// planMode returns the plan mode attached to ctx
+func planMode(ctx context.Context) PlanMode {
+	mode, _ := ctx.Value(planModeKey{}).(PlanMode)
+	return mode
+}
+
+// planInstruction asks the model to describe, not act. Tools are not sent
+// with this request, so they are listed in the prompt instead.
+const planInstruction = `Antes de agir, descreva em poucas linhas o plano para atender a última mensagem do usuário: o que pretende fazer e quais ferramentas chamaria, em ordem, com os argumentos principais. Se nenhuma ferramenta for necessária, diga isso. Não execute nada e não responda à pergunta ainda.
+
+Ferramentas disponíveis:
+%s`
+
+// explainPlan asks the model, with tools disabled, to describe how it would
+// answer the conversation in messages
+func (a *Agent) explainPlan(ctx context.Context, messages []llm.Message, tools []llm.Tool) (string, llm.Usage, error) {
+	var list strings.Builder
+	for _, t := range tools {
+		fmt.Fprintf(&list, "- %s: %s\n", t.Function.Name, t.Function.Description)
+	}
+
+	planMessages := append(append([]llm.Message(nil), messages...), llm.Message{
+		Role:    "system",
+		Content: fmt.Sprintf(planInstruction, list.String()),
+	})
+
+	resp, err := a.llmClient.Chat(ctx, planMessages, a.chatOptions(ctx)...)
+	if err != nil {
+		return "", llm.Usage{}, fmt.Errorf("failed to plan: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", resp.Usage, fmt.Errorf("no plan from LLM")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), resp.Usage, nil
+}
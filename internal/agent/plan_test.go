@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+)
+
+func TestPlanOnlyDescribesWithoutExecutingTools(t *testing.T) {
+	var requests []llm.ChatRequest
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		if len(req.Tools) > 0 {
+			respondChat(w, "", llm.ToolCall{ID: "1", Type: "function", Function: llm.ToolCallFunction{Name: "list_items", Arguments: "{}"}})
+			return
+		}
+		respondChat(w, "Vou chamar list_items para listar os itens ativos.")
+	})
+	tools := &fakeTools{names: []string{"list_items"}}
+	a.tools = append(a.tools, tools)
+	a.skillsValidator.RegisterCommands(tools.names)
+
+	ctx := WithPlanMode(context.Background(), PlanOnly)
+	res, err := a.ProcessMessageWithAttachments(ctx, "alice", "api", "Liste os itens ativos", nil)
+	if err != nil {
+		t.Fatalf("ProcessMessageWithAttachments() error = %v", err)
+	}
+
+	if res.Plan != "Vou chamar list_items para listar os itens ativos." || res.Response != res.Plan {
+		t.Errorf("Result = %+v, want the plan as the response", res)
+	}
+	if len(res.ToolCalls) != 0 {
+		t.Errorf("plan-only mode executed tools: %+v", res.ToolCalls)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("made %d LLM calls, want only the planning call", len(requests))
+	}
+	if len(requests[0].Tools) != 0 {
+		t.Error("planning call was sent with tools enabled")
+	}
+	last := requests[0].Messages[len(requests[0].Messages)-1]
+	if !strings.Contains(last.Content, "- list_items") {
+		t.Errorf("planning prompt does not list the tools:\n%s", last.Content)
+	}
+}
+
+func TestPlanShowRunsThePlan(t *testing.T) {
+	var calls int
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			respondChat(w, "Vou chamar list_items.")
+		case 2:
+			respondChat(w, "", llm.ToolCall{ID: "1", Type: "function", Function: llm.ToolCallFunction{Name: "list_items", Arguments: "{}"}})
+		default:
+			respondChat(w, "Pronto")
+		}
+	})
+	tools := &fakeTools{names: []string{"list_items"}}
+	a.tools = append(a.tools, tools)
+	a.skillsValidator.RegisterCommands(tools.names)
+
+	res, err := a.ProcessMessageWithAttachments(WithPlanMode(context.Background(), PlanShow), "alice", "api", "Liste", nil)
+	if err != nil {
+		t.Fatalf("ProcessMessageWithAttachments() error = %v", err)
+	}
+	if res.Plan != "Vou chamar list_items." || res.Response != "Pronto" || len(res.ToolCalls) != 1 {
+		t.Errorf("Result = %+v", res)
+	}
+}
@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/abelclopes/nomad-iabot/internal/stream"
+)
+
+// ProcessMessageStream processes a message like ProcessMessage, reporting
+// tool calls and the answer to emit as they happen; the answer arrives in
+// content events as the LLM writes it. The stream always ends with a done
+// event. emit is called from the calling goroutine only.
+func (a *Agent) ProcessMessageStream(ctx context.Context, userID, channel, message string, emit func(stream.Event)) (string, error) {
+	// Answers that don't come from a streamed LLM call (the usage limit,
+	// plan only) are sent in one piece
+	answered := false
+	res, err := a.process(ctx, userID, channel, message, nil, func(ev stream.Event) {
+		switch ev.Type {
+		case stream.Content:
+			answered = true
+		case stream.ToolStart:
+			answered = false
+		}
+		emit(ev)
+	})
+	if err != nil {
+		emit(stream.Event{Type: stream.Error, Error: "failed to process message"})
+		emit(stream.Event{Type: stream.Done})
+		return "", err
+	}
+
+	if !answered && res.Response != "" {
+		emit(stream.Event{Type: stream.Content, Content: res.Response})
+	}
+	emit(stream.Event{Type: stream.Done})
+	return res.Response, nil
+}
@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+	"github.com/abelclopes/nomad-iabot/internal/stream"
+)
+
+func TestProcessMessageStreamEndsWithDone(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		respondChat(w, "Olá!")
+	})
+
+	var events []stream.Event
+	response, err := a.ProcessMessageStream(context.Background(), "u1", "webchat", "oi", func(ev stream.Event) {
+		events = append(events, ev)
+	})
+	if err != nil {
+		t.Fatalf("ProcessMessageStream() error = %v", err)
+	}
+	if response != "Olá!" {
+		t.Errorf("response = %q, expected Olá!", response)
+	}
+
+	if len(events) != 2 || events[0].Type != stream.Content || events[1].Type != stream.Done {
+		t.Fatalf("events = %+v, expected content then done", events)
+	}
+	if events[0].Content != "Olá!" {
+		t.Errorf("content = %q", events[0].Content)
+	}
+}
+
+// respondStream writes content as a stream of chat completion chunks
+func respondStream(w http.ResponseWriter, pieces ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, piece := range pieces {
+		data, _ := json.Marshal(llm.StreamChunk{Choices: []llm.StreamChoice{{Delta: llm.StreamDelta{Content: piece}}}})
+		w.Write([]byte("data: " + string(data) + "\n\n"))
+	}
+	w.Write([]byte("data: [DONE]\n\n"))
+}
+
+func TestProcessMessageStreamSendsContentAsTheLLMWritesIt(t *testing.T) {
+	var calls int
+	var streamed []bool
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		streamed = append(streamed, req.Stream)
+		calls++
+		if calls == 1 {
+			respondChat(w, "", llm.ToolCall{ID: "1", Type: "function", Function: llm.ToolCallFunction{Name: "lookup", Arguments: "{}"}})
+			return
+		}
+		respondStream(w, "Você tem ", "2 itens")
+	})
+	tool := &authTools{name: "lookup"}
+	a.tools = append(a.tools, tool)
+	a.skillsValidator.RegisterCommands([]string{tool.name})
+
+	var events []stream.Event
+	response, err := a.ProcessMessageStream(context.Background(), "u1", "webchat", "oi", func(ev stream.Event) {
+		events = append(events, ev)
+	})
+	if err != nil {
+		t.Fatalf("ProcessMessageStream() error = %v", err)
+	}
+	if response != "Você tem 2 itens" {
+		t.Errorf("response = %q", response)
+	}
+	for i, s := range streamed {
+		if !s {
+			t.Errorf("LLM request %d did not ask for a stream", i+1)
+		}
+	}
+
+	var types []string
+	for _, ev := range events {
+		types = append(types, ev.Type+":"+ev.Content)
+	}
+	want := "tool_start:,tool_end:,content:Você tem ,content:2 itens,done:"
+	if got := strings.Join(types, ","); got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
+
+func TestProcessMessageStreamReportsErrors(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	var events []stream.Event
+	_, err := a.ProcessMessageStream(context.Background(), "u1", "webchat", "oi", func(ev stream.Event) {
+		events = append(events, ev)
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if len(events) != 2 || events[0].Type != stream.Error || events[1].Type != stream.Done {
+		t.Errorf("events = %+v, expected error then done", events)
+	}
+}
+
+func TestToolErrorsAreNotStreamedVerbatim(t *testing.T) {
+	var calls int
+	var toolResult string
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			respondChat(w, "", llm.ToolCall{ID: "1", Type: "function", Function: llm.ToolCallFunction{Name: "failing", Arguments: "{}"}})
+			return
+		}
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		toolResult = req.Messages[len(req.Messages)-1].Content
+		respondChat(w, "Falhou")
+	})
+	tool := &authTools{name: "failing", err: errors.New("GET https://dev.azure.com/org/_apis/wit: 500 internal stack trace")}
+	a.tools = append(a.tools, tool)
+	a.skillsValidator.RegisterCommands([]string{tool.name})
+
+	var events []stream.Event
+	if _, err := a.ProcessMessageStream(context.Background(), "u1", "webchat", "oi", func(ev stream.Event) {
+		events = append(events, ev)
+	}); err != nil {
+		t.Fatalf("ProcessMessageStream() error = %v", err)
+	}
+
+	if len(events) < 2 || events[1].Type != stream.ToolEnd {
+		t.Fatalf("events = %+v, expected tool_start then tool_end", events)
+	}
+	if events[1].Error != "tool call failed" {
+		t.Errorf("tool_end error = %q, expected the generic message", events[1].Error)
+	}
+	// The model still needs the details to recover
+	if !strings.Contains(toolResult, "internal stack trace") {
+		t.Errorf("tool result = %q, expected the error details", toolResult)
+	}
+}
@@ -0,0 +1,252 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abelclopes/nomad-iabot/internal/atomicfile"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+	"github.com/abelclopes/nomad-iabot/internal/skills"
+)
+
+// ErrUnknownTool is returned when toggling a tool no integration provides
+var ErrUnknownTool = errors.New("unknown tool")
+
+// ToolState describes a tool and whether it can currently be called
+type ToolState struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// toolOverrides is the on-disk form of the tools disabled at runtime
+type toolOverrides struct {
+	Disabled []string `json:"disabled"`
+}
+
+// ToolStates returns every tool provided by the configured integrations,
+// including the ones disabled at runtime
+func (a *Agent) ToolStates() []ToolState {
+	var states []ToolState
+	for _, def := range a.toolDefinitions() {
+		states = append(states, ToolState{
+			Name:        def.Function.Name,
+			Description: def.Function.Description,
+			Enabled:     a.skillsValidator.IsCommandAllowed(def.Function.Name),
+		})
+	}
+	return states
+}
+
+// toolDefinitions returns the definitions of every tool provider with the
+// TOOLS_DESCRIPTION_<TOOL> overrides applied, leaving out the tools turned
+// off by TOOLS_DISABLED
+func (a *Agent) toolDefinitions() []llm.Tool {
+	var defs []llm.Tool
+	for _, p := range a.tools {
+		for _, def := range p.GetToolDefinitions() {
+			if a.disabledByConfig(def.Function.Name) {
+				continue
+			}
+			if desc := a.config.Tools.Descriptions[def.Function.Name]; desc != "" {
+				def.Function.Description = desc
+			}
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
+// disabledByConfig reports whether TOOLS_DISABLED, or READ_ONLY for tools
+// that change data, turns tool off. Unlike runtime toggles, these cannot be
+// re-enabled through the admin API.
+func (a *Agent) disabledByConfig(tool string) bool {
+	if a.config.Security.ReadOnly && skills.IsMutatingCommand(tool) {
+		return true
+	}
+	for _, name := range a.config.Tools.Disabled {
+		if strings.TrimSpace(name) == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// warnUnknownToolConfig logs tool settings naming a tool no provider offers,
+// which usually means a typo in the variable name
+func (a *Agent) warnUnknownToolConfig() {
+	known := make(map[string]bool)
+	for _, p := range a.tools {
+		for _, def := range p.GetToolDefinitions() {
+			known[def.Function.Name] = true
+		}
+	}
+	for _, name := range a.config.Tools.Disabled {
+		if name = strings.TrimSpace(name); !known[name] {
+			a.logger.Warn("TOOLS_DISABLED names an unknown tool", "name", name)
+		}
+	}
+	for name := range a.config.Tools.Descriptions {
+		if !known[name] {
+			a.logger.Warn("tool description override for an unknown tool", "name", name)
+		}
+	}
+}
+
+// SetToolEnabled enables or disables a tool at runtime. A disabled tool is
+// no longer offered to the model and calls to it are rejected. The change
+// is saved to TOOLS_OVERRIDES_FILE when one is configured.
+func (a *Agent) SetToolEnabled(name string, enabled bool) (ToolState, error) {
+	a.overridesMu.Lock()
+	defer a.overridesMu.Unlock()
+
+	for _, state := range a.ToolStates() {
+		if state.Name != name {
+			continue
+		}
+		if !a.skillsValidator.IsRegistered(name) {
+			return ToolState{}, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+		}
+		a.skillsValidator.SetEnabled(name, enabled)
+		if err := a.saveToolOverrides(); err != nil {
+			a.skillsValidator.SetEnabled(name, state.Enabled)
+			return ToolState{}, err
+		}
+		a.logger.Info("tool toggled", "name", name, "enabled", enabled)
+		state.Enabled = enabled
+		return state, nil
+	}
+	return ToolState{}, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+}
+
+// loadToolOverrides disables the tools listed in TOOLS_OVERRIDES_FILE. A
+// missing file means nothing has been overridden yet.
+func (a *Agent) loadToolOverrides() error {
+	path := a.config.Tools.OverridesFile
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tool overrides: %w", err)
+	}
+	var overrides toolOverrides
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return fmt.Errorf("failed to parse tool overrides %s: %w", path, err)
+	}
+	for _, name := range overrides.Disabled {
+		a.skillsValidator.SetEnabled(name, false)
+	}
+	if len(overrides.Disabled) > 0 {
+		a.logger.Info("tools disabled by overrides", "tools", overrides.Disabled)
+	}
+	return nil
+}
+
+// saveToolOverrides writes the disabled tools to TOOLS_OVERRIDES_FILE,
+// replacing the file atomically so a crash never leaves it half written
+func (a *Agent) saveToolOverrides() error {
+	path := a.config.Tools.OverridesFile
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(toolOverrides{Disabled: a.skillsValidator.Disabled()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to save tool overrides: %w", err)
+	}
+	return nil
+}
+
+// allowedOnChannel reports whether channel may call tool under its
+// TOOLS_ALLOW_<CHANNEL> allowlist. Channels without one may call any tool.
+func (a *Agent) allowedOnChannel(channel, tool string) bool {
+	allowed, ok := a.config.Tools.ChannelAllowlists[strings.ToLower(channel)]
+	if !ok {
+		return true
+	}
+	for _, entry := range allowed {
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			if strings.HasPrefix(tool, prefix) {
+				return true
+			}
+		} else if entry == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// toolGuidancePreamble tells the model when and how to call tools; small
+// models often answer from memory or write the call as text instead
+const toolGuidancePreamble = `
+## Uso de Ferramentas
+Quando o pedido depender de dados ou ações das integrações, chame a ferramenta adequada em vez de responder de memória ou inventar resultados.
+- Chame ferramentas somente pelo mecanismo de chamada de funções, nunca escrevendo a chamada como texto ou JSON na resposta.
+- Use o nome exato da ferramenta e passe os argumentos como um objeto JSON válido, com todos os parâmetros obrigatórios.
+- Se faltar um parâmetro obrigatório, pergunte ao usuário antes de chamar a ferramenta.
+- Depois de receber o resultado, responda ao usuário com base nele.
+
+Ferramentas disponíveis (parâmetros obrigatórios entre parênteses):
+`
+
+// toolGuidance renders the tool-usage section of the system prompt: the
+// instructions above and one line per tool with its required parameters
+// and the first sentence of its description
+func toolGuidance(tools []llm.Tool) string {
+	if len(tools) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(toolGuidancePreamble)
+	for _, t := range tools {
+		required, _ := t.Function.Parameters["required"].([]string)
+		sb.WriteString(fmt.Sprintf("- %s(%s): %s\n", t.Function.Name, strings.Join(required, ", "), firstSentence(t.Function.Description)))
+	}
+	return sb.String()
+}
+
+// firstSentence returns the first line of s up to the end of its first
+// sentence
+func firstSentence(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	for _, sep := range []string{". ", "; "} {
+		if i := strings.Index(s, sep); i >= 0 {
+			s = s[:i]
+		}
+	}
+	return strings.TrimSuffix(s, ".")
+}
+
+// toolCallKey identifies a tool call by name and arguments. Arguments that
+// parse as JSON are compared by value, so formatting and key order do not
+// matter.
+func toolCallKey(tc llm.ToolCall) string {
+	args := tc.Function.Arguments
+	var v interface{}
+	if err := json.Unmarshal([]byte(args), &v); err == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			args = string(canonical)
+		}
+	}
+	return tc.Function.Name + "\x00" + args
+}
+
+// truncateResult cuts a tool result to limit bytes at a character
+// boundary, telling the model how much was left out
+func truncateResult(result string, limit int) string {
+	if len(result) <= limit {
+		return result
+	}
+	kept := strings.ToValidUTF8(result[:limit], "")
+	return kept + fmt.Sprintf("\n… (truncated, %d more bytes)", len(result)-len(kept))
+}
@@ -0,0 +1,256 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/caller"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+)
+
+func TestDisabledToolIsRejected(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {})
+	a.config.Tools.OverridesFile = filepath.Join(t.TempDir(), "tool-overrides.json")
+	tools := &fakeTools{names: []string{"list_items", "delete_items"}}
+	a.tools = append(a.tools, tools)
+	a.skillsValidator.RegisterCommands(tools.names)
+
+	if _, err := a.executeTool(context.Background(), "delete_items", "{}"); err != nil {
+		t.Fatalf("executeTool() before disabling error = %v", err)
+	}
+
+	state, err := a.SetToolEnabled("delete_items", false)
+	if err != nil {
+		t.Fatalf("SetToolEnabled() error = %v", err)
+	}
+	if state.Enabled {
+		t.Errorf("state = %+v, want disabled", state)
+	}
+
+	if _, err := a.executeTool(context.Background(), "delete_items", "{}"); err == nil || err.Error() != "operation not permitted" {
+		t.Errorf("executeTool() after disabling error = %v, want operation not permitted", err)
+	}
+	for _, def := range a.getAvailableTools("") {
+		if def.Function.Name == "delete_items" {
+			t.Error("disabled tool is still offered to the model")
+		}
+	}
+	if _, err := a.executeTool(context.Background(), "list_items", "{}"); err != nil {
+		t.Errorf("other tools are affected: %v", err)
+	}
+
+	// The override survives a restart
+	b, err := New(a.config, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	b.tools = append(b.tools, tools)
+	b.skillsValidator.RegisterCommands(tools.names)
+	if _, err := b.executeTool(context.Background(), "delete_items", "{}"); err == nil {
+		t.Error("override was not persisted")
+	}
+
+	if _, err := b.SetToolEnabled("delete_items", true); err != nil {
+		t.Fatalf("SetToolEnabled() error = %v", err)
+	}
+	if _, err := b.executeTool(context.Background(), "delete_items", "{}"); err != nil {
+		t.Errorf("executeTool() after re-enabling error = %v", err)
+	}
+}
+
+func TestSetToolEnabledUnknownTool(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {})
+	if _, err := a.SetToolEnabled("rm_rf", false); !errors.Is(err, ErrUnknownTool) {
+		t.Errorf("SetToolEnabled() error = %v, want ErrUnknownTool", err)
+	}
+}
+
+func TestToolAllowlistPerChannel(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {})
+	a.config.Tools.ChannelAllowlists = map[string][]string{
+		"webchat": {"devops_list_*", "devops_get_workitem"},
+	}
+	tools := &fakeTools{names: []string{"devops_list_pipelines", "devops_run_pipeline"}}
+	a.tools = append(a.tools, tools)
+	a.skillsValidator.RegisterCommands(tools.names)
+
+	webchat := caller.With(context.Background(), caller.Caller{UserID: "u1", Channel: "webchat"})
+	if _, err := a.executeTool(webchat, "devops_run_pipeline", "{}"); err == nil || !strings.Contains(err.Error(), "not available on webchat") {
+		t.Errorf("executeTool() on webchat error = %v, want not available", err)
+	}
+	if _, err := a.executeTool(webchat, "devops_list_pipelines", "{}"); err != nil {
+		t.Errorf("executeTool() list on webchat error = %v", err)
+	}
+
+	telegram := caller.With(context.Background(), caller.Caller{UserID: "u1", Channel: "telegram"})
+	if _, err := a.executeTool(telegram, "devops_run_pipeline", "{}"); err != nil {
+		t.Errorf("executeTool() on telegram error = %v", err)
+	}
+
+	for _, def := range a.getAvailableTools("webchat") {
+		if def.Function.Name == "devops_run_pipeline" {
+			t.Error("devops_run_pipeline offered to the model on webchat")
+		}
+	}
+}
+
+func TestToolConfigOverridesDescriptionAndDisables(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {})
+	a.config.Tools.Descriptions = map[string]string{"list_items": "List items; prefer this over search"}
+	a.config.Tools.Disabled = []string{" delete_items"}
+	tools := &fakeTools{names: []string{"list_items", "delete_items"}}
+	a.tools = append(a.tools, tools)
+	a.skillsValidator.RegisterCommands(tools.names)
+
+	var names []string
+	for _, def := range a.getAvailableTools("") {
+		names = append(names, def.Function.Name)
+		if def.Function.Name == "list_items" && def.Function.Description != "List items; prefer this over search" {
+			t.Errorf("list_items description = %q, want the override", def.Function.Description)
+		}
+	}
+	if strings.Join(names, ",") != "list_items" {
+		t.Errorf("offered tools = %v, want only list_items", names)
+	}
+
+	if _, err := a.executeTool(context.Background(), "delete_items", "{}"); err == nil || err.Error() != "operation not permitted" {
+		t.Errorf("executeTool() error = %v, want operation not permitted", err)
+	}
+	// Tools disabled by configuration are out of reach of the admin API
+	if _, err := a.SetToolEnabled("delete_items", true); !errors.Is(err, ErrUnknownTool) {
+		t.Errorf("SetToolEnabled() error = %v, want ErrUnknownTool", err)
+	}
+}
+
+func TestReadOnlyRemovesMutatingTools(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {})
+	a.config.Security.ReadOnly = true
+	tools := &fakeTools{names: []string{"devops_get_workitem", "devops_create_workitem", "trello_get_card", "trello_update_card"}}
+	a.tools = append(a.tools, tools)
+	a.skillsValidator.RegisterCommands(tools.names)
+
+	var names []string
+	for _, def := range a.getAvailableTools("") {
+		names = append(names, def.Function.Name)
+	}
+	if strings.Join(names, ",") != "devops_get_workitem,trello_get_card" {
+		t.Errorf("offered tools = %v, want only the read-only ones", names)
+	}
+
+	if _, err := a.executeTool(context.Background(), "devops_create_workitem", "{}"); err == nil || err.Error() != "operation not permitted" {
+		t.Errorf("executeTool() error = %v, want operation not permitted", err)
+	}
+	if _, err := a.executeTool(context.Background(), "devops_get_workitem", "{}"); err != nil {
+		t.Errorf("read-only tool failed: %v", err)
+	}
+}
+
+func TestToolGuidanceListsEnabledTools(t *testing.T) {
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {})
+	a.config.Tools.Descriptions = map[string]string{"list_items": "List items. Prefer this over search"}
+	tools := &fakeTools{names: []string{"list_items", "delete_items", "search_items"}}
+	a.tools = append(a.tools, tools)
+	a.skillsValidator.RegisterCommands(tools.names)
+	if _, err := a.SetToolEnabled("delete_items", false); err != nil {
+		t.Fatalf("SetToolEnabled() error = %v", err)
+	}
+
+	if strings.Contains(a.buildSystemPrompt("api"), "## Uso de Ferramentas") {
+		t.Error("tool guidance added while TOOLS_GUIDANCE is off")
+	}
+
+	a.config.Tools.Guidance = true
+	prompt := a.buildSystemPrompt("api")
+	for _, want := range []string{"## Uso de Ferramentas", "- list_items(): List items\n", "- search_items(): "} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("system prompt is missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "delete_items") {
+		t.Errorf("guidance lists a disabled tool:\n%s", prompt)
+	}
+}
+
+func TestToolGuidanceShowsRequiredParameters(t *testing.T) {
+	guidance := toolGuidance([]llm.Tool{{Type: "function", Function: llm.ToolFunction{
+		Name:        "get_item",
+		Description: "Get an item by ID; includes comments",
+		Parameters:  map[string]interface{}{"type": "object", "required": []string{"id", "project"}},
+	}}})
+	if !strings.Contains(guidance, "- get_item(id, project): Get an item by ID\n") {
+		t.Errorf("unexpected guidance:\n%s", guidance)
+	}
+	if toolGuidance(nil) != "" {
+		t.Error("guidance without tools should be empty")
+	}
+}
+
+// countingTools counts the executions of a fakeTools provider
+type countingTools struct {
+	fakeTools
+	calls int
+}
+
+func (c *countingTools) Execute(ctx context.Context, name string, args map[string]interface{}) (string, bool, error) {
+	c.calls++
+	return c.fakeTools.Execute(ctx, name, args)
+}
+
+func TestDuplicateToolCallsRunOnce(t *testing.T) {
+	var calls int
+	var toolResults []string
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			respondChat(w, "",
+				llm.ToolCall{ID: "1", Type: "function", Function: llm.ToolCallFunction{Name: "list_items", Arguments: `{"state":"Active","top":5}`}},
+				llm.ToolCall{ID: "2", Type: "function", Function: llm.ToolCallFunction{Name: "list_items", Arguments: `{ "top": 5, "state": "Active" }`}},
+				llm.ToolCall{ID: "3", Type: "function", Function: llm.ToolCallFunction{Name: "list_items", Arguments: `{"state":"Closed"}`}},
+			)
+			return
+		}
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, m := range req.Messages {
+			if m.Role == "tool" {
+				toolResults = append(toolResults, m.Content)
+			}
+		}
+		respondChat(w, "Pronto")
+	})
+	tools := &countingTools{fakeTools: fakeTools{names: []string{"list_items"}}}
+	a.tools = append(a.tools, tools)
+	a.skillsValidator.RegisterCommands(tools.names)
+
+	res, err := a.ProcessMessageWithAttachments(context.Background(), "alice", "api", "Liste", nil)
+	if err != nil {
+		t.Fatalf("ProcessMessageWithAttachments() error = %v", err)
+	}
+	if tools.calls != 2 {
+		t.Errorf("tool executed %d times, want 2 (the duplicate reuses the first result)", tools.calls)
+	}
+	if len(res.ToolCalls) != 2 {
+		t.Errorf("ToolCalls = %+v, want the two executions", res.ToolCalls)
+	}
+	if len(toolResults) != 3 || toolResults[0] != toolResults[1] {
+		t.Errorf("tool results sent to the model = %q, want one per call", toolResults)
+	}
+}
+
+func TestTruncateResult(t *testing.T) {
+	if got := truncateResult("short", 10); got != "short" {
+		t.Errorf("truncateResult under the limit = %q", got)
+	}
+	// "é" is two bytes; cutting through it drops it rather than half of it
+	got := truncateResult("abcé tail", 4)
+	if got != "abc\n… (truncated, 7 more bytes)" {
+		t.Errorf("truncateResult = %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+)
+
+// maxToolStats caps the (model, tool) pairs tracked, since a model may
+// invent any number of tool names
+const maxToolStats = 1000
+
+// ToolStat counts the outcomes of one model's calls to one tool. Malformed
+// counts calls whose arguments were not valid JSON; they are not included
+// in Failures, which are calls that ran (or were refused) and failed.
+type ToolStat struct {
+	Model     string `json:"model"`
+	Tool      string `json:"tool"`
+	Successes uint64 `json:"successes"`
+	Failures  uint64 `json:"failures"`
+	Malformed uint64 `json:"malformed_arguments"`
+}
+
+type toolStatKey struct{ model, tool string }
+
+// toolStats tracks tool call outcomes per model since the process started
+type toolStats struct {
+	mu    sync.Mutex
+	stats map[toolStatKey]*ToolStat
+}
+
+// malformedArgumentsError is returned by executeTool when the model sent
+// arguments that are not a JSON object
+type malformedArgumentsError struct {
+	err error
+}
+
+func (e *malformedArgumentsError) Error() string {
+	return "failed to parse arguments: " + e.err.Error()
+}
+
+func (e *malformedArgumentsError) Unwrap() error {
+	return e.err
+}
+
+// toolErrorMessage is what clients are told about a failed tool call.
+// Integration errors can carry URLs, response bodies and other internals,
+// so they are not passed on verbatim.
+func toolErrorMessage(err error) string {
+	var malformed *malformedArgumentsError
+	if errors.As(err, &malformed) {
+		return "invalid tool arguments"
+	}
+	return "tool call failed"
+}
+
+// record counts the outcome of a tool call, err being what executeTool
+// returned
+func (s *toolStats) record(model, tool string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := toolStatKey{model, tool}
+	stat, ok := s.stats[key]
+	if !ok {
+		if len(s.stats) >= maxToolStats {
+			return
+		}
+		if s.stats == nil {
+			s.stats = make(map[toolStatKey]*ToolStat)
+		}
+		stat = &ToolStat{Model: model, Tool: tool}
+		s.stats[key] = stat
+	}
+
+	var malformed *malformedArgumentsError
+	switch {
+	case err == nil:
+		stat.Successes++
+	case errors.As(err, &malformed):
+		stat.Malformed++
+	default:
+		stat.Failures++
+	}
+}
+
+// snapshot returns the counters ordered by model and tool
+func (s *toolStats) snapshot() []ToolStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]ToolStat, 0, len(s.stats))
+	for _, stat := range s.stats {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Model != stats[j].Model {
+			return stats[i].Model < stats[j].Model
+		}
+		return stats[i].Tool < stats[j].Tool
+	})
+	return stats
+}
+
+// ToolStats returns how each model's tool calls turned out, to compare how
+// reliably models drive the tools
+func (a *Agent) ToolStats() []ToolStat {
+	return a.toolStats.snapshot()
+}
+
+// recordToolCall counts a tool call made by the model that produced resp
+func (a *Agent) recordToolCall(resp *llm.ChatResponse, tool string, err error) {
+	model := a.config.LLM.Model
+	if resp != nil && resp.Model != "" {
+		model = resp.Model
+	}
+	a.toolStats.record(model, tool, err)
+
+	var malformed *malformedArgumentsError
+	if errors.As(err, &malformed) {
+		a.logger.Warn("model sent malformed tool arguments", "model", model, "tool", tool, "error", err)
+	}
+}
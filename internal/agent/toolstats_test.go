@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+)
+
+func TestToolStatsCountMalformedArgumentsSeparately(t *testing.T) {
+	var calls int
+	a := newTestAgent(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			respondChat(w, "",
+				llm.ToolCall{ID: "1", Type: "function", Function: llm.ToolCallFunction{Name: "list_items", Arguments: `{"state":"Active"}`}},
+				llm.ToolCall{ID: "2", Type: "function", Function: llm.ToolCallFunction{Name: "list_items", Arguments: `{"state":Active}`}},
+				llm.ToolCall{ID: "3", Type: "function", Function: llm.ToolCallFunction{Name: "not_allowed", Arguments: "{}"}},
+			)
+		default:
+			respondChat(w, "Pronto")
+		}
+	})
+	a.tools = append(a.tools, &fakeTools{names: []string{"list_items"}})
+	a.skillsValidator.RegisterCommands([]string{"list_items"})
+
+	if _, err := a.ProcessMessage(context.Background(), "alice", "api", "Liste"); err != nil {
+		t.Fatalf("ProcessMessage() error = %v", err)
+	}
+
+	want := []ToolStat{
+		{Model: "test-model", Tool: "list_items", Successes: 1, Malformed: 1},
+		{Model: "test-model", Tool: "not_allowed", Failures: 1},
+	}
+	if got := a.ToolStats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToolStats() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+// Package breaker implements a circuit breaker for outbound integrations.
+// After a number of consecutive failures the breaker opens and calls fail
+// fast for a cooldown; it then lets a single probe through (half-open) and
+// closes again once that probe succeeds.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+// ErrOpen is returned (wrapped) while a breaker is short-circuiting calls
+var ErrOpen = errors.New("integration temporarily unavailable")
+
+// State is the position of a breaker
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+// String returns the state name used in health reports
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Status is a point-in-time view of a breaker
+type Status struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Trips               uint64     `json:"trips"`
+	Rejected            uint64     `json:"rejected"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+// Breaker guards calls to one integration. A nil *Breaker is valid and
+// never short-circuits, so clients can hold one unconditionally.
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+	trips    uint64
+	rejected uint64
+	clock    clock.Clock
+}
+
+// New creates a breaker that opens after threshold consecutive failures and
+// stays open for cooldown. A threshold of 0 or less disables it (returns nil).
+func New(name string, threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &Breaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		clock:     clock.Real(),
+	}
+}
+
+// Name returns the integration the breaker guards
+func (b *Breaker) Name() string {
+	if b == nil {
+		return ""
+	}
+	return b.name
+}
+
+// Allow reports whether a call may proceed. Once the cooldown has elapsed an
+// open breaker admits a single probe; other callers keep failing fast until
+// that probe is recorded.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		remaining := b.cooldown - b.clock.Now().Sub(b.openedAt)
+		if remaining > 0 {
+			b.rejected++
+			return fmt.Errorf("%s: %w (retry in %s)", b.name, ErrOpen, remaining.Round(time.Second))
+		}
+		b.state = HalfOpen
+		b.probing = true
+		return nil
+	case HalfOpen:
+		if b.probing {
+			b.rejected++
+			return fmt.Errorf("%s: %w (probe in progress)", b.name, ErrOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success records a successful call, closing the breaker
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = Closed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure records a failed call, opening the breaker once the threshold is
+// reached or immediately when a half-open probe fails
+func (b *Breaker) Failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == HalfOpen || (b.state == Closed && b.failures >= b.threshold) {
+		b.state = Open
+		b.openedAt = b.clock.Now()
+		b.trips++
+	}
+}
+
+// release frees a half-open probe slot without judging the integration
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// Observe records the outcome of an HTTP call. Transport errors and 5xx
+// responses count as failures; calls cancelled by the caller count as
+// neither, since they say nothing about the integration's health.
+func (b *Breaker) Observe(resp *http.Response, err error) {
+	if b == nil {
+		return
+	}
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+		b.release()
+	case err != nil, resp != nil && resp.StatusCode >= http.StatusInternalServerError:
+		b.Failure()
+	default:
+		b.Success()
+	}
+}
+
+// State returns the current state, reporting an expired open breaker as half-open
+func (b *Breaker) State() State {
+	if b == nil {
+		return Closed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current()
+}
+
+// Status returns the breaker's state and counters
+func (b *Breaker) Status() Status {
+	if b == nil {
+		return Status{State: Closed.String()}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st := Status{
+		Name:                b.name,
+		State:               b.current().String(),
+		ConsecutiveFailures: b.failures,
+		Trips:               b.trips,
+		Rejected:            b.rejected,
+	}
+	if b.state != Closed {
+		openedAt := b.openedAt
+		st.OpenedAt = &openedAt
+	}
+	return st
+}
+
+func (b *Breaker) current() State {
+	if b.state == Open && b.clock.Now().Sub(b.openedAt) >= b.cooldown {
+		return HalfOpen
+	}
+	return b.state
+}
@@ -0,0 +1,104 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+func TestBreakerTripsAndRecovers(t *testing.T) {
+	b := New("devops", 3, 30*time.Second)
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	b.clock = clk
+
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Allow() before threshold = %v", err)
+		}
+		b.Observe(&http.Response{StatusCode: http.StatusBadGateway}, nil)
+	}
+	if got := b.State(); got != Closed {
+		t.Fatalf("state after 2 failures = %s, want closed", got)
+	}
+
+	// Client errors say nothing about availability and reset the streak
+	b.Observe(&http.Response{StatusCode: http.StatusNotFound}, nil)
+	if got := b.Status().ConsecutiveFailures; got != 0 {
+		t.Fatalf("failures after 404 = %d, want 0", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		b.Observe(nil, errors.New("connection refused"))
+	}
+	if got := b.State(); got != Open {
+		t.Fatalf("state after 3 failures = %s, want open", got)
+	}
+
+	err := b.Allow()
+	if !errors.Is(err, ErrOpen) {
+		t.Fatalf("Allow() while open = %v, want ErrOpen", err)
+	}
+
+	// Cooldown elapses: one probe is admitted, concurrent callers still fail fast
+	clk.Advance(30 * time.Second)
+	if got := b.State(); got != HalfOpen {
+		t.Fatalf("state after cooldown = %s, want half-open", got)
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe Allow() = %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("second Allow() during probe = %v, want ErrOpen", err)
+	}
+
+	// A failed probe reopens for another cooldown
+	b.Observe(nil, errors.New("timeout"))
+	if got := b.State(); got != Open {
+		t.Fatalf("state after failed probe = %s, want open", got)
+	}
+
+	clk.Advance(30 * time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("second probe Allow() = %v", err)
+	}
+	b.Observe(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	st := b.Status()
+	if st.State != "closed" || st.ConsecutiveFailures != 0 || st.OpenedAt != nil {
+		t.Errorf("status after recovery = %+v", st)
+	}
+	if st.Trips != 2 || st.Rejected != 2 {
+		t.Errorf("trips/rejected = %d/%d, want 2/2", st.Trips, st.Rejected)
+	}
+}
+
+func TestBreakerCancelledProbeReleasesSlot(t *testing.T) {
+	b := New("llm", 1, time.Second)
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	b.clock = clk
+
+	b.Failure()
+	clk.Advance(time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe Allow() = %v", err)
+	}
+	b.Observe(nil, context.Canceled)
+	if err := b.Allow(); err != nil {
+		t.Errorf("Allow() after cancelled probe = %v, want a new probe", err)
+	}
+}
+
+func TestNilBreakerIsDisabled(t *testing.T) {
+	b := New("llm", 0, time.Second)
+	if b != nil {
+		t.Fatal("New() with threshold 0 should return nil")
+	}
+	b.Failure()
+	if err := b.Allow(); err != nil {
+		t.Errorf("nil Allow() = %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+// Package caller carries the identity of the user a request is served for,
+// so tools can record who asked for an action without depending on the agent.
+package caller
+
+import "context"
+
+// Caller identifies the user behind a request
+type Caller struct {
+	UserID  string
+	Channel string // "telegram", "webchat", "api", ...
+}
+
+// String describes the caller for audit notes, e.g. "user 42 via telegram"
+func (c Caller) String() string {
+	if c.UserID == "" {
+		return "an unknown user"
+	}
+	if c.Channel == "" {
+		return "user " + c.UserID
+	}
+	return "user " + c.UserID + " via " + c.Channel
+}
+
+type contextKey struct{}
+
+// With returns a context carrying c
+func With(ctx context.Context, c Caller) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// From returns the caller attached to ctx, if any
+func From(ctx context.Context) (Caller, bool) {
+	c, ok := ctx.Value(contextKey{}).(Caller)
+	return c, ok
+}
@@ -0,0 +1,53 @@
+package channels
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// inflight collapses concurrent identical calls into one: callers that ask
+// for a key while it is running wait for and share the first call's result
+type inflight[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*inflightCall[T]
+}
+
+type inflightCall[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// do runs fn for key unless a call for key is already running, in which
+// case it waits for that call. shared reports whether the result came from
+// another caller. The key is released as soon as fn returns.
+func (g *inflight[T]) do(key string, fn func() (T, error)) (val T, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*inflightCall[T])
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		return c.val, c.err, true
+	}
+	c := &inflightCall[T]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
+
+// messageKey identifies a message within a session without keeping its text
+func messageKey(sessionID, content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return sessionID + ":" + hex.EncodeToString(sum[:])
+}
@@ -2,26 +2,67 @@ package channels
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	tele "gopkg.in/telebot.v3"
 
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+	"github.com/abelclopes/nomad-iabot/internal/clock"
 	"github.com/abelclopes/nomad-iabot/internal/config"
+	"github.com/abelclopes/nomad-iabot/internal/i18n"
+	"github.com/abelclopes/nomad-iabot/internal/identity"
+	"github.com/abelclopes/nomad-iabot/internal/skills"
+	"github.com/abelclopes/nomad-iabot/internal/usage"
 )
 
 // TelegramChannel handles Telegram bot integration
 type TelegramChannel struct {
-	cfg     *config.TelegramConfig
-	bot     *tele.Bot
-	logger  *slog.Logger
-	handler MessageHandler
+	cfg      *config.TelegramConfig
+	bot      *tele.Bot
+	logger   *slog.Logger
+	handler  MessageHandler
+	stream   StreamHandler // used for live replies when cfg.Streaming is set
+	feedback FeedbackHandler
+	limits   skills.InputLimits
+	newItem  NewItemHandler
+	forms    *formStore
+	usage    *usage.Tracker
+	export   ExportHandler
+	status   StatusHandler // nil keeps /status a fixed reply
+	greeting string   // replaces the localized /start greeting when set
+	caps     []string // capability keys listed under the greeting
+
+	identities *identity.Store // enables /link; nil when linking is disabled
+
+	planEnabled bool
+	plans       planToggles
+	locale   string // default locale when the user's language is unsupported
+	running  atomic.Bool
+
+	sleepFn func(time.Duration) // replaces time.Sleep between send retries in tests
+	clock   clock.Clock         // paces live reply edits
+
+	baseCtx  atomic.Pointer[context.Context] // set by Start; handlers are cancelled when it ends
+	handlers sync.WaitGroup                  // in-flight handlers, drained on shutdown
+	stopOnce sync.Once                       // guards bot.Stop, see stopPolling
 }
 
 // MessageHandler processes incoming messages
 type MessageHandler func(ctx context.Context, msg IncomingMessage) (string, error)
 
+// FeedbackHandler files feedback about the bot and returns a link to the created item
+type FeedbackHandler func(ctx context.Context, msg IncomingMessage) (string, error)
+
+// ExportHandler renders the conversation of msg's sender in format ("md" or
+// "txt"); an empty result means there is nothing to export
+type ExportHandler func(ctx context.Context, msg IncomingMessage, format string) (string, error)
+
 // IncomingMessage represents an incoming message from any channel
 type IncomingMessage struct {
 	Channel   string // "telegram", "webchat", etc.
@@ -49,8 +90,10 @@ func NewTelegramChannel(cfg *config.TelegramConfig, logger *slog.Logger, handler
 	tc := &TelegramChannel{
 		cfg:     cfg,
 		bot:     bot,
+		clock:   clock.Real(),
 		logger:  logger,
 		handler: handler,
+		forms:   newFormStore(),
 	}
 
 	tc.setupHandlers()
@@ -65,34 +108,204 @@ func (tc *TelegramChannel) setupHandlers() {
 	})
 
 	// Handle /start command
-	tc.bot.Handle("/start", func(c tele.Context) error {
-		return c.Send("👋 Olá! Eu sou o Nomad Agent. Como posso ajudar?")
-	})
+	tc.bot.Handle("/start", tc.handleStart)
 
 	// Handle /help command
 	tc.bot.Handle("/help", func(c tele.Context) error {
-		help := `🤖 *Nomad Agent*
-
-Comandos disponíveis:
-/start - Iniciar conversa
-/help - Mostrar esta ajuda
-/status - Ver status do sistema
-/workitems - Listar work items (Azure DevOps)
-
-Envie qualquer mensagem para conversar com o agente.`
-		return c.Send(help, tele.ModeMarkdown)
+		return tc.handleHelp(c)
 	})
 
 	// Handle /status command
-	tc.bot.Handle("/status", func(c tele.Context) error {
-		return c.Send("✅ Sistema operacional")
-	})
+	tc.bot.Handle("/status", tc.handleStatus)
 
 	// Handle /workitems command (Azure DevOps integration)
 	tc.bot.Handle("/workitems", func(c tele.Context) error {
 		// This will be handled by the agent with the DevOps tool
 		return tc.handleMessage(c)
 	})
+
+	// Handle /feedback command
+	tc.bot.Handle("/feedback", func(c tele.Context) error {
+		return tc.handleFeedback(c)
+	})
+
+	// Guided work item creation
+	tc.bot.Handle("/newitem", tc.handleNewItem)
+	tc.bot.Handle(&newItemTypeButton, tc.handleNewItemType)
+	tc.bot.Handle("/skip", tc.handleSkip)
+	tc.bot.Handle("/cancel", tc.handleCancel)
+
+	// Handle /usage command
+	tc.bot.Handle("/usage", tc.handleUsage)
+
+	// Handle /export command
+	tc.bot.Handle("/export", tc.handleExport)
+
+	// Handle /plan command
+	tc.bot.Handle("/plan", tc.handlePlan)
+
+	// Handle /link command
+	tc.bot.Handle("/link", tc.handleLink)
+}
+
+// SetClock replaces the clock that paces live reply edits
+func (tc *TelegramChannel) SetClock(c clock.Clock) {
+	tc.clock = c
+}
+
+// SetInputLimits bounds the size of messages accepted from users
+func (tc *TelegramChannel) SetInputLimits(limits skills.InputLimits) {
+	tc.limits = limits
+}
+
+// SetFeedbackHandler enables the /feedback command
+func (tc *TelegramChannel) SetFeedbackHandler(handler FeedbackHandler) {
+	tc.feedback = handler
+	tc.commandsChanged()
+}
+
+// SetGreeting customizes /start: greeting replaces the default text (empty
+// keeps it) and capabilities, keys as returned by the agent, are listed
+// below it as "capability.<key>" translations
+func (tc *TelegramChannel) SetGreeting(greeting string, capabilities []string) {
+	tc.greeting = greeting
+	tc.caps = capabilities
+}
+
+func (tc *TelegramChannel) handleStart(c tele.Context) error {
+	text := tc.greeting
+	if text == "" {
+		text = tc.t(c, "start")
+	}
+	if len(tc.caps) > 0 {
+		var sb strings.Builder
+		sb.WriteString(text)
+		sb.WriteString("\n\n")
+		sb.WriteString(tc.t(c, "start.capabilities"))
+		for _, key := range tc.caps {
+			sb.WriteString("\n• ")
+			sb.WriteString(tc.t(c, "capability."+key))
+		}
+		text = sb.String()
+	}
+	return c.Send(text)
+}
+
+// SetLocale sets the default locale for replies
+func (tc *TelegramChannel) SetLocale(locale string) {
+	tc.locale = locale
+	tc.commandsChanged()
+}
+
+// t translates key for the sender of c
+func (tc *TelegramChannel) t(c tele.Context, key string, args ...interface{}) string {
+	lang := ""
+	if c.Sender() != nil {
+		lang = c.Sender().LanguageCode
+	}
+	return i18n.T(i18n.Resolve(lang, tc.locale), key, args...)
+}
+
+// SetUsageTracker enables the /usage command
+func (tc *TelegramChannel) SetUsageTracker(tracker *usage.Tracker) {
+	tc.usage = tracker
+	tc.commandsChanged()
+}
+
+func (tc *TelegramChannel) handleUsage(c tele.Context) error {
+	if !tc.isUserAllowed(c.Sender().ID) {
+		return c.Send(tc.t(c, "error.unauthorized"))
+	}
+
+	if tc.usage == nil {
+		return c.Send(tc.t(c, "usage.disabled"))
+	}
+
+	st := tc.usage.Get(tc.userKey(c))
+	text := tc.t(c, "usage.summary", st.DayRequests, st.DayTokens, st.Requests, st.TotalTokens)
+	if limit := tc.usage.DailyTokenLimit(); limit > 0 {
+		text += tc.t(c, "usage.limit", limit)
+	}
+
+	return c.Send(text, tele.ModeMarkdown)
+}
+
+// SetExportHandler enables the /export command
+func (tc *TelegramChannel) SetExportHandler(handler ExportHandler) {
+	tc.export = handler
+	tc.commandsChanged()
+}
+
+func (tc *TelegramChannel) handleExport(c tele.Context) error {
+	if !tc.isUserAllowed(c.Sender().ID) {
+		return c.Send(tc.t(c, "error.unauthorized"))
+	}
+
+	if tc.export == nil {
+		return c.Send(tc.t(c, "export.disabled"))
+	}
+
+	// The export holds the whole conversation, so it never goes to a group
+	if c.Chat().Type != tele.ChatPrivate {
+		return c.Send(tc.t(c, "export.private"))
+	}
+
+	format := strings.ToLower(strings.TrimSpace(c.Message().Payload))
+	switch format {
+	case "", "md", "markdown":
+		format = "md"
+	case "txt", "text":
+		format = "txt"
+	default:
+		return c.Send(tc.t(c, "export.usage"))
+	}
+
+	msg := newIncomingMessage(c)
+	ctx, done := tc.requestContext()
+	defer done()
+	content, err := tc.export(ctx, msg, format)
+	if err != nil {
+		tc.logger.Error("failed to export conversation", "error", err, "user_id", msg.UserID)
+		return c.Send(tc.t(c, "export.failed"))
+	}
+	if content == "" {
+		return c.Send(tc.t(c, "export.empty"))
+	}
+
+	doc := &tele.Document{
+		File:     tele.FromReader(strings.NewReader(content)),
+		FileName: "conversa-" + time.Now().Format("2006-01-02") + "." + format,
+		Caption:  tc.t(c, "export.caption"),
+	}
+	return c.Send(doc)
+}
+
+func (tc *TelegramChannel) handleFeedback(c tele.Context) error {
+	if !tc.isUserAllowed(c.Sender().ID) {
+		return c.Send(tc.t(c, "error.unauthorized"))
+	}
+
+	if tc.feedback == nil {
+		return c.Send(tc.t(c, "feedback.disabled"))
+	}
+
+	text := strings.TrimSpace(c.Message().Payload)
+	if text == "" {
+		return c.Send(tc.t(c, "feedback.usage"))
+	}
+
+	msg := newIncomingMessage(c)
+	msg.Text = text
+
+	ctx, done := tc.requestContext()
+	defer done()
+	link, err := tc.feedback(ctx, msg)
+	if err != nil {
+		tc.logger.Error("failed to submit feedback", "error", err, "user_id", msg.UserID)
+		return c.Send(tc.t(c, "feedback.failed"))
+	}
+
+	return c.Send(tc.t(c, "feedback.done", link))
 }
 
 func (tc *TelegramChannel) handleMessage(c tele.Context) error {
@@ -102,25 +315,27 @@ func (tc *TelegramChannel) handleMessage(c tele.Context) error {
 			"user_id", c.Sender().ID,
 			"username", c.Sender().Username,
 		)
-		return c.Send("❌ Você não tem permissão para usar este bot.")
+		return c.Send(tc.t(c, "error.unauthorized"))
 	}
 
-	// Build incoming message
-	msg := IncomingMessage{
-		Channel:  "telegram",
-		UserID:   strconv.FormatInt(c.Sender().ID, 10),
-		Username: c.Sender().Username,
-		Text:     c.Text(),
-		ChatID:   strconv.FormatInt(c.Chat().ID, 10),
-		IsGroup:  c.Chat().Type == tele.ChatGroup || c.Chat().Type == tele.ChatSuperGroup,
-		Metadata: map[string]string{
-			"first_name": c.Sender().FirstName,
-			"last_name":  c.Sender().LastName,
-		},
+	// Reject oversized (e.g. forwarded) text before it reaches the agent
+	if err := tc.limits.Check(c.Text()); err != nil {
+		tc.logger.Warn("rejected oversized telegram message",
+			"user_id", c.Sender().ID,
+			"length", len(c.Text()),
+		)
+		return c.Send(tc.t(c, "error.too_long"))
 	}
 
-	if c.Message().ReplyTo != nil {
-		msg.ReplyToID = strconv.Itoa(c.Message().ReplyTo.ID)
+	// Answers to an in-progress /newitem form don't go to the agent
+	if handled, err := tc.handleFormInput(c); handled {
+		return err
+	}
+
+	// Build incoming message
+	msg := newIncomingMessage(c)
+	if tc.plans.enabled(c.Sender().ID) {
+		msg.Metadata[MetadataPlan] = "true"
 	}
 
 	tc.logger.Info("received telegram message",
@@ -129,21 +344,55 @@ func (tc *TelegramChannel) handleMessage(c tele.Context) error {
 		"is_group", msg.IsGroup,
 	)
 
-	// Show typing indicator
-	_ = c.Notify(tele.Typing)
-
 	// Process message
-	ctx := context.Background()
+	ctx, done := tc.requestContext()
+	defer done()
+	if tc.cfg.Streaming && tc.stream != nil && msg.Metadata[MetadataPlan] != "true" {
+		// The reply being edited in place shows progress
+		_ = c.Notify(tele.Typing)
+		return tc.streamReply(ctx, c, msg)
+	}
+	stopTyping := tc.keepTyping(ctx, c)
 	response, err := tc.handler(ctx, msg)
+	stopTyping()
+	if errors.Is(err, agent.ErrBusy) {
+		return c.Send(tc.t(c, "error.busy"))
+	}
+	if reply := tc.interrupted(c, err); reply != "" {
+		tc.logger.Warn("message processing interrupted", "error", err)
+		return c.Send(reply)
+	}
 	if err != nil {
 		tc.logger.Error("failed to process message", "error", err)
-		return c.Send("❌ Desculpe, ocorreu um erro ao processar sua mensagem.")
+		return c.Send(tc.t(c, "error.processing"))
 	}
 
 	// Send response (split if too long)
 	return tc.sendLongMessage(c, response)
 }
 
+// newIncomingMessage builds the channel-agnostic message for a Telegram update
+func newIncomingMessage(c tele.Context) IncomingMessage {
+	msg := IncomingMessage{
+		Channel:  "telegram",
+		UserID:   strconv.FormatInt(c.Sender().ID, 10),
+		Username: c.Sender().Username,
+		Text:     c.Text(),
+		ChatID:   strconv.FormatInt(c.Chat().ID, 10),
+		IsGroup:  c.Chat().Type == tele.ChatGroup || c.Chat().Type == tele.ChatSuperGroup,
+		Metadata: map[string]string{
+			"first_name": c.Sender().FirstName,
+			"last_name":  c.Sender().LastName,
+		},
+	}
+
+	if c.Message().ReplyTo != nil {
+		msg.ReplyToID = strconv.Itoa(c.Message().ReplyTo.ID)
+	}
+
+	return msg
+}
+
 func (tc *TelegramChannel) isUserAllowed(userID int64) bool {
 	// If no allowlist configured, allow all
 	if len(tc.cfg.AllowFrom) == 0 {
@@ -161,14 +410,18 @@ func (tc *TelegramChannel) isUserAllowed(userID int64) bool {
 func (tc *TelegramChannel) sendLongMessage(c tele.Context, text string) error {
 	const maxLength = 4000
 
-	if len(text) <= maxLength {
-		return c.Send(text)
-	}
-
-	// Split into chunks
-	chunks := splitText(text, maxLength)
-	for _, chunk := range chunks {
-		if err := c.Send(chunk); err != nil {
+	chunks := splitFormatted(text, maxLength)
+	for i, chunk := range chunks {
+		if err := tc.sendMarkdownV2(c, chunk.markdownV2, chunk.plain); err != nil {
+			tc.logger.Error("failed to send reply",
+				"chunk", i+1,
+				"chunks", len(chunks),
+				"error", err,
+			)
+			// Best effort: the notice may fail for the same reason
+			if notifyErr := c.Send(tc.t(c, "error.truncated")); notifyErr != nil {
+				tc.logger.Warn("failed to send truncation notice", "error", notifyErr)
+			}
 			return err
 		}
 	}
@@ -178,19 +431,33 @@ func (tc *TelegramChannel) sendLongMessage(c tele.Context, text string) error {
 // Start starts the Telegram bot
 func (tc *TelegramChannel) Start(ctx context.Context) error {
 	tc.logger.Info("starting Telegram bot")
-	
+
+	// Publish the command menu; the bot still works without it
+	if err := tc.RegisterCommands(); err != nil {
+		tc.logger.Warn("failed to register Telegram commands", "error", err)
+	}
+	tc.setBaseContext(ctx)
+	tc.running.Store(true)
+	defer tc.running.Store(false)
+
 	go func() {
 		tc.bot.Start()
 	}()
 
 	<-ctx.Done()
-	tc.bot.Stop()
+	tc.stopPolling()
+	// Handlers were cancelled with ctx; give them a moment to reply
+	drainCtx, cancel := context.WithTimeout(context.Background(), shutdownDrain)
+	defer cancel()
+	if !tc.drain(drainCtx) {
+		tc.logger.Warn("telegram handlers still running after shutdown")
+	}
 	return nil
 }
 
 // Stop stops the Telegram bot
 func (tc *TelegramChannel) Stop() {
-	tc.bot.Stop()
+	tc.stopPolling()
 }
 
 // SendMessage sends a message to a specific chat
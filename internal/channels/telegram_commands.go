@@ -0,0 +1,82 @@
+package channels
+
+import (
+	tele "gopkg.in/telebot.v3"
+
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+	"github.com/abelclopes/nomad-iabot/internal/i18n"
+)
+
+// handleHelp lists the commands and, while no integration is configured,
+// how to enable one
+func (tc *TelegramChannel) handleHelp(c tele.Context) error {
+	text := tc.t(c, "help")
+	if !tc.hasToolCapability() {
+		text += "\n\n" + tc.t(c, "help.setup")
+	}
+	return c.Send(text, tele.ModeMarkdown)
+}
+
+// hasToolCapability reports whether the agent can act on any integration
+func (tc *TelegramChannel) hasToolCapability() bool {
+	for _, key := range tc.caps {
+		if key == agent.CapabilityDevOps || key == agent.CapabilityTrello {
+			return true
+		}
+	}
+	return false
+}
+
+// commandList returns the command menu for locale, including integration
+// commands only when they are enabled
+func (tc *TelegramChannel) commandList(locale string) []tele.Command {
+	names := []string{"start", "help", "status"}
+	if tc.newItem != nil {
+		names = append(names, "workitems", "newitem")
+	}
+	if tc.feedback != nil {
+		names = append(names, "feedback")
+	}
+	if tc.usage != nil {
+		names = append(names, "usage")
+	}
+	if tc.export != nil {
+		names = append(names, "export")
+	}
+	if tc.planEnabled {
+		names = append(names, "plan")
+	}
+	if tc.identities != nil {
+		names = append(names, "link")
+	}
+
+	commands := make([]tele.Command, len(names))
+	for i, name := range names {
+		commands[i] = tele.Command{Text: name, Description: i18n.T(locale, "cmd."+name)}
+	}
+	return commands
+}
+
+// RegisterCommands publishes the command menu with Telegram (setMyCommands):
+// once per supported language, plus the default locale for everyone else
+func (tc *TelegramChannel) RegisterCommands() error {
+	if err := tc.bot.SetCommands(tc.commandList(i18n.Resolve("", tc.locale))); err != nil {
+		return err
+	}
+	for _, locale := range i18n.Locales() {
+		if err := tc.bot.SetCommands(tc.commandList(locale), i18n.LanguageCode(locale)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commandsChanged re-registers the menu when an integration is enabled after startup
+func (tc *TelegramChannel) commandsChanged() {
+	if !tc.running.Load() {
+		return
+	}
+	if err := tc.RegisterCommands(); err != nil {
+		tc.logger.Warn("failed to update Telegram command menu", "error", err)
+	}
+}
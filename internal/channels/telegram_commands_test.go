@@ -0,0 +1,100 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+func TestRegisterCommands(t *testing.T) {
+	var calls []tele.CommandParams
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/setMyCommands") {
+			t.Errorf("unexpected Bot API call %s", r.URL.Path)
+		}
+		var params tele.CommandParams
+		json.NewDecoder(r.Body).Decode(&params)
+		calls = append(calls, params)
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer srv.Close()
+
+	bot, err := tele.NewBot(tele.Settings{URL: srv.URL, Token: "test-token", Offline: true})
+	if err != nil {
+		t.Fatalf("NewBot() error = %v", err)
+	}
+
+	tc := &TelegramChannel{bot: bot, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	tc.SetLocale("en")
+	tc.SetFeedbackHandler(func(ctx context.Context, msg IncomingMessage) (string, error) { return "", nil })
+
+	if len(calls) != 0 {
+		t.Fatalf("setters registered commands before the bot started: %d calls", len(calls))
+	}
+
+	if err := tc.RegisterCommands(); err != nil {
+		t.Fatalf("RegisterCommands() error = %v", err)
+	}
+
+	if len(calls) != 3 {
+		t.Fatalf("setMyCommands called %d times, expected 3", len(calls))
+	}
+
+	expected := map[string]string{"": "Show help", "pt": "Mostrar ajuda", "en": "Show help"}
+	for _, call := range calls {
+		names := make([]string, len(call.Commands))
+		help := ""
+		for i, cmd := range call.Commands {
+			names[i] = cmd.Text
+			if cmd.Text == "help" {
+				help = cmd.Description
+			}
+		}
+		if got := strings.Join(names, ","); got != "start,help,status,feedback" {
+			t.Errorf("language %q commands = %s", call.LanguageCode, got)
+		}
+		if help != expected[call.LanguageCode] {
+			t.Errorf("language %q help description = %q, expected %q", call.LanguageCode, help, expected[call.LanguageCode])
+		}
+	}
+
+	// Enabling an integration while running updates the menu
+	tc.running.Store(true)
+	tc.SetNewItemHandler(func(ctx context.Context, msg IncomingMessage, form WorkItemForm) (string, error) { return "", nil })
+	if len(calls) != 6 {
+		t.Fatalf("setMyCommands called %d times after SetNewItemHandler, expected 6", len(calls))
+	}
+	if last := calls[len(calls)-1].Commands; len(last) != 6 || last[3].Text != "workitems" || last[4].Text != "newitem" {
+		t.Errorf("commands after enabling /newitem = %+v", last)
+	}
+}
+
+func TestHelpExplainsSetupWithoutIntegrations(t *testing.T) {
+	tc := &TelegramChannel{}
+	tc.SetLocale("en")
+	tc.SetGreeting("", []string{"chat", "code"})
+	c := &fakeContext{sender: &tele.User{ID: 1}}
+
+	if err := tc.handleHelp(c); err != nil {
+		t.Fatalf("handleHelp() error = %v", err)
+	}
+	if !strings.Contains(c.sent[0], "No integration is active") || !strings.Contains(c.sent[0], "`AZURE_DEVOPS_PAT`") {
+		t.Errorf("help = %q, want setup instructions", c.sent[0])
+	}
+
+	tc.SetGreeting("", []string{"chat", "code", "trello"})
+	c.sent = nil
+	if err := tc.handleHelp(c); err != nil {
+		t.Fatalf("handleHelp() error = %v", err)
+	}
+	if strings.Contains(c.sent[0], "No integration is active") {
+		t.Errorf("help = %q, want no setup instructions with Trello enabled", c.sent[0])
+	}
+}
@@ -0,0 +1,95 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// shutdownDrain bounds how long Start waits for in-flight handlers after
+// its context ends. They are cancelled by then and only need to reply.
+const shutdownDrain = 10 * time.Second
+
+// setBaseContext sets the context every message is processed under
+func (tc *TelegramChannel) setBaseContext(ctx context.Context) {
+	tc.baseCtx.Store(&ctx)
+}
+
+// baseContext returns the context set by Start, or a background context
+// before Start
+func (tc *TelegramChannel) baseContext() context.Context {
+	if p := tc.baseCtx.Load(); p != nil {
+		return *p
+	}
+	return context.Background()
+}
+
+// requestContext returns the context a handler works under: it ends when
+// the bot shuts down or the message timeout passes. done must be called
+// when the handler finishes, so shutdown can wait for it.
+func (tc *TelegramChannel) requestContext() (ctx context.Context, done func()) {
+	base := tc.baseContext()
+
+	var cancel context.CancelFunc
+	if timeout := time.Duration(tc.cfg.MessageTimeoutSec) * time.Second; timeout > 0 {
+		ctx, cancel = context.WithTimeout(base, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(base)
+	}
+
+	tc.handlers.Add(1)
+	return ctx, func() {
+		cancel()
+		tc.handlers.Done()
+	}
+}
+
+// drain waits until in-flight handlers finish or ctx ends and reports
+// whether they all finished
+func (tc *TelegramChannel) drain(ctx context.Context) bool {
+	finished := make(chan struct{})
+	go func() {
+		tc.handlers.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// stopPolling stops receiving updates. telebot's Stop blocks when the
+// poller is not running, so it runs once and only after Start.
+func (tc *TelegramChannel) stopPolling() {
+	if !tc.running.Load() {
+		return
+	}
+	tc.stopOnce.Do(tc.bot.Stop)
+}
+
+// Shutdown stops receiving updates and waits for in-flight handlers to
+// send their replies, until ctx ends
+func (tc *TelegramChannel) Shutdown(ctx context.Context) error {
+	tc.stopPolling()
+	if !tc.drain(ctx) {
+		return errors.New("telegram handlers still running")
+	}
+	return nil
+}
+
+// interrupted returns the reply for a handler whose context ended, or ""
+// when err is not about the context
+func (tc *TelegramChannel) interrupted(c tele.Context, err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return tc.t(c, "error.shutdown")
+	case errors.Is(err, context.DeadlineExceeded):
+		return tc.t(c, "error.timeout")
+	}
+	return ""
+}
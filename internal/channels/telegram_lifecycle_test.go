@@ -0,0 +1,95 @@
+package channels
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+
+	"github.com/abelclopes/nomad-iabot/internal/config"
+)
+
+// textContext is a fakeContext carrying an incoming text message
+type textContext struct {
+	fakeContext
+	text string
+}
+
+func (c *textContext) Text() string                        { return c.text }
+func (c *textContext) Chat() *tele.Chat                    { return &tele.Chat{ID: c.sender.ID, Type: tele.ChatPrivate} }
+func (c *textContext) Message() *tele.Message              { return &tele.Message{Text: c.text} }
+func (c *textContext) Notify(action tele.ChatAction) error { return nil }
+
+func TestCancellingBaseContextAbortsHandler(t *testing.T) {
+	started := make(chan struct{})
+	handler := func(ctx context.Context, msg IncomingMessage) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	tc := &TelegramChannel{
+		cfg:     &config.TelegramConfig{MessageTimeoutSec: 60},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		handler: handler,
+		forms:   newFormStore(),
+	}
+	tc.SetLocale("en")
+
+	parent, cancel := context.WithCancel(context.Background())
+	tc.setBaseContext(parent)
+
+	c := &textContext{fakeContext: fakeContext{sender: &tele.User{ID: 1}}, text: "list my work items"}
+	finished := make(chan error, 1)
+	go func() { finished <- tc.handleMessage(c) }()
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-finished:
+		if err != nil {
+			t.Fatalf("handleMessage() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler kept running after the parent context was cancelled")
+	}
+	drainCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if !tc.drain(drainCtx) {
+		t.Error("drain() reported handlers still running")
+	}
+	if len(c.sent) != 1 || c.sent[0] != tc.t(c, "error.shutdown") {
+		t.Errorf("sent %q, want the shutdown notice", c.sent)
+	}
+}
+
+func TestMessageTimeoutEndsHandler(t *testing.T) {
+	tc := &TelegramChannel{cfg: &config.TelegramConfig{MessageTimeoutSec: 1}}
+
+	ctx, done := tc.requestContext()
+	defer done()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > time.Second {
+		t.Errorf("deadline = %v, %v; want within a second", deadline, ok)
+	}
+}
+
+func TestShutdownWaitsForHandlersWithinBudget(t *testing.T) {
+	tc := &TelegramChannel{cfg: &config.TelegramConfig{}}
+
+	_, done := tc.requestContext()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	// The bot never started polling, so there is nothing to stop
+	if err := tc.Shutdown(ctx); err == nil {
+		t.Error("Shutdown() returned while a handler was still running")
+	}
+
+	done()
+	if err := tc.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package channels
+
+import (
+	"strconv"
+
+	tele "gopkg.in/telebot.v3"
+
+	"github.com/abelclopes/nomad-iabot/internal/identity"
+)
+
+// SetIdentities enables the /link command, which gives users a code to link
+// a WebChat session to their Telegram account, and makes /usage report the
+// linked user
+func (tc *TelegramChannel) SetIdentities(store *identity.Store) {
+	tc.identities = store
+	tc.commandsChanged()
+}
+
+// userKey returns the user the agent keeps memory and usage under for the
+// sender of c
+func (tc *TelegramChannel) userKey(c tele.Context) string {
+	return tc.identities.Resolve("telegram", strconv.FormatInt(c.Sender().ID, 10))
+}
+
+func (tc *TelegramChannel) handleLink(c tele.Context) error {
+	if !tc.isUserAllowed(c.Sender().ID) {
+		return c.Send(tc.t(c, "error.unauthorized"))
+	}
+
+	if tc.identities == nil {
+		return c.Send(tc.t(c, "link.disabled"))
+	}
+
+	code, err := tc.identities.NewCode("telegram", strconv.FormatInt(c.Sender().ID, 10))
+	if err != nil {
+		tc.logger.Error("failed to create link code", "error", err, "user_id", c.Sender().ID)
+		return c.Send(tc.t(c, "link.failed"))
+	}
+
+	return c.Send(tc.t(c, "link.code", code, int(identity.CodeTTL.Minutes())), tele.ModeMarkdown)
+}
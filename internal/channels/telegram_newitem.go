@@ -0,0 +1,226 @@
+package channels
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+	"github.com/abelclopes/nomad-iabot/internal/skills"
+)
+
+// newItemTimeout discards a /newitem form after this much inactivity
+const newItemTimeout = 10 * time.Minute
+
+// newItemTypes are offered as inline buttons when a /newitem flow starts
+var newItemTypes = []string{"Task", "Bug", "User Story", "Feature", "Epic"}
+
+// newItemTypeButton is the callback button used for type selection
+var newItemTypeButton = tele.Btn{Unique: "newitem_type"}
+
+// WorkItemForm is a work item collected through the /newitem flow
+type WorkItemForm struct {
+	Type        string
+	Title       string
+	Description string
+}
+
+// NewItemHandler creates the work item described by form and returns a link to it
+type NewItemHandler func(ctx context.Context, msg IncomingMessage, form WorkItemForm) (string, error)
+
+type formStep int
+
+const (
+	stepType formStep = iota
+	stepTitle
+	stepDescription
+)
+
+type pendingForm struct {
+	form    WorkItemForm
+	step    formStep
+	updated time.Time
+}
+
+// formKey identifies a /newitem form: the chat it runs in and the user
+// filling it, so members of a group chat each have their own
+type formKey struct {
+	chatID int64
+	userID int64
+}
+
+// formKeyFor returns the key of the form c's sender fills in c's chat
+func formKeyFor(c tele.Context) formKey {
+	return formKey{chatID: c.Chat().ID, userID: c.Sender().ID}
+}
+
+// formStore tracks in-progress /newitem forms per chat and user
+type formStore struct {
+	mu    sync.Mutex
+	forms map[formKey]*pendingForm
+	clock clock.Clock
+}
+
+func newFormStore() *formStore {
+	return &formStore{
+		forms: make(map[formKey]*pendingForm),
+		clock: clock.Real(),
+	}
+}
+
+// start begins a new form for key, replacing any previous one. Forms
+// abandoned past the timeout are swept at the same time, so they don't
+// pile up when users never come back to them.
+func (s *formStore) start(key formKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	for k, f := range s.forms {
+		if now.Sub(f.updated) > newItemTimeout {
+			delete(s.forms, k)
+		}
+	}
+	s.forms[key] = &pendingForm{step: stepType, updated: now}
+}
+
+// get returns a copy of the active form for key, dropping it if it expired
+func (s *formStore) get(key formKey) (pendingForm, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.forms[key]
+	if !ok {
+		return pendingForm{}, false
+	}
+	if s.clock.Now().Sub(f.updated) > newItemTimeout {
+		delete(s.forms, key)
+		return pendingForm{}, false
+	}
+	return *f, true
+}
+
+// put stores an updated form and refreshes its inactivity timer
+func (s *formStore) put(key formKey, f pendingForm) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f.updated = s.clock.Now()
+	s.forms[key] = &f
+}
+
+// remove discards the form for key and reports whether one existed
+func (s *formStore) remove(key formKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.forms[key]
+	delete(s.forms, key)
+	return ok
+}
+
+// SetNewItemHandler enables the /newitem command
+func (tc *TelegramChannel) SetNewItemHandler(handler NewItemHandler) {
+	tc.newItem = handler
+	tc.commandsChanged()
+}
+
+func (tc *TelegramChannel) handleNewItem(c tele.Context) error {
+	if !tc.isUserAllowed(c.Sender().ID) {
+		return c.Send(tc.t(c, "error.unauthorized"))
+	}
+
+	if tc.newItem == nil {
+		return c.Send(tc.t(c, "newitem.disabled"))
+	}
+
+	tc.forms.start(formKeyFor(c))
+
+	markup := &tele.ReplyMarkup{}
+	rows := make([]tele.Row, 0, len(newItemTypes))
+	for _, t := range newItemTypes {
+		rows = append(rows, markup.Row(markup.Data(t, newItemTypeButton.Unique, t)))
+	}
+	markup.Inline(rows...)
+
+	return c.Send(tc.t(c, "newitem.ask_type"), markup)
+}
+
+func (tc *TelegramChannel) handleNewItemType(c tele.Context) error {
+	key := formKeyFor(c)
+	f, ok := tc.forms.get(key)
+	if !ok || f.step != stepType {
+		return c.Respond(&tele.CallbackResponse{Text: tc.t(c, "newitem.none")})
+	}
+
+	itemType := c.Data()
+	if !skills.ValidateDevOpsWorkItemType(itemType) {
+		return c.Respond(&tele.CallbackResponse{Text: tc.t(c, "newitem.invalid_type")})
+	}
+
+	f.form.Type = itemType
+	f.step = stepTitle
+	tc.forms.put(key, f)
+
+	_ = c.Respond()
+	return c.Send(tc.t(c, "newitem.ask_title", itemType))
+}
+
+// handleFormInput consumes a text message for an active /newitem form. It
+// returns false when the sender has no form in progress in the chat.
+func (tc *TelegramChannel) handleFormInput(c tele.Context) (bool, error) {
+	key := formKeyFor(c)
+	f, ok := tc.forms.get(key)
+	if !ok {
+		return false, nil
+	}
+
+	text := strings.TrimSpace(c.Text())
+
+	switch f.step {
+	case stepType:
+		return true, c.Send(tc.t(c, "newitem.pick_type"))
+	case stepTitle:
+		if text == "" {
+			return true, c.Send(tc.t(c, "newitem.empty_title"))
+		}
+		f.form.Title = text
+		f.step = stepDescription
+		tc.forms.put(key, f)
+		return true, c.Send(tc.t(c, "newitem.ask_desc"))
+	default:
+		f.form.Description = text
+		return true, tc.submitForm(c, f.form)
+	}
+}
+
+func (tc *TelegramChannel) handleSkip(c tele.Context) error {
+	f, ok := tc.forms.get(formKeyFor(c))
+	if !ok || f.step != stepDescription {
+		return c.Send(tc.t(c, "newitem.nothing_skip"))
+	}
+	return tc.submitForm(c, f.form)
+}
+
+func (tc *TelegramChannel) handleCancel(c tele.Context) error {
+	if tc.forms.remove(formKeyFor(c)) {
+		return c.Send(tc.t(c, "newitem.cancelled"))
+	}
+	return c.Send(tc.t(c, "newitem.nothing_cancel"))
+}
+
+func (tc *TelegramChannel) submitForm(c tele.Context, form WorkItemForm) error {
+	tc.forms.remove(formKeyFor(c))
+
+	msg := newIncomingMessage(c)
+	ctx, done := tc.requestContext()
+	defer done()
+	link, err := tc.newItem(ctx, msg, form)
+	if err != nil {
+		tc.logger.Error("failed to create work item", "error", err, "user_id", msg.UserID)
+		return c.Send(tc.t(c, "newitem.failed"))
+	}
+
+	return c.Send(tc.t(c, "newitem.created", form.Type, link))
+}
@@ -0,0 +1,78 @@
+package channels
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+func TestFormStoreExpiresInactiveForms(t *testing.T) {
+	s := newFormStore()
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	s.clock = clk
+	key := formKey{chatID: 42, userID: 7}
+
+	s.start(key)
+
+	f, ok := s.get(key)
+	if !ok || f.step != stepType {
+		t.Fatalf("get() = %+v, %v; expected new form at type step", f, ok)
+	}
+
+	// Each answer refreshes the inactivity timer
+	clk.Advance(newItemTimeout - time.Minute)
+	f.form.Type = "Bug"
+	f.step = stepTitle
+	s.put(key, f)
+
+	clk.Advance(newItemTimeout - time.Minute)
+	f, ok = s.get(key)
+	if !ok || f.form.Type != "Bug" || f.step != stepTitle {
+		t.Fatalf("get() = %+v, %v; expected form kept after activity", f, ok)
+	}
+
+	clk.Advance(newItemTimeout + time.Second)
+	if _, ok := s.get(key); ok {
+		t.Error("get() returned a form after the inactivity timeout")
+	}
+	if s.remove(key) {
+		t.Error("remove() reported an expired form as active")
+	}
+}
+
+func TestFormStoreIsPerChatAndUser(t *testing.T) {
+	s := newFormStore()
+	alice := formKey{chatID: -100, userID: 1}
+	s.start(alice)
+
+	if _, ok := s.get(formKey{chatID: 2, userID: 1}); ok {
+		t.Error("get() returned a form for a chat that never started one")
+	}
+	// Another member of the same group must not answer alice's form
+	if _, ok := s.get(formKey{chatID: -100, userID: 2}); ok {
+		t.Error("get() returned another user's form in a group chat")
+	}
+	if !s.remove(alice) {
+		t.Error("remove() = false, expected true for active form")
+	}
+	if _, ok := s.get(alice); ok {
+		t.Error("get() returned a form after remove()")
+	}
+}
+
+func TestFormStoreSweepsAbandonedForms(t *testing.T) {
+	s := newFormStore()
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	s.clock = clk
+
+	for user := int64(1); user <= 3; user++ {
+		s.start(formKey{chatID: -100, userID: user})
+	}
+	clk.Advance(newItemTimeout + time.Second)
+	s.start(formKey{chatID: -100, userID: 4})
+
+	if n := len(s.forms); n != 1 {
+		t.Errorf("store holds %d forms, want only the new one after the sweep", n)
+	}
+}
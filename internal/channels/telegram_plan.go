@@ -0,0 +1,60 @@
+package channels
+
+import (
+	"sync"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// MetadataPlan is set to "true" on messages from users who turned plan mode
+// on with /plan; the handler should explain intended tool calls first
+const MetadataPlan = "plan"
+
+// planToggles remembers which users turned plan mode on
+type planToggles struct {
+	mu sync.Mutex
+	on map[int64]bool
+}
+
+// toggle flips plan mode for userID and returns the new state
+func (p *planToggles) toggle(userID int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.on == nil {
+		p.on = make(map[int64]bool)
+	}
+	if p.on[userID] {
+		delete(p.on, userID)
+		return false
+	}
+	p.on[userID] = true
+	return true
+}
+
+func (p *planToggles) enabled(userID int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.on[userID]
+}
+
+// SetPlanModeEnabled enables the /plan command, which lets each user turn
+// plan mode on and off for their own messages
+func (tc *TelegramChannel) SetPlanModeEnabled(enabled bool) {
+	tc.planEnabled = enabled
+	tc.commandsChanged()
+}
+
+func (tc *TelegramChannel) handlePlan(c tele.Context) error {
+	if !tc.isUserAllowed(c.Sender().ID) {
+		return c.Send(tc.t(c, "error.unauthorized"))
+	}
+
+	if !tc.planEnabled {
+		return c.Send(tc.t(c, "plan.disabled"))
+	}
+
+	if tc.plans.toggle(c.Sender().ID) {
+		return c.Send(tc.t(c, "plan.on"))
+	}
+	return c.Send(tc.t(c, "plan.off"))
+}
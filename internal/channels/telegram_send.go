@@ -0,0 +1,250 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tele "gopkg.in/telebot.v3"
+
+	"github.com/abelclopes/nomad-iabot/internal/format"
+)
+
+// Retry policy for messages Telegram fails to deliver
+const (
+	maxSendAttempts = 3
+	sendBackoff     = time.Second
+	// maxFloodWait is the longest flood wait honored before giving up on a
+	// chunk; beyond it the user is better served by the truncation notice
+	maxFloodWait = 60 * time.Second
+)
+
+// sendWithRetry sends text, retrying transient failures. A 429 waits the
+// retry_after Telegram asks for; other transient errors back off
+// exponentially.
+func (tc *TelegramChannel) sendWithRetry(c tele.Context, text string) error {
+	return tc.withRetry(func() error { return c.Send(text) })
+}
+
+// sendFormatted sends a Markdown reply as MarkdownV2. Telegram rejects the
+// whole message when it can't parse the markup, so a reply that fails for
+// any reason other than a transient one is sent again as plain text.
+func (tc *TelegramChannel) sendFormatted(c tele.Context, markdown string) error {
+	return tc.sendMarkdownV2(c, format.Response("telegram", markdown), markdown)
+}
+
+// sendMarkdownV2 sends text, already in MarkdownV2, falling back to plain
+// as sendFormatted does
+func (tc *TelegramChannel) sendMarkdownV2(c tele.Context, text, plain string) error {
+	return tc.deliver(formattedChunk{markdownV2: text, plain: plain}, func(text string, opts ...interface{}) error {
+		return c.Send(text, opts...)
+	})
+}
+
+// deliver sends or edits a message with call, retrying transient failures.
+// A chunk with MarkdownV2 that Telegram rejects for any other reason is
+// delivered again as plain text.
+func (tc *TelegramChannel) deliver(chunk formattedChunk, call func(text string, opts ...interface{}) error) error {
+	if chunk.markdownV2 == "" {
+		return tc.withRetry(func() error { return call(chunk.plain) })
+	}
+	err := tc.withRetry(func() error {
+		return call(chunk.markdownV2, tele.ModeMarkdownV2)
+	})
+	if err == nil {
+		return nil
+	}
+	if _, transient := sendRetryDelay(err, 1); transient {
+		return err
+	}
+	tc.logger.Warn("telegram rejected formatted reply, sending plain text", "error", err)
+	return tc.withRetry(func() error { return call(chunk.plain) })
+}
+
+// formattedChunk is one message of a long reply: its MarkdownV2 text and the
+// plain text sent instead if Telegram can't parse it. Without MarkdownV2 the
+// plain text is sent as is.
+type formattedChunk struct {
+	markdownV2 string
+	plain      string
+}
+
+// codeFence closes a code block cut between two messages
+const codeFence = "```"
+
+// splitFormatted converts markdown to MarkdownV2 and splits the result into
+// messages of at most maxLen bytes. Escaping lengthens the text, so the split
+// happens afterwards, at line breaks when possible and never inside an
+// escape sequence; a code block cut between messages is closed and reopened.
+func splitFormatted(markdown string, maxLen int) []formattedChunk {
+	// The conversion maps each source line to one line, plus the closing
+	// fence of a code block left open, so the source gives the plain text
+	source := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	escaped := strings.Split(format.Response("telegram", markdown), "\n")
+	// Room for the fence closing a code block cut at the end of a message
+	fenced := maxLen - len("\n"+codeFence)
+
+	var chunks []formattedChunk
+	var md, plain []string
+	size := 0
+	openFence := "" // opening line of the code block the text is in
+	flush := func() {
+		text := strings.Join(md, "\n")
+		if openFence != "" {
+			text += "\n" + codeFence
+		}
+		chunks = append(chunks, formattedChunk{markdownV2: text, plain: strings.Join(plain, "\n")})
+		md, plain, size = nil, nil, 0
+		if openFence != "" {
+			md, size = []string{openFence}, len(openFence)
+		}
+	}
+
+	for i, line := range escaped {
+		limit := maxLen
+		if openFence != "" || strings.HasPrefix(line, codeFence) {
+			limit = fenced
+		}
+
+		var pieces, plainPieces []string
+		if len(line) <= limit {
+			pieces = []string{line}
+			if i < len(source) {
+				plainPieces = []string{source[i]}
+			} else {
+				plainPieces = []string{""}
+			}
+		} else {
+			pieces = splitEscapedLine(line, fenced)
+			for _, piece := range pieces {
+				plainPieces = append(plainPieces, unescapeMarkdownV2(piece))
+			}
+		}
+
+		for j, piece := range pieces {
+			if len(md) > 0 && size+1+len(piece) > limit {
+				flush()
+			}
+			if len(md) > 0 {
+				size++
+			}
+			md = append(md, piece)
+			plain = append(plain, plainPieces[j])
+			size += len(piece)
+		}
+
+		// Code lines escape backticks, so only fences start with them
+		if strings.HasPrefix(line, codeFence) {
+			if openFence == "" {
+				openFence = line
+			} else {
+				openFence = ""
+			}
+		}
+	}
+	if len(md) > 0 {
+		flush()
+	}
+	return chunks
+}
+
+// splitEscapedLine cuts a MarkdownV2 line longer than limit into pieces,
+// preferring spaces and never separating a backslash from the character it
+// escapes or splitting a UTF-8 sequence
+func splitEscapedLine(line string, limit int) []string {
+	var pieces []string
+	for len(line) > limit {
+		cut := strings.LastIndexByte(line[:limit+1], ' ')
+		if cut <= 0 {
+			cut = limit
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			backslashes := 0
+			for k := cut - 1; k >= 0 && line[k] == '\\'; k-- {
+				backslashes++
+			}
+			if backslashes%2 == 1 {
+				cut--
+			}
+		}
+		pieces = append(pieces, line[:cut])
+		line = strings.TrimPrefix(line[cut:], " ")
+	}
+	return append(pieces, line)
+}
+
+// unescapeMarkdownV2 drops the backslashes escaping characters in text
+func unescapeMarkdownV2(text string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range text {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// withRetry runs call, retrying transient failures as sendWithRetry does.
+// It stops waiting to retry when the bot shuts down.
+func (tc *TelegramChannel) withRetry(call func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = call(); err == nil {
+			return nil
+		}
+		delay, retry := sendRetryDelay(err, attempt)
+		if !retry || attempt == maxSendAttempts {
+			return err
+		}
+		tc.logger.Warn("telegram send failed, retrying", "attempt", attempt, "delay", delay, "error", err)
+		if tc.sleep(tc.baseContext(), delay) != nil {
+			return err
+		}
+	}
+}
+
+// sendRetryDelay reports whether err is worth retrying and how long to wait
+func sendRetryDelay(err error, attempt int) (time.Duration, bool) {
+	var flood tele.FloodError
+	if errors.As(err, &flood) {
+		wait := time.Duration(flood.RetryAfter) * time.Second
+		return wait, wait <= maxFloodWait
+	}
+
+	backoff := sendBackoff << (attempt - 1)
+	var apiErr *tele.Error
+	if errors.As(err, &apiErr) {
+		return backoff, apiErr.Code >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return backoff, true
+	}
+	return 0, false
+}
+
+// sleep waits d between send attempts, or until ctx ends; tests replace
+// the wait to avoid it
+func (tc *TelegramChannel) sleep(ctx context.Context, d time.Duration) error {
+	if tc.sleepFn != nil {
+		tc.sleepFn(d)
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
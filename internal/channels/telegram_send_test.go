@@ -0,0 +1,217 @@
+package channels
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+
+	"github.com/abelclopes/nomad-iabot/internal/format"
+)
+
+// apiContext sends through a bot pointed at a fake Bot API server, so send
+// errors are the ones telebot really returns
+type apiContext struct {
+	fakeContext
+	bot *tele.Bot
+}
+
+func (c *apiContext) Send(what interface{}, opts ...interface{}) error {
+	if _, err := c.bot.Send(&tele.Chat{ID: 1}, what, opts...); err != nil {
+		return err
+	}
+	c.sent = append(c.sent, what.(string))
+	return nil
+}
+
+// newAPIContext returns a context whose sends are answered by respond,
+// which gets the 1-based number of the send request
+func newAPIContext(t *testing.T, respond func(n int, w http.ResponseWriter)) *apiContext {
+	t.Helper()
+	var n int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		respond(n, w)
+	}))
+	t.Cleanup(srv.Close)
+
+	bot, err := tele.NewBot(tele.Settings{URL: srv.URL, Token: "test", Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &apiContext{fakeContext: fakeContext{sender: &tele.User{ID: 1}}, bot: bot}
+}
+
+const sentOK = `{"ok":true,"result":{"message_id":1,"chat":{"id":1},"date":0}}`
+
+func TestSendLongMessageWaitsOutFloodLimit(t *testing.T) {
+	c := newAPIContext(t, func(n int, w http.ResponseWriter) {
+		if n == 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 3","parameters":{"retry_after":3}}`))
+			return
+		}
+		w.Write([]byte(sentOK))
+	})
+
+	var waits []time.Duration
+	tc := &TelegramChannel{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	tc.sleepFn = func(d time.Duration) { waits = append(waits, d) }
+
+	text := strings.Repeat("a", 3990) + "\n" + strings.Repeat("b", 3990)
+	if err := tc.sendLongMessage(c, text); err != nil {
+		t.Fatalf("sendLongMessage() error = %v", err)
+	}
+	if len(c.sent) != 2 || !strings.HasPrefix(c.sent[1], "b") {
+		t.Errorf("sent %d chunks, want both", len(c.sent))
+	}
+	if len(waits) != 1 || waits[0] != 3*time.Second {
+		t.Errorf("waits = %v, want the 3s retry_after", waits)
+	}
+}
+
+func TestSendLongMessageReportsTruncation(t *testing.T) {
+	c := newAPIContext(t, func(n int, w http.ResponseWriter) {
+		if n >= 2 && n <= 1+maxSendAttempts {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`))
+			return
+		}
+		w.Write([]byte(sentOK))
+	})
+
+	tc := &TelegramChannel{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	tc.SetLocale("en")
+	tc.sleepFn = func(time.Duration) {}
+
+	text := strings.Repeat("a", 3990) + "\n" + strings.Repeat("b", 3990)
+	if err := tc.sendLongMessage(c, text); err == nil {
+		t.Fatal("sendLongMessage() error = nil, want the flood error")
+	}
+	if len(c.sent) != 2 {
+		t.Fatalf("sent = %q, want the first chunk and the truncation notice", c.sent)
+	}
+	if c.sent[1] != "⚠️ I couldn't send the rest of the reply. Please try again in a moment." {
+		t.Errorf("notice = %q", c.sent[1])
+	}
+}
+
+func TestSendRetryDelaySkipsPermanentErrors(t *testing.T) {
+	if _, retry := sendRetryDelay(tele.ErrBlockedByUser, 1); retry {
+		t.Error("a user blocking the bot is retried")
+	}
+	if delay, retry := sendRetryDelay(tele.NewError(502, "Bad Gateway"), 2); !retry || delay != 2*sendBackoff {
+		t.Errorf("502 on attempt 2: delay = %v, retry = %v", delay, retry)
+	}
+}
+
+func TestSendLongMessageFormatsMarkdown(t *testing.T) {
+	c := newAPIContext(t, func(n int, w http.ResponseWriter) {
+		w.Write([]byte(sentOK))
+	})
+	tc := &TelegramChannel{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	if err := tc.sendLongMessage(c, "**#42** is done."); err != nil {
+		t.Fatalf("sendLongMessage() error = %v", err)
+	}
+	if len(c.sent) != 1 || c.sent[0] != "*\\#42* is done\\." {
+		t.Errorf("sent = %q, want MarkdownV2", c.sent)
+	}
+}
+
+func TestSendLongMessageFallsBackToPlainText(t *testing.T) {
+	c := newAPIContext(t, func(n int, w http.ResponseWriter) {
+		if n == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities: Can't find end of the entity starting at byte offset 3"}`))
+			return
+		}
+		w.Write([]byte(sentOK))
+	})
+	tc := &TelegramChannel{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	tc.sleepFn = func(time.Duration) { t.Error("a parse error must not be retried") }
+
+	if err := tc.sendLongMessage(c, "**#42** is done."); err != nil {
+		t.Fatalf("sendLongMessage() error = %v", err)
+	}
+	if len(c.sent) != 1 || c.sent[0] != "**#42** is done." {
+		t.Errorf("sent = %q, want the reply as plain text", c.sent)
+	}
+}
+
+func TestSplitFormattedSplitsEscapedText(t *testing.T) {
+	// Escaping doubles this text, so splitting before escaping would send
+	// messages over the limit
+	text := strings.Repeat("v1.2-rc!", 600)
+	chunks := splitFormatted(text, 1000)
+
+	var joined strings.Builder
+	for i, chunk := range chunks {
+		if len(chunk.markdownV2) > 1000 {
+			t.Errorf("chunk %d is %d bytes, over the limit", i, len(chunk.markdownV2))
+		}
+		if trailing := len(chunk.markdownV2) - len(strings.TrimRight(chunk.markdownV2, "\\")); trailing%2 == 1 {
+			t.Errorf("chunk %d ends inside an escape sequence: %q", i, chunk.markdownV2[len(chunk.markdownV2)-10:])
+		}
+		joined.WriteString(chunk.markdownV2)
+	}
+	if want := format.Response("telegram", text); joined.String() != want {
+		t.Error("chunks do not add up to the escaped text")
+	}
+	if len(chunks) < 2 || chunks[0].plain != unescapeMarkdownV2(chunks[0].markdownV2) || !strings.HasPrefix(text, chunks[0].plain) {
+		t.Errorf("plain fallback = %q", chunks[0].plain)
+	}
+}
+
+func TestSplitFormattedReopensCodeBlocks(t *testing.T) {
+	lines := []string{"Log:", "```text"}
+	for i := 0; i < 40; i++ {
+		lines = append(lines, "step "+strings.Repeat("x", 40))
+	}
+	lines = append(lines, "```", "Done.")
+	chunks := splitFormatted(strings.Join(lines, "\n"), 500)
+
+	if len(chunks) < 3 {
+		t.Fatalf("got %d chunks, want the code block split", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if len(chunk.markdownV2) > 500 {
+			t.Errorf("chunk %d is %d bytes, over the limit", i, len(chunk.markdownV2))
+		}
+		if strings.Count(chunk.markdownV2, "```")%2 != 0 {
+			t.Errorf("chunk %d leaves a code block open:\n%s", i, chunk.markdownV2)
+		}
+	}
+	if !strings.HasPrefix(chunks[1].markdownV2, "```text\n") {
+		t.Errorf("second chunk does not reopen the code block:\n%s", chunks[1].markdownV2)
+	}
+	if last := chunks[len(chunks)-1]; !strings.HasSuffix(last.markdownV2, "Done\\.") || !strings.HasSuffix(last.plain, "Done.") {
+		t.Errorf("last chunk = %+v", last)
+	}
+}
+
+func TestFloodWaitEndsOnShutdown(t *testing.T) {
+	c := newAPIContext(t, func(n int, w http.ResponseWriter) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 30","parameters":{"retry_after":30}}`))
+	})
+
+	tc := &TelegramChannel{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	ctx, cancel := context.WithCancel(context.Background())
+	tc.setBaseContext(ctx)
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if err := tc.sendWithRetry(c, "oi"); err == nil {
+		t.Fatal("sendWithRetry() error = nil, want the flood error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("sendWithRetry() took %s, want it to stop waiting on shutdown", elapsed)
+	}
+}
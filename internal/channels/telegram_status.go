@@ -0,0 +1,72 @@
+package channels
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+)
+
+// StatusHandler reports the agent's health for /status
+type StatusHandler func(ctx context.Context) agent.Health
+
+// integrationNames are the names /status shows for each integration
+var integrationNames = map[string]string{
+	"azure_devops": "Azure DevOps",
+	"trello":       "Trello",
+}
+
+// SetStatusHandler makes /status report the agent's health instead of a
+// fixed "operational" reply
+func (tc *TelegramChannel) SetStatusHandler(handler StatusHandler) {
+	tc.status = handler
+}
+
+func (tc *TelegramChannel) handleStatus(c tele.Context) error {
+	if !tc.isUserAllowed(c.Sender().ID) {
+		return c.Send(tc.t(c, "error.unauthorized"))
+	}
+
+	if tc.status == nil {
+		return c.Send(tc.t(c, "status.ok"))
+	}
+
+	ctx, done := tc.requestContext()
+	defer done()
+	return c.Send(tc.formatStatus(c, tc.status(ctx)), tele.ModeMarkdown)
+}
+
+// formatStatus renders h as one line per component
+func (tc *TelegramChannel) formatStatus(c tele.Context, h agent.Health) string {
+	lines := []string{tc.t(c, "status.ok")}
+	if h.Status != "healthy" {
+		lines[0] = tc.t(c, "status.degraded")
+	}
+
+	if h.LLM.Reachable {
+		lines = append(lines, tc.t(c, "status.llm_ok", h.Model))
+	} else {
+		lines = append(lines, tc.t(c, "status.llm_down", h.Model))
+	}
+
+	for _, ih := range h.Integrations {
+		name := integrationNames[ih.Name]
+		if name == "" {
+			name = ih.Name
+		}
+		switch ih.Problem {
+		case "credentials":
+			lines = append(lines, tc.t(c, "status.credentials", name))
+		case "circuit_open":
+			lines = append(lines, tc.t(c, "status.circuit", name))
+		default:
+			lines = append(lines, tc.t(c, "status.service_ok", name))
+		}
+	}
+
+	lines = append(lines, tc.t(c, "status.uptime", h.Uptime.Truncate(time.Second)))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,193 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+	"github.com/abelclopes/nomad-iabot/internal/stream"
+)
+
+const (
+	// streamEditInterval throttles live edits; Telegram allows about one
+	// message update per second in a chat
+	streamEditInterval = 1500 * time.Millisecond
+	// streamChunkLength keeps each live message under Telegram's 4096
+	// character limit
+	streamChunkLength = 4000
+)
+
+// liveReply shows a reply that builds up over time, editing the messages
+// already sent and adding new ones once the text outgrows a message.
+// Progress is shown as plain text; the final text is formatted as replies
+// sent in one go are.
+type liveReply struct {
+	send     func(chunk formattedChunk) (*tele.Message, error)
+	edit     func(msg *tele.Message, chunk formattedChunk) error
+	remove   func(msg *tele.Message) error
+	clock    clock.Clock
+	interval time.Duration
+	maxLen   int
+
+	sent      []*tele.Message
+	shown     []formattedChunk // what each sent message currently shows
+	lastFlush time.Time
+}
+
+// start shows a placeholder. It doesn't count against the edit budget, so
+// the first progress update shows right away.
+func (r *liveReply) start(text string) error {
+	if err := r.update(text, false); err != nil {
+		return err
+	}
+	r.lastFlush = time.Time{}
+	return nil
+}
+
+// update shows text. Unless final, an update within interval of the
+// previous one is skipped; the text passed to the next update (or the
+// final one) supersedes it anyway.
+func (r *liveReply) update(text string, final bool) error {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	now := r.clock.Now()
+	if !final && !r.lastFlush.IsZero() && now.Sub(r.lastFlush) < r.interval {
+		return nil
+	}
+
+	var chunks []formattedChunk
+	if final {
+		chunks = splitFormatted(text, r.maxLen)
+	} else {
+		for _, chunk := range splitText(text, r.maxLen) {
+			chunks = append(chunks, formattedChunk{plain: chunk})
+		}
+	}
+	for i, chunk := range chunks {
+		if i < len(r.sent) {
+			if r.shown[i] == chunk {
+				continue
+			}
+			if err := r.edit(r.sent[i], chunk); err != nil {
+				return err
+			}
+			r.shown[i] = chunk
+			r.lastFlush = now
+			continue
+		}
+		msg, err := r.send(chunk)
+		if err != nil {
+			return err
+		}
+		r.sent = append(r.sent, msg)
+		r.shown = append(r.shown, chunk)
+		r.lastFlush = now
+	}
+
+	// Text that shrank (e.g. progress replaced by a short answer) leaves
+	// messages with nothing to show
+	for len(r.sent) > len(chunks) {
+		last := len(r.sent) - 1
+		if err := r.remove(r.sent[last]); err != nil {
+			return err
+		}
+		r.sent, r.shown = r.sent[:last], r.shown[:last]
+	}
+	return nil
+}
+
+// SetStreamHandler enables live replies when the Telegram configuration
+// turns streaming on
+func (tc *TelegramChannel) SetStreamHandler(handler StreamHandler) {
+	tc.stream = handler
+}
+
+// newLiveReply returns a liveReply that sends to the chat of c
+func (tc *TelegramChannel) newLiveReply(c tele.Context) *liveReply {
+	return &liveReply{
+		send: func(chunk formattedChunk) (*tele.Message, error) {
+			var msg *tele.Message
+			err := tc.deliver(chunk, func(text string, opts ...interface{}) (err error) {
+				msg, err = c.Bot().Send(c.Recipient(), text, opts...)
+				return err
+			})
+			return msg, err
+		},
+		edit: func(msg *tele.Message, chunk formattedChunk) error {
+			return tc.deliver(chunk, func(text string, opts ...interface{}) error {
+				_, err := c.Bot().Edit(msg, text, opts...)
+				return err
+			})
+		},
+		remove: func(msg *tele.Message) error {
+			return c.Bot().Delete(msg)
+		},
+		clock:    tc.clock,
+		interval: streamEditInterval,
+		maxLen:   streamChunkLength,
+	}
+}
+
+// streamReply answers msg with a placeholder that is edited as the agent
+// reports progress and, finally, the answer
+func (tc *TelegramChannel) streamReply(ctx context.Context, c tele.Context, msg IncomingMessage) error {
+	return tc.followStream(ctx, c, msg, tc.newLiveReply(c))
+}
+
+// followStream shows the progress of msg on reply: the tool being used,
+// then the answer as the agent streams it
+func (tc *TelegramChannel) followStream(ctx context.Context, c tele.Context, msg IncomingMessage, reply *liveReply) error {
+	if err := reply.start(tc.t(c, "stream.thinking")); err != nil {
+		return err
+	}
+
+	var content strings.Builder
+	emit := func(ev stream.Event) {
+		var err error
+		switch ev.Type {
+		case stream.ToolStart:
+			// Text streamed before a tool call is the model's comment on
+			// it, not the answer
+			content.Reset()
+			err = reply.update(tc.t(c, "stream.tool", ev.Tool), false)
+		case stream.Content:
+			content.WriteString(ev.Content)
+			err = reply.update(content.String(), false)
+		}
+		if err != nil {
+			// Progress is best effort; the final update shows the latest text
+			tc.logger.Warn("failed to update live reply", "error", err)
+		}
+	}
+
+	response, err := tc.stream(ctx, msg, emit)
+	final := content.String()
+	switch {
+	case errors.Is(err, agent.ErrBusy):
+		final = tc.t(c, "error.busy")
+	case tc.interrupted(c, err) != "":
+		tc.logger.Warn("message processing interrupted", "error", err)
+		final = tc.interrupted(c, err)
+	case err != nil:
+		tc.logger.Error("failed to process message", "error", err)
+		final = tc.t(c, "error.processing")
+	case final == "":
+		final = response
+	}
+
+	if err := reply.update(final, true); err != nil {
+		tc.logger.Error("failed to finish live reply", "error", err)
+		// Best effort: the notice may fail for the same reason
+		if notifyErr := c.Send(tc.t(c, "error.truncated")); notifyErr != nil {
+			tc.logger.Warn("failed to send truncation notice", "error", notifyErr)
+		}
+		return err
+	}
+	return nil
+}
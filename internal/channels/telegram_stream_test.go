@@ -0,0 +1,194 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+	"github.com/abelclopes/nomad-iabot/internal/config"
+	"github.com/abelclopes/nomad-iabot/internal/stream"
+)
+
+// recordingReply returns a liveReply that records what it sends and edits
+func recordingReply(clk *clock.Fake, maxLen int) (*liveReply, *[]string) {
+	var ops []string
+	next := 0
+	return &liveReply{
+		send: func(chunk formattedChunk) (*tele.Message, error) {
+			next++
+			ops = append(ops, fmt.Sprintf("send %d: %s", next, shownText(chunk)))
+			return &tele.Message{ID: next}, nil
+		},
+		edit: func(msg *tele.Message, chunk formattedChunk) error {
+			ops = append(ops, fmt.Sprintf("edit %d: %s", msg.ID, shownText(chunk)))
+			return nil
+		},
+		remove: func(msg *tele.Message) error {
+			ops = append(ops, fmt.Sprintf("delete %d", msg.ID))
+			return nil
+		},
+		clock:    clk,
+		interval: time.Second,
+		maxLen:   maxLen,
+	}, &ops
+}
+
+// shownText is the text a chunk is displayed with
+func shownText(chunk formattedChunk) string {
+	if chunk.markdownV2 != "" {
+		return "[md] " + chunk.markdownV2
+	}
+	return chunk.plain
+}
+
+func TestLiveReplyThrottlesEdits(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	reply, ops := recordingReply(clk, 4000)
+
+	reply.start("Thinking…")
+	reply.update("Checking devops_list_my_workitems…", false)
+	clk.Advance(200 * time.Millisecond)
+	reply.update("Checking devops_get_workitem…", false) // within the interval: skipped
+	clk.Advance(time.Second)
+	reply.update("You have", false)
+	reply.update("You have 3 items", false) // skipped
+	reply.update("You have 3 items", true)  // final always shows
+	reply.update("You have 3 items", true)  // unchanged: no edit
+
+	want := []string{
+		"send 1: Thinking…",
+		"edit 1: Checking devops_list_my_workitems…",
+		"edit 1: You have",
+		"edit 1: [md] You have 3 items",
+	}
+	if strings.Join(*ops, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ops =\n%s\nwant\n%s", strings.Join(*ops, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLiveReplySplitsLongText(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	reply, ops := recordingReply(clk, 10)
+
+	reply.update("aaaa", true)
+	reply.update("aaaa\nbbbb\ncccc", true)
+	reply.update("aaaa\nbbbb\ncccc\ndddd", true)
+	reply.update("done", true)
+
+	want := []string{
+		"send 1: [md] aaaa",
+		"edit 1: [md] aaaa\nbbbb",
+		"send 2: [md] cccc",
+		"edit 2: [md] cccc\ndddd",
+		"edit 1: [md] done",
+		"delete 2",
+	}
+	if strings.Join(*ops, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ops =\n%s\nwant\n%s", strings.Join(*ops, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLiveReplyFormatsFinalText(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	reply, ops := recordingReply(clk, 4000)
+
+	reply.start("Thinking…")
+	clk.Advance(time.Second)
+	reply.update("**#42** is", false)
+	reply.update("**#42** is done.", true)
+
+	want := []string{
+		"send 1: Thinking…",
+		"edit 1: **#42** is",
+		"edit 1: [md] *\\#42* is done\\.",
+	}
+	if strings.Join(*ops, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ops =\n%s\nwant\n%s", strings.Join(*ops, "\n"), strings.Join(want, "\n"))
+	}
+	if plain := reply.shown[0].plain; plain != "**#42** is done." {
+		t.Errorf("plain fallback = %q", plain)
+	}
+}
+
+func TestStreamReplyShowsTheAnswerAsTheLLMWritesIt(t *testing.T) {
+	llmSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, piece := range []string{"You have ", "3 ", "items"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", piece)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer llmSrv.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a, err := agent.New(&config.Config{LLM: config.LLMConfig{BaseURL: llmSrv.URL, Model: "test-model", TimeoutSec: 5}}, logger)
+	if err != nil {
+		t.Fatalf("agent.New() error = %v", err)
+	}
+
+	clk := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	tc := &TelegramChannel{clock: clk, logger: logger}
+	tc.SetLocale("en")
+	tc.SetStreamHandler(func(ctx context.Context, msg IncomingMessage, emit func(stream.Event)) (string, error) {
+		return a.ProcessMessageStream(ctx, msg.UserID, msg.Channel, msg.Text, emit)
+	})
+
+	reply, ops := recordingReply(clk, 4000)
+	edit := reply.edit
+	reply.edit = func(msg *tele.Message, chunk formattedChunk) error {
+		// Each piece takes long enough for the next edit to show
+		clk.Advance(time.Second)
+		return edit(msg, chunk)
+	}
+
+	c := &fakeContext{sender: &tele.User{ID: 1}}
+	msg := IncomingMessage{Channel: "telegram", UserID: "1", Text: "my items?"}
+	if err := tc.followStream(context.Background(), c, msg, reply); err != nil {
+		t.Fatalf("followStream() error = %v", err)
+	}
+
+	want := []string{
+		"send 1: ⏳ Thinking…",
+		"edit 1: You have ",
+		"edit 1: You have 3 ",
+		"edit 1: You have 3 items",
+		"edit 1: [md] You have 3 items",
+	}
+	if strings.Join(*ops, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ops =\n%s\nwant\n%s", strings.Join(*ops, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestStreamReplyDropsTextBeforeAToolCall(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	tc := &TelegramChannel{clock: clk, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	tc.SetLocale("en")
+	tc.SetStreamHandler(func(ctx context.Context, msg IncomingMessage, emit func(stream.Event)) (string, error) {
+		emit(stream.Event{Type: stream.Content, Content: "Let me check."})
+		emit(stream.Event{Type: stream.ToolStart, Tool: "devops_list_my_workitems"})
+		emit(stream.Event{Type: stream.ToolEnd, Tool: "devops_list_my_workitems"})
+		emit(stream.Event{Type: stream.Content, Content: "You have 3 items"})
+		emit(stream.Event{Type: stream.Done})
+		return "You have 3 items", nil
+	})
+
+	reply, ops := recordingReply(clk, 4000)
+	c := &fakeContext{sender: &tele.User{ID: 1}}
+	if err := tc.followStream(context.Background(), c, IncomingMessage{Channel: "telegram", UserID: "1"}, reply); err != nil {
+		t.Fatalf("followStream() error = %v", err)
+	}
+
+	if last := (*ops)[len(*ops)-1]; last != "edit 1: [md] You have 3 items" {
+		t.Errorf("final op = %q, want only the answer", last)
+	}
+}
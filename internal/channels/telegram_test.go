@@ -0,0 +1,127 @@
+package channels
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+	"github.com/abelclopes/nomad-iabot/internal/config"
+)
+
+// fakeContext records messages sent through a tele.Context
+type fakeContext struct {
+	tele.Context
+	sender *tele.User
+	sent   []string
+}
+
+func (c *fakeContext) Sender() *tele.User {
+	return c.sender
+}
+
+func (c *fakeContext) Send(what interface{}, opts ...interface{}) error {
+	c.sent = append(c.sent, what.(string))
+	return nil
+}
+
+func TestStartGreetingLocale(t *testing.T) {
+	tests := []struct {
+		name         string
+		locale       string
+		languageCode string
+		expected     string
+	}{
+		{"Default locale", "pt-BR", "", "👋 Olá! Eu sou o Nomad Agent. Como posso ajudar?"},
+		{"Configured English", "en", "", "👋 Hi! I'm Nomad Agent. How can I help?"},
+		{"User language overrides config", "pt-BR", "en", "👋 Hi! I'm Nomad Agent. How can I help?"},
+		{"Unsupported user language", "en", "de", "👋 Hi! I'm Nomad Agent. How can I help?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := &TelegramChannel{}
+			tc.SetLocale(tt.locale)
+			c := &fakeContext{sender: &tele.User{ID: 1, LanguageCode: tt.languageCode}}
+
+			if err := tc.handleStart(c); err != nil {
+				t.Fatalf("handleStart() error = %v", err)
+			}
+			if len(c.sent) != 1 || c.sent[0] != tt.expected {
+				t.Errorf("handleStart() sent %q, expected %q", c.sent, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStartGreetingListsCapabilities(t *testing.T) {
+	tc := &TelegramChannel{}
+	tc.SetLocale("en")
+	tc.SetGreeting("Welcome to Acme!", []string{"chat", "devops"})
+	c := &fakeContext{sender: &tele.User{ID: 1}}
+
+	if err := tc.handleStart(c); err != nil {
+		t.Fatalf("handleStart() error = %v", err)
+	}
+	got := c.sent[0]
+	if !strings.HasPrefix(got, "Welcome to Acme!\n\nI can help with:") {
+		t.Errorf("greeting = %q, want the custom text first", got)
+	}
+	if !strings.Contains(got, "• Azure DevOps") {
+		t.Errorf("greeting = %q, want the DevOps capability", got)
+	}
+	// Trello is disabled, so it is not advertised
+	if strings.Contains(got, "Trello") {
+		t.Errorf("greeting = %q, should not mention Trello", got)
+	}
+}
+
+func TestStatusReportsUnreachableLLM(t *testing.T) {
+	tc := &TelegramChannel{cfg: &config.TelegramConfig{}}
+	tc.SetLocale("en")
+	tc.SetStatusHandler(func(ctx context.Context) agent.Health {
+		return agent.Health{
+			Status:       "degraded",
+			Model:        "llama3",
+			Uptime:       90 * time.Minute,
+			LLM:          agent.LLMHealth{Error: "connection refused"},
+			Integrations: []agent.IntegrationHealth{{Name: "azure_devops", Healthy: true}},
+		}
+	})
+	c := &fakeContext{sender: &tele.User{ID: 1}}
+
+	if err := tc.handleStatus(c); err != nil {
+		t.Fatalf("handleStatus() error = %v", err)
+	}
+	want := "⚠️ *System degraded*\n🤖 LLM `llama3`: ❌ unreachable\nAzure DevOps: ✅\n⏱ Up for 1h30m0s"
+	if len(c.sent) != 1 || c.sent[0] != want {
+		t.Errorf("handleStatus() sent %q, want %q", c.sent, want)
+	}
+}
+
+// groupContext is a fakeContext for a message sent in a group chat
+type groupContext struct {
+	fakeContext
+}
+
+func (c *groupContext) Chat() *tele.Chat { return &tele.Chat{ID: -100, Type: tele.ChatGroup} }
+
+func TestExportOnlyInPrivateChats(t *testing.T) {
+	tc := &TelegramChannel{cfg: &config.TelegramConfig{}}
+	tc.SetLocale("en")
+	tc.SetExportHandler(func(ctx context.Context, msg IncomingMessage, format string) (string, error) {
+		t.Error("export handler called from a group chat")
+		return "", nil
+	})
+	c := &groupContext{fakeContext{sender: &tele.User{ID: 1}}}
+
+	if err := tc.handleExport(c); err != nil {
+		t.Fatalf("handleExport() error = %v", err)
+	}
+	if want := "🔒 For privacy, use /export in a private chat with me."; len(c.sent) != 1 || c.sent[0] != want {
+		t.Errorf("handleExport() sent %q, want %q", c.sent, want)
+	}
+}
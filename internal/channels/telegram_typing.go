@@ -0,0 +1,70 @@
+package channels
+
+import (
+	"context"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// typingRefresh is how often the typing indicator is sent again while a
+// reply is produced; Telegram clears it after about five seconds
+const typingRefresh = 4 * time.Second
+
+// keepTyping shows the typing indicator in c's chat until the returned stop
+// is called, and sends a "still working" note once if TELEGRAM_PROGRESS_AFTER
+// passes first
+func (tc *TelegramChannel) keepTyping(ctx context.Context, c tele.Context) (stop func()) {
+	notify := func() { _ = c.Notify(tele.Typing) }
+
+	var progress func()
+	if tc.cfg.ProgressAfterSec > 0 {
+		progress = func() {
+			if err := c.Send(tc.t(c, "progress.working")); err != nil {
+				tc.logger.Warn("failed to send progress note", "error", err)
+			}
+		}
+	}
+	return startTyping(ctx, typingRefresh, time.Duration(tc.cfg.ProgressAfterSec)*time.Second, notify, progress)
+}
+
+// startTyping calls notify now and every interval until stop is called or
+// ctx ends. progress, when not nil, is called once if still running after
+// progressAfter. stop waits for the refresher to exit, so nothing is sent
+// after it returns.
+func startTyping(ctx context.Context, interval, progressAfter time.Duration, notify, progress func()) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var progressC <-chan time.Time
+		if progress != nil && progressAfter > 0 {
+			timer := time.NewTimer(progressAfter)
+			defer timer.Stop()
+			progressC = timer.C
+		}
+
+		notify()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				notify()
+			case <-progressC:
+				progress()
+				notify()
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,67 @@
+package channels
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTypingRefreshesUntilStopped(t *testing.T) {
+	var notifies, progress int32
+	stop := startTyping(context.Background(), 5*time.Millisecond, 20*time.Millisecond,
+		func() { atomic.AddInt32(&notifies, 1) },
+		func() { atomic.AddInt32(&progress, 1) })
+
+	time.Sleep(60 * time.Millisecond)
+	stop()
+
+	sent := atomic.LoadInt32(&notifies)
+	if sent < 3 {
+		t.Errorf("typing sent %d times in 60ms, expected it refreshed every 5ms", sent)
+	}
+	if got := atomic.LoadInt32(&progress); got != 1 {
+		t.Errorf("progress note sent %d times, expected once", got)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if got := atomic.LoadInt32(&notifies); got != sent {
+		t.Errorf("typing sent %d more times after stop", got-sent)
+	}
+}
+
+func TestTypingStopsWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var notifies int32
+	stop := startTyping(ctx, 5*time.Millisecond, 0, func() { atomic.AddInt32(&notifies, 1) }, nil)
+
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	sent := atomic.LoadInt32(&notifies)
+	time.Sleep(20 * time.Millisecond)
+	if got := atomic.LoadInt32(&notifies); got != sent {
+		t.Errorf("typing kept refreshing after the context ended (%d -> %d)", sent, got)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		stop()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("stop blocked after the context ended")
+	}
+}
+
+func TestTypingSkipsProgressForQuickReplies(t *testing.T) {
+	var progress int32
+	stop := startTyping(context.Background(), time.Hour, 50*time.Millisecond, func() {}, func() { atomic.AddInt32(&progress, 1) })
+	stop()
+
+	time.Sleep(70 * time.Millisecond)
+	if got := atomic.LoadInt32(&progress); got != 0 {
+		t.Errorf("progress note sent %d times for a reply that finished first", got)
+	}
+}
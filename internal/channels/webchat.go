@@ -3,6 +3,7 @@ package channels
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"sync"
@@ -10,16 +11,47 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+	"github.com/abelclopes/nomad-iabot/internal/format"
+	"github.com/abelclopes/nomad-iabot/internal/identity"
+	"github.com/abelclopes/nomad-iabot/internal/skills"
+	"github.com/abelclopes/nomad-iabot/internal/stream"
 )
 
 // WebChatChannel handles the web-based chat interface
 type WebChatChannel struct {
 	logger   *slog.Logger
 	handler  MessageHandler
+	stream   StreamHandler
+	limits   skills.InputLimits
 	sessions sync.Map // map[sessionID]*WebChatSession
+	clock    clock.Clock
+
+	identities *identity.Store // enables linking sessions to another channel's user
+
+	// sends collapses identical messages sent to a session while the first
+	// is still processing, e.g. a double-clicked send button
+	sends inflight[webChatExchange]
+}
+
+// sharedSendTimeout bounds a deduplicated send. The call outlives the
+// request that started it, since other requests may be waiting on it.
+const sharedSendTimeout = 60 * time.Second
+
+// webChatExchange is the outcome of one message sent through the webchat
+type webChatExchange struct {
+	User      WebChatMessage
+	Assistant WebChatMessage
 }
 
-// WebChatSession represents a webchat session
+// StreamHandler processes an incoming message, reporting progress events to emit
+type StreamHandler func(ctx context.Context, msg IncomingMessage, emit func(stream.Event)) (string, error)
+
+// WebChatSession represents a webchat session. UserID is a label chosen by
+// the browser; the agent knows the user by the session ID the server issued,
+// so memory, usage and links cannot be claimed by reusing someone's label.
 type WebChatSession struct {
 	ID        string    `json:"id"`
 	UserID    string    `json:"user_id"`
@@ -41,9 +73,15 @@ func NewWebChatChannel(logger *slog.Logger, handler MessageHandler) *WebChatChan
 	return &WebChatChannel{
 		logger:  logger,
 		handler: handler,
+		clock:   clock.Real(),
 	}
 }
 
+// SetClock replaces the clock used for session and message timestamps
+func (wc *WebChatChannel) SetClock(c clock.Clock) {
+	wc.clock = c
+}
+
 // RegisterRoutes registers the WebChat routes
 func (wc *WebChatChannel) RegisterRoutes(r chi.Router) {
 	r.Route("/webchat/api", func(r chi.Router) {
@@ -51,7 +89,9 @@ func (wc *WebChatChannel) RegisterRoutes(r chi.Router) {
 		r.Get("/sessions/{id}", wc.handleGetSession)
 		r.Delete("/sessions/{id}", wc.handleDeleteSession)
 		r.Post("/sessions/{id}/messages", wc.handleSendMessage)
+		r.Post("/sessions/{id}/messages/stream", wc.handleStreamMessage)
 		r.Get("/sessions/{id}/messages", wc.handleGetMessages)
+		r.Post("/sessions/{id}/link", wc.handleLinkSession)
 	})
 }
 
@@ -67,7 +107,7 @@ func (wc *WebChatChannel) handleCreateSession(w http.ResponseWriter, r *http.Req
 	session := &WebChatSession{
 		ID:        uuid.New().String(),
 		UserID:    req.UserID,
-		CreatedAt: time.Now(),
+		CreatedAt: wc.clock.Now(),
 		Messages:  []WebChatMessage{},
 	}
 
@@ -125,12 +165,45 @@ func (wc *WebChatChannel) handleSendMessage(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if err := wc.limits.Check(req.Content); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	exchange, err, shared := wc.sends.do(messageKey(session.ID, req.Content), func() (webChatExchange, error) {
+		// Detached so the first client disconnecting does not fail the others
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), sharedSendTimeout)
+		defer cancel()
+		return wc.exchange(ctx, session, req.Content)
+	})
+	if errors.Is(err, agent.ErrBusy) {
+		respondError(w, http.StatusServiceUnavailable, "server busy, try again shortly")
+		return
+	}
+	if err != nil {
+		wc.logger.Error("failed to process message", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to process message")
+		return
+	}
+	if shared {
+		wc.logger.Info("deduplicated concurrent webchat message", "session_id", session.ID)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"user_message":      exchange.User,
+		"assistant_message": exchange.Assistant,
+	})
+}
+
+// exchange records content as a user message, processes it and records the
+// assistant's reply
+func (wc *WebChatChannel) exchange(ctx context.Context, session *WebChatSession, content string) (webChatExchange, error) {
 	// Add user message
 	userMsg := WebChatMessage{
 		ID:        uuid.New().String(),
 		Role:      "user",
-		Content:   req.Content,
-		Timestamp: time.Now(),
+		Content:   content,
+		Timestamp: wc.clock.Now(),
 	}
 
 	session.mu.Lock()
@@ -140,9 +213,9 @@ func (wc *WebChatChannel) handleSendMessage(w http.ResponseWriter, r *http.Reque
 	// Process with handler
 	incomingMsg := IncomingMessage{
 		Channel:  "webchat",
-		UserID:   session.UserID,
+		UserID:   session.ID,
 		Username: session.UserID,
-		Text:     req.Content,
+		Text:     content,
 		ChatID:   session.ID,
 		IsGroup:  false,
 		Metadata: map[string]string{
@@ -150,20 +223,17 @@ func (wc *WebChatChannel) handleSendMessage(w http.ResponseWriter, r *http.Reque
 		},
 	}
 
-	ctx := r.Context()
 	response, err := wc.handler(ctx, incomingMsg)
 	if err != nil {
-		wc.logger.Error("failed to process message", "error", err)
-		respondError(w, http.StatusInternalServerError, "failed to process message")
-		return
+		return webChatExchange{}, err
 	}
 
 	// Add assistant message
 	assistantMsg := WebChatMessage{
 		ID:        uuid.New().String(),
 		Role:      "assistant",
-		Content:   response,
-		Timestamp: time.Now(),
+		Content:   format.Response("webchat", response),
+		Timestamp: wc.clock.Now(),
 	}
 
 	session.mu.Lock()
@@ -175,10 +245,189 @@ func (wc *WebChatChannel) handleSendMessage(w http.ResponseWriter, r *http.Reque
 		"user_id", session.UserID,
 	)
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"user_message":      userMsg,
-		"assistant_message": assistantMsg,
+	return webChatExchange{User: userMsg, Assistant: assistantMsg}, nil
+}
+
+// SetInputLimits bounds the size of messages accepted from the browser
+func (wc *WebChatChannel) SetInputLimits(limits skills.InputLimits) {
+	wc.limits = limits
+}
+
+// SetIdentities enables the link endpoint, which redeems a code from
+// Telegram's /link so the session shares that account's memory and usage
+func (wc *WebChatChannel) SetIdentities(store *identity.Store) {
+	wc.identities = store
+}
+
+func (wc *WebChatChannel) handleLinkSession(w http.ResponseWriter, r *http.Request) {
+	if wc.identities == nil {
+		respondError(w, http.StatusNotImplemented, "account linking is not enabled")
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+
+	sessionVal, ok := wc.sessions.Load(sessionID)
+	if !ok {
+		respondError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	session := sessionVal.(*WebChatSession)
+
+	var req struct {
+		Code string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Code == "" {
+		respondError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	// Links are tied to the session ID the server issued, not to the user_id
+	// the browser chose, so another session cannot claim them
+	linked, err := wc.identities.Redeem(req.Code, "webchat", session.ID)
+	switch {
+	case errors.Is(err, identity.ErrInvalidCode):
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		wc.logger.Error("failed to link webchat session", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to link session")
+		return
+	}
+
+	wc.logger.Info("linked webchat user", "session_id", session.ID, "user_id", session.UserID, "linked_user", linked)
+
+	respondJSON(w, http.StatusOK, map[string]string{"user_id": session.UserID, "linked_user": linked})
+}
+
+// SetStreamHandler enables the streaming message endpoint
+func (wc *WebChatChannel) SetStreamHandler(handler StreamHandler) {
+	wc.stream = handler
+}
+
+// handleStreamMessage processes a message and streams agent progress as
+// Server-Sent Events. Each event is a JSON-encoded stream.Event on a
+// "data:" line; the stream always ends with a "done" event.
+func (wc *WebChatChannel) handleStreamMessage(w http.ResponseWriter, r *http.Request) {
+	if wc.stream == nil {
+		respondError(w, http.StatusNotImplemented, "streaming is not enabled")
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+
+	sessionVal, ok := wc.sessions.Load(sessionID)
+	if !ok {
+		respondError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	session := sessionVal.(*WebChatSession)
+
+	var req struct {
+		Content string `json:"content"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Content == "" {
+		respondError(w, http.StatusBadRequest, "content is required")
+		return
+	}
+
+	if err := wc.limits.Check(req.Content); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	userMsg := WebChatMessage{
+		ID:        uuid.New().String(),
+		Role:      "user",
+		Content:   req.Content,
+		Timestamp: wc.clock.Now(),
+	}
+
+	session.mu.Lock()
+	session.Messages = append(session.Messages, userMsg)
+	session.mu.Unlock()
+
+	incomingMsg := IncomingMessage{
+		Channel:  "webchat",
+		UserID:   session.ID,
+		Username: session.UserID,
+		Text:     req.Content,
+		ChatID:   session.ID,
+		IsGroup:  false,
+		Metadata: map[string]string{
+			"session_id": session.ID,
+		},
+	}
+
+	// The request context ends when the client goes away (the tab is
+	// closed); the handler passes it on, which cancels the LLM request
+	ctx := r.Context()
+	emit := func(ev stream.Event) {
+		if ctx.Err() != nil {
+			return
+		}
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return
+		}
+		w.Write([]byte("data: "))
+		w.Write(data)
+		w.Write([]byte("\n\n"))
+		flusher.Flush()
+	}
+
+	response, err := wc.stream(ctx, incomingMsg, emit)
+	if ctx.Err() != nil {
+		// Whatever was produced is incomplete, so it is not kept
+		wc.logger.Info("streamed webchat message cancelled by the client",
+			"session_id", session.ID,
+			"user_id", session.UserID,
+		)
+		return
+	}
+	if err != nil {
+		wc.logger.Error("failed to process streamed message", "error", err)
+		return
+	}
+
+	session.mu.Lock()
+	session.Messages = append(session.Messages, WebChatMessage{
+		ID:        uuid.New().String(),
+		Role:      "assistant",
+		Content:   format.Response("webchat", response),
+		Timestamp: wc.clock.Now(),
 	})
+	session.mu.Unlock()
+
+	wc.logger.Info("processed streamed webchat message",
+		"session_id", session.ID,
+		"user_id", session.UserID,
+	)
 }
 
 func (wc *WebChatChannel) handleGetMessages(w http.ResponseWriter, r *http.Request) {
@@ -213,7 +462,7 @@ func respondError(w http.ResponseWriter, status int, message string) {
 
 // CleanupOldSessions removes sessions older than the specified duration
 func (wc *WebChatChannel) CleanupOldSessions(maxAge time.Duration) {
-	now := time.Now()
+	now := wc.clock.Now()
 	wc.sessions.Range(func(key, value interface{}) bool {
 		session := value.(*WebChatSession)
 		if now.Sub(session.CreatedAt) > maxAge {
@@ -0,0 +1,365 @@
+package channels
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+	"github.com/abelclopes/nomad-iabot/internal/identity"
+	"github.com/abelclopes/nomad-iabot/internal/skills"
+	"github.com/abelclopes/nomad-iabot/internal/stream"
+)
+
+func newTestWebChat(t *testing.T, handler MessageHandler) (*WebChatChannel, *httptest.Server) {
+	t.Helper()
+	wc := NewWebChatChannel(slog.New(slog.NewTextHandler(io.Discard, nil)), handler)
+	r := chi.NewRouter()
+	wc.RegisterRoutes(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return wc, srv
+}
+
+func createTestSession(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	resp, err := http.Post(srv.URL+"/webchat/api/sessions", "application/json", strings.NewReader(`{"user_id":"u1"}`))
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var session WebChatSession
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		t.Fatalf("decode session: %v", err)
+	}
+	return session.ID
+}
+
+func TestStreamMessageEmitsOrderedEvents(t *testing.T) {
+	wc, srv := newTestWebChat(t, nil)
+	wc.SetStreamHandler(func(ctx context.Context, msg IncomingMessage, emit func(stream.Event)) (string, error) {
+		emit(stream.Event{Type: stream.ToolStart, Tool: "devops_list_my_workitems"})
+		emit(stream.Event{Type: stream.ToolEnd, Tool: "devops_list_my_workitems", DurationMs: 12})
+		emit(stream.Event{Type: stream.Content, Content: "You have 2 items"})
+		emit(stream.Event{Type: stream.Done})
+		return "You have 2 items", nil
+	})
+
+	sessionID := createTestSession(t, srv)
+
+	resp, err := http.Post(srv.URL+"/webchat/api/sessions/"+sessionID+"/messages/stream", "application/json", strings.NewReader(`{"content":"my items?"}`))
+	if err != nil {
+		t.Fatalf("stream request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, expected text/event-stream", ct)
+	}
+
+	var types []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var ev stream.Event
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		types = append(types, ev.Type)
+	}
+
+	expected := []string{"tool_start", "tool_end", "content", "done"}
+	if strings.Join(types, ",") != strings.Join(expected, ",") {
+		t.Errorf("events = %v, expected %v", types, expected)
+	}
+
+	val, _ := wc.sessions.Load(sessionID)
+	session := val.(*WebChatSession)
+	if len(session.Messages) != 2 || session.Messages[1].Content != "You have 2 items" {
+		t.Errorf("expected user and assistant messages stored, got %+v", session.Messages)
+	}
+}
+
+func TestStreamMessageCancelledByClient(t *testing.T) {
+	wc, srv := newTestWebChat(t, nil)
+	upstreamCancelled := make(chan struct{})
+	wc.SetStreamHandler(func(ctx context.Context, msg IncomingMessage, emit func(stream.Event)) (string, error) {
+		emit(stream.Event{Type: stream.ToolStart, Tool: "devops_list_my_workitems"})
+		<-ctx.Done()
+		close(upstreamCancelled)
+		// A handler that still reports what it had must not reach the client
+		emit(stream.Event{Type: stream.Content, Content: "You have"})
+		return "You have", nil
+	})
+	sessionID := createTestSession(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "POST", srv.URL+"/webchat/api/sessions/"+sessionID+"/messages/stream", strings.NewReader(`{"content":"my items?"}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && !strings.HasPrefix(scanner.Text(), "data: ") {
+	}
+	cancel()
+
+	select {
+	case <-upstreamCancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream handler context was not cancelled")
+	}
+	// Close waits for the request to finish
+	srv.Close()
+
+	val, _ := wc.sessions.Load(sessionID)
+	session := val.(*WebChatSession)
+	if len(session.Messages) != 1 || session.Messages[0].Role != "user" {
+		t.Errorf("expected only the user message stored, got %+v", session.Messages)
+	}
+}
+
+func TestStreamMessageDisabled(t *testing.T) {
+	_, srv := newTestWebChat(t, nil)
+	sessionID := createTestSession(t, srv)
+
+	resp, err := http.Post(srv.URL+"/webchat/api/sessions/"+sessionID+"/messages/stream", "application/json", strings.NewReader(`{"content":"hi"}`))
+	if err != nil {
+		t.Fatalf("stream request: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotImplemented {
+		t.Errorf("status = %d, expected %d", resp.StatusCode, http.StatusNotImplemented)
+	}
+}
+
+func TestSendMessageRejectsOversizedContent(t *testing.T) {
+	calls := 0
+	wc, srv := newTestWebChat(t, func(ctx context.Context, msg IncomingMessage) (string, error) {
+		calls++
+		return "ok", nil
+	})
+	wc.SetInputLimits(skills.InputLimits{MaxChars: 5})
+	sessionID := createTestSession(t, srv)
+
+	tests := []struct {
+		content  string
+		expected int
+	}{
+		{"12345", http.StatusOK},
+		{"123456", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		body, _ := json.Marshal(map[string]string{"content": tt.content})
+		resp, err := http.Post(srv.URL+"/webchat/api/sessions/"+sessionID+"/messages", "application/json", strings.NewReader(string(body)))
+		if err != nil {
+			t.Fatalf("send message: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.expected {
+			t.Errorf("content of %d chars: status = %d, expected %d", len(tt.content), resp.StatusCode, tt.expected)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("handler called %d times, expected only the in-limit message to reach it", calls)
+	}
+}
+
+func TestSendMessageDeduplicatesConcurrentSends(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	wc, srv := newTestWebChat(t, func(ctx context.Context, msg IncomingMessage) (string, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return "resposta", nil
+	})
+	sessionID := createTestSession(t, srv)
+
+	send := func() (map[string]WebChatMessage, error) {
+		resp, err := http.Post(srv.URL+"/webchat/api/sessions/"+sessionID+"/messages", "application/json", strings.NewReader(`{"content":"status do sprint"}`))
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		var body map[string]WebChatMessage
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		return body, err
+	}
+
+	var wg sync.WaitGroup
+	results := make([]map[string]WebChatMessage, 2)
+	errs := make([]error, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], errs[0] = send()
+	}()
+	<-started
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[1], errs[1] = send()
+	}()
+	// Give the duplicate time to reach the handler before the first finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler called %d times, want 1", n)
+	}
+	if results[0]["assistant_message"].ID != results[1]["assistant_message"].ID {
+		t.Errorf("duplicate got a different reply: %+v vs %+v", results[0], results[1])
+	}
+
+	session, _ := wc.sessions.Load(sessionID)
+	if n := len(session.(*WebChatSession).Messages); n != 2 {
+		t.Errorf("session has %d messages, want one user and one assistant message", n)
+	}
+
+	// Once the first send completed, the same content is processed again
+	if _, err := send(); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("handler called %d times after a later resend, want 2", n)
+	}
+}
+
+func TestLinkSessionRedeemsTelegramCode(t *testing.T) {
+	wc, srv := newTestWebChat(t, nil)
+	store, err := identity.NewStore(nil, "")
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	wc.SetIdentities(store)
+
+	sessionID := createTestSession(t, srv)
+	code, _ := store.NewCode("telegram", "42")
+
+	link := func(body string) int {
+		resp, err := http.Post(srv.URL+"/webchat/api/sessions/"+sessionID+"/link", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("link session: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := link(`{"code":"` + code + `"}`); status != http.StatusOK {
+		t.Fatalf("link status = %d, want 200", status)
+	}
+	if got := store.Resolve("webchat", sessionID); got != "telegram:42" {
+		t.Errorf("session resolves to %q, want the Telegram user", got)
+	}
+	// Another session claiming the same user_id does not get the link
+	if got := store.Resolve("webchat", "u1"); got != "webchat:u1" {
+		t.Errorf("session user_id resolves to %q, want it unlinked", got)
+	}
+	if status := link(`{"code":"` + code + `"}`); status != http.StatusBadRequest {
+		t.Errorf("reused code status = %d, want 400", status)
+	}
+}
+
+func TestCleanupOldSessionsUsesClock(t *testing.T) {
+	wc, srv := newTestWebChat(t, nil)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	wc.SetClock(fake)
+
+	old := createTestSession(t, srv)
+	fake.Advance(30 * time.Minute)
+	recent := createTestSession(t, srv)
+
+	// old is now 1h01m old, recent 31m
+	fake.Advance(31 * time.Minute)
+	wc.CleanupOldSessions(time.Hour)
+
+	if _, ok := wc.sessions.Load(old); ok {
+		t.Error("session older than maxAge was not cleaned up")
+	}
+	if _, ok := wc.sessions.Load(recent); !ok {
+		t.Error("recent session was cleaned up")
+	}
+}
+
+func TestSharedSendSurvivesTheFirstClientLeaving(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var handlerErr atomic.Value
+	_, srv := newTestWebChat(t, func(ctx context.Context, msg IncomingMessage) (string, error) {
+		close(started)
+		<-release
+		if err := ctx.Err(); err != nil {
+			handlerErr.Store(err)
+			return "", err
+		}
+		return "resposta", nil
+	})
+	sessionID := createTestSession(t, srv)
+	url := srv.URL + "/webchat/api/sessions/" + sessionID + "/messages"
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		req, _ := http.NewRequestWithContext(firstCtx, http.MethodPost, url, strings.NewReader(`{"content":"status do sprint"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	second := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(url, "application/json", strings.NewReader(`{"content":"status do sprint"}`))
+		if err != nil {
+			second <- 0
+			return
+		}
+		resp.Body.Close()
+		second <- resp.StatusCode
+	}()
+	// Let the duplicate join the call, then drop the first client
+	time.Sleep(50 * time.Millisecond)
+	cancelFirst()
+	<-firstDone
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if status := <-second; status != http.StatusOK {
+		t.Errorf("waiting client got status %d, want 200", status)
+	}
+	if err := handlerErr.Load(); err != nil {
+		t.Errorf("handler context ended with the first client: %v", err)
+	}
+}
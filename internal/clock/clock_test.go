@@ -0,0 +1,23 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeOnlyMovesWhenTold(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+
+	if !f.Now().Equal(start) {
+		t.Fatalf("Now() = %v, want %v", f.Now(), start)
+	}
+	f.Advance(90 * time.Second)
+	if got, want := f.Now(), start.Add(90*time.Second); !got.Equal(want) {
+		t.Errorf("after Advance Now() = %v, want %v", got, want)
+	}
+	f.Set(start)
+	if !f.Now().Equal(start) {
+		t.Errorf("after Set Now() = %v, want %v", f.Now(), start)
+	}
+}
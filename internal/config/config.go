@@ -5,6 +5,10 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/i18n"
+	"github.com/abelclopes/nomad-iabot/internal/skills"
 )
 
 // Config holds all configuration for Nomad Agent
@@ -16,6 +20,17 @@ type Config struct {
 	Trello      TrelloConfig
 	Telegram    TelegramConfig
 	Tools       ToolsConfig
+	Feedback    FeedbackConfig
+	Usage       UsageConfig
+	I18n        I18nConfig
+	Breaker     BreakerConfig
+	Agent       AgentConfig
+	HTTP        HTTPConfig
+}
+
+// HTTPConfig holds settings shared by the outbound HTTP clients
+type HTTPConfig struct {
+	UserAgent string // User-Agent sent to Azure DevOps, Trello and the LLM (empty = nomad-iabot/<version>)
 }
 
 // GatewayConfig holds gateway/server configuration
@@ -24,6 +39,14 @@ type GatewayConfig struct {
 	WSPort      int
 	Bind        string // IP address to bind to (e.g., "0.0.0.0" for all interfaces, "127.0.0.1" for localhost)
 	CORSOrigins []string
+	OpenAPI     bool // Serve the API description at /openapi.json
+
+	WebChatStaticDir string // Directory with the built WebChat frontend, served at /webchat/
+
+	BatchMaxItems    int // Max prompts accepted by POST /api/v1/chat/batch
+	BatchConcurrency int // Prompts of one batch processed in parallel
+
+	ShutdownTimeoutSec int // budget for draining requests and stopping channels on exit
 }
 
 // LLMConfig holds LLM provider configuration
@@ -35,6 +58,20 @@ type LLMConfig struct {
 	MaxTokens   int
 	Temperature float64
 	TimeoutSec  int
+	AutoPull    bool // pull the model at startup when missing (Ollama only)
+	Vision      bool // the model accepts images in messages
+	Debug       bool // log raw request/response bodies at debug level
+
+	CacheEnabled        bool    // cache responses to identical deterministic prompts
+	CacheTTLSec         int     // how long cached responses stay valid
+	CacheMaxTemperature float64 // requests above this temperature are never cached
+
+	MaxIdleConns        int // keep-alive connections kept across all hosts
+	MaxIdleConnsPerHost int // keep-alive connections kept to the LLM server
+	IdleConnTimeoutSec  int // how long an unused connection stays open
+
+	ReadyMaxP95Ms    int // /ready fails while the chat p95 latency exceeds this (0 = disabled)
+	LatencyWindowSec int // how long a chat latency counts toward the p95
 }
 
 // SecurityConfig holds security settings
@@ -43,6 +80,14 @@ type SecurityConfig struct {
 	RateLimitRPS   int    // requests per second
 	RateLimitBurst int    // burst size
 	AuthMode       string // "jwt", "api-key", "none"
+	AdminToken     string // guards /api/v1/admin; empty disables the admin API
+	ReadOnly       bool   // refuse every tool and endpoint that changes Azure DevOps or Trello
+	MaxInputChars  int    // max characters per chat message (0 = unlimited)
+	MaxInputTokens int    // max estimated tokens per chat message (0 = unlimited)
+
+	MaxAttachments         int      // attachments per chat message (0 = unlimited)
+	MaxAttachmentBytes     int      // decoded size of one attachment (0 = unlimited)
+	AllowedAttachmentTypes []string // MIME types accepted as attachments
 }
 
 // AzureDevOpsConfig holds Azure DevOps integration settings
@@ -52,13 +97,30 @@ type AzureDevOpsConfig struct {
 	Project      string
 	PAT          string // Personal Access Token
 	APIVersion   string
+	CustomFields []string // Field reference names the HTTP API may set directly
+
+	PipelineBranches map[int]string // branch per pipeline ID for runs that name none
+	QueryTemplates   string         // JSON file adding or overriding WIQL query templates
+
+	DefaultWorkItemType string // type of work items the model creates without one
+	DefaultPriority     int    // priority (1-4) of work items the model creates without one
+
+	WebhookSecret     string // shared secret sent by service hook subscriptions
+	WebhookNotifyChat string // Telegram chat that receives service hook notifications
 }
 
 // TrelloConfig holds Trello integration settings
 type TrelloConfig struct {
-	Enabled bool
-	APIKey  string
-	Token   string
+	Enabled       bool
+	APIKey        string
+	Token         string
+	RateLimit     int // max requests per RateWindowSec (0 disables client-side pacing)
+	RateWindowSec int
+	WIPLimit      int // soft cards-per-list limit flagged by trello_board_overview (0 = none)
+
+	WebhookSecret      string // application secret used to sign webhook requests
+	WebhookCallbackURL string // public URL registered with Trello (part of the signature)
+	WebhookNotifyChat  string // Telegram chat that receives webhook notifications
 }
 
 // TelegramConfig holds Telegram bot configuration
@@ -66,13 +128,89 @@ type TelegramConfig struct {
 	Enabled   bool
 	BotToken  string
 	AllowFrom []int64 // allowed user IDs (empty = all)
+	Streaming bool    // edit the reply in place while it is being produced
+
+	MessageTimeoutSec int // budget for handling one message (0 = until shutdown)
+	ProgressAfterSec  int // send a "still working" note when a reply takes longer (0 = never)
+}
+
+// I18nConfig holds localization settings
+type I18nConfig struct {
+	Locale   string // default locale for bot replies ("pt-BR" or "en")
+	Timezone string // IANA zone the agent uses for "today" (empty = server local time)
+}
+
+// UsageConfig holds per-user usage tracking settings
+type UsageConfig struct {
+	DailyTokenLimit int // max tokens per user per day (0 = unlimited)
+}
+
+// AgentConfig holds conversation settings for the agent
+type AgentConfig struct {
+	MemoryMessages int    // messages remembered per user across channels (0 = no memory)
+	HistoryTurns   int    // remembered turns sent with each message (0 = all remembered)
+	HistoryTokens  int    // estimated token budget for those turns (0 = no limit)
+	Greeting       string // custom /start greeting (empty = localized default)
+	MaxConcurrency int    // messages processed at once across all channels (0 = unlimited)
+	QueueWaitSec   int    // how long a message waits for a free slot before "server busy"
+
+	// SystemPrompt replaces the default persona and guidelines (empty = built-in).
+	// ChannelPrompts override it per channel ("telegram", "webchat", "api").
+	// Integration, capability and date sections are appended to either.
+	SystemPrompt   string
+	ChannelPrompts map[string]string
+
+	// PostToolNudge adds a system message to the request that follows tool
+	// calls, asking the model to summarize the results instead of echoing
+	// them. PostToolPrompt replaces its localized text.
+	PostToolNudge  bool
+	PostToolPrompt string
+
+	// IdentityLinks maps channel user IDs to one user, as comma-separated
+	// "channel:id=user" entries, so they share memory, usage and limits.
+	// Links made with /link are saved to IdentityLinksFile (empty = in memory).
+	IdentityLinks     string
+	IdentityLinksFile string
+}
+
+// BreakerConfig holds circuit breaker settings for outbound integrations
+type BreakerConfig struct {
+	FailureThreshold int // consecutive failures before calls fail fast (0 = disabled)
+	CooldownSec      int // seconds to fail fast before probing again
+}
+
+// FeedbackConfig holds settings for filing feedback about the bot itself
+type FeedbackConfig struct {
+	Target       string // "devops", "trello" or "" (disabled)
+	Project      string // Azure DevOps project for feedback items (defaults to AZURE_DEVOPS_PROJECT)
+	WorkItemType string // Azure DevOps work item type for feedback items
+	TrelloListID string // Trello list that receives feedback cards
 }
 
 // ToolsConfig holds tool permissions
 type ToolsConfig struct {
+	CallTimeoutSec int    // Budget for a single tool call, including chained API requests
+	OverridesFile  string // JSON file persisting tools disabled at runtime (empty = not persisted)
 	FileRead       FileReadConfig
 	CommandExecute CommandExecuteConfig
 	WebSearch      WebSearchConfig
+	Limits         ToolLimitsConfig
+
+	// ChannelAllowlists limits the tools a channel may call, by lower-case
+	// channel name. Entries are tool names or prefixes ending in "*"; a
+	// channel without an entry may call every tool.
+	ChannelAllowlists map[string][]string
+
+	// Descriptions replace the description the model sees for a tool, by
+	// tool name. Disabled tools are never offered or run, and unlike runtime
+	// toggles cannot be re-enabled through the admin API.
+	Descriptions map[string]string
+	Disabled     []string
+
+	// Guidance adds a section to the system prompt listing the channel's
+	// tools and how to call them. It helps small local models that
+	// misformat tool calls and costs tokens on every message.
+	Guidance bool
 }
 
 // FileReadConfig holds file reading permissions
@@ -96,6 +234,19 @@ type WebSearchConfig struct {
 	BaseURL string
 }
 
+// ToolLimitsConfig caps how much output the tools hand back to the model,
+// so one large result cannot crowd the conversation out of the context
+type ToolLimitsConfig struct {
+	ResultBytes  int // tool result sent to the model; longer results are truncated
+	SnippetChars int // text shown per search hit or comment
+}
+
+// DefaultToolLimits applies to the limits left unset
+var DefaultToolLimits = ToolLimitsConfig{
+	ResultBytes:  64 * 1024,
+	SnippetChars: 200,
+}
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	cfg := &Config{
@@ -104,6 +255,14 @@ func Load() (*Config, error) {
 			WSPort:      getEnvInt("GATEWAY_WS_PORT", 8081),
 			Bind:        getEnv("GATEWAY_HOST", "0.0.0.0"),
 			CORSOrigins: getEnvSlice("GATEWAY_CORS_ORIGINS", []string{"http://localhost:*"}),
+			OpenAPI:     getEnvBool("GATEWAY_OPENAPI_ENABLED", true),
+
+			WebChatStaticDir: getEnv("WEBCHAT_STATIC_DIR", "./web/dist"),
+
+			BatchMaxItems:    getEnvInt("CHAT_BATCH_MAX_ITEMS", 20),
+			BatchConcurrency: getEnvInt("CHAT_BATCH_CONCURRENCY", 4),
+
+			ShutdownTimeoutSec: getEnvInt("SHUTDOWN_TIMEOUT", 30),
 		},
 		LLM: LLMConfig{
 			Provider:    getEnv("LLM_PROVIDER", "ollama"),
@@ -113,12 +272,34 @@ func Load() (*Config, error) {
 			MaxTokens:   getEnvInt("LLM_MAX_TOKENS", 4096),
 			Temperature: getEnvFloat("LLM_TEMPERATURE", 0.7),
 			TimeoutSec:  getEnvInt("LLM_TIMEOUT", 120),
+			AutoPull:    getEnvBool("OLLAMA_AUTO_PULL", false),
+			Vision:      getEnvBool("LLM_VISION", false),
+			Debug:       getEnvBool("LLM_DEBUG", false),
+
+			CacheEnabled:        getEnvBool("LLM_CACHE_ENABLED", false),
+			CacheTTLSec:         getEnvInt("LLM_CACHE_TTL", 3600),
+			CacheMaxTemperature: getEnvFloat("LLM_CACHE_MAX_TEMPERATURE", 0.3),
+
+			MaxIdleConns:        getEnvInt("LLM_MAX_IDLE_CONNS", 100),
+			MaxIdleConnsPerHost: getEnvInt("LLM_MAX_IDLE_CONNS_PER_HOST", 32),
+			IdleConnTimeoutSec:  getEnvInt("LLM_IDLE_CONN_TIMEOUT", 90),
+
+			ReadyMaxP95Ms:    getEnvInt("LLM_READY_MAX_P95_MS", 0),
+			LatencyWindowSec: getEnvInt("LLM_LATENCY_WINDOW", 300),
 		},
 		Security: SecurityConfig{
 			JWTSecret:      getEnv("JWT_SECRET", ""),
 			RateLimitRPS:   getEnvInt("RATE_LIMIT_RPS", 10),
 			RateLimitBurst: getEnvInt("RATE_LIMIT_BURST", 20),
 			AuthMode:       getEnv("AUTH_MODE", "jwt"),
+			AdminToken:     getEnv("ADMIN_TOKEN", ""),
+			ReadOnly:       getEnvBool("READ_ONLY", false),
+			MaxInputChars:  getEnvInt("MAX_INPUT_CHARS", 10000),
+			MaxInputTokens: getEnvInt("MAX_INPUT_TOKENS", 0),
+
+			MaxAttachments:         getEnvInt("MAX_ATTACHMENTS", 4),
+			MaxAttachmentBytes:     getEnvInt("MAX_ATTACHMENT_SIZE", 5*1024*1024), // 5MB
+			AllowedAttachmentTypes: getEnvSlice("ALLOWED_ATTACHMENT_TYPES", []string{"image/png", "image/jpeg", "image/gif", "image/webp", "text/plain"}),
 		},
 		AzureDevOps: AzureDevOpsConfig{
 			Enabled:      getEnvBool("AZURE_DEVOPS_ENABLED", false),
@@ -126,18 +307,41 @@ func Load() (*Config, error) {
 			Project:      getEnv("AZURE_DEVOPS_PROJECT", ""),
 			PAT:          getEnv("AZURE_DEVOPS_PAT", ""),
 			APIVersion:   getEnv("AZURE_DEVOPS_API_VERSION", "7.0"),
+			CustomFields: getEnvSlice("AZURE_DEVOPS_CUSTOM_FIELDS", nil),
+
+			PipelineBranches: getEnvIntMap("AZURE_DEVOPS_PIPELINE_BRANCHES"),
+			QueryTemplates:   getEnv("AZURE_DEVOPS_QUERY_TEMPLATES", ""),
+
+			DefaultWorkItemType: getEnv("AZURE_DEVOPS_DEFAULT_WORKITEM_TYPE", "Task"),
+			DefaultPriority:     getEnvInt("AZURE_DEVOPS_DEFAULT_PRIORITY", 2),
+
+			WebhookSecret:     getEnv("AZURE_DEVOPS_WEBHOOK_SECRET", ""),
+			WebhookNotifyChat: getEnv("AZURE_DEVOPS_WEBHOOK_NOTIFY_CHAT", ""),
 		},
 		Trello: TrelloConfig{
-			Enabled: getEnvBool("TRELLO_ENABLED", false),
-			APIKey:  getEnv("TRELLO_API_KEY", ""),
-			Token:   getEnv("TRELLO_TOKEN", ""),
+			Enabled:       getEnvBool("TRELLO_ENABLED", false),
+			APIKey:        getEnv("TRELLO_API_KEY", ""),
+			Token:         getEnv("TRELLO_TOKEN", ""),
+			RateLimit:     getEnvInt("TRELLO_RATE_LIMIT", 100),
+			RateWindowSec: getEnvInt("TRELLO_RATE_WINDOW", 10),
+			WIPLimit:      getEnvInt("TRELLO_WIP_LIMIT", 0),
+
+			WebhookSecret:      getEnv("TRELLO_WEBHOOK_SECRET", ""),
+			WebhookCallbackURL: getEnv("TRELLO_WEBHOOK_CALLBACK_URL", ""),
+			WebhookNotifyChat:  getEnv("TRELLO_WEBHOOK_NOTIFY_CHAT", ""),
 		},
 		Telegram: TelegramConfig{
 			Enabled:   getEnvBool("TELEGRAM_ENABLED", false),
 			BotToken:  getEnv("TELEGRAM_BOT_TOKEN", ""),
 			AllowFrom: getEnvInt64Slice("TELEGRAM_ALLOWED_USERS", nil),
+			Streaming: getEnvBool("TELEGRAM_STREAMING", false),
+
+			MessageTimeoutSec: getEnvInt("TELEGRAM_MESSAGE_TIMEOUT", 300),
+			ProgressAfterSec:  getEnvInt("TELEGRAM_PROGRESS_AFTER", 0),
 		},
 		Tools: ToolsConfig{
+			CallTimeoutSec: getEnvInt("TOOLS_CALL_TIMEOUT", 60),
+			OverridesFile:  getEnv("TOOLS_OVERRIDES_FILE", ""),
 			FileRead: FileReadConfig{
 				Enabled:          getEnvBool("TOOLS_FILE_READ", true),
 				AllowedPaths:     getEnvSlice("TOOLS_FILE_ALLOWED_PATHS", []string{"/workspace"}),
@@ -153,6 +357,49 @@ func Load() (*Config, error) {
 				Engine:  getEnv("TOOLS_SEARCH_ENGINE", "duckduckgo"),
 				BaseURL: getEnv("TOOLS_SEARCH_URL", ""),
 			},
+			Limits: ToolLimitsConfig{
+				ResultBytes:  getEnvInt("TOOLS_LIMIT_RESULT_BYTES", DefaultToolLimits.ResultBytes),
+				SnippetChars: getEnvInt("TOOLS_LIMIT_SNIPPET_CHARS", DefaultToolLimits.SnippetChars),
+			},
+			ChannelAllowlists: getEnvSliceMap("TOOLS_ALLOW_"),
+			Descriptions:      getEnvSuffixMap("TOOLS_DESCRIPTION_"),
+			Disabled:          getEnvSlice("TOOLS_DISABLED", nil),
+			Guidance:          getEnvBool("TOOLS_GUIDANCE", false),
+		},
+		Feedback: FeedbackConfig{
+			Target:       getEnv("FEEDBACK_TARGET", ""),
+			Project:      getEnv("FEEDBACK_DEVOPS_PROJECT", ""),
+			WorkItemType: getEnv("FEEDBACK_WORKITEM_TYPE", "Bug"),
+			TrelloListID: getEnv("FEEDBACK_TRELLO_LIST_ID", ""),
+		},
+		Usage: UsageConfig{
+			DailyTokenLimit: getEnvInt("USAGE_DAILY_TOKEN_LIMIT", 0),
+		},
+		I18n: I18nConfig{
+			Locale:   getEnv("BOT_LOCALE", i18n.Fallback),
+			Timezone: getEnv("AGENT_TIMEZONE", getEnv("TZ", "")),
+		},
+		Agent: AgentConfig{
+			MemoryMessages: getEnvInt("AGENT_MEMORY_MESSAGES", 20),
+			HistoryTurns:   getEnvInt("AGENT_HISTORY_TURNS", 0),
+			HistoryTokens:  getEnvInt("AGENT_HISTORY_TOKENS", 0),
+			Greeting:       getEnv("AGENT_GREETING", ""),
+			MaxConcurrency: getEnvInt("AGENT_MAX_CONCURRENCY", 16),
+			QueueWaitSec:   getEnvInt("AGENT_QUEUE_WAIT", 5),
+			SystemPrompt:   getEnv("AGENT_SYSTEM_PROMPT", ""),
+			ChannelPrompts: getEnvSuffixMap("AGENT_SYSTEM_PROMPT_"),
+			PostToolNudge:  getEnvBool("AGENT_POST_TOOL_NUDGE", false),
+			PostToolPrompt: getEnv("AGENT_POST_TOOL_PROMPT", ""),
+
+			IdentityLinks:     getEnv("IDENTITY_LINKS", ""),
+			IdentityLinksFile: getEnv("IDENTITY_LINKS_FILE", ""),
+		},
+		Breaker: BreakerConfig{
+			FailureThreshold: getEnvInt("BREAKER_FAILURE_THRESHOLD", 5),
+			CooldownSec:      getEnvInt("BREAKER_COOLDOWN", 30),
+		},
+		HTTP: HTTPConfig{
+			UserAgent: getEnv("HTTP_USER_AGENT", ""),
 		},
 	}
 
@@ -181,6 +428,43 @@ func (c *Config) validate() error {
 		if c.AzureDevOps.PAT == "" {
 			return fmt.Errorf("AZURE_DEVOPS_PAT is required when Azure DevOps is enabled")
 		}
+		for _, field := range c.AzureDevOps.CustomFields {
+			if !skills.ValidateDevOpsFieldName(field) {
+				return fmt.Errorf("AZURE_DEVOPS_CUSTOM_FIELDS contains an invalid field reference name: %q", field)
+			}
+		}
+		if !skills.ValidateDevOpsWorkItemType(c.AzureDevOps.DefaultWorkItemType) {
+			return fmt.Errorf("AZURE_DEVOPS_DEFAULT_WORKITEM_TYPE must be one of Task, Bug, User Story, Feature, Epic: %q", c.AzureDevOps.DefaultWorkItemType)
+		}
+		if !skills.ValidateDevOpsPriority(c.AzureDevOps.DefaultPriority) {
+			return fmt.Errorf("AZURE_DEVOPS_DEFAULT_PRIORITY must be between 1 and 4")
+		}
+	}
+
+	if c.I18n.Timezone != "" {
+		if _, err := time.LoadLocation(c.I18n.Timezone); err != nil {
+			return fmt.Errorf("AGENT_TIMEZONE is not a valid IANA time zone: %q", c.I18n.Timezone)
+		}
+	}
+
+	if c.LLM.MaxIdleConns < 0 || c.LLM.MaxIdleConnsPerHost < 0 || c.LLM.IdleConnTimeoutSec < 0 {
+		return fmt.Errorf("LLM_MAX_IDLE_CONNS, LLM_MAX_IDLE_CONNS_PER_HOST and LLM_IDLE_CONN_TIMEOUT cannot be negative")
+	}
+
+	if c.LLM.ReadyMaxP95Ms < 0 || c.LLM.LatencyWindowSec < 0 {
+		return fmt.Errorf("LLM_READY_MAX_P95_MS and LLM_LATENCY_WINDOW cannot be negative")
+	}
+
+	if l := c.Tools.Limits; l.ResultBytes <= 0 || l.SnippetChars <= 0 {
+		return fmt.Errorf("TOOLS_LIMIT_RESULT_BYTES and TOOLS_LIMIT_SNIPPET_CHARS must be positive")
+	}
+
+	if c.Breaker.FailureThreshold > 0 && c.Breaker.CooldownSec <= 0 {
+		return fmt.Errorf("BREAKER_COOLDOWN must be positive when BREAKER_FAILURE_THRESHOLD is set")
+	}
+
+	if i18n.Normalize(c.I18n.Locale) == "" {
+		return fmt.Errorf("BOT_LOCALE must be one of %s, %s", i18n.PtBR, i18n.En)
 	}
 
 	// Trello validation
@@ -191,6 +475,9 @@ func (c *Config) validate() error {
 		if c.Trello.Token == "" {
 			return fmt.Errorf("TRELLO_TOKEN is required when Trello is enabled")
 		}
+		if c.Trello.WebhookCallbackURL != "" && c.Trello.WebhookSecret == "" {
+			return fmt.Errorf("TRELLO_WEBHOOK_SECRET is required when TRELLO_WEBHOOK_CALLBACK_URL is set")
+		}
 	}
 
 	// Telegram validation
@@ -198,9 +485,52 @@ func (c *Config) validate() error {
 		return fmt.Errorf("TELEGRAM_BOT_TOKEN is required when Telegram is enabled")
 	}
 
+	// Feedback validation
+	switch c.Feedback.Target {
+	case "", "devops":
+	case "trello":
+		if c.Feedback.TrelloListID == "" {
+			return fmt.Errorf("FEEDBACK_TRELLO_LIST_ID is required when feedback target is 'trello'")
+		}
+	default:
+		return fmt.Errorf("FEEDBACK_TARGET must be 'devops' or 'trello', got %q", c.Feedback.Target)
+	}
+
 	return nil
 }
 
+// InputLimits returns the per-message size limits applied by all channels
+func (c *Config) InputLimits() skills.InputLimits {
+	return skills.InputLimits{
+		MaxChars:  c.Security.MaxInputChars,
+		MaxTokens: c.Security.MaxInputTokens,
+	}
+}
+
+// AttachmentLimits returns the limits applied to chat attachments
+func (c *Config) AttachmentLimits() skills.AttachmentLimits {
+	return skills.AttachmentLimits{
+		MaxCount:     c.Security.MaxAttachments,
+		MaxBytes:     c.Security.MaxAttachmentBytes,
+		AllowedTypes: c.Security.AllowedAttachmentTypes,
+	}
+}
+
+// Secrets returns every configured credential so it can be redacted from
+// logs and error messages
+func (c *Config) Secrets() []string {
+	return []string{
+		c.Security.JWTSecret,
+		c.LLM.APIKey,
+		c.AzureDevOps.PAT,
+		c.AzureDevOps.WebhookSecret,
+		c.Trello.APIKey,
+		c.Trello.Token,
+		c.Trello.WebhookSecret,
+		c.Telegram.BotToken,
+	}
+}
+
 // Helper functions
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -252,6 +582,56 @@ func getEnvSlice(key string, defaultValue []string) []string {
 	return defaultValue
 }
 
+// getEnvIntMap parses "id=value" pairs separated by commas, skipping malformed entries
+func getEnvIntMap(key string) map[int]string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+
+	result := make(map[int]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(k))
+		if err != nil || strings.TrimSpace(v) == "" {
+			continue
+		}
+		result[id] = strings.TrimSpace(v)
+	}
+	return result
+}
+
+// getEnvSuffixMap collects the non-empty variables named prefix+SUFFIX,
+// keyed by the lowercased suffix (AGENT_SYSTEM_PROMPT_TELEGRAM -> "telegram")
+func getEnvSuffixMap(prefix string) map[string]string {
+	result := make(map[string]string)
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if suffix, ok := strings.CutPrefix(k, prefix); ok && suffix != "" && strings.TrimSpace(v) != "" {
+			result[strings.ToLower(suffix)] = v
+		}
+	}
+	return result
+}
+
+// getEnvSliceMap is getEnvSuffixMap for comma-separated values
+func getEnvSliceMap(prefix string) map[string][]string {
+	result := make(map[string][]string)
+	for key, value := range getEnvSuffixMap(prefix) {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		result[key] = items
+	}
+	return result
+}
+
 func getEnvInt64Slice(key string, defaultValue []int64) []int64 {
 	if value := os.Getenv(key); value != "" {
 		parts := strings.Split(value, ",")
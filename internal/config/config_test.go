@@ -0,0 +1,39 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToolLimitsDefaultWhenUnset(t *testing.T) {
+	t.Setenv("AUTH_MODE", "none")
+	for _, key := range []string{"TOOLS_LIMIT_RESULT_BYTES", "TOOLS_LIMIT_SNIPPET_CHARS"} {
+		t.Setenv(key, "")
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Tools.Limits != DefaultToolLimits {
+		t.Errorf("Limits = %+v, want the defaults %+v", cfg.Tools.Limits, DefaultToolLimits)
+	}
+
+	t.Setenv("TOOLS_LIMIT_SNIPPET_CHARS", "500")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Tools.Limits.SnippetChars != 500 || cfg.Tools.Limits.ResultBytes != DefaultToolLimits.ResultBytes {
+		t.Errorf("Limits = %+v, want SnippetChars 500 and the other defaults", cfg.Tools.Limits)
+	}
+}
+
+func TestToolLimitsMustBePositive(t *testing.T) {
+	t.Setenv("AUTH_MODE", "none")
+	t.Setenv("TOOLS_LIMIT_RESULT_BYTES", "0")
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "must be positive") {
+		t.Errorf("Load() error = %v, want the limits to be rejected", err)
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+// IntegrationStatus reports whether an integration is active and, when it
+// is not, which environment variables still need a value to enable it
+type IntegrationStatus struct {
+	Name    string   `json:"name"`
+	Enabled bool     `json:"enabled"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// requirement is an environment variable an integration needs and whether
+// it is set
+type requirement struct {
+	env string
+	set bool
+}
+
+// Integrations summarizes the optional integrations, using the same rules
+// that decide whether each one starts
+func (c *Config) Integrations() []IntegrationStatus {
+	return []IntegrationStatus{
+		integration("azure_devops",
+			requirement{"AZURE_DEVOPS_ORGANIZATION", c.AzureDevOps.Organization != ""},
+			requirement{"AZURE_DEVOPS_PAT", c.AzureDevOps.PAT != ""},
+		),
+		integration("trello",
+			requirement{"TRELLO_ENABLED", c.Trello.Enabled},
+			requirement{"TRELLO_API_KEY", c.Trello.APIKey != ""},
+			requirement{"TRELLO_TOKEN", c.Trello.Token != ""},
+		),
+		integration("telegram",
+			requirement{"TELEGRAM_BOT_TOKEN", c.Telegram.BotToken != ""},
+		),
+	}
+}
+
+func integration(name string, reqs ...requirement) IntegrationStatus {
+	st := IntegrationStatus{Name: name, Enabled: true}
+	for _, r := range reqs {
+		if !r.set {
+			st.Enabled = false
+			st.Missing = append(st.Missing, r.env)
+		}
+	}
+	return st
+}
+
+// HasToolIntegrations reports whether Azure DevOps or Trello is active;
+// without either the agent can only chat
+func (c *Config) HasToolIntegrations() bool {
+	for _, st := range c.Integrations() {
+		if st.Enabled && st.Name != "telegram" {
+			return true
+		}
+	}
+	return false
+}
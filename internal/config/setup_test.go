@@ -0,0 +1,34 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIntegrationsReflectConfig(t *testing.T) {
+	cfg := &Config{}
+	cfg.AzureDevOps.Organization = "acme"
+	cfg.Trello.APIKey = "key"
+	cfg.Trello.Token = "token"
+	cfg.Telegram.BotToken = "123:abc"
+
+	want := []IntegrationStatus{
+		{Name: "azure_devops", Missing: []string{"AZURE_DEVOPS_PAT"}},
+		{Name: "trello", Missing: []string{"TRELLO_ENABLED"}},
+		{Name: "telegram", Enabled: true},
+	}
+	if got := cfg.Integrations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Integrations() = %+v, want %+v", got, want)
+	}
+	if cfg.HasToolIntegrations() {
+		t.Error("HasToolIntegrations() = true with only Telegram configured")
+	}
+
+	cfg.Trello.Enabled = true
+	if got := cfg.Integrations()[1]; !got.Enabled || got.Missing != nil {
+		t.Errorf("trello = %+v, want enabled", got)
+	}
+	if !cfg.HasToolIntegrations() {
+		t.Error("HasToolIntegrations() = false with Trello configured")
+	}
+}
@@ -0,0 +1,237 @@
+package devops
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxBulkItems caps how many work items one bulk operation may touch
+	maxBulkItems = 50
+	// bulkConcurrency bounds the requests a bulk operation has in flight
+	bulkConcurrency = 4
+)
+
+// fanOut calls fn for every index below n, with up to bulkConcurrency calls
+// in flight, and returns each call's error by index. Calls still waiting for
+// a slot when ctx ends are skipped and get ctx's error.
+func fanOut(ctx context.Context, n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	sem := make(chan struct{}, bulkConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+// BulkCommentResult is the outcome of posting one comment of a bulk
+// operation
+type BulkCommentResult struct {
+	ID   int
+	Text string
+	Err  error
+}
+
+// BulkComment posts template to every work item in ids, replacing {id}
+// with each item's ID. Results are returned in the order of ids; a failure
+// on one item doesn't stop the others. With dryRun nothing is posted.
+func (c *Client) BulkComment(ctx context.Context, ids []int, template string, dryRun bool) ([]BulkCommentResult, error) {
+	if strings.TrimSpace(template) == "" {
+		return nil, fmt.Errorf("comment is required")
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("at least one work item ID is required")
+	}
+	if len(ids) > maxBulkItems {
+		return nil, fmt.Errorf("at most %d work items can be commented on at once, got %d", maxBulkItems, len(ids))
+	}
+
+	results := make([]BulkCommentResult, len(ids))
+	for i, id := range ids {
+		results[i] = BulkCommentResult{ID: id, Text: strings.ReplaceAll(template, "{id}", strconv.Itoa(id))}
+	}
+	if dryRun {
+		return results, nil
+	}
+
+	errs := fanOut(ctx, len(results), func(i int) error {
+		_, err := c.AddWorkItemComment(ctx, results[i].ID, results[i].Text)
+		return err
+	})
+	for i, err := range errs {
+		results[i].Err = err
+	}
+	return results, nil
+}
+
+// SubtaskResult is the outcome of creating one task of CreateSubtasks
+type SubtaskResult struct {
+	Title string
+	ID    int // the created task, zero when Err is set
+	Err   error
+}
+
+// CreateSubtasks creates a Task under parentID for every title, with up to
+// bulkConcurrency requests in flight. Throttled requests are retried by the
+// client's transport. Results are returned in the order of titles; a
+// failure on one task doesn't stop the others.
+func (c *Client) CreateSubtasks(ctx context.Context, parentID int, titles []string, priority int) ([]SubtaskResult, error) {
+	if parentID <= 0 {
+		return nil, fmt.Errorf("parent_id must be a positive work item ID")
+	}
+	if len(titles) == 0 {
+		return nil, fmt.Errorf("at least one task title is required")
+	}
+	if len(titles) > maxBulkItems {
+		return nil, fmt.Errorf("at most %d tasks can be created at once, got %d", maxBulkItems, len(titles))
+	}
+	for _, title := range titles {
+		if strings.TrimSpace(title) == "" {
+			return nil, fmt.Errorf("task titles cannot be empty")
+		}
+	}
+	// Fail once for a missing parent rather than once per task
+	if _, err := c.GetWorkItemView(ctx, parentID, LeanView); err != nil {
+		return nil, fmt.Errorf("parent work item #%d: %w", parentID, err)
+	}
+
+	results := make([]SubtaskResult, len(titles))
+	for i, title := range titles {
+		results[i] = SubtaskResult{Title: strings.TrimSpace(title)}
+	}
+
+	errs := fanOut(ctx, len(results), func(i int) error {
+		item, err := c.CreateWorkItem(ctx, WorkItemCreateRequest{
+			Type:     "Task",
+			Title:    results[i].Title,
+			Priority: priority,
+			ParentID: parentID,
+		})
+		if err != nil {
+			return err
+		}
+		results[i].ID = item.ID
+		return nil
+	})
+	for i, err := range errs {
+		results[i].Err = err
+	}
+	return results, nil
+}
+
+func (t *Tool) bulkComment(ctx context.Context, args map[string]interface{}) (string, error) {
+	var ids []int
+	seen := make(map[int]bool)
+	if raw, ok := args["ids"].([]interface{}); ok {
+		for _, v := range raw {
+			id, ok := toInt(v)
+			if !ok || id <= 0 {
+				return "", fmt.Errorf("ids must be positive work item IDs")
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	dryRun, _ := args["dry_run"].(bool)
+
+	results, err := t.client.BulkComment(ctx, ids, getString(args, "comment"), dryRun)
+	if err != nil {
+		return "", err
+	}
+	return formatBulkComment(results, dryRun), nil
+}
+
+func formatBulkComment(results []BulkCommentResult, dryRun bool) string {
+	if dryRun {
+		result := fmt.Sprintf("Dry run: would comment on %d work items:\n\n", len(results))
+		for _, r := range results {
+			result += fmt.Sprintf("- #%d: %s\n", r.ID, r.Text)
+		}
+		return result
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+
+	result := fmt.Sprintf("Commented on %d of %d work items", len(results)-failed, len(results))
+	if failed > 0 {
+		result += fmt.Sprintf(" (%d failed)", failed)
+	}
+	result += ":\n\n"
+	for _, r := range results {
+		if r.Err != nil {
+			result += fmt.Sprintf("- ❌ #%d: %v\n", r.ID, r.Err)
+		} else {
+			result += fmt.Sprintf("- ✅ #%d\n", r.ID)
+		}
+	}
+	return result
+}
+
+func (t *Tool) createSubtasks(ctx context.Context, args map[string]interface{}) (string, error) {
+	parentID, err := requireInt(args, "parent_id")
+	if err != nil {
+		return "", err
+	}
+	var titles []string
+	if raw, ok := args["titles"].([]interface{}); ok {
+		for _, v := range raw {
+			title, ok := v.(string)
+			if !ok {
+				return "", fmt.Errorf("titles must be a list of strings")
+			}
+			titles = append(titles, title)
+		}
+	}
+
+	results, err := t.client.CreateSubtasks(ctx, parentID, titles, t.defaultPriority)
+	if err != nil {
+		return "", err
+	}
+	return formatSubtasks(parentID, results), nil
+}
+
+func formatSubtasks(parentID int, results []SubtaskResult) string {
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+
+	result := fmt.Sprintf("Created %d of %d tasks under #%d", len(results)-failed, len(results), parentID)
+	if failed > 0 {
+		result += fmt.Sprintf(" (%d failed)", failed)
+	}
+	result += ":\n\n"
+	for _, r := range results {
+		if r.Err != nil {
+			result += fmt.Sprintf("- ❌ %s: %v\n", r.Title, r.Err)
+		} else {
+			result += fmt.Sprintf("- ✅ #%d %s\n", r.ID, r.Title)
+		}
+	}
+	return result
+}
@@ -0,0 +1,197 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBulkCommentReportsFailuresPerID(t *testing.T) {
+	var mu sync.Mutex
+	posted := make(map[string]string)
+	var throttled int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("unexpected method %s", r.Method)
+		}
+		// The first request for #102 is throttled and must be retried
+		if r.URL.Path == "/_apis/wit/workItems/102/comments" && atomic.AddInt32(&throttled, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if r.URL.Path == "/_apis/wit/workItems/103/comments" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Work item 103 does not exist"}`))
+			return
+		}
+
+		var body struct {
+			Text string `json:"text"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		posted[r.URL.Path] = body.Text
+		mu.Unlock()
+		w.Write([]byte(`{"id":1,"text":"` + body.Text + `"}`))
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_bulk_comment", map[string]interface{}{
+		"ids":     []interface{}{101.0, 102.0, 103.0, 101.0},
+		"comment": "Shipped in 2.3 (#{id})",
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if got := posted["/_apis/wit/workItems/102/comments"]; got != "Shipped in 2.3 (#102)" {
+		t.Errorf("comment on #102 = %q", got)
+	}
+	if len(posted) != 2 {
+		t.Errorf("posted to %d items, want 2: %v", len(posted), posted)
+	}
+	for _, want := range []string{
+		"Commented on 2 of 3 work items (1 failed)",
+		"- ✅ #101\n",
+		"- ✅ #102\n",
+		"- ❌ #103: API error (status 404)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestBulkCommentDryRunPostsNothing(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_bulk_comment", map[string]interface{}{
+		"ids":     []interface{}{7.0, 8.0},
+		"comment": "Closing #{id}",
+		"dry_run": true,
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if want := "Dry run: would comment on 2 work items:\n\n- #7: Closing #7\n- #8: Closing #8\n"; result != want {
+		t.Errorf("result = %q, want %q", result, want)
+	}
+}
+
+func TestCreateSubtasksLinksEachTaskToParent(t *testing.T) {
+	var mu sync.Mutex
+	parents := make(map[string]string) // task title -> parent relation URL
+	var nextID int32 = 200
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.Path == "/_apis/wit/workitems/100" {
+			w.Write([]byte(`{"id":100,"fields":{"System.Title":"Checkout story"}}`))
+			return
+		}
+		if r.Method != "POST" || r.URL.Path != "/_apis/wit/workitems/$Task" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var ops []struct {
+			Path  string          `json:"path"`
+			Value json.RawMessage `json:"value"`
+		}
+		json.NewDecoder(r.Body).Decode(&ops)
+		var title, parent string
+		for _, op := range ops {
+			switch op.Path {
+			case "/fields/System.Title":
+				json.Unmarshal(op.Value, &title)
+			case "/relations/-":
+				var rel struct{ Rel, URL string }
+				json.Unmarshal(op.Value, &rel)
+				if rel.Rel == "System.LinkTypes.Hierarchy-Reverse" {
+					parent = rel.URL
+				}
+			}
+		}
+		if title == "Broken" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"TF401320: rule error"}`))
+			return
+		}
+		mu.Lock()
+		parents[title] = parent
+		mu.Unlock()
+		id := atomic.AddInt32(&nextID, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "fields": map[string]string{"System.Title": title}})
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_create_subtasks", map[string]interface{}{
+		"parent_id": 100.0,
+		"titles":    []interface{}{"Add endpoint", "Broken", "Write tests"},
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	for _, title := range []string{"Add endpoint", "Write tests"} {
+		if !strings.HasSuffix(parents[title], "/_apis/wit/workitems/100") {
+			t.Errorf("task %q parent relation = %q, want #100", title, parents[title])
+		}
+	}
+	for _, want := range []string{"Created 2 of 3 tasks under #100 (1 failed)", "✅ #20", "❌ Broken: ", "TF401320"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestCreateSubtasksRequiresParent(t *testing.T) {
+	var posts int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			atomic.AddInt32(&posts, 1)
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"TF401232: Work item 100 does not exist"}`))
+	})
+
+	_, err := c.CreateSubtasks(context.Background(), 100, []string{"Add endpoint"}, 2)
+	if err == nil || !strings.Contains(err.Error(), "parent work item #100") {
+		t.Errorf("err = %v, want the missing parent", err)
+	}
+	if posts != 0 {
+		t.Errorf("created %d tasks under a missing parent", posts)
+	}
+}
+
+func TestFanOutBoundsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	errs := fanOut(context.Background(), 20, func(i int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		if i == 3 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if p := peak.Load(); p > bulkConcurrency {
+		t.Errorf("peak concurrency = %d, want at most %d", p, bulkConcurrency)
+	}
+	for i, err := range errs {
+		if (err != nil) != (i == 3) {
+			t.Errorf("errs[%d] = %v", i, err)
+		}
+	}
+}
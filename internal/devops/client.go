@@ -5,13 +5,27 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/breaker"
+	"github.com/abelclopes/nomad-iabot/internal/httpx"
+	"github.com/abelclopes/nomad-iabot/internal/idempotency"
+	"github.com/abelclopes/nomad-iabot/internal/redact"
 )
 
+// ErrAuthExpired is returned when Azure DevOps rejects the PAT, usually
+// because it expired, was revoked or lacks the required scopes
+var ErrAuthExpired = errors.New("azure devops rejected the PAT")
+
 // Client is an Azure DevOps REST API client
 type Client struct {
 	organization string
@@ -20,6 +34,15 @@ type Client struct {
 	apiVersion   string
 	httpClient   *http.Client
 	baseURL      string
+	orgURL       string // organization-scoped endpoints (projects, teams)
+	searchURL    string // work item search, served from a separate host
+
+	pipelineBranches map[int]string // branch used when a run names none, by pipeline ID
+	idempotency      *idempotency.Store
+	breaker          *breaker.Breaker
+
+	meMu sync.Mutex
+	me   *Identity // the PAT owner, cached by WhoAmI
 }
 
 // NewClient creates a new Azure DevOps client
@@ -30,12 +53,40 @@ func NewClient(organization, project, pat, apiVersion string) *Client {
 		pat:          pat,
 		apiVersion:   apiVersion,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: httpx.NewUserAgentTransport(httpx.NewRetryTransport(nil, httpx.DefaultPolicy)),
 		},
-		baseURL: fmt.Sprintf("https://dev.azure.com/%s/%s", organization, project),
+		baseURL:   fmt.Sprintf("https://dev.azure.com/%s/%s", organization, project),
+		orgURL:    fmt.Sprintf("https://dev.azure.com/%s", organization),
+		searchURL: fmt.Sprintf("https://almsearch.dev.azure.com/%s/%s", organization, project),
+
+		idempotency: idempotency.NewStore(idempotency.DefaultTTL),
 	}
 }
 
+// SetIdempotencyStore shares the store of created work items with other
+// clients, so keys are honoured across short-lived clients
+func (c *Client) SetIdempotencyStore(store *idempotency.Store) {
+	c.idempotency = store
+}
+
+// SetPipelineBranches sets the branch to run per pipeline ID when a run
+// request names none, overriding the repository's default branch
+func (c *Client) SetPipelineBranches(branches map[int]string) {
+	c.pipelineBranches = branches
+}
+
+// SetBreaker guards API requests with a circuit breaker, shared by every
+// client talking to the same organization
+func (c *Client) SetBreaker(b *breaker.Breaker) {
+	c.breaker = b
+}
+
+// SetHTTPClient replaces the HTTP client used for API requests
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
 // ========================================
 // Work Items
 // ========================================
@@ -46,6 +97,12 @@ type WorkItem struct {
 	Rev    int                    `json:"rev"`
 	Fields map[string]interface{} `json:"fields"`
 	URL    string                 `json:"url"`
+
+	// Relations are returned only when the view expands them
+	Relations []WorkItemRelation `json:"relations,omitempty"`
+
+	// Highlights are the matching snippets, set only by SearchWorkItems
+	Highlights []string `json:"-"`
 }
 
 // WorkItemCreateRequest represents a work item creation request
@@ -59,6 +116,10 @@ type WorkItemCreateRequest struct {
 	Tags        []string
 	ParentID    int // Optional parent work item ID
 	CustomFields map[string]interface{}
+
+	// IdempotencyKey makes retried creates return the first work item. It is
+	// also stored as a tag so duplicates are found after a restart.
+	IdempotencyKey string
 }
 
 // WorkItemUpdateRequest represents a work item update request
@@ -86,10 +147,55 @@ type WorkItemRef struct {
 	URL string `json:"url"`
 }
 
-// GetWorkItem retrieves a work item by ID
+// WorkItemView selects how much of a work item is returned. Azure DevOps
+// rejects requests that combine $expand with fields, so only one of
+// Expand and Fields should be set.
+type WorkItemView struct {
+	Expand string   // none, relations, fields, links or all
+	Fields []string // reference names, e.g. System.Title
+}
+
+// listFields is the lean projection used when work items are listed
+var listFields = []string{
+	"System.Id",
+	"System.Title",
+	"System.State",
+	"System.AssignedTo",
+	"System.WorkItemType",
+	"System.CreatedDate",
+	"System.ChangedDate",
+	"Microsoft.VSTS.Common.Priority",
+	"System.Tags",
+}
+
+var (
+	// FullView returns every field, relations and links
+	FullView = WorkItemView{Expand: "all"}
+	// LeanView returns just enough to show a work item in a list
+	LeanView = WorkItemView{Fields: listFields}
+)
+
+// GetWorkItem retrieves a work item by ID with all fields and relations
 func (c *Client) GetWorkItem(ctx context.Context, id int) (*WorkItem, error) {
-	endpoint := fmt.Sprintf("%s/_apis/wit/workitems/%d?api-version=%s&$expand=all",
-		c.baseURL, id, c.apiVersion)
+	return c.GetWorkItemView(ctx, id, FullView)
+}
+
+// GetWorkItemView retrieves a work item by ID, returning only what view
+// selects
+func (c *Client) GetWorkItemView(ctx context.Context, id int, view WorkItemView) (*WorkItem, error) {
+	if view.Expand != "" && len(view.Fields) > 0 {
+		return nil, fmt.Errorf("expand and fields cannot be combined")
+	}
+
+	params := url.Values{}
+	params.Set("api-version", c.apiVersion)
+	if view.Expand != "" {
+		params.Set("$expand", view.Expand)
+	}
+	if len(view.Fields) > 0 {
+		params.Set("fields", strings.Join(view.Fields, ","))
+	}
+	endpoint := fmt.Sprintf("%s/_apis/wit/workitems/%d?%s", c.baseURL, id, params.Encode())
 
 	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
@@ -105,6 +211,133 @@ func (c *Client) GetWorkItem(ctx context.Context, id int) (*WorkItem, error) {
 	return &wi, nil
 }
 
+// WorkItemComment is a discussion comment on a work item
+type WorkItemComment struct {
+	ID          int    `json:"id"`
+	WorkItemID  int    `json:"workItemId"`
+	Text        string `json:"text"` // HTML
+	CreatedDate string `json:"createdDate"`
+	CreatedBy   struct {
+		DisplayName string `json:"displayName"`
+		UniqueName  string `json:"uniqueName"`
+	} `json:"createdBy"`
+
+	// WorkItemTitle is set only by GetMyMentions
+	WorkItemTitle string `json:"-"`
+}
+
+// maxCommentPages bounds pagination for work items with very long discussions
+const maxCommentPages = 10
+
+// commentsAPIVersion returns the API version for the comments API, which
+// is only available as a preview version
+func (c *Client) commentsAPIVersion() string {
+	if strings.Contains(c.apiVersion, "preview") {
+		return c.apiVersion
+	}
+	return c.apiVersion + "-preview.3"
+}
+
+// pipelinesAPIVersion returns the API version for the pipelines artifacts
+// API, which is only available as a preview version
+func (c *Client) pipelinesAPIVersion() string {
+	if strings.Contains(c.apiVersion, "preview") {
+		return c.apiVersion
+	}
+	return c.apiVersion + "-preview.1"
+}
+
+// GetWorkItemComments returns the comments on a work item, newest first
+func (c *Client) GetWorkItemComments(ctx context.Context, id int) ([]WorkItemComment, error) {
+	apiVersion := c.commentsAPIVersion()
+
+	comments := []WorkItemComment{}
+	token := ""
+	for page := 0; page < maxCommentPages; page++ {
+		endpoint := fmt.Sprintf("%s/_apis/wit/workItems/%d/comments?api-version=%s&order=desc&$top=200",
+			c.baseURL, id, apiVersion)
+		if token != "" {
+			endpoint += "&continuationToken=" + url.QueryEscape(token)
+		}
+
+		resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			TotalCount        int               `json:"totalCount"`
+			Comments          []WorkItemComment `json:"comments"`
+			ContinuationToken string            `json:"continuationToken"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode comments: %w", err)
+		}
+
+		comments = append(comments, result.Comments...)
+		if result.ContinuationToken == "" {
+			break
+		}
+		token = result.ContinuationToken
+	}
+
+	return comments, nil
+}
+
+// GetLatestWorkItemComments returns the n newest comments on a work item,
+// newest first, and how many comments it has in total, in a single request
+func (c *Client) GetLatestWorkItemComments(ctx context.Context, id, n int) ([]WorkItemComment, int, error) {
+	endpoint := fmt.Sprintf("%s/_apis/wit/workItems/%d/comments?api-version=%s&order=desc&$top=%d",
+		c.baseURL, id, c.commentsAPIVersion(), n)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		TotalCount int               `json:"totalCount"`
+		Comments   []WorkItemComment `json:"comments"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode comments: %w", err)
+	}
+	if len(result.Comments) > n {
+		result.Comments = result.Comments[:n]
+	}
+	return result.Comments, max(result.TotalCount, len(result.Comments)), nil
+}
+
+// AddWorkItemComment posts a comment (HTML or plain text) to a work item
+func (c *Client) AddWorkItemComment(ctx context.Context, id int, text string) (*WorkItemComment, error) {
+	endpoint := fmt.Sprintf("%s/_apis/wit/workItems/%d/comments?api-version=%s",
+		c.baseURL, id, c.commentsAPIVersion())
+
+	jsonBody, _ := json.Marshal(map[string]string{"text": text})
+
+	resp, err := c.doRequest(ctx, "POST", endpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var comment WorkItemComment
+	if err := json.NewDecoder(resp.Body).Decode(&comment); err != nil {
+		return nil, fmt.Errorf("failed to decode comment: %w", err)
+	}
+
+	return &comment, nil
+}
+
+// WorkItemWebURL returns the browser link for a work item
+func (c *Client) WorkItemWebURL(id int) string {
+	return fmt.Sprintf("https://dev.azure.com/%s/%s/_workitems/edit/%d",
+		c.organization, url.PathEscape(c.project), id)
+}
+
 // QueryWorkItems executes a WIQL query
 func (c *Client) QueryWorkItems(ctx context.Context, query string) ([]WorkItem, error) {
 	endpoint := fmt.Sprintf("%s/_apis/wit/wiql?api-version=%s", c.baseURL, c.apiVersion)
@@ -131,29 +364,23 @@ func (c *Client) QueryWorkItems(ctx context.Context, query string) ([]WorkItem,
 	return c.GetWorkItemsBatch(ctx, result.WorkItems)
 }
 
-// GetWorkItemsBatch retrieves multiple work items by ID
+// GetWorkItemsBatch retrieves multiple work items by ID, limited to the
+// fields needed to list them
 func (c *Client) GetWorkItemsBatch(ctx context.Context, refs []WorkItemRef) ([]WorkItem, error) {
 	ids := make([]int, len(refs))
 	for i, ref := range refs {
 		ids[i] = ref.ID
 	}
+	return c.getWorkItemsFields(ctx, ids, listFields)
+}
 
+// getWorkItemsFields retrieves the given fields of up to 200 work items
+func (c *Client) getWorkItemsFields(ctx context.Context, ids []int, fields []string) ([]WorkItem, error) {
 	endpoint := fmt.Sprintf("%s/_apis/wit/workitemsbatch?api-version=%s", c.baseURL, c.apiVersion)
 
 	body := map[string]interface{}{
 		"ids":    ids,
-		"fields": []string{
-			"System.Id",
-			"System.Title",
-			"System.State",
-			"System.AssignedTo",
-			"System.WorkItemType",
-			"System.Description",
-			"System.CreatedDate",
-			"System.ChangedDate",
-			"Microsoft.VSTS.Common.Priority",
-			"System.Tags",
-		},
+		"fields": fields,
 	}
 	jsonBody, _ := json.Marshal(body)
 
@@ -174,8 +401,57 @@ func (c *Client) GetWorkItemsBatch(ctx context.Context, refs []WorkItemRef) ([]W
 	return result.Value, nil
 }
 
-// CreateWorkItem creates a new work item
+// CreateWorkItem creates a new work item. With an IdempotencyKey, repeated
+// calls return the work item created by the first one.
 func (c *Client) CreateWorkItem(ctx context.Context, req WorkItemCreateRequest) (*WorkItem, error) {
+	if req.IdempotencyKey == "" {
+		return c.createWorkItem(ctx, req)
+	}
+	if err := idempotency.ValidateKey(req.IdempotencyKey); err != nil {
+		return nil, err
+	}
+
+	key := "devops:" + c.organization + "/" + c.project + ":" + req.IdempotencyKey
+	v, _, err := c.idempotency.Do(key, func() (interface{}, error) {
+		tag := idempotencyTagPrefix + req.IdempotencyKey
+		if existing, err := c.findByTag(ctx, tag); err != nil {
+			return nil, err
+		} else if existing != nil {
+			return existing, nil
+		}
+
+		req.Tags = append(append([]string(nil), req.Tags...), tag)
+		return c.createWorkItem(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	wi := *v.(*WorkItem)
+	return &wi, nil
+}
+
+// idempotencyTagPrefix marks work items created with an idempotency key
+const idempotencyTagPrefix = "idempotency:"
+
+// findByTag returns the oldest work item carrying tag, or nil when none does
+func (c *Client) findByTag(ctx context.Context, tag string) (*WorkItem, error) {
+	query := fmt.Sprintf(`SELECT [System.Id] FROM WorkItems
+              WHERE [System.TeamProject] = @project
+              AND [System.Tags] CONTAINS '%s'
+              ORDER BY [System.Id] ASC`, escapeWIQL(tag))
+
+	items, err := c.QueryWorkItems(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up idempotency tag: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return &items[0], nil
+}
+
+func (c *Client) createWorkItem(ctx context.Context, req WorkItemCreateRequest) (*WorkItem, error) {
 	endpoint := fmt.Sprintf("%s/_apis/wit/workitems/$%s?api-version=%s",
 		c.baseURL, url.PathEscape(req.Type), c.apiVersion)
 
@@ -241,15 +517,15 @@ func (c *Client) CreateWorkItem(ctx context.Context, req WorkItemCreateRequest)
 	httpReq.Header.Set("Content-Type", "application/json-patch+json")
 	httpReq.Header.Set("Authorization", "Basic "+c.basicAuth())
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", redact.Error(err, c.pat, c.basicAuth()))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, c.redact(string(bodyBytes)))
 	}
 
 	var wi WorkItem
@@ -318,15 +594,15 @@ func (c *Client) UpdateWorkItem(ctx context.Context, id int, req WorkItemUpdateR
 	httpReq.Header.Set("Content-Type", "application/json-patch+json")
 	httpReq.Header.Set("Authorization", "Basic "+c.basicAuth())
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", redact.Error(err, c.pat, c.basicAuth()))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, c.redact(string(bodyBytes)))
 	}
 
 	var wi WorkItem
@@ -359,6 +635,145 @@ func (c *Client) GetRecentWorkItems(ctx context.Context, days int) ([]WorkItem,
 	return c.QueryWorkItems(ctx, query)
 }
 
+// maxSearchResults caps how many work items a text search returns
+const maxSearchResults = 25
+
+// errSearchUnavailable means the organization has no work item search
+var errSearchUnavailable = errors.New("work item search is not available")
+
+// searchFields maps the lowercase field names of search results to the
+// reference names used by the work item API
+var searchFields = map[string]string{
+	"system.title":        "System.Title",
+	"system.state":        "System.State",
+	"system.workitemtype": "System.WorkItemType",
+	"system.assignedto":   "System.AssignedTo",
+	"system.tags":         "System.Tags",
+}
+
+// SearchWorkItems finds work items matching text with the work item search
+// API. When the Search extension is not installed it falls back to a WIQL
+// CONTAINS on the title, which returns no highlights.
+func (c *Client) SearchWorkItems(ctx context.Context, text string) ([]WorkItem, error) {
+	items, err := c.searchWorkItems(ctx, text)
+	if !errors.Is(err, errSearchUnavailable) {
+		return items, err
+	}
+
+	query := fmt.Sprintf(`SELECT [System.Id] FROM WorkItems
+              WHERE [System.TeamProject] = @project
+              AND [System.Title] CONTAINS '%s'
+              ORDER BY [System.ChangedDate] DESC`, escapeWIQL(text))
+	refs, err := c.queryWorkItemRefsTop(ctx, query, maxSearchResults)
+	if err != nil || len(refs) == 0 {
+		return []WorkItem{}, err
+	}
+	return c.GetWorkItemsBatch(ctx, refs)
+}
+
+func (c *Client) searchWorkItems(ctx context.Context, text string) ([]WorkItem, error) {
+	endpoint := fmt.Sprintf("%s/_apis/search/workitemsearchresults?api-version=%s", c.searchURL, c.apiVersion)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"searchText":    text,
+		"$skip":         0,
+		"$top":          maxSearchResults,
+		"includeFacets": false,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Basic "+c.basicAuth())
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", redact.Error(err, c.pat, c.basicAuth()))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		// The search host answers 404 when the extension is not installed
+		if resp.StatusCode == http.StatusNotFound || strings.Contains(string(bodyBytes), "ExtensionNotInstalled") {
+			return nil, errSearchUnavailable
+		}
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, c.redact(string(bodyBytes)))
+	}
+
+	var result struct {
+		Results []struct {
+			Fields map[string]string `json:"fields"`
+			Hits   []struct {
+				Highlights []string `json:"highlights"`
+			} `json:"hits"`
+			URL string `json:"url"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode search results: %w", err)
+	}
+
+	items := make([]WorkItem, 0, len(result.Results))
+	for _, r := range result.Results {
+		id, _ := strconv.Atoi(r.Fields["system.id"])
+		item := WorkItem{ID: id, URL: r.URL, Fields: map[string]interface{}{}}
+		for name, ref := range searchFields {
+			if v, ok := r.Fields[name]; ok {
+				item.Fields[ref] = v
+			}
+		}
+		for _, hit := range r.Hits {
+			item.Highlights = append(item.Highlights, hit.Highlights...)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// FindAssignedWorkItems returns the work items assigned to user (display
+// name or email). When onlyStates is empty, closed and removed items are skipped.
+func (c *Client) FindAssignedWorkItems(ctx context.Context, user string, onlyStates []string) ([]WorkItem, error) {
+	query := fmt.Sprintf(`SELECT [System.Id] FROM WorkItems
+              WHERE [System.TeamProject] = @project
+              AND [System.AssignedTo] = '%s'`, escapeWIQL(user))
+
+	if len(onlyStates) > 0 {
+		quoted := make([]string, len(onlyStates))
+		for i, s := range onlyStates {
+			quoted[i] = "'" + escapeWIQL(s) + "'"
+		}
+		query += fmt.Sprintf("\n              AND [System.State] IN (%s)", strings.Join(quoted, ", "))
+	} else {
+		query += "\n              AND [System.State] NOT IN ('Closed', 'Done', 'Removed')"
+	}
+	query += "\n              ORDER BY [System.ChangedDate] DESC"
+
+	return c.QueryWorkItems(ctx, query)
+}
+
+// ReassignWorkItems moves every work item assigned to fromUser (optionally
+// only those in onlyStates) to toUser and returns how many were changed.
+// On failure the count reflects the items already updated.
+func (c *Client) ReassignWorkItems(ctx context.Context, fromUser, toUser string, onlyStates []string) (int, error) {
+	items, err := c.FindAssignedWorkItems(ctx, fromUser, onlyStates)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, item := range items {
+		if _, err := c.UpdateWorkItem(ctx, item.ID, WorkItemUpdateRequest{AssignedTo: &toUser}); err != nil {
+			return changed, fmt.Errorf("failed to reassign work item #%d: %w", item.ID, err)
+		}
+		changed++
+	}
+
+	return changed, nil
+}
+
 // ========================================
 // Pipelines
 // ========================================
@@ -385,6 +800,11 @@ type PipelineRun struct {
 		ID   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"pipeline"`
+	Branch     string `json:"branch,omitempty"` // branch the run was queued on
+
+	// Set by RunPipelineChecked
+	AcceptedVariables []string `json:"accepted_variables,omitempty"`
+	IgnoredVariables  []string `json:"ignored_variables,omitempty"`
 }
 
 // ListPipelines lists all pipelines
@@ -408,8 +828,161 @@ func (c *Client) ListPipelines(ctx context.Context) ([]Pipeline, error) {
 	return result.Value, nil
 }
 
-// RunPipeline triggers a pipeline run
+// fallbackBranch is used when neither the configuration nor the pipeline's
+// repository names a default branch
+const fallbackBranch = "refs/heads/main"
+
+// ResolvePipelineBranch returns the branch a run of pipelineID should use
+// when none is given: the configured branch for the pipeline, else the
+// default branch of the repository the pipeline builds
+func (c *Client) ResolvePipelineBranch(ctx context.Context, pipelineID int) (string, error) {
+	if branch := c.pipelineBranches[pipelineID]; branch != "" {
+		return qualifyBranch(branch), nil
+	}
+
+	def, err := c.getBuildDefinition(ctx, pipelineID)
+	if err != nil {
+		return "", err
+	}
+
+	if def.Repository.DefaultBranch == "" {
+		return fallbackBranch, nil
+	}
+	return qualifyBranch(def.Repository.DefaultBranch), nil
+}
+
+// buildDefinition holds the parts of a pipeline's build definition the client uses
+type buildDefinition struct {
+	Repository struct {
+		ID            string `json:"id"`
+		Name          string `json:"name"`
+		Type          string `json:"type"` // TfsGit for Azure Repos
+		DefaultBranch string `json:"defaultBranch"`
+	} `json:"repository"`
+	Process struct {
+		Type         int    `json:"type"` // 1 for classic (designer), 2 for YAML
+		YAMLFilename string `json:"yamlFilename"`
+	} `json:"process"`
+	Variables map[string]struct {
+		AllowOverride bool `json:"allowOverride"`
+		IsSecret      bool `json:"isSecret"`
+	} `json:"variables"`
+}
+
+// getBuildDefinition fetches a pipeline's definition. Pipeline IDs are
+// build definition IDs.
+func (c *Client) getBuildDefinition(ctx context.Context, pipelineID int) (*buildDefinition, error) {
+	endpoint := fmt.Sprintf("%s/_apis/build/definitions/%d?api-version=%s", c.baseURL, pipelineID, c.apiVersion)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var def buildDefinition
+	if err := json.NewDecoder(resp.Body).Decode(&def); err != nil {
+		return nil, fmt.Errorf("failed to decode pipeline definition: %w", err)
+	}
+	return &def, nil
+}
+
+// UnknownVariablesError is returned in strict mode when a run sets
+// variables the pipeline does not declare
+type UnknownVariablesError struct {
+	Unknown  []string
+	Declared []string
+}
+
+func (e *UnknownVariablesError) Error() string {
+	declared := "none"
+	if len(e.Declared) > 0 {
+		declared = strings.Join(e.Declared, ", ")
+	}
+	return fmt.Sprintf("unknown pipeline variables: %s (declared: %s)", strings.Join(e.Unknown, ", "), declared)
+}
+
+// CheckPipelineVariables splits variables into the names the pipeline
+// declares as settable at queue time and the names Azure DevOps would
+// ignore. Names are compared case-insensitively, as Azure DevOps does.
+func (c *Client) CheckPipelineVariables(ctx context.Context, pipelineID int, variables map[string]string) (accepted, ignored, declared []string, err error) {
+	def, err := c.getBuildDefinition(ctx, pipelineID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	settable := make(map[string]bool, len(def.Variables))
+	for name, v := range def.Variables {
+		if v.AllowOverride {
+			settable[strings.ToLower(name)] = true
+			declared = append(declared, name)
+		}
+	}
+
+	for name := range variables {
+		if settable[strings.ToLower(name)] {
+			accepted = append(accepted, name)
+		} else {
+			ignored = append(ignored, name)
+		}
+	}
+
+	sort.Strings(accepted)
+	sort.Strings(ignored)
+	sort.Strings(declared)
+	return accepted, ignored, declared, nil
+}
+
+// RunPipelineChecked validates variables against the pipeline definition
+// before triggering a run. Unknown variables are dropped and reported in
+// PipelineRun.IgnoredVariables, or rejected with *UnknownVariablesError
+// when strict is set.
+func (c *Client) RunPipelineChecked(ctx context.Context, pipelineID int, branch string, variables map[string]string, strict bool) (*PipelineRun, error) {
+	if len(variables) == 0 {
+		return c.RunPipeline(ctx, pipelineID, branch, variables)
+	}
+
+	accepted, ignored, declared, err := c.CheckPipelineVariables(ctx, pipelineID, variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pipeline variables: %w", err)
+	}
+	if strict && len(ignored) > 0 {
+		return nil, &UnknownVariablesError{Unknown: ignored, Declared: declared}
+	}
+
+	kept := make(map[string]string, len(accepted))
+	for _, name := range accepted {
+		kept[name] = variables[name]
+	}
+
+	run, err := c.RunPipeline(ctx, pipelineID, branch, kept)
+	if err != nil {
+		return nil, err
+	}
+	run.AcceptedVariables = accepted
+	run.IgnoredVariables = ignored
+	return run, nil
+}
+
+// qualifyBranch turns a short branch name such as "develop" into a full ref
+func qualifyBranch(branch string) string {
+	if strings.HasPrefix(branch, "refs/") {
+		return branch
+	}
+	return "refs/heads/" + branch
+}
+
+// RunPipeline triggers a pipeline run. An empty branch is resolved with
+// ResolvePipelineBranch; the branch used is reported in PipelineRun.Branch.
 func (c *Client) RunPipeline(ctx context.Context, pipelineID int, branch string, variables map[string]string) (*PipelineRun, error) {
+	if branch == "" {
+		resolved, err := c.ResolvePipelineBranch(ctx, pipelineID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve default branch: %w", err)
+		}
+		branch = resolved
+	}
+
 	endpoint := fmt.Sprintf("%s/_apis/pipelines/%d/runs?api-version=%s", c.baseURL, pipelineID, c.apiVersion)
 
 	body := map[string]interface{}{
@@ -442,6 +1015,7 @@ func (c *Client) RunPipeline(ctx context.Context, pipelineID int, branch string,
 	if err := json.NewDecoder(resp.Body).Decode(&run); err != nil {
 		return nil, fmt.Errorf("failed to decode pipeline run: %w", err)
 	}
+	run.Branch = branch
 
 	return &run, nil
 }
@@ -468,6 +1042,92 @@ func (c *Client) GetPipelineRuns(ctx context.Context, pipelineID int, top int) (
 	return result.Value, nil
 }
 
+// Artifact is a file or folder published by a build
+type Artifact struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+	Resource struct {
+		Type        string `json:"type"` // e.g. "Container", "PipelineArtifact"
+		DownloadURL string `json:"downloadUrl"`
+		Properties  struct {
+			ArtifactSize string `json:"artifactsize"`
+		} `json:"properties"`
+	} `json:"resource"`
+}
+
+// ListBuildArtifacts lists the artifacts published by a build (pipeline run).
+// A build without artifacts returns an empty slice.
+func (c *Client) ListBuildArtifacts(ctx context.Context, buildID int) ([]Artifact, error) {
+	endpoint := fmt.Sprintf("%s/_apis/build/builds/%d/artifacts?api-version=%s", c.baseURL, buildID, c.apiVersion)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Count int        `json:"count"`
+		Value []Artifact `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode artifacts: %w", err)
+	}
+	if result.Value == nil {
+		return []Artifact{}, nil
+	}
+
+	return result.Value, nil
+}
+
+// GetArtifactDownloadURL returns a signed, time-limited link to download a
+// build artifact, so the file never passes through the chat
+func (c *Client) GetArtifactDownloadURL(ctx context.Context, buildID int, name string) (string, error) {
+	// The pipelines API needs the pipeline ID, which is the build's definition
+	endpoint := fmt.Sprintf("%s/_apis/build/builds/%d?api-version=%s", c.baseURL, buildID, c.apiVersion)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	var build struct {
+		Definition struct {
+			ID int `json:"id"`
+		} `json:"definition"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&build)
+	resp.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to decode build: %w", err)
+	}
+
+	endpoint = fmt.Sprintf("%s/_apis/pipelines/%d/runs/%d/artifacts?artifactName=%s&$expand=signedContent&api-version=%s",
+		c.baseURL, build.Definition.ID, buildID, url.QueryEscape(name), c.pipelinesAPIVersion())
+
+	resp, err = c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var artifact struct {
+		Name          string `json:"name"`
+		SignedContent struct {
+			URL              string `json:"url"`
+			SignatureExpires string `json:"signatureExpires"`
+		} `json:"signedContent"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&artifact); err != nil {
+		return "", fmt.Errorf("failed to decode artifact: %w", err)
+	}
+	if artifact.SignedContent.URL == "" {
+		return "", fmt.Errorf("no signed download link available for artifact %q of build %d", name, buildID)
+	}
+
+	return artifact.SignedContent.URL, nil
+}
+
 // ========================================
 // Repositories
 // ========================================
@@ -503,6 +1163,93 @@ func (c *Client) ListRepositories(ctx context.Context) ([]Repository, error) {
 	return result.Value, nil
 }
 
+// GitUserDate is the author or committer of a commit
+type GitUserDate struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Date  string `json:"date"`
+}
+
+// Commit represents a Git commit
+type Commit struct {
+	CommitID         string         `json:"commitId"`
+	Author           GitUserDate    `json:"author"`
+	Committer        GitUserDate    `json:"committer"`
+	Comment          string         `json:"comment"`
+	CommentTruncated bool           `json:"commentTruncated"`
+	ChangeCounts     map[string]int `json:"changeCounts"`
+	RemoteURL        string         `json:"remoteUrl"`
+}
+
+// CommitChange is one file changed by a commit
+type CommitChange struct {
+	ChangeType string `json:"changeType"` // "add", "edit", "delete", "rename", or a combination such as "edit, rename"
+	Item       struct {
+		Path          string `json:"path"`
+		GitObjectType string `json:"gitObjectType"`
+		IsFolder      bool   `json:"isFolder"`
+	} `json:"item"`
+	SourceServerItem string `json:"sourceServerItem,omitempty"` // previous path of a rename
+}
+
+// CommitChanges lists the files changed by a commit
+type CommitChanges struct {
+	ChangeCounts map[string]int `json:"changeCounts"`
+	Changes      []CommitChange `json:"changes"`
+}
+
+// GetCommits lists the latest commits of a repository (by name or ID). An
+// empty branch means the repository's default branch.
+func (c *Client) GetCommits(ctx context.Context, repoID, branch string, top int) ([]Commit, error) {
+	query := url.Values{}
+	query.Set("api-version", c.apiVersion)
+	if top > 0 {
+		query.Set("searchCriteria.$top", strconv.Itoa(top))
+	}
+	if branch = strings.TrimPrefix(branch, "refs/heads/"); branch != "" {
+		query.Set("searchCriteria.itemVersion.version", branch)
+		query.Set("searchCriteria.itemVersion.versionType", "branch")
+	}
+
+	endpoint := fmt.Sprintf("%s/_apis/git/repositories/%s/commits?%s",
+		c.baseURL, url.PathEscape(repoID), query.Encode())
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Count int      `json:"count"`
+		Value []Commit `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode commits: %w", err)
+	}
+
+	return result.Value, nil
+}
+
+// GetCommitChanges lists the files changed by a commit
+func (c *Client) GetCommitChanges(ctx context.Context, repoID, commitID string) (*CommitChanges, error) {
+	endpoint := fmt.Sprintf("%s/_apis/git/repositories/%s/commits/%s/changes?api-version=%s",
+		c.baseURL, url.PathEscape(repoID), url.PathEscape(commitID), c.apiVersion)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var changes CommitChanges
+	if err := json.NewDecoder(resp.Body).Decode(&changes); err != nil {
+		return nil, fmt.Errorf("failed to decode commit changes: %w", err)
+	}
+
+	return &changes, nil
+}
+
 // ========================================
 // Boards
 // ========================================
@@ -531,8 +1278,8 @@ func (c *Client) ListBoards(ctx context.Context, team string) ([]Board, error) {
 		team = c.project + " Team"
 	}
 	
-	endpoint := fmt.Sprintf("https://dev.azure.com/%s/%s/%s/_apis/work/boards?api-version=%s",
-		c.organization, c.project, url.PathEscape(team), c.apiVersion)
+	endpoint := fmt.Sprintf("%s/%s/_apis/work/boards?api-version=%s",
+		c.baseURL, url.PathEscape(team), c.apiVersion)
 
 	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
@@ -557,8 +1304,8 @@ func (c *Client) GetBoardColumns(ctx context.Context, team, boardName string) ([
 		team = c.project + " Team"
 	}
 	
-	endpoint := fmt.Sprintf("https://dev.azure.com/%s/%s/%s/_apis/work/boards/%s/columns?api-version=%s",
-		c.organization, c.project, url.PathEscape(team), url.PathEscape(boardName), c.apiVersion)
+	endpoint := fmt.Sprintf("%s/%s/_apis/work/boards/%s/columns?api-version=%s",
+		c.baseURL, url.PathEscape(team), url.PathEscape(boardName), c.apiVersion)
 
 	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
@@ -577,6 +1324,243 @@ func (c *Client) GetBoardColumns(ctx context.Context, team, boardName string) ([
 	return result.Value, nil
 }
 
+// BoardColumnStatus is a board column with the number of work items in it
+type BoardColumnStatus struct {
+	BoardColumn
+	Count int `json:"count"`
+}
+
+// OverLimit reports whether the column holds more items than its WIP limit.
+// Columns without a limit (incoming, outgoing, or unset) never are.
+func (s BoardColumnStatus) OverLimit() bool {
+	return s.ItemLimit > 0 && s.Count > s.ItemLimit
+}
+
+// GetBoardStatus returns each column of a board with its current work item
+// count, counting only the work item types the board maps and the area
+// paths the team owns
+func (c *Client) GetBoardStatus(ctx context.Context, team, boardName string) ([]BoardColumnStatus, error) {
+	if team == "" {
+		team = c.project + " Team"
+	}
+
+	columns, err := c.GetBoardColumns(ctx, team, boardName)
+	if err != nil {
+		return nil, err
+	}
+	area, err := c.teamAreaCondition(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]BoardColumnStatus, 0, len(columns))
+	for _, col := range columns {
+		types := make([]string, 0, len(col.StateMappings))
+		for t := range col.StateMappings {
+			types = append(types, "'"+escapeWIQL(t)+"'")
+		}
+		sort.Strings(types)
+
+		query := fmt.Sprintf(`SELECT [System.Id] FROM WorkItems
+              WHERE [System.TeamProject] = @project
+              AND [System.BoardColumn] = '%s'
+              AND %s`, escapeWIQL(col.Name), area)
+		if len(types) > 0 {
+			query += fmt.Sprintf("\n              AND [System.WorkItemType] IN (%s)", strings.Join(types, ", "))
+		}
+
+		count, err := c.countWorkItems(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count items in column %q: %w", col.Name, err)
+		}
+		status = append(status, BoardColumnStatus{BoardColumn: col, Count: count})
+	}
+
+	return status, nil
+}
+
+// teamAreaCondition returns a WIQL condition matching the work items a team
+// owns: those under the area paths (or other team field values) set in its
+// team settings. Boards of different teams share columns, so counts need it.
+func (c *Client) teamAreaCondition(ctx context.Context, team string) (string, error) {
+	endpoint := fmt.Sprintf("%s/%s/_apis/work/teamsettings/teamfieldvalues?api-version=%s",
+		c.baseURL, url.PathEscape(team), c.apiVersion)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Field struct {
+			ReferenceName string `json:"referenceName"`
+		} `json:"field"`
+		Values []struct {
+			Value           string `json:"value"`
+			IncludeChildren bool   `json:"includeChildren"`
+		} `json:"values"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode team field values: %w", err)
+	}
+	if len(result.Values) == 0 {
+		return "", fmt.Errorf("team %q has no area paths configured", team)
+	}
+
+	field := result.Field.ReferenceName
+	if field == "" {
+		field = "System.AreaPath"
+	}
+	conditions := make([]string, 0, len(result.Values))
+	for _, v := range result.Values {
+		op := "="
+		if v.IncludeChildren {
+			op = "UNDER"
+		}
+		conditions = append(conditions, fmt.Sprintf("[%s] %s '%s'", field, op, escapeWIQL(v.Value)))
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", nil
+}
+
+// countWorkItems runs a WIQL query and returns how many work items match,
+// without fetching their details
+func (c *Client) countWorkItems(ctx context.Context, query string) (int, error) {
+	refs, err := c.queryWorkItemRefs(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return len(refs), nil
+}
+
+// queryWorkItemRefs runs a WIQL query and returns the matching work item
+// references without fetching the items
+func (c *Client) queryWorkItemRefs(ctx context.Context, query string) ([]WorkItemRef, error) {
+	return c.queryWorkItemRefsTop(ctx, query, 0)
+}
+
+// queryWorkItemRefsTop is queryWorkItemRefs returning at most top references
+// (0 = all of them)
+func (c *Client) queryWorkItemRefsTop(ctx context.Context, query string, top int) ([]WorkItemRef, error) {
+	endpoint := fmt.Sprintf("%s/_apis/wit/wiql?api-version=%s", c.baseURL, c.apiVersion)
+	if top > 0 {
+		endpoint += fmt.Sprintf("&$top=%d", top)
+	}
+
+	jsonBody, _ := json.Marshal(map[string]string{"query": query})
+
+	resp, err := c.doRequest(ctx, "POST", endpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result WorkItemQueryResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode query result: %w", err)
+	}
+	return result.WorkItems, nil
+}
+
+// ========================================
+// Teams
+// ========================================
+
+// Identity represents an Azure DevOps user identity
+type Identity struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+	ImageURL    string `json:"imageUrl,omitempty"`
+}
+
+// ListTeamMembers lists the members of a team (defaults to the project's default team)
+func (c *Client) ListTeamMembers(ctx context.Context, team string) ([]Identity, error) {
+	if team == "" {
+		team = c.project + " Team"
+	}
+
+	endpoint := fmt.Sprintf("%s/_apis/projects/%s/teams/%s/members?api-version=%s",
+		c.orgURL, url.PathEscape(c.project), url.PathEscape(team), c.apiVersion)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Count int `json:"count"`
+		Value []struct {
+			Identity    Identity `json:"identity"`
+			IsTeamAdmin bool     `json:"isTeamAdmin"`
+		} `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode team members: %w", err)
+	}
+
+	members := make([]Identity, 0, len(result.Value))
+	for _, m := range result.Value {
+		members = append(members, m.Identity)
+	}
+
+	return members, nil
+}
+
+// ========================================
+// Projects
+// ========================================
+
+// Project represents a team project in the organization
+type Project struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	State          string `json:"state"`
+	Visibility     string `json:"visibility"`
+	LastUpdateTime string `json:"lastUpdateTime"`
+}
+
+// maxProjectPages bounds how many pages of projects are fetched
+const maxProjectPages = 10
+
+// ListProjects lists the projects of the organization. Unlike most calls it
+// is organization-scoped, so it does not depend on the configured project.
+func (c *Client) ListProjects(ctx context.Context) ([]Project, error) {
+	projects := []Project{}
+	token := ""
+	for page := 0; page < maxProjectPages; page++ {
+		endpoint := fmt.Sprintf("%s/_apis/projects?api-version=%s&$top=100", c.orgURL, c.apiVersion)
+		if token != "" {
+			endpoint += "&continuationToken=" + url.QueryEscape(token)
+		}
+
+		resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Count int       `json:"count"`
+			Value []Project `json:"value"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode projects: %w", err)
+		}
+
+		projects = append(projects, result.Value...)
+		token = resp.Header.Get("X-MS-ContinuationToken")
+		if token == "" {
+			break
+		}
+	}
+
+	return projects, nil
+}
+
 // ========================================
 // Helpers
 // ========================================
@@ -590,25 +1574,64 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body io.Read
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Basic "+c.basicAuth())
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", redact.Error(err, c.pat, c.basicAuth()))
 	}
 
 	if resp.StatusCode >= 400 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, c.redact(string(bodyBytes)))
 	}
 
 	return resp, nil
 }
 
+// do sends a request through the circuit breaker. Throttled requests are
+// retried by the client's transport.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	c.breaker.Observe(resp, err)
+	if err == nil && isAuthFailure(resp) {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w (status %d)", ErrAuthExpired, resp.StatusCode)
+	}
+	return resp, err
+}
+
+// isAuthFailure reports whether resp rejects the credentials. Besides 401
+// and 403, Azure DevOps answers an invalid PAT with 203 and its sign-in page.
+func isAuthFailure(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	case http.StatusNonAuthoritativeInfo:
+		return strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html")
+	}
+	return false
+}
+
 func (c *Client) basicAuth() string {
 	auth := ":" + c.pat
 	return base64.StdEncoding.EncodeToString([]byte(auth))
 }
 
+// redact masks the PAT (raw and Basic-encoded) and any globally registered
+// secrets in text that may be echoed back by the API
+func (c *Client) redact(s string) string {
+	return redact.String(s, c.pat, c.basicAuth())
+}
+
+// escapeWIQL escapes a value for use inside a single-quoted WIQL string
+func escapeWIQL(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
+
 func joinTags(tags []string) string {
 	result := ""
 	for i, tag := range tags {
@@ -0,0 +1,481 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/httpx"
+)
+
+// newTestClient returns a client whose requests are served by handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c := NewClient("org", "proj", "test-pat-secret", "7.0")
+	c.baseURL = srv.URL
+	c.orgURL = srv.URL
+	c.searchURL = srv.URL
+	return c
+}
+
+func TestErrorBodyRedactsPAT(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"token test-pat-secret is not valid for header ` + r.Header.Get("Authorization") + `"}`))
+	})
+
+	_, err := c.GetWorkItem(context.Background(), 1)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), "test-pat-secret") {
+		t.Errorf("error leaked PAT: %s", err)
+	}
+	if strings.Contains(err.Error(), c.basicAuth()) {
+		t.Errorf("error leaked encoded PAT: %s", err)
+	}
+	if !strings.Contains(err.Error(), "[REDACTED]") {
+		t.Errorf("expected masked placeholder in error: %s", err)
+	}
+}
+
+func TestReassignWorkItems(t *testing.T) {
+	var query string
+	var patched []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/wiql"):
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			query = body["query"]
+			w.Write([]byte(`{"workItems":[{"id":1},{"id":2}]}`))
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/workitemsbatch"):
+			w.Write([]byte(`{"count":2,"value":[
+				{"id":1,"fields":{"System.Title":"Fix login","System.State":"Active","System.WorkItemType":"Bug"}},
+				{"id":2,"fields":{"System.Title":"Write docs","System.State":"New","System.WorkItemType":"Task"}}
+			]}`))
+		case r.Method == http.MethodPatch:
+			var ops []map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&ops)
+			if len(ops) != 1 || ops[0]["path"] != "/fields/System.AssignedTo" || ops[0]["value"] != "bruno@example.com" {
+				t.Errorf("unexpected patch for %s: %v", r.URL.Path, ops)
+			}
+			patched = append(patched, r.URL.Path)
+			w.Write([]byte(`{"id":1}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	tool := NewTool(c)
+	ctx := context.Background()
+	args := map[string]interface{}{
+		"from_user": "O'Brien <ana@example.com>",
+		"to_user":   "bruno@example.com",
+		"states":    []interface{}{"New", "Active"},
+	}
+
+	// Without confirmation nothing is changed
+	preview, handled, err := tool.Execute(ctx, "devops_reassign_workitems", args)
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+	if !strings.Contains(preview, "Dry run: 2 work items") || !strings.Contains(preview, "Fix login") {
+		t.Errorf("preview = %q", preview)
+	}
+	if len(patched) != 0 {
+		t.Fatalf("dry run updated %d work items", len(patched))
+	}
+	if !strings.Contains(query, "[System.AssignedTo] = 'O''Brien <ana@example.com>'") ||
+		!strings.Contains(query, "[System.State] IN ('New', 'Active')") {
+		t.Errorf("unexpected WIQL: %s", query)
+	}
+
+	args["confirm"] = true
+	result, _, err := tool.Execute(ctx, "devops_reassign_workitems", args)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result != "Reassigned 2 work items from O'Brien <ana@example.com> to bruno@example.com." {
+		t.Errorf("result = %q", result)
+	}
+	if len(patched) != 2 || !strings.HasSuffix(patched[0], "/workitems/1") || !strings.HasSuffix(patched[1], "/workitems/2") {
+		t.Errorf("patched = %v", patched)
+	}
+}
+
+func TestGetWorkItemCommentsPagination(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		if r.URL.Path != "/_apis/wit/workItems/7/comments" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("api-version") != "7.0-preview.3" {
+			t.Errorf("api-version = %q", r.URL.Query().Get("api-version"))
+		}
+		if r.URL.Query().Get("continuationToken") == "" {
+			w.Write([]byte(`{"totalCount":3,"comments":[
+				{"id":3,"text":"<div>Deployed to <b>staging</b></div>","createdBy":{"displayName":"Ana"},"createdDate":"2024-03-12T10:00:00Z"},
+				{"id":2,"text":"<p>Looking into it</p>","createdBy":{"displayName":"Bruno"},"createdDate":"2024-03-11T10:00:00Z"}
+			],"continuationToken":"page2"}`))
+			return
+		}
+		w.Write([]byte(`{"totalCount":3,"comments":[
+			{"id":1,"text":"First!","createdBy":{"displayName":"Carla"},"createdDate":"2024-03-10T10:00:00Z"}
+		]}`))
+	})
+
+	comments, err := c.GetWorkItemComments(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetWorkItemComments() error = %v", err)
+	}
+	if len(requests) != 2 || !strings.Contains(requests[1], "continuationToken=page2") {
+		t.Errorf("requests = %v", requests)
+	}
+	if len(comments) != 3 || comments[0].ID != 3 || comments[2].ID != 1 {
+		t.Fatalf("comments = %+v", comments)
+	}
+
+	item := &WorkItem{ID: 7, Fields: map[string]interface{}{"System.Title": "Broken login"}}
+	result := formatWorkItem(item, comments[:2]...)
+	if !strings.Contains(result, "- Ana (2024-03-12): Deployed to staging") {
+		t.Errorf("formatWorkItem() = %q, expected plain-text latest comment", result)
+	}
+}
+
+func TestGetWorkItemFetchesOnlyLatestComments(t *testing.T) {
+	var commentRequests int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/comments"):
+			commentRequests++
+			q := r.URL.Query()
+			if q.Get("$top") != "3" || q.Get("order") != "desc" {
+				t.Errorf("comments query = %s, want the 3 newest", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"totalCount":250,"comments":[
+				{"id":250,"text":"Newest","createdBy":{"displayName":"Ana"},"createdDate":"2024-03-12T10:00:00Z"},
+				{"id":249,"text":"Older","createdBy":{"displayName":"Bruno"},"createdDate":"2024-03-11T10:00:00Z"},
+				{"id":248,"text":"Oldest","createdBy":{"displayName":"Carla"},"createdDate":"2024-03-10T10:00:00Z"}
+			],"continuationToken":"page2"}`))
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/workitems/7"):
+			w.Write([]byte(`{"id":7,"fields":{"System.Title":"Broken login"}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_get_workitem", map[string]interface{}{"id": float64(7)})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if commentRequests != 1 {
+		t.Errorf("comments requested %d times, want 1", commentRequests)
+	}
+	if !strings.Contains(result, "Latest comments (3 of 250)") || !strings.Contains(result, "- Ana (2024-03-12): Newest") {
+		t.Errorf("result = %q", result)
+	}
+}
+
+func TestRunPipelineUsesRepositoryDefaultBranch(t *testing.T) {
+	var refName string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_apis/build/definitions/7"):
+			w.Write([]byte(`{"id":7,"name":"CI","repository":{"id":"r1","type":"TfsGit","defaultBranch":"refs/heads/master"}}`))
+		case strings.HasSuffix(r.URL.Path, "/_apis/pipelines/7/runs"):
+			var body struct {
+				Resources struct {
+					Repositories struct {
+						Self struct {
+							RefName string `json:"refName"`
+						} `json:"self"`
+					} `json:"repositories"`
+				} `json:"resources"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			refName = body.Resources.Repositories.Self.RefName
+			w.Write([]byte(`{"id":101,"name":"20240101.1","state":"inProgress"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	run, err := c.RunPipeline(context.Background(), 7, "", nil)
+	if err != nil {
+		t.Fatalf("RunPipeline() error = %v", err)
+	}
+	if refName != "refs/heads/master" {
+		t.Errorf("run queued on %q, expected refs/heads/master", refName)
+	}
+	if run.Branch != "refs/heads/master" {
+		t.Errorf("run.Branch = %q", run.Branch)
+	}
+
+	// A configured branch wins without looking up the definition
+	c.SetPipelineBranches(map[int]string{7: "develop"})
+	run, err = c.RunPipeline(context.Background(), 7, "", nil)
+	if err != nil {
+		t.Fatalf("RunPipeline() error = %v", err)
+	}
+	if refName != "refs/heads/develop" || run.Branch != "refs/heads/develop" {
+		t.Errorf("run queued on %q (Branch %q), expected refs/heads/develop", refName, run.Branch)
+	}
+}
+
+func TestRunPipelineCheckedVariables(t *testing.T) {
+	var queued map[string]interface{}
+	var runs int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_apis/build/definitions/7"):
+			w.Write([]byte(`{"id":7,"repository":{"defaultBranch":"refs/heads/main"},"variables":{
+				"Environment":{"value":"staging","allowOverride":true},
+				"Internal":{"value":"x"}
+			}}`))
+		case strings.HasSuffix(r.URL.Path, "/_apis/pipelines/7/runs"):
+			runs++
+			var body struct {
+				Variables map[string]interface{} `json:"variables"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			queued = body.Variables
+			w.Write([]byte(`{"id":101,"state":"inProgress"}`))
+		}
+	})
+
+	vars := map[string]string{"environment": "prod", "Enviroment": "typo"}
+
+	_, err := c.RunPipelineChecked(context.Background(), 7, "refs/heads/main", vars, true)
+	var unknown *UnknownVariablesError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("strict RunPipelineChecked() error = %v, expected UnknownVariablesError", err)
+	}
+	if len(unknown.Unknown) != 1 || unknown.Unknown[0] != "Enviroment" {
+		t.Errorf("unknown = %v", unknown.Unknown)
+	}
+	if runs != 0 {
+		t.Errorf("strict mode queued %d runs", runs)
+	}
+
+	run, err := c.RunPipelineChecked(context.Background(), 7, "refs/heads/main", vars, false)
+	if err != nil {
+		t.Fatalf("RunPipelineChecked() error = %v", err)
+	}
+	if len(run.AcceptedVariables) != 1 || run.AcceptedVariables[0] != "environment" {
+		t.Errorf("accepted = %v", run.AcceptedVariables)
+	}
+	if len(run.IgnoredVariables) != 1 || run.IgnoredVariables[0] != "Enviroment" {
+		t.Errorf("ignored = %v", run.IgnoredVariables)
+	}
+	if _, ok := queued["Enviroment"]; ok || queued["environment"] == nil {
+		t.Errorf("queued variables = %v, expected only the declared one", queued)
+	}
+}
+
+func TestCreateWorkItemIdempotencyKey(t *testing.T) {
+	var creates int
+	var tags string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/wiql"):
+			w.Write([]byte(`{"workItems":[]}`))
+		case strings.Contains(r.URL.Path, "/_apis/wit/workitems/$Bug"):
+			creates++
+			var ops []map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&ops)
+			for _, op := range ops {
+				if op["path"] == "/fields/System.Tags" {
+					tags, _ = op["value"].(string)
+				}
+			}
+			w.Write([]byte(`{"id":42,"fields":{"System.Title":"Login falha"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	req := WorkItemCreateRequest{Type: "Bug", Title: "Login falha", Tags: []string{"auth"}, IdempotencyKey: "req-1"}
+	for i := 0; i < 2; i++ {
+		item, err := c.CreateWorkItem(context.Background(), req)
+		if err != nil {
+			t.Fatalf("CreateWorkItem() error = %v", err)
+		}
+		if item.ID != 42 {
+			t.Errorf("CreateWorkItem() returned #%d", item.ID)
+		}
+	}
+
+	if creates != 1 {
+		t.Errorf("create calls = %d, expected 1", creates)
+	}
+	if tags != "auth; idempotency:req-1" {
+		t.Errorf("tags = %q, expected the dedup tag", tags)
+	}
+
+	if _, err := c.CreateWorkItem(context.Background(), WorkItemCreateRequest{Type: "Bug", Title: "x", IdempotencyKey: "bad key'"}); err == nil {
+		t.Error("expected an invalid key error")
+	}
+}
+
+func TestCreateWorkItemFindsTaggedDuplicate(t *testing.T) {
+	var query string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/wiql"):
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			query = body["query"]
+			w.Write([]byte(`{"workItems":[{"id":7}]}`))
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/workitemsbatch"):
+			w.Write([]byte(`{"count":1,"value":[{"id":7,"fields":{"System.Title":"Login falha"}}]}`))
+		default:
+			t.Errorf("unexpected request %s %s; the existing item should be reused", r.Method, r.URL.Path)
+		}
+	})
+
+	item, err := c.CreateWorkItem(context.Background(), WorkItemCreateRequest{Type: "Bug", Title: "Login falha", IdempotencyKey: "req-1"})
+	if err != nil {
+		t.Fatalf("CreateWorkItem() error = %v", err)
+	}
+	if item.ID != 7 {
+		t.Errorf("CreateWorkItem() returned #%d, expected the tagged item #7", item.ID)
+	}
+	if !strings.Contains(query, "CONTAINS 'idempotency:req-1'") {
+		t.Errorf("query = %q", query)
+	}
+}
+
+func TestListProjectsUsesOrganizationURL(t *testing.T) {
+	var paths []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Query().Get("continuationToken") == "" {
+			w.Header().Set("X-MS-ContinuationToken", "page2")
+			w.Write([]byte(`{"count":1,"value":[{"id":"1","name":"Web App","description":"Customer portal","state":"wellFormed"}]}`))
+			return
+		}
+		w.Write([]byte(`{"count":1,"value":[{"id":"2","name":"api","state":"wellFormed"}]}`))
+	})
+	c.baseURL = c.orgURL + "/proj"
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_list_projects", nil)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	for _, p := range paths {
+		if p != "/_apis/projects" {
+			t.Errorf("requested %s, want the organization-level /_apis/projects", p)
+		}
+	}
+	if len(paths) != 2 {
+		t.Errorf("made %d requests, want both pages", len(paths))
+	}
+	if want := "Found 2 projects:\n\n- api\n- Web App: Customer portal\n"; result != want {
+		t.Errorf("result = %q, want %q", result, want)
+	}
+}
+
+func TestGetWorkItemViewQueryParams(t *testing.T) {
+	var queries []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		queries = append(queries, "expand="+q.Get("$expand")+" fields="+q.Get("fields"))
+		w.Write([]byte(`{"id":7,"fields":{"System.Title":"Fix login"}}`))
+	})
+	ctx := context.Background()
+
+	if _, err := c.GetWorkItem(ctx, 7); err != nil {
+		t.Fatalf("GetWorkItem: %v", err)
+	}
+	if _, err := c.GetWorkItemView(ctx, 7, WorkItemView{Fields: []string{"System.Title", "System.State"}}); err != nil {
+		t.Fatalf("GetWorkItemView: %v", err)
+	}
+	if _, err := c.GetWorkItemView(ctx, 7, WorkItemView{Expand: "all", Fields: []string{"System.Title"}}); err == nil {
+		t.Error("expected an error when combining expand and fields")
+	}
+
+	want := []string{
+		"expand=all fields=",
+		"expand= fields=System.Title,System.State",
+	}
+	if strings.Join(queries, "\n") != strings.Join(want, "\n") {
+		t.Errorf("queries = %q, want %q", queries, want)
+	}
+}
+
+func TestGetWorkItemsBatchRequestsListFields(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Fields []string `json:"fields"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		for _, f := range body.Fields {
+			if f == "System.Description" {
+				t.Error("batch requested System.Description")
+			}
+		}
+		if len(body.Fields) != len(LeanView.Fields) {
+			t.Errorf("fields = %v, want %v", body.Fields, LeanView.Fields)
+		}
+		w.Write([]byte(`{"count":0,"value":[]}`))
+	})
+
+	if _, err := c.GetWorkItemsBatch(context.Background(), []WorkItemRef{{ID: 1}}); err != nil {
+		t.Fatalf("GetWorkItemsBatch: %v", err)
+	}
+}
+
+func TestUnauthorizedYieldsErrAuthExpired(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+	}{
+		{"401", http.StatusUnauthorized, "application/json"},
+		{"403", http.StatusForbidden, "application/json"},
+		{"203 sign-in page", http.StatusNonAuthoritativeInfo, "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`<html>Sign in to your account</html>`))
+			})
+
+			_, err := c.GetWorkItem(context.Background(), 1)
+			if !errors.Is(err, ErrAuthExpired) {
+				t.Fatalf("GetWorkItem() error = %v, want ErrAuthExpired", err)
+			}
+		})
+	}
+}
+
+func TestRequestsSendUserAgent(t *testing.T) {
+	httpx.SetUserAgent("nomad-iabot/test")
+	t.Cleanup(func() { httpx.SetUserAgent("") })
+
+	var got string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		w.Write([]byte(`{"id":1,"fields":{"System.Title":"x"}}`))
+	})
+
+	if _, err := c.GetWorkItem(context.Background(), 1); err != nil {
+		t.Fatalf("GetWorkItem: %v", err)
+	}
+	if got != "nomad-iabot/test" {
+		t.Errorf("User-Agent = %q, expected nomad-iabot/test", got)
+	}
+}
@@ -0,0 +1,303 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Commit diff limits. Azure DevOps has no diff endpoint, so diffs are
+// computed from the file contents before and after the commit.
+const (
+	// maxDiffFiles caps how many files of a commit are diffed
+	maxDiffFiles = 5
+	// maxDiffLines caps the diff lines shown per file
+	maxDiffLines = 80
+	// maxDiffCells bounds the line comparison table of one file; files whose
+	// changed regions are larger are reported without a diff
+	maxDiffCells = 1 << 20
+	// diffContext is how many unchanged lines surround each hunk
+	diffContext = 3
+)
+
+// FileDiff is the unified diff of one file changed by a commit
+type FileDiff struct {
+	Path       string
+	ChangeType string
+	Diff       string // empty when Note explains why there is no diff
+	Note       string // e.g. "binary file" or "content unchanged"
+	Truncated  bool   // Diff was cut at maxDiffLines
+}
+
+// GetCommitDiffs diffs the files changed by a commit against its first
+// parent, up to maxDiffFiles files. A non-empty path diffs only that file.
+func (c *Client) GetCommitDiffs(ctx context.Context, repoID, commitID string, changes []CommitChange, path string) ([]FileDiff, error) {
+	var files []CommitChange
+	for _, ch := range changes {
+		if ch.Item.IsFolder || (path != "" && ch.Item.Path != path) {
+			continue
+		}
+		files = append(files, ch)
+		if len(files) == maxDiffFiles {
+			break
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	parent, err := c.commitParent(ctx, repoID, commitID)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]FileDiff, len(files))
+	errs := fanOut(ctx, len(files), func(i int) error {
+		d, err := c.diffFile(ctx, repoID, commitID, parent, files[i])
+		diffs[i] = d
+		return err
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return diffs, nil
+}
+
+// commitParent returns the first parent of a commit, or "" for the root
+// commit
+func (c *Client) commitParent(ctx context.Context, repoID, commitID string) (string, error) {
+	endpoint := fmt.Sprintf("%s/_apis/git/repositories/%s/commits/%s?api-version=%s",
+		c.baseURL, url.PathEscape(repoID), url.PathEscape(commitID), c.apiVersion)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var commit struct {
+		Parents []string `json:"parents"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&commit); err != nil {
+		return "", fmt.Errorf("failed to decode commit: %w", err)
+	}
+	if len(commit.Parents) == 0 {
+		return "", nil
+	}
+	return commit.Parents[0], nil
+}
+
+func (c *Client) diffFile(ctx context.Context, repoID, commitID, parent string, ch CommitChange) (FileDiff, error) {
+	d := FileDiff{Path: ch.Item.Path, ChangeType: ch.ChangeType}
+	if ch.ChangeType == "rename" {
+		d.Note = "content unchanged"
+		return d, nil
+	}
+
+	var before, after string
+	if !strings.Contains(ch.ChangeType, "add") && parent != "" {
+		oldPath := ch.Item.Path
+		if ch.SourceServerItem != "" {
+			oldPath = ch.SourceServerItem
+		}
+		content, binary, err := c.fileAtCommit(ctx, repoID, oldPath, parent)
+		if err != nil {
+			return d, err
+		}
+		if binary {
+			d.Note = "binary file"
+			return d, nil
+		}
+		before = content
+	}
+	if !strings.Contains(ch.ChangeType, "delete") {
+		content, binary, err := c.fileAtCommit(ctx, repoID, ch.Item.Path, commitID)
+		if err != nil {
+			return d, err
+		}
+		if binary {
+			d.Note = "binary file"
+			return d, nil
+		}
+		after = content
+	}
+
+	diff, ok := unifiedDiff(before, after)
+	if !ok {
+		d.Note = "too large to diff"
+		return d, nil
+	}
+	if len(diff) > maxDiffLines {
+		diff = diff[:maxDiffLines]
+		d.Truncated = true
+	}
+	d.Diff = strings.Join(diff, "\n")
+	return d, nil
+}
+
+// fileAtCommit returns a file's content at a commit
+func (c *Client) fileAtCommit(ctx context.Context, repoID, path, commitID string) (string, bool, error) {
+	params := url.Values{}
+	params.Set("path", path)
+	params.Set("includeContent", "true")
+	params.Set("versionDescriptor.version", commitID)
+	params.Set("versionDescriptor.versionType", "commit")
+	params.Set("$format", "json")
+	params.Set("api-version", c.apiVersion)
+	endpoint := fmt.Sprintf("%s/_apis/git/repositories/%s/items?%s", c.baseURL, url.PathEscape(repoID), params.Encode())
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+
+	var item struct {
+		Content         string `json:"content"`
+		ContentMetadata struct {
+			IsBinary bool `json:"isBinary"`
+		} `json:"contentMetadata"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return "", false, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return item.Content, item.ContentMetadata.IsBinary, nil
+}
+
+// diffLine is one line of a line diff: ' ' kept, '-' removed or '+' added
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the hunks turning before into after, or false when
+// the changed region is too large to compare
+func unifiedDiff(before, after string) ([]string, bool) {
+	lines, ok := diffLines(splitLines(before), splitLines(after))
+	if !ok {
+		return nil, false
+	}
+
+	// Line numbers before each diff line, for the hunk headers
+	oldNo := make([]int, len(lines)+1)
+	newNo := make([]int, len(lines)+1)
+	for i, l := range lines {
+		oldNo[i+1], newNo[i+1] = oldNo[i], newNo[i]
+		if l.op != '+' {
+			oldNo[i+1]++
+		}
+		if l.op != '-' {
+			newNo[i+1]++
+		}
+	}
+
+	var out []string
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		// Extend the hunk over changes separated by few unchanged lines
+		start, end := max(0, i-diffContext), i
+		for k := i; k < len(lines); k++ {
+			if lines[k].op != ' ' {
+				end = k
+			} else if k-end > 2*diffContext {
+				break
+			}
+		}
+		stop := min(len(lines), end+diffContext+1)
+
+		out = append(out, fmt.Sprintf("@@ -%s +%s @@",
+			hunkRange(oldNo[start], oldNo[stop]-oldNo[start]),
+			hunkRange(newNo[start], newNo[stop]-newNo[start])))
+		for _, l := range lines[start:stop] {
+			out = append(out, string(l.op)+l.text)
+		}
+		i = stop
+	}
+	return out, true
+}
+
+// hunkRange formats the start and length of a hunk side as unified diffs do
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// diffLines compares two files line by line. Common leading and trailing
+// lines are matched directly; the rest through a longest common
+// subsequence table of at most maxDiffCells entries.
+func diffLines(a, b []string) ([]diffLine, bool) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(midA), len(midB)
+	if (n+1)*(m+1) > maxDiffCells {
+		return nil, false
+	}
+
+	out := make([]diffLine, 0, len(a)+m)
+	for _, l := range a[:prefix] {
+		out = append(out, diffLine{' ', l})
+	}
+
+	// lcs[i*(m+1)+j] is the LCS length of midA[i:] and midB[j:]
+	lcs := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			} else {
+				lcs[i*(m+1)+j] = max(lcs[(i+1)*(m+1)+j], lcs[i*(m+1)+j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case midA[i] == midB[j]:
+			out = append(out, diffLine{' ', midA[i]})
+			i++
+			j++
+		case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+			out = append(out, diffLine{'-', midA[i]})
+			i++
+		default:
+			out = append(out, diffLine{'+', midB[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		out = append(out, diffLine{'-', midA[i]})
+	}
+	for ; j < m; j++ {
+		out = append(out, diffLine{'+', midB[j]})
+	}
+
+	for _, l := range a[len(a)-suffix:] {
+		out = append(out, diffLine{' ', l})
+	}
+	return out, true
+}
+
+// splitLines splits file content into lines without their terminators
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,61 @@
+package devops
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiffSplitsDistantChanges(t *testing.T) {
+	var before []string
+	for i := 1; i <= 20; i++ {
+		before = append(before, fmt.Sprintf("line %d", i))
+	}
+	after := append([]string(nil), before...)
+	after[1] = "line 2 changed"
+	after = append(after[:15], after[16:]...) // drop line 16
+
+	diff, ok := unifiedDiff(strings.Join(before, "\n")+"\n", strings.Join(after, "\n")+"\n")
+	if !ok {
+		t.Fatal("unifiedDiff() refused a small file")
+	}
+	want := []string{
+		"@@ -1,5 +1,5 @@",
+		" line 1",
+		"-line 2",
+		"+line 2 changed",
+		" line 3",
+		" line 4",
+		" line 5",
+		"@@ -13,7 +13,6 @@",
+		" line 13",
+		" line 14",
+		" line 15",
+		"-line 16",
+		" line 17",
+		" line 18",
+		" line 19",
+	}
+	if got := strings.Join(diff, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("diff =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestUnifiedDiffOfNewAndDeletedFiles(t *testing.T) {
+	diff, _ := unifiedDiff("", "a\nb\n")
+	if got := strings.Join(diff, "\n"); got != "@@ -0,0 +1,2 @@\n+a\n+b" {
+		t.Errorf("added file diff =\n%s", got)
+	}
+	diff, _ = unifiedDiff("a\n", "")
+	if got := strings.Join(diff, "\n"); got != "@@ -1,1 +0,0 @@\n-a" {
+		t.Errorf("deleted file diff =\n%s", got)
+	}
+}
+
+func TestUnifiedDiffRefusesHugeChanges(t *testing.T) {
+	before := strings.Repeat("a\n", 2000)
+	after := strings.Repeat("b\n", 2000)
+	if _, ok := unifiedDiff(before, after); ok {
+		t.Error("unifiedDiff() compared a change larger than maxDiffCells")
+	}
+}
@@ -0,0 +1,170 @@
+package devops
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRangeDays caps how wide a date range query may be, so a vague request
+// doesn't pull the whole project history
+const maxRangeDays = 92
+
+// wiqlDate is the date layout WIQL accepts without timePrecision
+const wiqlDate = "2006-01-02"
+
+// GetWorkItemsChangedBetween returns work items changed on any day from
+// from through to, inclusive, most recently changed first. Only the dates
+// matter; times are ignored.
+func (c *Client) GetWorkItemsChangedBetween(ctx context.Context, from, to time.Time) ([]WorkItem, error) {
+	query, err := changedBetweenQuery(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := c.queryWorkItemRefs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]WorkItem, 0, len(refs))
+	for start := 0; start < len(refs); start += workItemsBatchSize {
+		end := min(start+workItemsBatchSize, len(refs))
+		batch, err := c.GetWorkItemsBatch(ctx, refs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, batch...)
+	}
+	return items, nil
+}
+
+// changedBetweenQuery builds the WIQL for GetWorkItemsChangedBetween
+func changedBetweenQuery(from, to time.Time) (string, error) {
+	from = startOfDay(from)
+	to = startOfDay(to)
+	if from.After(to) {
+		return "", fmt.Errorf("from (%s) is after to (%s)", from.Format(wiqlDate), to.Format(wiqlDate))
+	}
+	if days := int(to.Sub(from).Hours()/24) + 1; days > maxRangeDays {
+		return "", fmt.Errorf("date range of %d days is too wide; the maximum is %d days", days, maxRangeDays)
+	}
+
+	return fmt.Sprintf(`SELECT [System.Id], [System.Title], [System.State], [System.AssignedTo], [System.WorkItemType]
+              FROM WorkItems
+              WHERE [System.TeamProject] = @project
+              AND [System.ChangedDate] >= '%s'
+              AND [System.ChangedDate] < '%s'
+              ORDER BY [System.ChangedDate] DESC`,
+		from.Format(wiqlDate), to.AddDate(0, 0, 1).Format(wiqlDate)), nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// daysAgoRe matches "3 days ago", "2 weeks ago", "há 3 dias", "ha 2 semanas"
+var daysAgoRe = regexp.MustCompile(`^(?:(\d+)\s*(days?|weeks?)\s+ago|h[áa]\s+(\d+)\s*(dias?|semanas?))$`)
+
+// ParseDay parses an ISO date (2024-03-15 or RFC 3339) or a relative day
+// such as "today", "yesterday", "3 days ago", "hoje", "ontem" or "há 2
+// semanas", relative to now and in now's location
+func ParseDay(input string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	if s == "" {
+		return time.Time{}, fmt.Errorf("date is required")
+	}
+	if t, err := time.ParseInLocation(wiqlDate, s, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, strings.TrimSpace(input)); err == nil {
+		return startOfDay(t.In(now.Location())), nil
+	}
+
+	today := startOfDay(now)
+	switch s {
+	case "today", "hoje":
+		return today, nil
+	case "yesterday", "ontem":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if m := daysAgoRe.FindStringSubmatch(s); m != nil {
+		count, unit := m[1], m[2]
+		if count == "" {
+			count, unit = m[3], m[4]
+		}
+		n, _ := strconv.Atoi(count)
+		if strings.HasPrefix(unit, "w") || strings.HasPrefix(unit, "s") {
+			n *= 7
+		}
+		return today.AddDate(0, 0, -n), nil
+	}
+
+	return time.Time{}, fmt.Errorf("could not understand date %q; use YYYY-MM-DD or e.g. 'yesterday', '7 days ago'", input)
+}
+
+func (t *Tool) workItemsInRange(ctx context.Context, args map[string]interface{}) (string, error) {
+	now := t.clock.Now().In(t.location)
+	from, err := ParseDay(getString(args, "from"), now)
+	if err != nil {
+		return "", fmt.Errorf("from: %w", err)
+	}
+	to := startOfDay(now)
+	if s := getString(args, "to"); s != "" {
+		if to, err = ParseDay(s, now); err != nil {
+			return "", fmt.Errorf("to: %w", err)
+		}
+	}
+
+	items, err := t.client.GetWorkItemsChangedBetween(ctx, from, to)
+	if err != nil {
+		return "", err
+	}
+	return formatWorkItemsByState(from, to, items), nil
+}
+
+// formatWorkItemsByState lists items under one heading per state, states
+// with the most items first
+func formatWorkItemsByState(from, to time.Time, items []WorkItem) string {
+	period := from.Format(wiqlDate)
+	if !to.Equal(from) {
+		period += " to " + to.Format(wiqlDate)
+	}
+	if len(items) == 0 {
+		return fmt.Sprintf("No work items changed from %s.", period)
+	}
+
+	byState := make(map[string][]WorkItem)
+	for _, item := range items {
+		state, _ := item.Fields["System.State"].(string)
+		byState[state] = append(byState[state], item)
+	}
+	states := make([]string, 0, len(byState))
+	for state := range byState {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if len(byState[states[i]]) != len(byState[states[j]]) {
+			return len(byState[states[i]]) > len(byState[states[j]])
+		}
+		return states[i] < states[j]
+	})
+
+	result := fmt.Sprintf("%d work items changed from %s:\n", len(items), period)
+	for _, state := range states {
+		result += fmt.Sprintf("\n%s (%d):\n", state, len(byState[state]))
+		for _, item := range byState[state] {
+			result += fmt.Sprintf("- #%d [%s] %s", item.ID, item.Fields["System.WorkItemType"], item.Fields["System.Title"])
+			if assigned, ok := item.Fields["System.AssignedTo"].(map[string]interface{}); ok {
+				result += fmt.Sprintf(" (%s)", assigned["displayName"])
+			}
+			result += "\n"
+		}
+	}
+	return result
+}
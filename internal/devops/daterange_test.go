@@ -0,0 +1,170 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+func TestChangedBetweenQuery(t *testing.T) {
+	loc := time.FixedZone("BRT", -3*3600)
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 15, 30, 0, 0, loc) }
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []string
+		wantErr  string
+	}{
+		{"single day", day(14), day(14), []string{"[System.ChangedDate] >= '2024-03-14'", "[System.ChangedDate] < '2024-03-15'"}, ""},
+		{"to is inclusive", day(1), day(31), []string{">= '2024-03-01'", "< '2024-04-01'"}, ""},
+		{"from after to", day(15), day(14), nil, "is after to"},
+		{"too wide", day(1), day(1).AddDate(0, 3, 1), nil, "too wide"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := changedBetweenQuery(tt.from, tt.to)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(query, want) {
+					t.Errorf("query missing %q:\n%s", want, query)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDay(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC) // a Friday
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"2024-03-01", "2024-03-01"},
+		{"2024-03-01T23:10:00-03:00", "2024-03-02"},
+		{"today", "2024-03-15"},
+		{"Ontem", "2024-03-14"},
+		{"7 days ago", "2024-03-08"},
+		{"2 weeks ago", "2024-03-01"},
+		{"há 3 dias", "2024-03-12"},
+		{"ha 1 semana", "2024-03-08"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDay(tt.input, now)
+		if err != nil {
+			t.Errorf("ParseDay(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got.Format(wiqlDate) != tt.want || got.Hour() != 0 {
+			t.Errorf("ParseDay(%q) = %v, want %s at midnight", tt.input, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "last sprint", "2024-13-01", "in 3 days"} {
+		if _, err := ParseDay(bad, now); err == nil {
+			t.Errorf("ParseDay(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestWorkItemsInRangeGroupsByState(t *testing.T) {
+	var query string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/wiql"):
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			query = body["query"]
+			w.Write([]byte(`{"workItems":[{"id":1},{"id":2},{"id":3}]}`))
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/workitemsbatch"):
+			w.Write([]byte(`{"count":3,"value":[
+				{"id":1,"fields":{"System.Title":"Login","System.State":"Active","System.WorkItemType":"Bug","System.AssignedTo":{"displayName":"Ana"}}},
+				{"id":2,"fields":{"System.Title":"Export","System.State":"Closed","System.WorkItemType":"Task"}},
+				{"id":3,"fields":{"System.Title":"Search","System.State":"Active","System.WorkItemType":"Task"}}
+			]}`))
+		}
+	})
+
+	tool := NewTool(c)
+	tool.SetClock(clock.NewFake(time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)))
+	tool.SetLocation(time.UTC)
+
+	result, handled, err := tool.Execute(context.Background(), "devops_workitems_in_range", map[string]interface{}{"from": "yesterday"})
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+	if !strings.Contains(query, ">= '2024-03-14'") || !strings.Contains(query, "< '2024-03-16'") {
+		t.Errorf("query = %s", query)
+	}
+	want := "3 work items changed from 2024-03-14 to 2024-03-15:\n\n" +
+		"Active (2):\n- #1 [Bug] Login (Ana)\n- #3 [Task] Search\n\n" +
+		"Closed (1):\n- #2 [Task] Export\n"
+	if result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result, want)
+	}
+
+	if _, _, err := tool.Execute(context.Background(), "devops_workitems_in_range", map[string]interface{}{"from": "today", "to": "7 days ago"}); err == nil {
+		t.Error("from after to was accepted")
+	}
+}
+
+func TestGetWorkItemsChangedBetweenFetchesInBatches(t *testing.T) {
+	const total = 450
+	var batches []int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_apis/wit/wiql":
+			refs := make([]string, total)
+			for i := range refs {
+				refs[i] = fmt.Sprintf(`{"id":%d}`, i+1)
+			}
+			w.Write([]byte(`{"workItems":[` + strings.Join(refs, ",") + `]}`))
+		case "/_apis/wit/workitemsbatch":
+			var body struct {
+				IDs []int `json:"ids"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			batches = append(batches, len(body.IDs))
+			items := make([]string, len(body.IDs))
+			for i, id := range body.IDs {
+				items[i] = fmt.Sprintf(`{"id":%d,"fields":{}}`, id)
+			}
+			w.Write([]byte(`{"count":` + fmt.Sprint(len(items)) + `,"value":[` + strings.Join(items, ",") + `]}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	day := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	items, err := c.GetWorkItemsChangedBetween(context.Background(), day, day)
+	if err != nil {
+		t.Fatalf("GetWorkItemsChangedBetween: %v", err)
+	}
+	if len(items) != total {
+		t.Fatalf("got %d items, want %d", len(items), total)
+	}
+	for i, item := range items {
+		if item.ID != i+1 {
+			t.Fatalf("items[%d].ID = %d, want %d (query order)", i, item.ID, i+1)
+		}
+	}
+	if fmt.Sprint(batches) != "[200 200 50]" {
+		t.Errorf("batch sizes = %v, want [200 200 50]", batches)
+	}
+}
@@ -0,0 +1,131 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Effort fields of the Agile (Story Points) and Scrum (Effort) processes
+const (
+	fieldStoryPoints = "Microsoft.VSTS.Scheduling.StoryPoints"
+	fieldEffort      = "Microsoft.VSTS.Scheduling.Effort"
+)
+
+// effortFields maps the field names the tool accepts to reference names
+var effortFields = map[string]string{
+	"story_points": fieldStoryPoints,
+	"storypoints":  fieldStoryPoints,
+	"points":       fieldStoryPoints,
+	"effort":       fieldEffort,
+}
+
+// effortLabels are the names formatWorkItem reports the effort fields with
+var effortLabels = []struct{ field, label string }{
+	{fieldStoryPoints, "Story Points"},
+	{fieldEffort, "Effort"},
+}
+
+// ErrFieldNotFound means the work item's type does not have a field
+var ErrFieldNotFound = errors.New("field not found on work item type")
+
+// SetEffort sets a numeric effort field (Story Points or Effort) of a work
+// item. A field the work item's type lacks returns ErrFieldNotFound.
+func (c *Client) SetEffort(ctx context.Context, id int, field string, value float64) (*WorkItem, error) {
+	item, err := c.UpdateWorkItem(ctx, id, WorkItemUpdateRequest{
+		CustomFields: map[string]interface{}{field: value},
+	})
+	// TF51535: Cannot find field
+	if err != nil && strings.Contains(err.Error(), "TF51535") {
+		return nil, fmt.Errorf("%w: %s", ErrFieldNotFound, field)
+	}
+	return item, err
+}
+
+// parseEffort reads a non-negative number sent as a JSON number or a
+// numeric string
+func parseEffort(v interface{}) (float64, error) {
+	var n float64
+	switch x := v.(type) {
+	case nil:
+		return 0, fmt.Errorf("value is required")
+	case float64:
+		n = x
+	case int:
+		n = float64(x)
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("value must be a number, got %q", x)
+		}
+		n = f
+	case string:
+		f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(x), ",", "."), 64)
+		if err != nil {
+			return 0, fmt.Errorf("value must be a number, got %q", x)
+		}
+		n = f
+	default:
+		return 0, fmt.Errorf("value must be a number")
+	}
+	if math.IsNaN(n) || math.IsInf(n, 0) || n < 0 {
+		return 0, fmt.Errorf("value must be a non-negative number")
+	}
+	return n, nil
+}
+
+func (t *Tool) setEffort(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, err := requireInt(args, "id")
+	if err != nil {
+		return "", err
+	}
+	value, err := parseEffort(args["value"])
+	if err != nil {
+		return "", err
+	}
+
+	// Without a field, Story Points is tried first and Effort for work item
+	// types (Scrum) that only have that one
+	fields := []string{fieldStoryPoints, fieldEffort}
+	if name := strings.ToLower(strings.TrimSpace(getString(args, "field"))); name != "" {
+		field, ok := effortFields[name]
+		if !ok {
+			return "", fmt.Errorf("field must be story_points or effort, got %q", name)
+		}
+		fields = []string{field}
+	}
+
+	for _, field := range fields {
+		item, err := t.client.SetEffort(ctx, id, field, value)
+		if errors.Is(err, ErrFieldNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ Work item #%d (%v): %s set to %s", item.ID, item.Fields["System.Title"], effortLabel(field), formatEffort(value)), nil
+	}
+
+	labels := make([]string, len(fields))
+	for i, field := range fields {
+		labels[i] = effortLabel(field)
+	}
+	return "", fmt.Errorf("work item #%d has no %s field; its type does not track effort that way", id, strings.Join(labels, " or "))
+}
+
+func effortLabel(field string) string {
+	for _, l := range effortLabels {
+		if l.field == field {
+			return l.label
+		}
+	}
+	return field
+}
+
+func formatEffort(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,83 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSetEffortPatchesField(t *testing.T) {
+	var patches [][]map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || r.URL.Path != "/_apis/wit/workitems/42" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var patch []map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&patch)
+		patches = append(patches, patch)
+		if len(patch) == 1 && patch[0]["path"] == "/fields/"+fieldStoryPoints {
+			// A Scrum Product Backlog Item has Effort, not Story Points
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"TF51535: Cannot find field Microsoft.VSTS.Scheduling.StoryPoints."}`))
+			return
+		}
+		w.Write([]byte(`{"id":42,"fields":{"System.Title":"Checkout"}}`))
+	})
+
+	tool := NewTool(c)
+	result, _, err := tool.Execute(context.Background(), "devops_set_effort", map[string]interface{}{"id": 42.0, "value": "2,5"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if want := "✅ Work item #42 (Checkout): Effort set to 2.5"; result != want {
+		t.Errorf("result = %q, want %q", result, want)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("got %d PATCH requests, want Story Points then Effort", len(patches))
+	}
+	want := map[string]interface{}{"op": "add", "path": "/fields/" + fieldEffort, "value": 2.5}
+	if p := patches[1]; len(p) != 1 || p[0]["op"] != want["op"] || p[0]["path"] != want["path"] || p[0]["value"] != want["value"] {
+		t.Errorf("patch = %v, want [%v]", p, want)
+	}
+
+	_, _, err = tool.Execute(context.Background(), "devops_set_effort", map[string]interface{}{"id": 42.0, "value": 3.0, "field": "story_points"})
+	if err == nil || !strings.Contains(err.Error(), "has no Story Points field") {
+		t.Errorf("err = %v, want a missing Story Points field error", err)
+	}
+}
+
+func TestParseEffort(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  float64
+		valid bool
+	}{
+		{5.0, 5, true},
+		{0.5, 0.5, true},
+		{"8", 8, true},
+		{" 1.5 ", 1.5, true},
+		{json.Number("13"), 13, true},
+		{nil, 0, false},
+		{"five", 0, false},
+		{-1.0, 0, false},
+		{true, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseEffort(tt.value)
+		if (err == nil) != tt.valid || got != tt.want {
+			t.Errorf("parseEffort(%#v) = %v, %v; want %v, valid %v", tt.value, got, err, tt.want, tt.valid)
+		}
+	}
+}
+
+func TestFormatWorkItemIncludesEffort(t *testing.T) {
+	item := &WorkItem{ID: 7, Fields: map[string]interface{}{"System.Title": "Login", fieldStoryPoints: 3.0}}
+	if got := formatWorkItem(item); !strings.Contains(got, "Story Points: 3\n") {
+		t.Errorf("formatWorkItem = %q, want story points", got)
+	}
+}
@@ -0,0 +1,221 @@
+package devops
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Link types between a work item and its children (forward) or parent (reverse)
+const (
+	relHierarchyForward = "System.LinkTypes.Hierarchy-Forward"
+	relHierarchyReverse = "System.LinkTypes.Hierarchy-Reverse"
+)
+
+const (
+	// maxTreeDepth bounds how many levels of children devops_get_children walks
+	maxTreeDepth = 3
+	// maxTreeItems bounds the work items shown in one tree
+	maxTreeItems = 100
+	// maxAncestors bounds how far devops_get_parent walks up
+	maxAncestors = 5
+)
+
+// relationsView returns every field of a work item with its relations
+var relationsView = WorkItemView{Expand: "relations"}
+
+// WorkItemRelation is a link from a work item to another work item or artifact
+type WorkItemRelation struct {
+	Rel        string                 `json:"rel"`
+	URL        string                 `json:"url"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// relatedIDs returns the IDs of the work items item links to with rel
+func relatedIDs(item *WorkItem, rel string) []int {
+	var ids []int
+	for _, r := range item.Relations {
+		if r.Rel != rel {
+			continue
+		}
+		// e.g. https://dev.azure.com/org/_apis/wit/workItems/123
+		if id, err := strconv.Atoi(r.URL[strings.LastIndex(r.URL, "/")+1:]); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// parentID returns the ID of item's parent, or 0 when it has none
+func parentID(item *WorkItem) int {
+	if ids := relatedIDs(item, relHierarchyReverse); len(ids) > 0 {
+		return ids[0]
+	}
+	return 0
+}
+
+// GetWorkItemChildren returns the direct children of a work item, with the
+// fields needed to list them. An item without children returns none.
+func (c *Client) GetWorkItemChildren(ctx context.Context, id int) ([]WorkItem, error) {
+	item, err := c.GetWorkItemView(ctx, id, relationsView)
+	if err != nil {
+		return nil, err
+	}
+	return c.workItemsByID(ctx, relatedIDs(item, relHierarchyForward))
+}
+
+// GetWorkItemParent returns the parent of a work item with its relations,
+// or nil when the item has no parent
+func (c *Client) GetWorkItemParent(ctx context.Context, id int) (*WorkItem, error) {
+	item, err := c.GetWorkItemView(ctx, id, relationsView)
+	if err != nil {
+		return nil, err
+	}
+	pid := parentID(item)
+	if pid == 0 {
+		return nil, nil
+	}
+	return c.GetWorkItemView(ctx, pid, relationsView)
+}
+
+// workItemsByID fetches work items in batches, keeping the order of ids
+func (c *Client) workItemsByID(ctx context.Context, ids []int) ([]WorkItem, error) {
+	var items []WorkItem
+	for start := 0; start < len(ids); start += workItemsBatchSize {
+		end := min(start+workItemsBatchSize, len(ids))
+		batch, err := c.getWorkItemsFields(ctx, ids[start:end], listFields)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, batch...)
+	}
+	return items, nil
+}
+
+// workItemNode is a work item and its children in a hierarchy
+type workItemNode struct {
+	item     WorkItem
+	children []*workItemNode
+}
+
+func (t *Tool) getChildren(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, err := requireInt(args, "id")
+	if err != nil {
+		return "", err
+	}
+	depth := 1
+	if v, ok := getInt(args, "depth"); ok && v >= 1 {
+		depth = v
+	}
+	if depth > maxTreeDepth {
+		depth = maxTreeDepth
+	}
+
+	root, err := t.client.GetWorkItemView(ctx, id, relationsView)
+	if err != nil {
+		return "", err
+	}
+	children, err := t.client.workItemsByID(ctx, relatedIDs(root, relHierarchyForward))
+	if err != nil {
+		return "", err
+	}
+	if len(children) == 0 {
+		return fmt.Sprintf("%s has no child work items.", workItemLine(*root)), nil
+	}
+
+	tree := &workItemNode{item: *root}
+	count := 0
+	if err := t.addChildren(ctx, tree, children, depth, &count); err != nil {
+		return "", err
+	}
+
+	result := formatWorkItemTree(tree)
+	if count >= maxTreeItems {
+		result += fmt.Sprintf("… (stopped at %d work items)\n", maxTreeItems)
+	}
+	return result, nil
+}
+
+// addChildren attaches children to node, fetching depth-1 further levels
+// below them, until count reaches maxTreeItems
+func (t *Tool) addChildren(ctx context.Context, node *workItemNode, children []WorkItem, depth int, count *int) error {
+	for _, child := range children {
+		if *count >= maxTreeItems {
+			return nil
+		}
+		n := &workItemNode{item: child}
+		node.children = append(node.children, n)
+		*count++
+
+		if depth > 1 {
+			grandchildren, err := t.client.GetWorkItemChildren(ctx, child.ID)
+			if err != nil {
+				return err
+			}
+			if err := t.addChildren(ctx, n, grandchildren, depth-1, count); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *Tool) getParent(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, err := requireInt(args, "id")
+	if err != nil {
+		return "", err
+	}
+
+	item, err := t.client.GetWorkItemView(ctx, id, relationsView)
+	if err != nil {
+		return "", err
+	}
+	if parentID(item) == 0 {
+		return fmt.Sprintf("%s has no parent work item.", workItemLine(*item)), nil
+	}
+
+	// Walk up to the top of the hierarchy, nesting each item under its parent
+	node := &workItemNode{item: *item}
+	for i := 0; i < maxAncestors; i++ {
+		pid := parentID(&node.item)
+		if pid == 0 {
+			break
+		}
+		parent, err := t.client.GetWorkItemView(ctx, pid, relationsView)
+		if err != nil {
+			return "", err
+		}
+		node = &workItemNode{item: *parent, children: []*workItemNode{node}}
+	}
+	return formatWorkItemTree(node), nil
+}
+
+func workItemLine(item WorkItem) string {
+	return fmt.Sprintf("#%d [%v] %v (State: %v)",
+		item.ID,
+		item.Fields["System.WorkItemType"],
+		item.Fields["System.Title"],
+		item.Fields["System.State"],
+	)
+}
+
+// formatWorkItemTree draws root and its descendants with box-drawing
+// branches, one work item per line
+func formatWorkItemTree(root *workItemNode) string {
+	var sb strings.Builder
+	sb.WriteString("🌳 " + workItemLine(root.item) + "\n")
+	writeWorkItemBranches(&sb, root.children, "")
+	return sb.String()
+}
+
+func writeWorkItemBranches(sb *strings.Builder, nodes []*workItemNode, prefix string) {
+	for i, n := range nodes {
+		branch, indent := "├─ ", "│  "
+		if i == len(nodes)-1 {
+			branch, indent = "└─ ", "   "
+		}
+		sb.WriteString(prefix + branch + workItemLine(n.item) + "\n")
+		writeWorkItemBranches(sb, n.children, prefix+indent)
+	}
+}
@@ -0,0 +1,125 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// expandedEpic is a work item fetched with $expand=relations
+const expandedEpic = `{"id":100,"rev":9,"fields":{"System.WorkItemType":"Epic","System.Title":"Checkout revamp","System.State":"Active"},
+  "relations":[
+    {"rel":"System.LinkTypes.Hierarchy-Forward","url":"https://dev.azure.com/org/_apis/wit/workItems/101","attributes":{"isLocked":false,"name":"Child"}},
+    {"rel":"ArtifactLink","url":"vstfs:///Git/Commit/abc","attributes":{"name":"Fixed in Commit"}},
+    {"rel":"System.LinkTypes.Related","url":"https://dev.azure.com/org/_apis/wit/workItems/300","attributes":{"name":"Related"}},
+    {"rel":"System.LinkTypes.Hierarchy-Forward","url":"https://dev.azure.com/org/_apis/wit/workItems/102","attributes":{"isLocked":false,"name":"Child"}}
+  ],
+  "url":"https://dev.azure.com/org/_apis/wit/workItems/100"}`
+
+const expandedFeature = `{"id":101,"rev":3,"fields":{"System.WorkItemType":"Feature","System.Title":"Payments","System.State":"New"},
+  "relations":[
+    {"rel":"System.LinkTypes.Hierarchy-Reverse","url":"https://dev.azure.com/org/_apis/wit/workItems/100","attributes":{"isLocked":false,"name":"Parent"}}
+  ]}`
+
+const childrenBatch = `{"count":2,"value":[
+  {"id":101,"fields":{"System.WorkItemType":"Feature","System.Title":"Payments","System.State":"New"}},
+  {"id":102,"fields":{"System.WorkItemType":"Feature","System.Title":"Cart","System.State":"Active"}}
+]}`
+
+func TestRelatedIDs(t *testing.T) {
+	var item WorkItem
+	if err := json.Unmarshal([]byte(expandedEpic), &item); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got := relatedIDs(&item, relHierarchyForward); len(got) != 2 || got[0] != 101 || got[1] != 102 {
+		t.Errorf("children = %v, want [101 102]", got)
+	}
+	if got := parentID(&item); got != 0 {
+		t.Errorf("parent = %d, want none", got)
+	}
+}
+
+func TestGetChildrenTree(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_apis/wit/workitems/100":
+			if r.URL.Query().Get("$expand") != "relations" {
+				t.Errorf("work item requested without relations: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(expandedEpic))
+		case "/_apis/wit/workitemsbatch":
+			var body struct{ IDs []int }
+			json.NewDecoder(r.Body).Decode(&body)
+			if len(body.IDs) != 2 || body.IDs[0] != 101 || body.IDs[1] != 102 {
+				t.Errorf("batch ids = %v", body.IDs)
+			}
+			w.Write([]byte(childrenBatch))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_get_children", map[string]interface{}{"id": float64(100)})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	want := "🌳 #100 [Epic] Checkout revamp (State: Active)\n" +
+		"├─ #101 [Feature] Payments (State: New)\n" +
+		"└─ #102 [Feature] Cart (State: Active)\n"
+	if result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result, want)
+	}
+}
+
+func TestGetChildrenWithoutRelations(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":7,"fields":{"System.WorkItemType":"Task","System.Title":"Write docs","System.State":"New"}}`))
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_get_children", map[string]interface{}{"id": float64(7)})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if result != "#7 [Task] Write docs (State: New) has no child work items." {
+		t.Errorf("result = %q", result)
+	}
+}
+
+func TestGetParentWalksUp(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_apis/wit/workitems/103":
+			w.Write([]byte(`{"id":103,"fields":{"System.WorkItemType":"User Story","System.Title":"Pix","System.State":"New"},
+			  "relations":[{"rel":"System.LinkTypes.Hierarchy-Reverse","url":"https://dev.azure.com/org/_apis/wit/workItems/101"}]}`))
+		case "/_apis/wit/workitems/101":
+			w.Write([]byte(expandedFeature))
+		case "/_apis/wit/workitems/100":
+			w.Write([]byte(expandedEpic))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	parent, err := c.GetWorkItemParent(context.Background(), 103)
+	if err != nil || parent == nil || parent.ID != 101 {
+		t.Fatalf("GetWorkItemParent() = %v, %v; want #101", parent, err)
+	}
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_get_parent", map[string]interface{}{"id": float64(103)})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	want := strings.Join([]string{
+		"🌳 #100 [Epic] Checkout revamp (State: Active)",
+		"└─ #101 [Feature] Payments (State: New)",
+		"   └─ #103 [User Story] Pix (State: New)",
+		"",
+	}, "\n")
+	if result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result, want)
+	}
+}
@@ -0,0 +1,172 @@
+package devops
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`(?s)<(/?)([a-zA-Z][a-zA-Z0-9]*)([^>]*)>|<!--.*?-->`)
+	htmlHrefPattern   = regexp.MustCompile(`(?i)href\s*=\s*("([^"]*)"|'([^']*)'|([^\s>]+))`)
+	blankLinePattern  = regexp.MustCompile(`\n{3,}`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// HTMLToText converts an Azure DevOps HTML field (e.g. System.Description)
+// into plain text with light Markdown, keeping line breaks, list items and
+// links while dropping every other tag.
+func HTMLToText(s string) string {
+	if !strings.Contains(s, "<") {
+		return strings.TrimSpace(html.UnescapeString(s))
+	}
+
+	var sb strings.Builder
+	var lists []int // -1 for unordered, otherwise the next ordered index
+	var linkHref string
+	var linkStart int
+
+	last := 0
+	for _, m := range htmlTagPattern.FindAllStringSubmatchIndex(s, -1) {
+		writeText(&sb, s[last:m[0]])
+		last = m[1]
+
+		if m[4] < 0 {
+			// HTML comment
+			continue
+		}
+
+		closing := s[m[2]:m[3]] == "/"
+		tag := strings.ToLower(s[m[4]:m[5]])
+		attrs := s[m[6]:m[7]]
+
+		switch tag {
+		case "br":
+			sb.WriteString("\n")
+		case "p", "div", "h1", "h2", "h3", "h4", "h5", "h6", "tr", "table", "blockquote", "pre":
+			ensureNewline(&sb)
+		case "ul", "ol":
+			ensureNewline(&sb)
+			if closing {
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+				if len(lists) == 0 {
+					sb.WriteString("\n")
+				}
+				continue
+			}
+			if len(lists) == 0 {
+				sb.WriteString("\n")
+			}
+			if tag == "ol" {
+				lists = append(lists, 1)
+			} else {
+				lists = append(lists, -1)
+			}
+		case "li":
+			if closing {
+				continue
+			}
+			ensureNewline(&sb)
+			if len(lists) > 1 {
+				sb.WriteString(strings.Repeat("  ", len(lists)-1))
+			}
+			if len(lists) > 0 && lists[len(lists)-1] > 0 {
+				sb.WriteString(strconv.Itoa(lists[len(lists)-1]) + ". ")
+				lists[len(lists)-1]++
+			} else {
+				sb.WriteString("- ")
+			}
+		case "td", "th":
+			if closing {
+				sb.WriteString(" ")
+			}
+		case "a":
+			if !closing {
+				linkHref = extractHref(attrs)
+				linkStart = sb.Len()
+				continue
+			}
+			if linkHref == "" {
+				continue
+			}
+			text := strings.TrimSpace(sb.String()[linkStart:])
+			rendered := linkHref
+			if text != "" && text != linkHref {
+				rendered = "[" + text + "](" + linkHref + ")"
+			}
+			prefix := sb.String()[:linkStart]
+			sb.Reset()
+			sb.WriteString(prefix)
+			sb.WriteString(rendered)
+			linkHref = ""
+		}
+	}
+	writeText(&sb, s[last:])
+
+	// Normalize whitespace line by line
+	lines := strings.Split(sb.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+		if !strings.HasPrefix(strings.TrimLeft(lines[i], " "), "- ") && !isOrderedItem(lines[i]) {
+			lines[i] = strings.TrimLeft(lines[i], " \t")
+		}
+	}
+
+	result := strings.Join(lines, "\n")
+	result = blankLinePattern.ReplaceAllString(result, "\n\n")
+	return strings.TrimSpace(result)
+}
+
+func extractHref(attrs string) string {
+	m := htmlHrefPattern.FindStringSubmatch(attrs)
+	if m == nil {
+		return ""
+	}
+	for _, v := range m[2:] {
+		if v != "" {
+			return html.UnescapeString(v)
+		}
+	}
+	return ""
+}
+
+// ensureNewline starts a new line unless the output is already at one
+func ensureNewline(sb *strings.Builder) {
+	out := sb.String()
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		sb.WriteString("\n")
+	}
+}
+
+// writeText appends an HTML text node, avoiding doubled spaces at the
+// boundary with the previous output
+func writeText(sb *strings.Builder, raw string) {
+	text := collapseSpaces(html.UnescapeString(raw))
+	if strings.HasPrefix(text, " ") {
+		out := sb.String()
+		if out == "" || strings.HasSuffix(out, " ") || strings.HasSuffix(out, "\n") {
+			text = text[1:]
+		}
+	}
+	sb.WriteString(text)
+}
+
+// collapseSpaces folds runs of whitespace (including source newlines, which
+// carry no meaning in HTML) into a single space
+func collapseSpaces(s string) string {
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	return whitespacePattern.ReplaceAllString(s, " ")
+}
+
+func isOrderedItem(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	dot := strings.Index(trimmed, ". ")
+	if dot <= 0 {
+		return false
+	}
+	_, err := strconv.Atoi(trimmed[:dot])
+	return err == nil
+}
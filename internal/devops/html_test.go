@@ -0,0 +1,108 @@
+package devops
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Plain text",
+			input:    "Fix login timeout",
+			expected: "Fix login timeout",
+		},
+		{
+			name:     "Div and line breaks",
+			input:    "<div>First line<br>Second line</div><div>Third line</div>",
+			expected: "First line\nSecond line\nThird line",
+		},
+		{
+			name:     "Entities",
+			input:    "<div>Tom &amp; Jerry&nbsp;&lt;3</div>",
+			expected: "Tom & Jerry <3",
+		},
+		{
+			name:     "Unordered list",
+			input:    "<div>Steps:</div><ul><li>Open the app</li><li>Click <b>Login</b></li></ul>",
+			expected: "Steps:\n\n- Open the app\n- Click Login",
+		},
+		{
+			name:     "Ordered list",
+			input:    "<ol><li>One</li><li>Two</li></ol>",
+			expected: "1. One\n2. Two",
+		},
+		{
+			name:     "Link",
+			input:    `<div>See <a href="https://dev.azure.com/org/proj/_wiki">the wiki</a> for details</div>`,
+			expected: "See [the wiki](https://dev.azure.com/org/proj/_wiki) for details",
+		},
+		{
+			name:     "Bare link",
+			input:    `<a href="https://example.com">https://example.com</a>`,
+			expected: "https://example.com",
+		},
+		{
+			name: "Azure DevOps repro steps",
+			input: `<div><span style="font-size:14px;">Repro:</span></div>
+<div><br></div>
+<ol>
+  <li>Go to <a href="https://app.example.com/login">login</a></li>
+  <li>Enter an expired password</li>
+</ol>
+<!-- generated -->
+<p>Expected: error message</p>`,
+			expected: "Repro:\n\n1. Go to [login](https://app.example.com/login)\n2. Enter an expired password\n\nExpected: error message",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := HTMLToText(tt.input)
+			if result != tt.expected {
+				t.Errorf("HTMLToText(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatWorkItemStripsHTML(t *testing.T) {
+	item := &WorkItem{
+		ID: 42,
+		Fields: map[string]interface{}{
+			"System.WorkItemType": "Bug",
+			"System.Title":        "Broken login",
+			"System.State":        "Active",
+			"System.Description":  "<div>Users <b>cannot</b> log in</div>",
+		},
+	}
+
+	result := formatWorkItem(item)
+
+	if strings.Contains(result, "<div>") || strings.Contains(result, "<b>") {
+		t.Errorf("formatWorkItem() left HTML tags in output: %q", result)
+	}
+	if !strings.Contains(result, "Description: Users cannot log in") {
+		t.Errorf("formatWorkItem() = %q, expected plain-text description", result)
+	}
+}
+
+func TestFormatWorkItemCapsComments(t *testing.T) {
+	item := &WorkItem{ID: 1, Fields: map[string]interface{}{"System.Title": "Busy item"}}
+	comments := make([]WorkItemComment, 5)
+	for i := range comments {
+		comments[i].Text = "comment"
+	}
+
+	result := formatWorkItem(item, comments...)
+	if n := strings.Count(result, ": comment"); n != maxFormattedComments {
+		t.Errorf("formatWorkItem() listed %d comments, expected %d", n, maxFormattedComments)
+	}
+	if !strings.Contains(result, "Latest comments (3 of 5)") {
+		t.Errorf("formatWorkItem() = %q", result)
+	}
+}
@@ -0,0 +1,140 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Iteration is a sprint a team subscribed to
+type Iteration struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Path       string `json:"path"` // e.g. Project\Release 1\Sprint 3
+	Attributes struct {
+		StartDate  string `json:"startDate"`
+		FinishDate string `json:"finishDate"`
+		TimeFrame  string `json:"timeFrame"` // past, current or future
+	} `json:"attributes"`
+}
+
+// ListIterations lists the iterations of a team (defaults to the project
+// default team)
+func (c *Client) ListIterations(ctx context.Context, team string) ([]Iteration, error) {
+	if team == "" {
+		team = c.project + " Team"
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/_apis/work/teamsettings/iterations?api-version=%s",
+		c.baseURL, url.PathEscape(team), c.apiVersion)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Count int         `json:"count"`
+		Value []Iteration `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode iterations: %w", err)
+	}
+
+	return result.Value, nil
+}
+
+// SetWorkItemIteration moves a work item to the iteration at iterationPath
+func (c *Client) SetWorkItemIteration(ctx context.Context, id int, iterationPath string) error {
+	_, err := c.UpdateWorkItem(ctx, id, WorkItemUpdateRequest{
+		CustomFields: map[string]interface{}{"System.IterationPath": iterationPath},
+	})
+	return err
+}
+
+// ResolveIteration finds the iteration sprint refers to: a full path, a
+// trailing part of a nested path (Release 1\Sprint 3), a name, or
+// "current". A name shared by iterations under different parents is
+// reported as ambiguous.
+func ResolveIteration(iterations []Iteration, sprint string) (*Iteration, error) {
+	sprint = strings.Trim(strings.TrimSpace(strings.ReplaceAll(sprint, "/", `\`)), `\`)
+	if sprint == "" {
+		return nil, fmt.Errorf("sprint is required")
+	}
+
+	var matches []Iteration
+	switch strings.ToLower(sprint) {
+	case "current", "atual":
+		for _, it := range iterations {
+			if it.Attributes.TimeFrame == "current" {
+				matches = append(matches, it)
+			}
+		}
+	default:
+		for _, it := range iterations {
+			if strings.EqualFold(it.Path, sprint) {
+				return &it, nil
+			}
+		}
+		for _, it := range iterations {
+			path := strings.ToLower(it.Path)
+			if strings.HasSuffix(path, `\`+strings.ToLower(sprint)) {
+				matches = append(matches, it)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return &matches[0], nil
+	case 0:
+		names := make([]string, len(iterations))
+		for i, it := range iterations {
+			names[i] = it.Name
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("sprint %q not found: the team has no iterations", sprint)
+		}
+		return nil, fmt.Errorf("sprint %q not found for the team; available: %s", sprint, strings.Join(names, ", "))
+	default:
+		paths := make([]string, len(matches))
+		for i, it := range matches {
+			paths[i] = it.Path
+		}
+		return nil, fmt.Errorf("sprint %q is ambiguous, use the full path: %s", sprint, strings.Join(paths, "; "))
+	}
+}
+
+func (t *Tool) assignToSprint(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, err := requireInt(args, "id")
+	if err != nil {
+		return "", err
+	}
+
+	iterations, err := t.client.ListIterations(ctx, getString(args, "team"))
+	if err != nil {
+		return "", err
+	}
+	iteration, err := ResolveIteration(iterations, getString(args, "sprint"))
+	if err != nil {
+		return "", err
+	}
+
+	if err := t.client.SetWorkItemIteration(ctx, id, iteration.Path); err != nil {
+		return "", err
+	}
+	return formatSprintAssignment(id, iteration), nil
+}
+
+func formatSprintAssignment(id int, it *Iteration) string {
+	result := fmt.Sprintf("✅ Work item #%d moved to %s (%s)", id, it.Name, it.Path)
+	if len(it.Attributes.StartDate) >= 10 && len(it.Attributes.FinishDate) >= 10 {
+		result += fmt.Sprintf(", %s to %s", it.Attributes.StartDate[:10], it.Attributes.FinishDate[:10])
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const teamIterations = `{"count":4,"value":[
+  {"id":"a","name":"Sprint 1","path":"Web\\Release 1\\Sprint 1","attributes":{"timeFrame":"past"}},
+  {"id":"b","name":"Sprint 1","path":"Web\\Release 2\\Sprint 1","attributes":{"timeFrame":"future"}},
+  {"id":"c","name":"Sprint 14","path":"Web\\Release 2\\Sprint 14","attributes":{"startDate":"2026-10-12T00:00:00Z","finishDate":"2026-10-23T00:00:00Z","timeFrame":"current"}},
+  {"id":"d","name":"Backlog","path":"Web\\Backlog","attributes":{}}
+]}`
+
+func TestResolveIteration(t *testing.T) {
+	var result struct {
+		Value []Iteration `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(teamIterations), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		sprint  string
+		want    string
+		wantErr string
+	}{
+		{sprint: "Sprint 14", want: `Web\Release 2\Sprint 14`},
+		{sprint: "sprint 14", want: `Web\Release 2\Sprint 14`},
+		{sprint: "current", want: `Web\Release 2\Sprint 14`},
+		{sprint: `Release 1\Sprint 1`, want: `Web\Release 1\Sprint 1`},
+		{sprint: "Release 2/Sprint 1", want: `Web\Release 2\Sprint 1`},
+		{sprint: `Web\Backlog`, want: `Web\Backlog`},
+		{sprint: "Sprint 1", wantErr: "ambiguous"},
+		{sprint: "Sprint 99", wantErr: "available: Backlog, Sprint 1, Sprint 1, Sprint 14"},
+		{sprint: "print 14", wantErr: "not found"},
+	}
+
+	for _, tt := range tests {
+		it, err := ResolveIteration(result.Value, tt.sprint)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolveIteration(%q) error = %v, want %q", tt.sprint, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ResolveIteration(%q) error = %v", tt.sprint, err)
+			continue
+		}
+		if it.Path != tt.want {
+			t.Errorf("ResolveIteration(%q) = %q, want %q", tt.sprint, it.Path, tt.want)
+		}
+	}
+}
+
+func TestAssignToSprintPatchesIterationPath(t *testing.T) {
+	var patch []map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/Platform/_apis/work/teamsettings/iterations":
+			w.Write([]byte(teamIterations))
+		case r.Method == "PATCH" && r.URL.Path == "/_apis/wit/workitems/42":
+			if ct := r.Header.Get("Content-Type"); ct != "application/json-patch+json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			json.NewDecoder(r.Body).Decode(&patch)
+			w.Write([]byte(`{"id":42,"fields":{}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_assign_to_sprint", map[string]interface{}{
+		"id":     42.0,
+		"sprint": "Sprint 14",
+		"team":   "Platform",
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if len(patch) != 1 || patch[0]["op"] != "add" || patch[0]["path"] != "/fields/System.IterationPath" ||
+		patch[0]["value"] != `Web\Release 2\Sprint 14` {
+		t.Errorf("patch = %v", patch)
+	}
+	if want := `✅ Work item #42 moved to Sprint 14 (Web\Release 2\Sprint 14), 2026-10-12 to 2026-10-23`; result != want {
+		t.Errorf("result = %q, want %q", result, want)
+	}
+}
@@ -0,0 +1,139 @@
+package devops
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxMentionDays bounds how far back devops_my_mentions looks
+	maxMentionDays = 30
+	// maxMentionItems bounds the work items whose comments are read
+	maxMentionItems = 30
+)
+
+// GetMyMentions returns the comments of the last sinceDays days that
+// @mention the identity the PAT authenticates as, newest first. Each
+// comment's WorkItemTitle is set.
+func (c *Client) GetMyMentions(ctx context.Context, sinceDays int) ([]WorkItemComment, error) {
+	return c.getMentions(ctx, sinceDays, time.Now())
+}
+
+// getMentions is GetMyMentions counting the days back from now
+func (c *Client) getMentions(ctx context.Context, days int, now time.Time) ([]WorkItemComment, error) {
+	me, err := c.WhoAmI(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the authenticated user: %w", err)
+	}
+
+	// Full-text search on the discussion narrows the items; the comments
+	// are then checked for an actual mention
+	since := now.AddDate(0, 0, -days)
+	query := fmt.Sprintf(`SELECT [System.Id] FROM WorkItems
+              WHERE [System.TeamProject] = @project
+              AND [System.ChangedDate] >= @Today - %d
+              AND [System.History] CONTAINS WORDS '%s'
+              ORDER BY [System.ChangedDate] DESC`, days, escapeWIQL(me.DisplayName))
+
+	refs, err := c.queryWorkItemRefs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) > maxMentionItems {
+		refs = refs[:maxMentionItems]
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	ids := make([]int, len(refs))
+	for i, ref := range refs {
+		ids[i] = ref.ID
+	}
+	items, err := c.getWorkItemsFields(ctx, ids, []string{"System.Id", "System.Title"})
+	if err != nil {
+		return nil, err
+	}
+
+	var mentions []WorkItemComment
+	for _, item := range items {
+		comments, err := c.GetWorkItemComments(ctx, item.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, cm := range comments {
+			created, err := time.Parse(time.RFC3339, cm.CreatedDate)
+			if err != nil || created.Before(since) {
+				continue
+			}
+			if strings.EqualFold(cm.CreatedBy.UniqueName, me.UniqueName) || !mentionsIdentity(cm.Text, me) {
+				continue
+			}
+			cm.WorkItemID = item.ID
+			cm.WorkItemTitle, _ = item.Fields["System.Title"].(string)
+			mentions = append(mentions, cm)
+		}
+	}
+
+	sort.SliceStable(mentions, func(i, j int) bool {
+		return mentions[i].CreatedDate > mentions[j].CreatedDate
+	})
+	return mentions, nil
+}
+
+// mentionsIdentity reports whether comment HTML @mentions id. The web UI stores a
+// mention as <a data-vss-mention="version:2.0,{identity id}">@Name</a>;
+// comments posted through the API may only carry the @Name text.
+func mentionsIdentity(text string, id *Identity) bool {
+	lower := strings.ToLower(text)
+	if id.ID != "" && strings.Contains(lower, "data-vss-mention=\"version:2.0,"+strings.ToLower(id.ID)+"\"") {
+		return true
+	}
+	for _, name := range []string{id.DisplayName, id.UniqueName} {
+		if name != "" && strings.Contains(lower, "@"+strings.ToLower(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mentionLink matches a mention anchor, whose href is a placeholder
+var mentionLink = regexp.MustCompile(`(?is)<a[^>]*data-vss-mention[^>]*>(.*?)</a>`)
+
+func (t *Tool) myMentions(ctx context.Context, args map[string]interface{}) (string, error) {
+	days := 7
+	if v, ok := getInt(args, "days"); ok && v >= 1 {
+		days = v
+	}
+	if days > maxMentionDays {
+		days = maxMentionDays
+	}
+
+	comments, err := t.client.getMentions(ctx, days, t.clock.Now())
+	if err != nil {
+		return "", err
+	}
+	return t.formatMentions(comments, days), nil
+}
+
+func (t *Tool) formatMentions(comments []WorkItemComment, days int) string {
+	if len(comments) == 0 {
+		return fmt.Sprintf("No one mentioned you in work item comments in the last %d days.", days)
+	}
+
+	result := fmt.Sprintf("You were mentioned %d times in the last %d days:\n\n", len(comments), days)
+	for _, cm := range comments {
+		date := cm.CreatedDate
+		if created, err := time.Parse(time.RFC3339, cm.CreatedDate); err == nil {
+			date = created.In(t.location).Format("2006-01-02 15:04")
+		}
+		text := mentionLink.ReplaceAllString(cm.Text, "$1")
+		snippet := clip(strings.Join(strings.Fields(HTMLToText(text)), " "), t.snippetChars)
+		result += fmt.Sprintf("- #%d %s — %s (%s): %s\n  %s\n",
+			cm.WorkItemID, cm.WorkItemTitle, cm.CreatedBy.DisplayName, date, snippet, t.client.WorkItemWebURL(cm.WorkItemID))
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+// mentionComments are recorded comments of work item 42: a web UI mention
+// of Ana, an old mention, Ana's own comment and one mentioning someone else
+const mentionComments = `{"totalCount":4,"count":4,"comments":[
+  {"id":904,"workItemId":42,"version":1,"text":"<div><a href=\"#\" data-vss-mention=\"version:2.0,6d3b-4a1f\">@Ana Silva</a> can you review the rollback plan before Friday?</div>",
+   "createdBy":{"displayName":"Bruno Costa","uniqueName":"bruno@contoso.com"},"createdDate":"2024-03-14T13:20:05.12Z"},
+  {"id":903,"workItemId":42,"version":1,"text":"<div>Done, thanks <a href=\"#\" data-vss-mention=\"version:2.0,9c1e-77aa\">@Bruno Costa</a></div>",
+   "createdBy":{"displayName":"Ana Silva","uniqueName":"ana@contoso.com"},"createdDate":"2024-03-13T10:00:00Z"},
+  {"id":902,"workItemId":42,"version":1,"text":"<div>@Carla Dias please check the logs</div>",
+   "createdBy":{"displayName":"Bruno Costa","uniqueName":"bruno@contoso.com"},"createdDate":"2024-03-12T09:00:00Z"},
+  {"id":850,"workItemId":42,"version":1,"text":"<div><a href=\"#\" data-vss-mention=\"version:2.0,6d3b-4a1f\">@Ana Silva</a> old question</div>",
+   "createdBy":{"displayName":"Bruno Costa","uniqueName":"bruno@contoso.com"},"createdDate":"2024-02-01T09:00:00Z"}
+]}`
+
+func TestMyMentions(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/_apis/connectionData":
+			w.Write([]byte(connectionData))
+		case r.URL.Path == "/_apis/wit/wiql":
+			var body struct{ Query string }
+			json.NewDecoder(r.Body).Decode(&body)
+			if !strings.Contains(body.Query, "CONTAINS WORDS 'Ana Silva'") || !strings.Contains(body.Query, "@Today - 7") {
+				t.Errorf("query = %s", body.Query)
+			}
+			w.Write([]byte(`{"workItems":[{"id":42}]}`))
+		case r.URL.Path == "/_apis/wit/workitemsbatch":
+			w.Write([]byte(`{"count":1,"value":[{"id":42,"fields":{"System.Id":42,"System.Title":"Release 2.3 rollout"}}]}`))
+		case r.URL.Path == "/_apis/wit/workItems/42/comments":
+			w.Write([]byte(mentionComments))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	tool := NewTool(c)
+	tool.SetClock(clock.NewFake(time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)))
+	tool.SetLocation(time.UTC)
+
+	result, _, err := tool.Execute(context.Background(), "devops_my_mentions", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	want := "You were mentioned 1 times in the last 7 days:\n\n" +
+		"- #42 Release 2.3 rollout — Bruno Costa (2024-03-14 13:20): @Ana Silva can you review the rollback plan before Friday?\n" +
+		"  https://dev.azure.com/org/proj/_workitems/edit/42\n"
+	if result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result, want)
+	}
+}
+
+func TestMentionsIdentity(t *testing.T) {
+	ana := &Identity{ID: "6d3b-4a1f", DisplayName: "Ana Silva", UniqueName: "ana@contoso.com"}
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{`<a data-vss-mention="version:2.0,6D3B-4A1F">@Someone Renamed</a> hi`, true},
+		{"ping @ana silva about it", true},
+		{"cc @ana@contoso.com", true},
+		{"Ana Silva fixed it", false},
+		{`<a data-vss-mention="version:2.0,9c1e-77aa">@Bruno</a>`, false},
+	}
+	for _, tt := range tests {
+		if got := mentionsIdentity(tt.text, ana); got != tt.want {
+			t.Errorf("mentionsIdentity(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPipelineYAMLChars bounds the pipeline YAML handed to the LLM
+const maxPipelineYAMLChars = 20000
+
+// yamlProcessType is the build definition process type of YAML pipelines
+const yamlProcessType = 2
+
+var (
+	// ErrClassicPipeline is returned for pipelines edited in the classic
+	// designer, which have no YAML file
+	ErrClassicPipeline = errors.New("classic pipeline has no YAML definition")
+
+	// ErrExternalPipelineRepo is returned when the YAML file lives outside
+	// Azure Repos (e.g. GitHub), where the PAT cannot read it
+	ErrExternalPipelineRepo = errors.New("pipeline YAML is not in an Azure Repos repository")
+)
+
+// pipelineYAML is a pipeline's YAML file and where it was read from
+type pipelineYAML struct {
+	Repository string
+	Path       string
+	Branch     string
+	Content    string
+}
+
+// GetPipelineDefinition returns the YAML of a pipeline, read from its
+// repository's default branch
+func (c *Client) GetPipelineDefinition(ctx context.Context, pipelineID int) (string, error) {
+	def, err := c.getPipelineYAML(ctx, pipelineID)
+	if err != nil {
+		return "", err
+	}
+	return def.Content, nil
+}
+
+func (c *Client) getPipelineYAML(ctx context.Context, pipelineID int) (*pipelineYAML, error) {
+	def, err := c.getBuildDefinition(ctx, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+	if def.Process.Type != yamlProcessType || def.Process.YAMLFilename == "" {
+		return nil, ErrClassicPipeline
+	}
+	if def.Repository.Type != "TfsGit" {
+		return nil, fmt.Errorf("%w: %s repository %s", ErrExternalPipelineRepo, def.Repository.Type, def.Repository.Name)
+	}
+
+	path := def.Process.YAMLFilename
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	branch := strings.TrimPrefix(def.Repository.DefaultBranch, "refs/heads/")
+	if branch == "" {
+		branch = strings.TrimPrefix(fallbackBranch, "refs/heads/")
+	}
+
+	params := url.Values{}
+	params.Set("path", path)
+	params.Set("includeContent", "true")
+	params.Set("versionDescriptor.version", branch)
+	params.Set("versionDescriptor.versionType", "branch")
+	// Without it the items API returns the raw file instead of JSON
+	params.Set("$format", "json")
+	params.Set("api-version", c.apiVersion)
+	endpoint := fmt.Sprintf("%s/_apis/git/repositories/%s/items?%s", c.baseURL, url.PathEscape(def.Repository.ID), params.Encode())
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var item struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return nil, fmt.Errorf("failed to decode pipeline YAML: %w", err)
+	}
+
+	return &pipelineYAML{
+		Repository: def.Repository.Name,
+		Path:       path,
+		Branch:     branch,
+		Content:    item.Content,
+	}, nil
+}
+
+func (t *Tool) getPipelineYAML(ctx context.Context, args map[string]interface{}) (string, error) {
+	pipelineID, err := requireInt(args, "pipeline_id")
+	if err != nil {
+		return "", err
+	}
+
+	def, err := t.client.getPipelineYAML(ctx, pipelineID)
+	switch {
+	case errors.Is(err, ErrClassicPipeline):
+		return fmt.Sprintf("Pipeline #%d is a classic pipeline, edited in the designer; it has no YAML definition to show.", pipelineID), nil
+	case errors.Is(err, ErrExternalPipelineRepo):
+		return fmt.Sprintf("The YAML of pipeline #%d cannot be read from here (%v).", pipelineID, err), nil
+	case err != nil:
+		return "", err
+	}
+	return formatPipelineYAML(pipelineID, def), nil
+}
+
+func formatPipelineYAML(pipelineID int, def *pipelineYAML) string {
+	result := fmt.Sprintf("🔧 Pipeline #%d — %s:%s (branch %s)\n\n", pipelineID, def.Repository, def.Path, def.Branch)
+	if strings.TrimSpace(def.Content) == "" {
+		return result + "(empty file)"
+	}
+
+	content := def.Content
+	var note string
+	if n := utf8.RuneCountInString(content); n > maxPipelineYAMLChars {
+		content = string([]rune(content)[:maxPipelineYAMLChars])
+		note = fmt.Sprintf("\n… (truncated, %d more characters)", n-maxPipelineYAMLChars)
+	}
+	return result + "```yaml\n" + strings.TrimRight(content, "\n") + "\n```" + note
+}
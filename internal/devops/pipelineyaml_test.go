@@ -0,0 +1,77 @@
+package devops
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const yamlBuildDefinition = `{"id":12,"name":"web-ci","path":"\\","type":"build","queueStatus":"enabled","revision":7,
+  "process":{"yamlFilename":"ci/azure-pipelines.yml","type":2},
+  "repository":{"id":"5e1c7c02-3b4b-4b8e-9d4f-0c2a9d0f1a11","type":"TfsGit","name":"web","defaultBranch":"refs/heads/develop",
+    "url":"https://dev.azure.com/org/proj/_git/web"}}`
+
+const classicBuildDefinition = `{"id":3,"name":"legacy-release","revision":41,
+  "process":{"phases":[{"name":"Agent job 1","steps":[]}],"type":1},
+  "repository":{"id":"7a0d","type":"TfsGit","name":"legacy","defaultBranch":"refs/heads/master"}}`
+
+const pipelineYAMLItem = `{"objectId":"9a8b7c","gitObjectType":"blob","commitId":"3f2a9c1b","path":"/ci/azure-pipelines.yml",` +
+	`"content":"trigger:\n- develop\n\npool:\n  vmImage: ubuntu-latest\n\nsteps:\n- script: make test\n"}`
+
+func TestGetPipelineYAML(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_apis/build/definitions/12":
+			w.Write([]byte(yamlBuildDefinition))
+		case "/_apis/git/repositories/5e1c7c02-3b4b-4b8e-9d4f-0c2a9d0f1a11/items":
+			q := r.URL.Query()
+			if q.Get("path") != "/ci/azure-pipelines.yml" || q.Get("versionDescriptor.version") != "develop" || q.Get("includeContent") != "true" || q.Get("$format") != "json" {
+				t.Errorf("unexpected item query %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(pipelineYAMLItem))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_get_pipeline_yaml", map[string]interface{}{
+		"pipeline_id": float64(12),
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	want := "🔧 Pipeline #12 — web:/ci/azure-pipelines.yml (branch develop)\n\n" +
+		"```yaml\ntrigger:\n- develop\n\npool:\n  vmImage: ubuntu-latest\n\nsteps:\n- script: make test\n```"
+	if result != want {
+		t.Errorf("result = %q, want %q", result, want)
+	}
+}
+
+func TestGetPipelineYAMLClassic(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_apis/build/definitions/3" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(classicBuildDefinition))
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_get_pipeline_yaml", map[string]interface{}{
+		"pipeline_id": float64(3),
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.Contains(result, "classic pipeline") {
+		t.Errorf("result = %q, expected the classic pipeline message", result)
+	}
+}
+
+func TestFormatPipelineYAMLTruncates(t *testing.T) {
+	result := formatPipelineYAML(1, &pipelineYAML{Repository: "web", Path: "/big.yml", Branch: "main",
+		Content: strings.Repeat("a", maxPipelineYAMLChars+5)})
+	if !strings.HasSuffix(result, "… (truncated, 5 more characters)") {
+		t.Errorf("result not truncated: ...%s", result[len(result)-60:])
+	}
+}
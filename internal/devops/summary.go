@@ -0,0 +1,117 @@
+package devops
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxSummaryItems caps how many work items a state summary may count
+	maxSummaryItems = 2000
+	// workItemsBatchSize is the most IDs the batch API accepts at once
+	workItemsBatchSize = 200
+)
+
+// GetWorkItemCountsByState counts the project's work items by state.
+// wiqlScope is an optional WIQL condition narrowing the items, e.g.
+// [System.AssignedTo] = @Me. Removed items are not counted.
+func (c *Client) GetWorkItemCountsByState(ctx context.Context, wiqlScope string) (map[string]int, error) {
+	query := `SELECT [System.Id] FROM WorkItems
+              WHERE [System.TeamProject] = @project
+              AND [System.State] <> 'Removed'`
+	if strings.TrimSpace(wiqlScope) != "" {
+		query += "\n              AND (" + wiqlScope + ")"
+	}
+
+	refs, err := c.queryWorkItemRefs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) > maxSummaryItems {
+		return nil, fmt.Errorf("the scope matches %d work items, more than the %d a summary counts; narrow it down", len(refs), maxSummaryItems)
+	}
+
+	counts := make(map[string]int)
+	for start := 0; start < len(refs); start += workItemsBatchSize {
+		end := min(start+workItemsBatchSize, len(refs))
+		ids := make([]int, 0, end-start)
+		for _, ref := range refs[start:end] {
+			ids = append(ids, ref.ID)
+		}
+
+		items, err := c.getWorkItemsFields(ctx, ids, []string{"System.State"})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			state, _ := item.Fields["System.State"].(string)
+			counts[state]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (t *Tool) stateSummary(ctx context.Context, args map[string]interface{}) (string, error) {
+	var conditions, labels []string
+	if mine, _ := args["assigned_to_me"].(bool); mine {
+		conditions = append(conditions, "[System.AssignedTo] = @Me")
+		labels = append(labels, "assigned to me")
+	}
+	if tag := strings.TrimSpace(getString(args, "tag")); tag != "" {
+		conditions = append(conditions, fmt.Sprintf("[System.Tags] CONTAINS '%s'", escapeWIQL(tag)))
+		labels = append(labels, "tagged "+tag)
+	}
+	if sprint := getString(args, "sprint"); sprint != "" {
+		iterations, err := t.client.ListIterations(ctx, getString(args, "team"))
+		if err != nil {
+			return "", err
+		}
+		iteration, err := ResolveIteration(iterations, sprint)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, fmt.Sprintf("[System.IterationPath] UNDER '%s'", escapeWIQL(iteration.Path)))
+		labels = append(labels, "in "+iteration.Name)
+	}
+
+	counts, err := t.client.GetWorkItemCountsByState(ctx, strings.Join(conditions, " AND "))
+	if err != nil {
+		return "", err
+	}
+	return formatStateSummary(strings.Join(labels, ", "), counts), nil
+}
+
+func formatStateSummary(scope string, counts map[string]int) string {
+	title := "Work items by state"
+	if scope != "" {
+		title += " (" + scope + ")"
+	}
+
+	states := make([]string, 0, len(counts))
+	total := 0
+	width := len("Total")
+	for state, n := range counts {
+		states = append(states, state)
+		total += n
+		width = max(width, len(state))
+	}
+	if total == 0 {
+		return title + ": no work items found."
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if counts[states[i]] != counts[states[j]] {
+			return counts[states[i]] > counts[states[j]]
+		}
+		return states[i] < states[j]
+	})
+
+	result := title + ":\n```\n"
+	for _, state := range states {
+		result += fmt.Sprintf("%-*s %5d\n", width, state, counts[state])
+	}
+	result += fmt.Sprintf("%-*s %5d\n```", width, "Total", total)
+	return result
+}
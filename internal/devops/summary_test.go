@@ -0,0 +1,66 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStateSummaryCountsByState(t *testing.T) {
+	states := []string{"Active", "New", "Active", "Resolved", "Active", "New"}
+	var wiql string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_apis/wit/wiql":
+			var body struct {
+				Query string `json:"query"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			wiql = body.Query
+			refs := make([]string, len(states))
+			for i := range states {
+				refs[i] = fmt.Sprintf(`{"id":%d}`, i+1)
+			}
+			w.Write([]byte(`{"workItems":[` + strings.Join(refs, ",") + `]}`))
+		case "/_apis/wit/workitemsbatch":
+			var body struct {
+				IDs    []int    `json:"ids"`
+				Fields []string `json:"fields"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if len(body.Fields) != 1 || body.Fields[0] != "System.State" {
+				t.Errorf("fields = %v, want only System.State", body.Fields)
+			}
+			items := make([]string, len(body.IDs))
+			for i, id := range body.IDs {
+				items[i] = fmt.Sprintf(`{"id":%d,"fields":{"System.State":%q}}`, id, states[id-1])
+			}
+			w.Write([]byte(`{"count":` + fmt.Sprint(len(items)) + `,"value":[` + strings.Join(items, ",") + `]}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_state_summary", map[string]interface{}{
+		"assigned_to_me": true,
+		"tag":            "release-2.3",
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if !strings.Contains(wiql, "AND ([System.AssignedTo] = @Me AND [System.Tags] CONTAINS 'release-2.3')") {
+		t.Errorf("query does not apply the scope:\n%s", wiql)
+	}
+	want := "Work items by state (assigned to me, tagged release-2.3):\n```\n" +
+		"Active       3\n" +
+		"New          2\n" +
+		"Resolved     1\n" +
+		"Total        6\n```"
+	if result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result, want)
+	}
+}
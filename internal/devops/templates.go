@@ -0,0 +1,169 @@
+package devops
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// QueryTemplate is a named WIQL query with {{param}} placeholders, so common
+// questions don't depend on the model writing WIQL. {{project}} is always
+// available and expands to the client's project.
+type QueryTemplate struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Query       string          `json:"query"`
+	Params      []TemplateParam `json:"params,omitempty"`
+}
+
+// TemplateParam declares a template placeholder
+type TemplateParam struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"` // "string" (default) or "int"
+	Default     string `json:"default,omitempty"`
+}
+
+// DefaultQueryTemplates are the templates available without configuration
+func DefaultQueryTemplates() map[string]QueryTemplate {
+	return map[string]QueryTemplate{
+		"my-active": {
+			Name:        "my-active",
+			Description: "Work items assigned to me that are not finished",
+			Query: `SELECT [System.Id] FROM WorkItems
+              WHERE [System.TeamProject] = @project
+              AND [System.AssignedTo] = @Me
+              AND [System.State] NOT IN ('Closed', 'Done', 'Removed')
+              ORDER BY [System.ChangedDate] DESC`,
+		},
+		"recently-closed": {
+			Name:        "recently-closed",
+			Description: "Work items closed in the last N days",
+			Query: `SELECT [System.Id] FROM WorkItems
+              WHERE [System.TeamProject] = @project
+              AND [System.State] IN ('Closed', 'Done')
+              AND [Microsoft.VSTS.Common.ClosedDate] >= @Today - {{days}}
+              ORDER BY [Microsoft.VSTS.Common.ClosedDate] DESC`,
+			Params: []TemplateParam{
+				{Name: "days", Description: "How many days back to look", Type: "int", Default: "7"},
+			},
+		},
+		"blocked": {
+			Name:        "blocked",
+			Description: "Open work items tagged as blocked",
+			Query: `SELECT [System.Id] FROM WorkItems
+              WHERE [System.TeamProject] = @project
+              AND [System.Tags] CONTAINS '{{tag}}'
+              AND [System.State] NOT IN ('Closed', 'Done', 'Removed')
+              ORDER BY [Microsoft.VSTS.Common.Priority] ASC`,
+			Params: []TemplateParam{
+				{Name: "tag", Description: "Tag that marks blocked items", Default: "Blocked"},
+			},
+		},
+		"in-sprint": {
+			Name:        "in-sprint",
+			Description: "Work items in a team's current sprint",
+			Query: `SELECT [System.Id] FROM WorkItems
+              WHERE [System.TeamProject] = @project
+              AND [System.IterationPath] = @CurrentIteration('[{{project}}]\{{team}}')
+              ORDER BY [System.State], [Microsoft.VSTS.Common.Priority] ASC`,
+			Params: []TemplateParam{
+				{Name: "team", Description: "Team name (default: the project's default team)", Default: "{{project}} Team"},
+			},
+		},
+	}
+}
+
+// LoadQueryTemplates returns the default templates overlaid with those in
+// the JSON file at path (an array of QueryTemplate). A template with the
+// name of a default replaces it. An empty path returns the defaults.
+func LoadQueryTemplates(path string) (map[string]QueryTemplate, error) {
+	templates := DefaultQueryTemplates()
+	if path == "" {
+		return templates, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query templates: %w", err)
+	}
+	var custom []QueryTemplate
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("failed to parse query templates %s: %w", path, err)
+	}
+	for _, tpl := range custom {
+		if tpl.Name == "" || tpl.Query == "" {
+			return nil, fmt.Errorf("query template in %s needs a name and a query", path)
+		}
+		templates[tpl.Name] = tpl
+	}
+	return templates, nil
+}
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// Render substitutes params into the template. Values are escaped for
+// single-quoted WIQL strings and "int" params must be integers, so a value
+// can never change the shape of the query.
+func (t QueryTemplate) Render(project string, params map[string]string) (string, error) {
+	values := map[string]string{"project": escapeWIQL(project)}
+	builtins := func(s string) string {
+		return placeholderRe.ReplaceAllStringFunc(s, func(m string) string {
+			if placeholderRe.FindStringSubmatch(m)[1] == "project" {
+				return project
+			}
+			return m
+		})
+	}
+
+	for _, p := range t.Params {
+		v, ok := params[p.Name]
+		if !ok || v == "" {
+			v = builtins(p.Default)
+		}
+		if v == "" {
+			return "", fmt.Errorf("template %s: parameter %q is required", t.Name, p.Name)
+		}
+		if p.Type == "int" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return "", fmt.Errorf("template %s: parameter %q must be a non-negative integer", t.Name, p.Name)
+			}
+			v = strconv.Itoa(n)
+		}
+		values[p.Name] = escapeWIQL(v)
+	}
+
+	for name := range params {
+		if _, ok := values[name]; !ok || name == "project" {
+			return "", fmt.Errorf("template %s: unknown parameter %q", t.Name, name)
+		}
+	}
+
+	var missing string
+	query := placeholderRe.ReplaceAllStringFunc(t.Query, func(m string) string {
+		name := placeholderRe.FindStringSubmatch(m)[1]
+		v, ok := values[name]
+		if !ok {
+			missing = name
+		}
+		return v
+	})
+	if missing != "" {
+		return "", fmt.Errorf("template %s: placeholder {{%s}} is not a declared parameter", t.Name, missing)
+	}
+	return query, nil
+}
+
+// sortedTemplates returns templates ordered by name
+func sortedTemplates(templates map[string]QueryTemplate) []QueryTemplate {
+	list := make([]QueryTemplate, 0, len(templates))
+	for _, tpl := range templates {
+		list = append(list, tpl)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
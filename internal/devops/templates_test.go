@@ -0,0 +1,113 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplateSubstitutesAndEscapes(t *testing.T) {
+	templates := DefaultQueryTemplates()
+
+	tests := []struct {
+		name     string
+		template string
+		params   map[string]string
+		want     string
+		wantErr  string
+	}{
+		{"default param", "recently-closed", nil, "@Today - 7", ""},
+		{"int param", "recently-closed", map[string]string{"days": "14"}, "@Today - 14", ""},
+		{"int param rejects WIQL", "recently-closed", map[string]string{"days": "1 OR 1=1"}, "", "must be a non-negative integer"},
+		{"string param is escaped", "blocked", map[string]string{"tag": "won't fix' OR [System.Id] > '0"}, "CONTAINS 'won''t fix'' OR [System.Id] > ''0'", ""},
+		{"default uses project", "in-sprint", nil, `@CurrentIteration('[O''Brien Apps]\O''Brien Apps Team')`, ""},
+		{"unknown param", "my-active", map[string]string{"user": "ana"}, "", `unknown parameter "user"`},
+		{"project is not a param", "blocked", map[string]string{"project": "Other"}, "", `unknown parameter "project"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := templates[tt.template].Render("O'Brien Apps", tt.params)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if !strings.Contains(query, tt.want) {
+				t.Errorf("query missing %q:\n%s", tt.want, query)
+			}
+			if strings.Contains(query, "{{") {
+				t.Errorf("query has unexpanded placeholders:\n%s", query)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateUndeclaredPlaceholder(t *testing.T) {
+	tpl := QueryTemplate{Name: "broken", Query: "SELECT [System.Id] FROM WorkItems WHERE [System.Title] = '{{title}}'"}
+	if _, err := tpl.Render("proj", nil); err == nil || !strings.Contains(err.Error(), "{{title}}") {
+		t.Errorf("Render() error = %v, want undeclared placeholder error", err)
+	}
+}
+
+func TestLoadQueryTemplatesOverridesDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	data := `[
+		{"name": "blocked", "description": "Impedidos", "query": "SELECT [System.Id] FROM WorkItems WHERE [Custom.Impediment] = 'Yes'"},
+		{"name": "my-bugs", "query": "SELECT [System.Id] FROM WorkItems WHERE [System.WorkItemType] = 'Bug'"}
+	]`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := LoadQueryTemplates(path)
+	if err != nil {
+		t.Fatalf("LoadQueryTemplates() error = %v", err)
+	}
+	if got := templates["blocked"].Description; got != "Impedidos" {
+		t.Errorf("blocked was not overridden: %q", got)
+	}
+	if _, ok := templates["my-bugs"]; !ok {
+		t.Error("custom template was not added")
+	}
+	if _, ok := templates["in-sprint"]; !ok {
+		t.Error("default templates were dropped")
+	}
+}
+
+func TestRunTemplateTool(t *testing.T) {
+	var query string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/wiql"):
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			query = body["query"]
+			w.Write([]byte(`{"workItems":[{"id":5}]}`))
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/workitemsbatch"):
+			w.Write([]byte(`{"count":1,"value":[{"id":5,"fields":{"System.Title":"Ship it","System.State":"Closed","System.WorkItemType":"Task"}}]}`))
+		}
+	})
+
+	result, handled, err := NewTool(c).Execute(context.Background(), "devops_run_template", map[string]interface{}{
+		"template": "recently-closed",
+		"params":   map[string]interface{}{"days": float64(30)},
+	})
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+	if !strings.Contains(query, "@Today - 30") {
+		t.Errorf("query = %s", query)
+	}
+	if !strings.Contains(result, "#5 [Task] Ship it") {
+		t.Errorf("result = %s", result)
+	}
+}
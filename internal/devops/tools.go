@@ -4,19 +4,86 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/abelclopes/nomad-iabot/internal/clock"
 	"github.com/abelclopes/nomad-iabot/internal/llm"
 	"github.com/abelclopes/nomad-iabot/internal/skills"
 )
 
 // Tool represents an Azure DevOps tool for the LLM
 type Tool struct {
-	client *Client
+	client    *Client
+	templates map[string]QueryTemplate
+	clock     clock.Clock
+	location  *time.Location
+
+	// Applied by devops_create_workitem when the model omits them
+	defaultType     string
+	defaultPriority int
+
+	snippetChars int // text shown per search hit or mention
 }
 
+// Work item type and priority used when creating without them
+const (
+	DefaultWorkItemType = "Task"
+	DefaultPriority     = 2
+)
+
+// DefaultSnippetChars is how much text a search hit or mention shows
+const DefaultSnippetChars = 200
+
 // NewTool creates a new DevOps tool
 func NewTool(client *Client) *Tool {
-	return &Tool{client: client}
+	return &Tool{
+		client:          client,
+		templates:       DefaultQueryTemplates(),
+		clock:           clock.Real(),
+		location:        time.Local,
+		defaultType:     DefaultWorkItemType,
+		defaultPriority: DefaultPriority,
+		snippetChars:    DefaultSnippetChars,
+	}
+}
+
+// SetCreateDefaults sets the type and priority of created work items that
+// name none. Empty or zero values keep the current defaults.
+func (t *Tool) SetCreateDefaults(workItemType string, priority int) {
+	if workItemType != "" {
+		t.defaultType = workItemType
+	}
+	if priority > 0 {
+		t.defaultPriority = priority
+	}
+}
+
+// SetSnippetChars sets how many characters of text a search hit or mention
+// shows. Zero or negative values keep the current limit.
+func (t *Tool) SetSnippetChars(n int) {
+	if n > 0 {
+		t.snippetChars = n
+	}
+}
+
+// SetClock replaces the clock used to resolve relative dates
+func (t *Tool) SetClock(c clock.Clock) {
+	t.clock = c
+}
+
+// SetLocation sets the time zone relative dates are interpreted in
+func (t *Tool) SetLocation(loc *time.Location) {
+	t.location = loc
+}
+
+// SetQueryTemplates replaces the templates offered by devops_run_template
+func (t *Tool) SetQueryTemplates(templates map[string]QueryTemplate) {
+	t.templates = templates
 }
 
 // GetToolDefinitions returns the tool definitions for the LLM
@@ -61,7 +128,7 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 					"properties": map[string]interface{}{
 						"type": map[string]interface{}{
 							"type":        "string",
-							"description": "Work item type: Task, Bug, User Story, Feature, Epic",
+							"description": "Work item type: Task, Bug, User Story, Feature, Epic. Omit to use the configured default",
 							"enum":        []string{"Task", "Bug", "User Story", "Feature", "Epic"},
 						},
 						"title": map[string]interface{}{
@@ -78,7 +145,7 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 						},
 						"priority": map[string]interface{}{
 							"type":        "integer",
-							"description": "Priority (1=highest, 4=lowest)",
+							"description": "Priority (1=highest, 4=lowest). Omit to use the configured default",
 							"enum":        []int{1, 2, 3, 4},
 						},
 						"tags": map[string]interface{}{
@@ -90,8 +157,12 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 							"type":        "integer",
 							"description": "Parent work item ID (for hierarchy)",
 						},
+						"idempotency_key": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional unique key for this request (letters, digits, '.', '_', ':' or '-'). Retrying with the same key returns the work item created the first time instead of a duplicate",
+						},
 					},
-					"required": []string{"type", "title"},
+					"required": []string{"title"},
 				},
 			},
 		},
@@ -128,6 +199,27 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_close_workitem_by_title",
+				Description: "Close (or resolve) a work item the user names by title instead of ID, e.g. 'close the login bug'. Closes it only when exactly one open item matches; otherwise returns the candidates so the user can pick an ID",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"title": map[string]interface{}{
+							"type":        "string",
+							"description": "Words from the work item title",
+						},
+						"state": map[string]interface{}{
+							"type":        "string",
+							"description": "Final state: Closed (default) or Resolved",
+						},
+					},
+					"required": []string{"title"},
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: llm.ToolFunction{
@@ -145,6 +237,106 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_search_workitems",
+				Description: "Search Azure DevOps work items by free text (title, description, comments). Prefer this over WIQL when the user describes an item in their own words",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"text": map[string]interface{}{
+							"type":        "string",
+							"description": "Words to search for, e.g. 'login timeout'",
+						},
+					},
+					"required": []string{"text"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_workitems_in_range",
+				Description: "List work items changed within a date range, grouped by state. Useful for standups and retrospectives.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"from": map[string]interface{}{
+							"type":        "string",
+							"description": "First day, as YYYY-MM-DD or relative: today, yesterday, '7 days ago', '2 weeks ago'",
+						},
+						"to": map[string]interface{}{
+							"type":        "string",
+							"description": "Last day, inclusive, in the same formats (optional, defaults to today)",
+						},
+					},
+					"required": []string{"from"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_state_summary",
+				Description: "Count work items by state (e.g. New: 4, Active: 7, Resolved: 2) for a quick dashboard, optionally only those assigned to me, in a sprint or with a tag",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"assigned_to_me": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Only work items assigned to the current user",
+						},
+						"sprint": map[string]interface{}{
+							"type":        "string",
+							"description": "Only work items in this sprint (name, iteration path or 'current')",
+						},
+						"team": map[string]interface{}{
+							"type":        "string",
+							"description": "Team whose sprints are searched (optional, defaults to project default team)",
+						},
+						"tag": map[string]interface{}{
+							"type":        "string",
+							"description": "Only work items with this tag",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_list_templates",
+				Description: "List the named work item query templates and their parameters. Prefer a template over writing WIQL when one fits",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+					"required":   []string{},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_run_template",
+				Description: "Run a named work item query template (see devops_list_templates), e.g. my-active, recently-closed, blocked, in-sprint",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"template": map[string]interface{}{
+							"type":        "string",
+							"description": "Template name",
+						},
+						"params": map[string]interface{}{
+							"type":        "object",
+							"description": "Template parameters by name, e.g. {\"days\": 14}. Omitted parameters use their defaults",
+						},
+					},
+					"required": []string{"template"},
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: llm.ToolFunction{
@@ -171,18 +363,54 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 						},
 						"branch": map[string]interface{}{
 							"type":        "string",
-							"description": "Git branch to run the pipeline on (e.g., refs/heads/main)",
-							"default":     "refs/heads/main",
+							"description": "Git branch to run the pipeline on (e.g., refs/heads/develop). Omit to use the pipeline's default branch",
 						},
 						"variables": map[string]interface{}{
 							"type":        "object",
-							"description": "Pipeline variables as key-value pairs",
+							"description": "Pipeline variables as key-value pairs. Only variables the pipeline declares as settable at queue time are applied",
+						},
+						"strict_variables": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Refuse to run when a variable is not declared by the pipeline, instead of ignoring it",
 						},
 					},
 					"required": []string{"pipeline_id"},
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_list_artifacts",
+				Description: "List the artifacts produced by a pipeline run (build), or get a time-limited download link for one of them",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"build_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "The build (pipeline run) ID",
+						},
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "Artifact name to get a download link for (optional; omit to list all artifacts)",
+						},
+					},
+					"required": []string{"build_id"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_list_projects",
+				Description: "List the projects in the Azure DevOps organization with their descriptions",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+					"required":   []string{},
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: llm.ToolFunction{
@@ -195,6 +423,56 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_list_commits",
+				Description: "List the latest commits of a Git repository branch with author, date and message. Use it to answer what changed recently in a repository.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Repository name or ID",
+						},
+						"branch": map[string]interface{}{
+							"type":        "string",
+							"description": "Branch name, e.g. main or feature/login (optional, defaults to the repository's default branch)",
+						},
+						"top": map[string]interface{}{
+							"type":        "integer",
+							"description": "How many commits to return (default 10, max 50)",
+						},
+					},
+					"required": []string{"repo"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_commit_changes",
+				Description: "List the files added, edited, deleted or renamed by a commit, with the diffs of the first 5 files",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"repo": map[string]interface{}{
+							"type":        "string",
+							"description": "Repository name or ID",
+						},
+						"commit_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The commit ID (full SHA or as listed by devops_list_commits)",
+						},
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Only show the diff of this file, e.g. /src/auth.go (optional)",
+						},
+					},
+					"required": []string{"repo", "commit_id"},
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: llm.ToolFunction{
@@ -212,6 +490,282 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_board_status",
+				Description: "Show a Kanban health snapshot of a board: work items per column and columns over their WIP limit",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"board": map[string]interface{}{
+							"type":        "string",
+							"description": "Board name, e.g. 'Stories' (see devops_list_boards)",
+						},
+						"team": map[string]interface{}{
+							"type":        "string",
+							"description": "Team name (optional, defaults to project default team)",
+						},
+					},
+					"required": []string{"board"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_reassign_workitems",
+				Description: "Reassign all open work items of one user to another (e.g. when someone goes on leave). Always call first without confirm to preview the affected items, show them to the user, and only call again with confirm=true after the user agrees.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"from_user": map[string]interface{}{
+							"type":        "string",
+							"description": "Current assignee (display name or email)",
+						},
+						"to_user": map[string]interface{}{
+							"type":        "string",
+							"description": "New assignee (display name or email)",
+						},
+						"states": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string"},
+							"description": "Only reassign items in these states (optional, defaults to all open states)",
+						},
+						"confirm": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Set to true to apply the changes; otherwise only a preview is returned",
+						},
+					},
+					"required": []string{"from_user", "to_user"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_bulk_comment",
+				Description: "Post the same comment to several work items, e.g. for release notes or sprint wrap-ups. Use dry_run first to show the user what would be posted.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"ids": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "integer"},
+							"description": "Work item IDs (at most 50)",
+						},
+						"comment": map[string]interface{}{
+							"type":        "string",
+							"description": "Comment text; {id} is replaced by each work item's ID",
+						},
+						"dry_run": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Only show the comments that would be posted",
+						},
+					},
+					"required": []string{"ids", "comment"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_assign_to_sprint",
+				Description: "Move a work item to a sprint (iteration) of the team, by sprint name, nested path such as 'Release 1\\Sprint 3', or 'current'",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id": map[string]interface{}{
+							"type":        "integer",
+							"description": "Work item ID",
+						},
+						"sprint": map[string]interface{}{
+							"type":        "string",
+							"description": "Sprint name, iteration path or 'current'",
+						},
+						"team": map[string]interface{}{
+							"type":        "string",
+							"description": "Team name (optional, defaults to project default team)",
+						},
+					},
+					"required": []string{"id", "sprint"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_list_team_members",
+				Description: "List the members of an Azure DevOps team with their display names and emails (useful before assigning work items)",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"team": map[string]interface{}{
+							"type":        "string",
+							"description": "Team name (optional, defaults to project default team)",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_assign_to_me",
+				Description: "Assign a work item to the user the Azure DevOps integration is signed in as; use it when the user says 'assign this to me' instead of asking for their email",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id": map[string]interface{}{
+							"type":        "integer",
+							"description": "Work item ID",
+						},
+					},
+					"required": []string{"id"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_set_effort",
+				Description: "Set the story points or effort estimate of a work item",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id": map[string]interface{}{
+							"type":        "integer",
+							"description": "Work item ID",
+						},
+						"value": map[string]interface{}{
+							"type":        "number",
+							"description": "Estimate, a non-negative number",
+						},
+						"field": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"story_points", "effort"},
+							"description": "Field to set; when omitted Story Points is used, or Effort for work item types without it",
+						},
+					},
+					"required": []string{"id", "value"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_get_wiki_page",
+				Description: "Read a page of the Azure DevOps wiki as Markdown, to answer from the team's internal documentation",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Page path, e.g. '/Onboarding/Dev Setup'",
+						},
+						"wiki": map[string]interface{}{
+							"type":        "string",
+							"description": "Wiki name or ID (optional, defaults to the project wiki)",
+						},
+					},
+					"required": []string{"path"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_get_pipeline_yaml",
+				Description: "Show the YAML definition of a pipeline, read from its repository",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"pipeline_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "Pipeline ID",
+						},
+					},
+					"required": []string{"pipeline_id"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_get_children",
+				Description: "Show the child work items of a work item as a tree, e.g. the features and stories under an epic",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id": map[string]interface{}{
+							"type":        "integer",
+							"description": "Work item ID",
+						},
+						"depth": map[string]interface{}{
+							"type":        "integer",
+							"description": "Levels of children to show (default 1, max 3)",
+						},
+					},
+					"required": []string{"id"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_get_parent",
+				Description: "Show the parent of a work item and the hierarchy above it, up to the epic",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id": map[string]interface{}{
+							"type":        "integer",
+							"description": "Work item ID",
+						},
+					},
+					"required": []string{"id"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_my_mentions",
+				Description: "List recent work item comments that @mention the current user, with links to the work items",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"days": map[string]interface{}{
+							"type":        "integer",
+							"description": "How many days back to look (default 7, max 30)",
+						},
+					},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "devops_create_subtasks",
+				Description: "Break a work item (e.g. a user story) down into tasks: create one Task per title as a child of the parent. Returns the created IDs and any failures.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"parent_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "ID of the parent work item",
+						},
+						"titles": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string"},
+							"description": "Task titles, one task each (at most 50)",
+						},
+					},
+					"required": []string{"parent_id", "titles"},
+				},
+			},
+		},
 	}
 }
 
@@ -230,20 +784,88 @@ func (t *Tool) Execute(ctx context.Context, name string, args map[string]interfa
 	case "devops_update_workitem":
 		result, err := t.updateWorkItem(ctx, args)
 		return result, true, err
+	case "devops_close_workitem_by_title":
+		result, err := t.closeWorkItemByTitle(ctx, args)
+		return result, true, err
 	case "devops_query_workitems":
 		result, err := t.queryWorkItems(ctx, args)
 		return result, true, err
+	case "devops_search_workitems":
+		result, err := t.searchWorkItems(ctx, args)
+		return result, true, err
+	case "devops_workitems_in_range":
+		result, err := t.workItemsInRange(ctx, args)
+		return result, true, err
+	case "devops_state_summary":
+		result, err := t.stateSummary(ctx, args)
+		return result, true, err
+	case "devops_list_templates":
+		return t.listTemplates(), true, nil
+	case "devops_run_template":
+		result, err := t.runTemplate(ctx, args)
+		return result, true, err
 	case "devops_list_pipelines":
 		result, err := t.listPipelines(ctx)
 		return result, true, err
 	case "devops_run_pipeline":
 		result, err := t.runPipeline(ctx, args)
 		return result, true, err
+	case "devops_list_artifacts":
+		result, err := t.listArtifacts(ctx, args)
+		return result, true, err
+	case "devops_list_projects":
+		result, err := t.listProjects(ctx)
+		return result, true, err
 	case "devops_list_repos":
 		result, err := t.listRepos(ctx)
 		return result, true, err
-	case "devops_list_boards":
-		result, err := t.listBoards(ctx, args)
+	case "devops_list_commits":
+		result, err := t.listCommits(ctx, args)
+		return result, true, err
+	case "devops_commit_changes":
+		result, err := t.commitChanges(ctx, args)
+		return result, true, err
+	case "devops_list_boards":
+		result, err := t.listBoards(ctx, args)
+		return result, true, err
+	case "devops_board_status":
+		result, err := t.boardStatus(ctx, args)
+		return result, true, err
+	case "devops_list_team_members":
+		result, err := t.listTeamMembers(ctx, args)
+		return result, true, err
+	case "devops_reassign_workitems":
+		result, err := t.reassignWorkItems(ctx, args)
+		return result, true, err
+	case "devops_bulk_comment":
+		result, err := t.bulkComment(ctx, args)
+		return result, true, err
+	case "devops_assign_to_sprint":
+		result, err := t.assignToSprint(ctx, args)
+		return result, true, err
+	case "devops_get_wiki_page":
+		result, err := t.getWikiPage(ctx, args)
+		return result, true, err
+	case "devops_assign_to_me":
+		result, err := t.assignToMe(ctx, args)
+		return result, true, err
+	case "devops_set_effort":
+		result, err := t.setEffort(ctx, args)
+		return result, true, err
+	case "devops_get_pipeline_yaml":
+		result, err := t.getPipelineYAML(ctx, args)
+		return result, true, err
+	case "devops_get_children":
+		result, err := t.getChildren(ctx, args)
+		return result, true, err
+	case "devops_get_parent":
+		result, err := t.getParent(ctx, args)
+		return result, true, err
+	case "devops_my_mentions":
+		result, err := t.myMentions(ctx, args)
+		return result, true, err
+	case "devops_create_subtasks":
+		result, err := t.createSubtasks(ctx, args)
 		return result, true, err
 	default:
 		return "", false, nil
@@ -268,16 +890,62 @@ func (t *Tool) ExecuteTool(ctx context.Context, name string, arguments string) (
 		return t.createWorkItem(ctx, args)
 	case "devops_update_workitem":
 		return t.updateWorkItem(ctx, args)
+	case "devops_close_workitem_by_title":
+		return t.closeWorkItemByTitle(ctx, args)
 	case "devops_query_workitems":
 		return t.queryWorkItems(ctx, args)
+	case "devops_search_workitems":
+		return t.searchWorkItems(ctx, args)
+	case "devops_workitems_in_range":
+		return t.workItemsInRange(ctx, args)
+	case "devops_state_summary":
+		return t.stateSummary(ctx, args)
+	case "devops_list_templates":
+		return t.listTemplates(), nil
+	case "devops_run_template":
+		return t.runTemplate(ctx, args)
 	case "devops_list_pipelines":
 		return t.listPipelines(ctx)
 	case "devops_run_pipeline":
 		return t.runPipeline(ctx, args)
+	case "devops_list_artifacts":
+		return t.listArtifacts(ctx, args)
+	case "devops_list_projects":
+		return t.listProjects(ctx)
 	case "devops_list_repos":
 		return t.listRepos(ctx)
+	case "devops_list_commits":
+		return t.listCommits(ctx, args)
+	case "devops_commit_changes":
+		return t.commitChanges(ctx, args)
 	case "devops_list_boards":
 		return t.listBoards(ctx, args)
+	case "devops_board_status":
+		return t.boardStatus(ctx, args)
+	case "devops_list_team_members":
+		return t.listTeamMembers(ctx, args)
+	case "devops_reassign_workitems":
+		return t.reassignWorkItems(ctx, args)
+	case "devops_bulk_comment":
+		return t.bulkComment(ctx, args)
+	case "devops_assign_to_sprint":
+		return t.assignToSprint(ctx, args)
+	case "devops_get_wiki_page":
+		return t.getWikiPage(ctx, args)
+	case "devops_assign_to_me":
+		return t.assignToMe(ctx, args)
+	case "devops_set_effort":
+		return t.setEffort(ctx, args)
+	case "devops_get_pipeline_yaml":
+		return t.getPipelineYAML(ctx, args)
+	case "devops_get_children":
+		return t.getChildren(ctx, args)
+	case "devops_get_parent":
+		return t.getParent(ctx, args)
+	case "devops_my_mentions":
+		return t.myMentions(ctx, args)
+	case "devops_create_subtasks":
+		return t.createSubtasks(ctx, args)
 	default:
 		return "", fmt.Errorf("unknown tool: %s", name)
 	}
@@ -292,16 +960,19 @@ func (t *Tool) listMyWorkItems(ctx context.Context) (string, error) {
 }
 
 func (t *Tool) getWorkItem(ctx context.Context, args map[string]interface{}) (string, error) {
-	id, ok := args["id"].(float64)
-	if !ok {
-		return "", fmt.Errorf("id is required")
+	id, err := requireInt(args, "id")
+	if err != nil {
+		return "", err
 	}
 
-	item, err := t.client.GetWorkItem(ctx, int(id))
+	item, err := t.client.GetWorkItem(ctx, id)
 	if err != nil {
 		return "", err
 	}
-	return formatWorkItem(item), nil
+
+	// Comments are supplementary; the item is still reported if they can't be loaded
+	comments, total, _ := t.client.GetLatestWorkItemComments(ctx, item.ID, maxFormattedComments)
+	return formatWorkItemComments(item, comments, total), nil
 }
 
 func (t *Tool) createWorkItem(ctx context.Context, args map[string]interface{}) (string, error) {
@@ -310,9 +981,8 @@ func (t *Tool) createWorkItem(ctx context.Context, args map[string]interface{})
 		Title: getString(args, "title"),
 	}
 
-	// Validate work item type is not empty
 	if req.Type == "" {
-		return "", fmt.Errorf("work item type is required")
+		req.Type = t.defaultType
 	}
 	
 	// Validate work item type against allowed types
@@ -331,16 +1001,21 @@ func (t *Tool) createWorkItem(ctx context.Context, args map[string]interface{})
 	if assigned := getString(args, "assigned_to"); assigned != "" {
 		req.AssignedTo = assigned
 	}
-	if priority, ok := args["priority"].(float64); ok {
-		p := int(priority)
+	if args["priority"] != nil {
+		p, ok := getInt(args, "priority")
+		if !ok {
+			return "", fmt.Errorf("priority must be an integer")
+		}
 		// Validate priority is within allowed range
 		if !skills.ValidateDevOpsPriority(p) {
 			return "", fmt.Errorf("invalid priority: %d (allowed: 1-4)", p)
 		}
 		req.Priority = p
+	} else {
+		req.Priority = t.defaultPriority
 	}
-	if parentID, ok := args["parent_id"].(float64); ok {
-		req.ParentID = int(parentID)
+	if parentID, ok := getInt(args, "parent_id"); ok {
+		req.ParentID = parentID
 	}
 	if tags, ok := args["tags"].([]interface{}); ok {
 		for _, tag := range tags {
@@ -349,18 +1024,19 @@ func (t *Tool) createWorkItem(ctx context.Context, args map[string]interface{})
 			}
 		}
 	}
+	req.IdempotencyKey = getString(args, "idempotency_key")
 
 	item, err := t.client.CreateWorkItem(ctx, req)
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("Created work item #%d: %s", item.ID, item.Fields["System.Title"]), nil
+	return fmt.Sprintf("Created work item #%d: %s (Type: %s, Priority: %d)", item.ID, item.Fields["System.Title"], req.Type, req.Priority), nil
 }
 
 func (t *Tool) updateWorkItem(ctx context.Context, args map[string]interface{}) (string, error) {
-	id, ok := args["id"].(float64)
-	if !ok {
-		return "", fmt.Errorf("id is required")
+	id, err := requireInt(args, "id")
+	if err != nil {
+		return "", err
 	}
 
 	req := WorkItemUpdateRequest{}
@@ -378,8 +1054,11 @@ func (t *Tool) updateWorkItem(ctx context.Context, args map[string]interface{})
 	if assigned := getString(args, "assigned_to"); assigned != "" {
 		req.AssignedTo = &assigned
 	}
-	if priority, ok := args["priority"].(float64); ok {
-		p := int(priority)
+	if args["priority"] != nil {
+		p, ok := getInt(args, "priority")
+		if !ok {
+			return "", fmt.Errorf("priority must be an integer")
+		}
 		// Validate priority is within allowed range
 		if !skills.ValidateDevOpsPriority(p) {
 			return "", fmt.Errorf("invalid priority: %d (allowed: 1-4)", p)
@@ -387,19 +1066,150 @@ func (t *Tool) updateWorkItem(ctx context.Context, args map[string]interface{})
 		req.Priority = &p
 	}
 
-	item, err := t.client.UpdateWorkItem(ctx, int(id), req)
+	item, err := t.client.UpdateWorkItem(ctx, id, req)
 	if err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("Updated work item #%d: %s", item.ID, item.Fields["System.Title"]), nil
 }
 
+// finishedStates are states a work item cannot be closed from again
+var finishedStates = map[string]bool{"Closed": true, "Done": true, "Removed": true}
+
+func (t *Tool) closeWorkItemByTitle(ctx context.Context, args map[string]interface{}) (string, error) {
+	title := strings.TrimSpace(getString(args, "title"))
+	if title == "" {
+		return "", fmt.Errorf("title is required")
+	}
+	state := getString(args, "state")
+	if state == "" {
+		state = "Closed"
+	}
+	if !skills.ValidateDevOpsState(state) || (state != "Closed" && state != "Resolved") {
+		return "", fmt.Errorf("invalid state: %s (allowed: Resolved, Closed)", state)
+	}
+
+	found, err := t.client.SearchWorkItems(ctx, title)
+	if err != nil {
+		return "", err
+	}
+
+	// Only items that can still move to the target state are candidates
+	var candidates []WorkItem
+	for _, item := range found {
+		current, _ := item.Fields["System.State"].(string)
+		if finishedStates[current] || current == state {
+			continue
+		}
+		candidates = append(candidates, item)
+	}
+
+	// An exact title match wins over partial ones
+	if len(candidates) > 1 {
+		var exact []WorkItem
+		for _, item := range candidates {
+			if name, _ := item.Fields["System.Title"].(string); strings.EqualFold(strings.TrimSpace(name), title) {
+				exact = append(exact, item)
+			}
+		}
+		if len(exact) == 1 {
+			candidates = exact
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return fmt.Sprintf("No open work item matches %q.", title), nil
+	case 1:
+	default:
+		result := fmt.Sprintf("%d open work items match %q. Which one should be set to %s? Use devops_update_workitem with its ID.\n\n", len(candidates), title, state)
+		return result + strings.TrimPrefix(formatWorkItems(candidates), fmt.Sprintf("Found %d work items:\n\n", len(candidates))), nil
+	}
+
+	match := candidates[0]
+	item, err := t.client.UpdateWorkItem(ctx, match.ID, WorkItemUpdateRequest{State: &state})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Set work item #%d %q to %s (was %s).", item.ID, item.Fields["System.Title"], state, match.Fields["System.State"]), nil
+}
+
 func (t *Tool) queryWorkItems(ctx context.Context, args map[string]interface{}) (string, error) {
 	query := getString(args, "query")
-	if query == "" {
-		return "", fmt.Errorf("query is required")
+	if err := skills.ValidateWIQL(query); err != nil {
+		return "", err
+	}
+
+	items, err := t.client.QueryWorkItems(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	return formatWorkItems(items), nil
+}
+
+func (t *Tool) searchWorkItems(ctx context.Context, args map[string]interface{}) (string, error) {
+	text := strings.TrimSpace(getString(args, "text"))
+	if text == "" {
+		return "", fmt.Errorf("text is required")
+	}
+
+	items, err := t.client.SearchWorkItems(ctx, text)
+	if err != nil {
+		return "", err
+	}
+	return formatSearchResults(text, items, t.snippetChars), nil
+}
+
+func (t *Tool) listTemplates() string {
+	if len(t.templates) == 0 {
+		return "No query templates are configured."
+	}
+
+	result := fmt.Sprintf("%d query templates:\n\n", len(t.templates))
+	for _, tpl := range sortedTemplates(t.templates) {
+		result += fmt.Sprintf("- %s: %s\n", tpl.Name, tpl.Description)
+		for _, p := range tpl.Params {
+			line := fmt.Sprintf("  - %s", p.Name)
+			if p.Description != "" {
+				line += ": " + p.Description
+			}
+			if p.Default != "" {
+				line += fmt.Sprintf(" (default: %s)", p.Default)
+			}
+			result += line + "\n"
+		}
+	}
+	return result
+}
+
+func (t *Tool) runTemplate(ctx context.Context, args map[string]interface{}) (string, error) {
+	name := getString(args, "template")
+	if name == "" {
+		return "", fmt.Errorf("template is required")
+	}
+	tpl, ok := t.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown template %q (use devops_list_templates)", name)
 	}
 
+	params := make(map[string]string)
+	if raw, ok := args["params"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			switch v := v.(type) {
+			case string:
+				params[k] = v
+			case float64:
+				params[k] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				return "", fmt.Errorf("parameter %q must be a string or a number", k)
+			}
+		}
+	}
+
+	query, err := tpl.Render(t.client.project, params)
+	if err != nil {
+		return "", err
+	}
 	items, err := t.client.QueryWorkItems(ctx, query)
 	if err != nil {
 		return "", err
@@ -416,15 +1226,13 @@ func (t *Tool) listPipelines(ctx context.Context) (string, error) {
 }
 
 func (t *Tool) runPipeline(ctx context.Context, args map[string]interface{}) (string, error) {
-	pipelineID, ok := args["pipeline_id"].(float64)
-	if !ok {
-		return "", fmt.Errorf("pipeline_id is required")
+	pipelineID, err := requireInt(args, "pipeline_id")
+	if err != nil {
+		return "", err
 	}
 
+	// An empty branch is resolved from the pipeline's repository
 	branch := getString(args, "branch")
-	if branch == "" {
-		branch = "refs/heads/main"
-	}
 
 	var variables map[string]string
 	if vars, ok := args["variables"].(map[string]interface{}); ok {
@@ -436,11 +1244,29 @@ func (t *Tool) runPipeline(ctx context.Context, args map[string]interface{}) (st
 		}
 	}
 
-	run, err := t.client.RunPipeline(ctx, int(pipelineID), branch, variables)
+	strict, _ := args["strict_variables"].(bool)
+
+	run, err := t.client.RunPipelineChecked(ctx, pipelineID, branch, variables, strict)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("Started pipeline run #%d: %s on %s (state: %s)", run.ID, run.Name, run.Branch, run.State)
+	if len(run.AcceptedVariables) > 0 {
+		result += fmt.Sprintf("\nVariables applied: %s", strings.Join(run.AcceptedVariables, ", "))
+	}
+	if len(run.IgnoredVariables) > 0 {
+		result += fmt.Sprintf("\nVariables ignored (not declared by the pipeline): %s", strings.Join(run.IgnoredVariables, ", "))
+	}
+	return result, nil
+}
+
+func (t *Tool) listProjects(ctx context.Context) (string, error) {
+	projects, err := t.client.ListProjects(ctx)
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("Started pipeline run #%d: %s (state: %s)", run.ID, run.Name, run.State), nil
+	return formatProjects(projects), nil
 }
 
 func (t *Tool) listRepos(ctx context.Context) (string, error) {
@@ -451,6 +1277,70 @@ func (t *Tool) listRepos(ctx context.Context) (string, error) {
 	return formatRepos(repos), nil
 }
 
+// Commit listing limits
+const (
+	defaultCommitCount = 10
+	maxCommitCount     = 50
+)
+
+func (t *Tool) listCommits(ctx context.Context, args map[string]interface{}) (string, error) {
+	repo := getString(args, "repo")
+	if repo == "" {
+		return "", fmt.Errorf("repo is required")
+	}
+	top := defaultCommitCount
+	if n, ok := getInt(args, "top"); ok && n > 0 {
+		top = min(n, maxCommitCount)
+	}
+
+	branch := getString(args, "branch")
+	commits, err := t.client.GetCommits(ctx, repo, branch, top)
+	if err != nil {
+		return "", err
+	}
+	return formatCommits(repo, branch, commits), nil
+}
+
+func (t *Tool) commitChanges(ctx context.Context, args map[string]interface{}) (string, error) {
+	repo := getString(args, "repo")
+	commitID := getString(args, "commit_id")
+	if repo == "" || commitID == "" {
+		return "", fmt.Errorf("repo and commit_id are required")
+	}
+
+	changes, err := t.client.GetCommitChanges(ctx, repo, commitID)
+	if err != nil {
+		return "", err
+	}
+	path := getString(args, "path")
+	diffs, err := t.client.GetCommitDiffs(ctx, repo, commitID, changes.Changes, path)
+	if err != nil {
+		return "", err
+	}
+	return formatCommitChanges(commitID, changes, path, diffs), nil
+}
+
+func (t *Tool) listArtifacts(ctx context.Context, args map[string]interface{}) (string, error) {
+	buildID, err := requireInt(args, "build_id")
+	if err != nil {
+		return "", err
+	}
+
+	if name := getString(args, "name"); name != "" {
+		link, err := t.client.GetArtifactDownloadURL(ctx, buildID, name)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Download link for artifact '%s' of build %d (expires shortly, do not share):\n%s", name, buildID, link), nil
+	}
+
+	artifacts, err := t.client.ListBuildArtifacts(ctx, buildID)
+	if err != nil {
+		return "", err
+	}
+	return formatArtifacts(buildID, artifacts), nil
+}
+
 func (t *Tool) listBoards(ctx context.Context, args map[string]interface{}) (string, error) {
 	team := getString(args, "team")
 	boards, err := t.client.ListBoards(ctx, team)
@@ -460,6 +1350,63 @@ func (t *Tool) listBoards(ctx context.Context, args map[string]interface{}) (str
 	return formatBoards(boards), nil
 }
 
+func (t *Tool) boardStatus(ctx context.Context, args map[string]interface{}) (string, error) {
+	board := getString(args, "board")
+	if board == "" {
+		return "", fmt.Errorf("board is required")
+	}
+
+	columns, err := t.client.GetBoardStatus(ctx, getString(args, "team"), board)
+	if err != nil {
+		return "", err
+	}
+	return formatBoardStatus(board, columns), nil
+}
+
+func (t *Tool) listTeamMembers(ctx context.Context, args map[string]interface{}) (string, error) {
+	team := getString(args, "team")
+	members, err := t.client.ListTeamMembers(ctx, team)
+	if err != nil {
+		return "", err
+	}
+	return formatTeamMembers(members), nil
+}
+
+func (t *Tool) reassignWorkItems(ctx context.Context, args map[string]interface{}) (string, error) {
+	from := getString(args, "from_user")
+	to := getString(args, "to_user")
+	if from == "" || to == "" {
+		return "", fmt.Errorf("from_user and to_user are required")
+	}
+
+	var states []string
+	if raw, ok := args["states"].([]interface{}); ok {
+		for _, v := range raw {
+			if s, ok := v.(string); ok && s != "" {
+				states = append(states, s)
+			}
+		}
+	}
+
+	if confirm, _ := args["confirm"].(bool); !confirm {
+		items, err := t.client.FindAssignedWorkItems(ctx, from, states)
+		if err != nil {
+			return "", err
+		}
+		if len(items) == 0 {
+			return fmt.Sprintf("No work items assigned to %s match; nothing to reassign.", from), nil
+		}
+		return fmt.Sprintf("Dry run: %d work items would be reassigned from %s to %s.\n\n%s\nAsk the user to confirm, then call again with confirm=true.",
+			len(items), from, to, formatWorkItems(items)), nil
+	}
+
+	changed, err := t.client.ReassignWorkItems(ctx, from, to, states)
+	if err != nil {
+		return "", fmt.Errorf("reassigned %d work items before failing: %w", changed, err)
+	}
+	return fmt.Sprintf("Reassigned %d work items from %s to %s.", changed, from, to), nil
+}
+
 // Helper functions
 func getString(args map[string]interface{}, key string) string {
 	if v, ok := args[key].(string); ok {
@@ -468,6 +1415,53 @@ func getString(args map[string]interface{}, key string) string {
 	return ""
 }
 
+// getInt reads an integer argument. Providers send integers as JSON
+// numbers, json.Number or numeric strings ("42", "#42"), so all are
+// accepted; ok is false when the argument is missing or not a whole number.
+func getInt(args map[string]interface{}, key string) (int, bool) {
+	return toInt(args[key])
+}
+
+// RequireInt reads a required integer argument, accepting the encodings
+// models send (see toInt), for tools outside this package
+func RequireInt(args map[string]interface{}, key string) (int, error) {
+	return requireInt(args, key)
+}
+
+// requireInt is getInt for mandatory arguments, with an error that tells
+// a missing argument from a malformed one
+func requireInt(args map[string]interface{}, key string) (int, error) {
+	n, ok := getInt(args, key)
+	if !ok {
+		if args[key] != nil {
+			return 0, fmt.Errorf("%s must be an integer", key)
+		}
+		return 0, fmt.Errorf("%s is required", key)
+	}
+	return n, nil
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, false
+		}
+		return int(n), true
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	case string:
+		i, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(n), "#"))
+		return i, err == nil
+	}
+	return 0, false
+}
+
 func formatWorkItems(items []WorkItem) string {
 	if len(items) == 0 {
 		return "No work items found."
@@ -485,7 +1479,47 @@ func formatWorkItems(items []WorkItem) string {
 	return result
 }
 
-func formatWorkItem(item *WorkItem) string {
+// highlightReplacer turns the search API's hit markers into Markdown bold
+var highlightReplacer = strings.NewReplacer("<highlighthit>", "**", "</highlighthit>", "**")
+
+func formatSearchResults(text string, items []WorkItem, snippetChars int) string {
+	if len(items) == 0 {
+		return fmt.Sprintf("No work items match %q.", text)
+	}
+
+	result := fmt.Sprintf("Found %d work items matching %q:\n\n", len(items), text)
+	for _, item := range items {
+		result += fmt.Sprintf("- #%d [%s] %s (State: %s)\n",
+			item.ID,
+			item.Fields["System.WorkItemType"],
+			item.Fields["System.Title"],
+			item.Fields["System.State"],
+		)
+		for _, h := range item.Highlights {
+			result += fmt.Sprintf("  …%s…\n", clip(highlightReplacer.Replace(h), snippetChars))
+		}
+	}
+	return result
+}
+
+// clip shortens s to at most n characters, marking the cut with an ellipsis
+func clip(s string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
+
+// maxFormattedComments caps how many of the latest comments are reported
+const maxFormattedComments = 3
+
+func formatWorkItem(item *WorkItem, comments ...WorkItemComment) string {
+	return formatWorkItemComments(item, comments, len(comments))
+}
+
+// formatWorkItemComments is formatWorkItem for the latest comments of an
+// item that has totalComments of them
+func formatWorkItemComments(item *WorkItem, comments []WorkItemComment, totalComments int) string {
 	result := fmt.Sprintf("Work Item #%d\n", item.ID)
 	result += fmt.Sprintf("Type: %s\n", item.Fields["System.WorkItemType"])
 	result += fmt.Sprintf("Title: %s\n", item.Fields["System.Title"])
@@ -496,13 +1530,35 @@ func formatWorkItem(item *WorkItem) string {
 	}
 	
 	if desc, ok := item.Fields["System.Description"].(string); ok && desc != "" {
-		result += fmt.Sprintf("Description: %s\n", desc)
+		if text := HTMLToText(desc); text != "" {
+			result += fmt.Sprintf("Description: %s\n", text)
+		}
 	}
 	
 	if tags, ok := item.Fields["System.Tags"].(string); ok && tags != "" {
 		result += fmt.Sprintf("Tags: %s\n", tags)
 	}
 
+	for _, l := range effortLabels {
+		if v, ok := item.Fields[l.field].(float64); ok {
+			result += fmt.Sprintf("%s: %s\n", l.label, formatEffort(v))
+		}
+	}
+
+	if len(comments) > 0 {
+		result += fmt.Sprintf("\nLatest comments (%d of %d):\n", min(len(comments), maxFormattedComments), max(totalComments, len(comments)))
+		for i, cm := range comments {
+			if i == maxFormattedComments {
+				break
+			}
+			date := cm.CreatedDate
+			if len(date) >= 10 {
+				date = date[:10]
+			}
+			result += fmt.Sprintf("- %s (%s): %s\n", cm.CreatedBy.DisplayName, date, HTMLToText(cm.Text))
+		}
+	}
+
 	return result
 }
 
@@ -518,6 +1574,23 @@ func formatPipelines(pipelines []Pipeline) string {
 	return result
 }
 
+func formatProjects(projects []Project) string {
+	if len(projects) == 0 {
+		return "No projects found."
+	}
+
+	sort.Slice(projects, func(i, j int) bool { return strings.ToLower(projects[i].Name) < strings.ToLower(projects[j].Name) })
+	result := fmt.Sprintf("Found %d projects:\n\n", len(projects))
+	for _, p := range projects {
+		result += "- " + p.Name
+		if desc := strings.TrimSpace(p.Description); desc != "" {
+			result += ": " + desc
+		}
+		result += "\n"
+	}
+	return result
+}
+
 func formatRepos(repos []Repository) string {
 	if len(repos) == 0 {
 		return "No repositories found."
@@ -530,6 +1603,131 @@ func formatRepos(repos []Repository) string {
 	return result
 }
 
+// shortCommitID is the abbreviated form of a commit SHA shown to users
+func shortCommitID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// commitSummary returns the first line of a commit message
+func commitSummary(message string) string {
+	summary, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(summary)
+}
+
+func formatCommits(repo, branch string, commits []Commit) string {
+	where := repo
+	if branch != "" {
+		where += " (" + strings.TrimPrefix(branch, "refs/heads/") + ")"
+	}
+	if len(commits) == 0 {
+		return fmt.Sprintf("No commits found in %s.", where)
+	}
+
+	result := fmt.Sprintf("Latest %d commits in %s:\n\n", len(commits), where)
+	for _, c := range commits {
+		date := c.Author.Date
+		if len(date) >= 16 {
+			date = strings.Replace(date[:16], "T", " ", 1)
+		}
+		result += fmt.Sprintf("- %s %s — %s (%s)", shortCommitID(c.CommitID), date, commitSummary(c.Comment), c.Author.Name)
+		if len(c.ChangeCounts) > 0 {
+			result += fmt.Sprintf(" [+%d ~%d -%d]", c.ChangeCounts["Add"], c.ChangeCounts["Edit"], c.ChangeCounts["Delete"])
+		}
+		result += "\n"
+	}
+	return result
+}
+
+// maxFormattedChanges caps how many changed files are listed for a commit
+const maxFormattedChanges = 50
+
+func formatCommitChanges(commitID string, changes *CommitChanges, path string, diffs []FileDiff) string {
+	var files []CommitChange
+	for _, ch := range changes.Changes {
+		if !ch.Item.IsFolder {
+			files = append(files, ch)
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Sprintf("Commit %s changed no files.", shortCommitID(commitID))
+	}
+
+	result := fmt.Sprintf("Commit %s changed %d files:\n\n", shortCommitID(commitID), len(files))
+	for i, ch := range files {
+		if i == maxFormattedChanges {
+			result += fmt.Sprintf("… and %d more files (truncated)\n", len(files)-maxFormattedChanges)
+			break
+		}
+		result += fmt.Sprintf("- %s %s", ch.ChangeType, ch.Item.Path)
+		if ch.SourceServerItem != "" && ch.SourceServerItem != ch.Item.Path {
+			result += fmt.Sprintf(" (from %s)", ch.SourceServerItem)
+		}
+		result += "\n"
+	}
+	return result + formatFileDiffs(len(files), path, diffs)
+}
+
+func formatFileDiffs(files int, path string, diffs []FileDiff) string {
+	if len(diffs) == 0 {
+		if path != "" {
+			return fmt.Sprintf("\n%s was not changed by this commit.\n", path)
+		}
+		return ""
+	}
+
+	result := "\nDiffs"
+	if path == "" && files > len(diffs) {
+		result += fmt.Sprintf(" of the first %d files (pass path for another file)", len(diffs))
+	}
+	result += ":\n"
+	for _, d := range diffs {
+		result += fmt.Sprintf("\n%s (%s)", d.Path, d.ChangeType)
+		if d.Note != "" {
+			result += ": " + d.Note + "\n"
+			continue
+		}
+		result += ":\n```diff\n" + d.Diff + "\n```\n"
+		if d.Truncated {
+			result += fmt.Sprintf("(diff truncated after %d lines)\n", maxDiffLines)
+		}
+	}
+	return result
+}
+
+func formatArtifacts(buildID int, artifacts []Artifact) string {
+	if len(artifacts) == 0 {
+		return fmt.Sprintf("Build %d has no artifacts.", buildID)
+	}
+
+	result := fmt.Sprintf("Build %d has %d artifacts:\n\n", buildID, len(artifacts))
+	for _, a := range artifacts {
+		result += fmt.Sprintf("- %s (%s", a.Name, a.Resource.Type)
+		if size, err := strconv.ParseInt(a.Resource.Properties.ArtifactSize, 10, 64); err == nil && size > 0 {
+			result += fmt.Sprintf(", %s", formatBytes(size))
+		}
+		result += ")\n"
+	}
+	result += "\nAsk for an artifact by name to get a download link."
+	return result
+}
+
+// formatBytes renders a size such as 1536 as "1.5 KB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func formatBoards(boards []Board) string {
 	if len(boards) == 0 {
 		return "No boards found."
@@ -541,3 +1739,43 @@ func formatBoards(boards []Board) string {
 	}
 	return result
 }
+
+func formatBoardStatus(board string, columns []BoardColumnStatus) string {
+	if len(columns) == 0 {
+		return fmt.Sprintf("Board %s has no columns.", board)
+	}
+
+	over := 0
+	result := fmt.Sprintf("Board %s:\n\n", board)
+	for _, col := range columns {
+		if col.ItemLimit > 0 {
+			result += fmt.Sprintf("- %s (%d/%d)", col.Name, col.Count, col.ItemLimit)
+		} else {
+			result += fmt.Sprintf("- %s (%d)", col.Name, col.Count)
+		}
+		if col.OverLimit() {
+			result += " ⚠️ over WIP"
+			over++
+		}
+		result += "\n"
+	}
+
+	if over > 0 {
+		result += fmt.Sprintf("\n%d column(s) over the WIP limit.", over)
+	} else {
+		result += "\nAll columns are within their WIP limits."
+	}
+	return result
+}
+
+func formatTeamMembers(members []Identity) string {
+	if len(members) == 0 {
+		return "No team members found."
+	}
+
+	result := fmt.Sprintf("Found %d team members:\n\n", len(members))
+	for _, m := range members {
+		result += fmt.Sprintf("- %s (%s)\n", m.DisplayName, m.UniqueName)
+	}
+	return result
+}
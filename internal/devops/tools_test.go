@@ -0,0 +1,600 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/skills"
+)
+
+func TestToolDefinitionsAreAllowlisted(t *testing.T) {
+	allowed := make(map[string]bool)
+	for _, cmd := range skills.GetAllowedDevOpsCommands() {
+		allowed[cmd] = true
+	}
+
+	for _, def := range NewTool(nil).GetToolDefinitions() {
+		if !allowed[def.Function.Name] {
+			t.Errorf("tool %q is not in GetAllowedDevOpsCommands()", def.Function.Name)
+		}
+	}
+}
+
+func TestListTeamMembers(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/_apis/projects/proj/teams/proj%20Team/members" {
+			t.Errorf("unexpected path %s", r.URL.EscapedPath())
+		}
+		w.Write([]byte(`{"value":[
+			{"identity":{"id":"1","displayName":"Ana Souza","uniqueName":"ana@example.com"},"isTeamAdmin":true},
+			{"identity":{"id":"2","displayName":"Bruno Lima","uniqueName":"bruno@example.com"}}
+		],"count":2}`))
+	})
+
+	result, handled, err := NewTool(c).Execute(context.Background(), "devops_list_team_members", map[string]interface{}{})
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+
+	for _, expected := range []string{"Found 2 team members", "- Ana Souza (ana@example.com)", "- Bruno Lima (bruno@example.com)"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("result missing %q:\n%s", expected, result)
+		}
+	}
+}
+
+func TestBoardStatusFlagsColumnsOverWIP(t *testing.T) {
+	var queries []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.EscapedPath() == "/proj%20Team/_apis/work/boards/Stories/columns":
+			w.Write([]byte(`{"count":3,"value":[
+				{"name":"New","itemLimit":0,"columnType":"incoming","stateMappings":{"User Story":"New","Bug":"New"}},
+				{"name":"Active","itemLimit":3,"columnType":"inProgress","stateMappings":{"User Story":"Active","Bug":"Active"}},
+				{"name":"Resolved","itemLimit":5,"columnType":"inProgress","stateMappings":{"User Story":"Resolved","Bug":"Resolved"}}
+			]}`))
+		case r.URL.EscapedPath() == "/proj%20Team/_apis/work/teamsettings/teamfieldvalues":
+			w.Write([]byte(`{"field":{"referenceName":"System.AreaPath"},"defaultValue":"proj\\Web","values":[
+				{"value":"proj\\Web","includeChildren":true},
+				{"value":"proj\\Shared","includeChildren":false}
+			]}`))
+		case r.URL.Path == "/_apis/wit/wiql":
+			var body struct{ Query string }
+			json.NewDecoder(r.Body).Decode(&body)
+			queries = append(queries, body.Query)
+			refs := 2
+			if strings.Contains(body.Query, "'Active'") {
+				refs = 5
+			}
+			items := make([]string, refs)
+			for i := range items {
+				items[i] = fmt.Sprintf(`{"id":%d}`, i+1)
+			}
+			w.Write([]byte(`{"workItems":[` + strings.Join(items, ",") + `]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	result, handled, err := NewTool(c).Execute(context.Background(), "devops_board_status", map[string]interface{}{"board": "Stories"})
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+
+	for _, want := range []string{"- New (2)\n", "- Active (5/3) ⚠️ over WIP", "- Resolved (2/5)\n", "1 column(s) over the WIP limit"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+	if len(queries) != 3 || !strings.Contains(queries[0], "[System.WorkItemType] IN ('Bug', 'User Story')") {
+		t.Errorf("queries = %q, expected one per column scoped to the board's types", queries)
+	}
+	for _, q := range queries {
+		if !strings.Contains(q, `AND ([System.AreaPath] UNDER 'proj\Web' OR [System.AreaPath] = 'proj\Shared')`) {
+			t.Errorf("query not scoped to the team's area paths:\n%s", q)
+		}
+	}
+}
+
+func TestListArtifacts(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_apis/build/builds/1234/artifacts" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"count":2,"value":[
+			{"id":7,"name":"drop","source":"3","resource":{"type":"Container","data":"#/1/drop",
+				"properties":{"localpath":"/home/vsts/work/1/a","artifactsize":"1572864"},
+				"url":"https://dev.azure.com/org/proj/_apis/build/builds/1234/artifacts?artifactName=drop",
+				"downloadUrl":"https://dev.azure.com/org/proj/_apis/build/builds/1234/artifacts?artifactName=drop&$format=zip"}},
+			{"id":8,"name":"coverage","source":"3","resource":{"type":"PipelineArtifact","data":"ABC123","properties":{}}}
+		]}`))
+	})
+
+	result, handled, err := NewTool(c).Execute(context.Background(), "devops_list_artifacts", map[string]interface{}{"build_id": float64(1234)})
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+	for _, want := range []string{"Build 1234 has 2 artifacts", "- drop (Container, 1.5 MB)", "- coverage (PipelineArtifact)"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestListArtifactsEmptyBuild(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"count":0,"value":[]}`))
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_list_artifacts", map[string]interface{}{"build_id": float64(99)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result != "Build 99 has no artifacts." {
+		t.Errorf("result = %q", result)
+	}
+}
+
+func TestGetArtifactDownloadURLIsSigned(t *testing.T) {
+	const signed = "https://artprodcus3.artifacts.visualstudio.com/A1/B2/_apis/artifact/cGlwZWxpbmVhcnRpZmFjdDo/content?format=zip&sig=abc"
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_apis/build/builds/1234":
+			w.Write([]byte(`{"id":1234,"buildNumber":"20240315.2","definition":{"id":42,"name":"ci"}}`))
+		case "/_apis/pipelines/42/runs/1234/artifacts":
+			q := r.URL.Query()
+			if q.Get("artifactName") != "drop" || q.Get("$expand") != "signedContent" {
+				t.Errorf("query = %v, want artifactName=drop and $expand=signedContent", q)
+			}
+			// The pipelines artifacts API is only available as a preview
+			if v := q.Get("api-version"); v != "7.0-preview.1" {
+				t.Errorf("api-version = %q, want 7.0-preview.1", v)
+			}
+			w.Write([]byte(`{"name":"drop","signedContent":{"url":"` + signed + `","signatureExpires":"2024-03-15T13:00:00Z"}}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_list_artifacts", map[string]interface{}{
+		"build_id": float64(1234),
+		"name":     "drop",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.Contains(result, signed) {
+		t.Errorf("result = %q, want the signed link", result)
+	}
+}
+
+// searchResponse is a work item search API response, trimmed to the fields we read
+const searchResponse = `{
+  "count": 2,
+  "results": [
+    {
+      "project": {"name": "proj", "id": "8c1b7b4e"},
+      "fields": {
+        "system.id": "1042",
+        "system.workitemtype": "Bug",
+        "system.title": "Login times out after SSO redirect",
+        "system.assignedto": "Ana Souza <ana@example.com>",
+        "system.state": "Active",
+        "system.tags": "auth",
+        "system.changeddate": "2024-03-12T18:21:43.193Z"
+      },
+      "hits": [
+        {"fieldReferenceName": "system.title", "highlights": ["<highlighthit>Login</highlighthit> times out after SSO redirect"]},
+        {"fieldReferenceName": "system.description", "highlights": ["the <highlighthit>login</highlighthit> page hangs"]}
+      ],
+      "url": "https://dev.azure.com/org/_apis/wit/workItems/1042"
+    },
+    {
+      "project": {"name": "proj", "id": "8c1b7b4e"},
+      "fields": {
+        "system.id": "987",
+        "system.workitemtype": "Task",
+        "system.title": "Add login audit log",
+        "system.state": "New"
+      },
+      "hits": [
+        {"fieldReferenceName": "system.title", "highlights": ["Add <highlighthit>login</highlighthit> audit log"]}
+      ],
+      "url": "https://dev.azure.com/org/_apis/wit/workItems/987"
+    }
+  ],
+  "facets": {}
+}`
+
+func TestSearchWorkItemsHighlightsMatches(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_apis/search/workitemsearchresults" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["searchText"] != "login" {
+			t.Errorf("searchText = %v", body["searchText"])
+		}
+		w.Write([]byte(searchResponse))
+	})
+
+	result, handled, err := NewTool(c).Execute(context.Background(), "devops_search_workitems", map[string]interface{}{"text": "login"})
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+
+	for _, expected := range []string{
+		`Found 2 work items matching "login"`,
+		"- #1042 [Bug] Login times out after SSO redirect (State: Active)",
+		"…**Login** times out after SSO redirect…",
+		"…the **login** page hangs…",
+		"- #987 [Task] Add login audit log (State: New)",
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("result missing %q:\n%s", expected, result)
+		}
+	}
+}
+
+func TestSearchWorkItemsFallsBackToWIQL(t *testing.T) {
+	var query, top string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/workitemsearchresults"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/wiql"):
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			query = body["query"]
+			top = r.URL.Query().Get("$top")
+			w.Write([]byte(`{"workItems":[{"id":7}]}`))
+		case strings.HasSuffix(r.URL.Path, "/_apis/wit/workitemsbatch"):
+			w.Write([]byte(`{"count":1,"value":[{"id":7,"fields":{"System.Title":"Ana's login bug","System.State":"New","System.WorkItemType":"Bug"}}]}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+
+	items, err := c.SearchWorkItems(context.Background(), "Ana's login")
+	if err != nil {
+		t.Fatalf("SearchWorkItems() error = %v", err)
+	}
+	if len(items) != 1 || items[0].ID != 7 {
+		t.Errorf("items = %+v", items)
+	}
+	if !strings.Contains(query, "[System.Title] CONTAINS 'Ana''s login'") {
+		t.Errorf("fallback query = %s", query)
+	}
+	if top != fmt.Sprint(maxSearchResults) {
+		t.Errorf("fallback $top = %q, want %d", top, maxSearchResults)
+	}
+}
+
+func TestCloseWorkItemByTitle(t *testing.T) {
+	searchResult := func(items ...string) string {
+		return `{"count":` + fmt.Sprint(len(items)) + `,"results":[` + strings.Join(items, ",") + `]}`
+	}
+	hit := func(id, title, state string) string {
+		return `{"fields":{"system.id":"` + id + `","system.title":"` + title + `","system.state":"` + state + `","system.workitemtype":"Bug"},"hits":[]}`
+	}
+
+	tests := []struct {
+		name      string
+		search    string
+		wantPatch string
+		want      []string
+	}{
+		{
+			name:      "single open match is closed",
+			search:    searchResult(hit("12", "Login fails on Safari", "Active"), hit("9", "Login page typo", "Closed")),
+			wantPatch: "/_apis/wit/workitems/12",
+			want:      []string{`Set work item #12 "Login fails on Safari" to Closed (was Active).`},
+		},
+		{
+			name:   "ambiguous match lists candidates",
+			search: searchResult(hit("12", "Login fails on Safari", "Active"), hit("15", "Login button misaligned", "New")),
+			want: []string{
+				`2 open work items match "login"`,
+				"- #12 [Bug] Login fails on Safari (State: Active)",
+				"- #15 [Bug] Login button misaligned (State: New)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patched string
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/workitemsearchresults"):
+					w.Write([]byte(tt.search))
+				case r.Method == http.MethodPatch:
+					patched = r.URL.Path
+					var ops []map[string]interface{}
+					json.NewDecoder(r.Body).Decode(&ops)
+					if len(ops) != 1 || ops[0]["path"] != "/fields/System.State" || ops[0]["value"] != "Closed" {
+						t.Errorf("patch = %v", ops)
+					}
+					w.Write([]byte(`{"id":12,"fields":{"System.Title":"Login fails on Safari","System.State":"Closed"}}`))
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+			})
+
+			result, handled, err := NewTool(c).Execute(context.Background(), "devops_close_workitem_by_title", map[string]interface{}{"title": "login"})
+			if !handled || err != nil {
+				t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+			}
+			if patched != tt.wantPatch {
+				t.Errorf("patched %q, want %q", patched, tt.wantPatch)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("result missing %q:\n%s", want, result)
+				}
+			}
+		})
+	}
+}
+
+func TestListCommitsEncodesBranch(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/_apis/git/repositories/My%20Api/commits" {
+			t.Errorf("unexpected path %s", r.URL.EscapedPath())
+		}
+		q := r.URL.Query()
+		if got := q.Get("searchCriteria.itemVersion.version"); got != "feature/login #2" {
+			t.Errorf("branch = %q", got)
+		}
+		if got := q.Get("searchCriteria.itemVersion.versionType"); got != "branch" {
+			t.Errorf("versionType = %q", got)
+		}
+		if got := q.Get("searchCriteria.$top"); got != "50" {
+			t.Errorf("$top = %q, want the capped count", got)
+		}
+		w.Write([]byte(`{"count":2,"value":[
+			{"commitId":"3f2a9c1b7d4e5f60718293a4b5c6d7e8f9012345",
+			 "author":{"name":"Ana Souza","email":"ana@example.com","date":"2024-03-14T16:02:11Z"},
+			 "committer":{"name":"Ana Souza","email":"ana@example.com","date":"2024-03-14T16:02:11Z"},
+			 "comment":"Fix login redirect loop\n\nThe callback kept the stale state cookie.",
+			 "changeCounts":{"Add":1,"Edit":3,"Delete":0},
+			 "remoteUrl":"https://dev.azure.com/org/proj/_git/My%20Api/commit/3f2a9c1b7d4e5f60718293a4b5c6d7e8f9012345"},
+			{"commitId":"a1b2c3d4e5f60718293a4b5c6d7e8f9012345678",
+			 "author":{"name":"Bruno Lima","email":"bruno@example.com","date":"2024-03-13T09:45:00Z"},
+			 "comment":"Add login form","commentTruncated":false}
+		]}`))
+	})
+
+	result, handled, err := NewTool(c).Execute(context.Background(), "devops_list_commits", map[string]interface{}{
+		"repo":   "My Api",
+		"branch": "refs/heads/feature/login #2",
+		"top":    float64(500),
+	})
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+	for _, want := range []string{
+		"Latest 2 commits in My Api (feature/login #2)",
+		"- 3f2a9c1b 2024-03-14 16:02 — Fix login redirect loop (Ana Souza) [+1 ~3 -0]",
+		"- a1b2c3d4 2024-03-13 09:45 — Add login form (Bruno Lima)\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "stale state cookie") {
+		t.Errorf("result includes the message body, want only the summary:\n%s", result)
+	}
+}
+
+func TestCommitChangesTruncatesLargeCommits(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_apis/git/repositories/api/commits/3f2a9c1b":
+			w.Write([]byte(`{"commitId":"3f2a9c1b","parents":["0a1b2c3d"]}`))
+			return
+		case "/_apis/git/repositories/api/items":
+			w.Write([]byte(`{"content":"package gen\n"}`))
+			return
+		case "/_apis/git/repositories/api/commits/3f2a9c1b/changes":
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		changes := []string{
+			`{"item":{"gitObjectType":"tree","path":"/src","isFolder":true},"changeType":"edit"}`,
+			`{"item":{"gitObjectType":"blob","path":"/src/auth.go"},"changeType":"rename, edit","sourceServerItem":"/src/login.go"}`,
+		}
+		for i := 0; i < 60; i++ {
+			changes = append(changes, fmt.Sprintf(`{"item":{"gitObjectType":"blob","path":"/gen/file%02d.go"},"changeType":"add"}`, i))
+		}
+		fmt.Fprintf(w, `{"changeCounts":{"Add":60,"Edit":1},"changes":[%s]}`, strings.Join(changes, ","))
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_commit_changes", map[string]interface{}{
+		"repo":      "api",
+		"commit_id": "3f2a9c1b",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	for _, want := range []string{
+		"Commit 3f2a9c1b changed 61 files",
+		"- rename, edit /src/auth.go (from /src/login.go)",
+		"- add /gen/file48.go",
+		"… and 11 more files (truncated)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "/gen/file49.go") || strings.Contains(result, "- edit /src\n") {
+		t.Errorf("result not truncated or lists folders:\n%s", result)
+	}
+}
+
+func TestIntegerArgumentEncodings(t *testing.T) {
+	encodings := map[string]interface{}{
+		"float64":        float64(2),
+		"json.Number":    json.Number("2"),
+		"numeric string": "2",
+		"hash string":    "#2",
+	}
+
+	for name, value := range encodings {
+		t.Run(name, func(t *testing.T) {
+			var paths []string
+			var patch []map[string]interface{}
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				if r.Method == "PATCH" {
+					json.NewDecoder(r.Body).Decode(&patch)
+				}
+				w.Write([]byte(`{"id":2,"fields":{"System.Title":"Fix login"}}`))
+			})
+			tool := NewTool(c)
+
+			if _, _, err := tool.Execute(context.Background(), "devops_get_workitem", map[string]interface{}{"id": value}); err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if len(paths) == 0 || paths[0] != "/_apis/wit/workitems/2" {
+				t.Errorf("get paths = %q", paths)
+			}
+
+			if _, _, err := tool.Execute(context.Background(), "devops_update_workitem", map[string]interface{}{
+				"id":       value,
+				"priority": value,
+			}); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if len(patch) != 1 || patch[0]["path"] != "/fields/Microsoft.VSTS.Common.Priority" || patch[0]["value"] != float64(2) {
+				t.Errorf("patch = %v", patch)
+			}
+		})
+	}
+}
+
+func TestIntegerArgumentErrors(t *testing.T) {
+	tool := NewTool(newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	}))
+
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{}, "id is required"},
+		{map[string]interface{}{"id": nil}, "id is required"},
+		{map[string]interface{}{"id": "abc"}, "id must be an integer"},
+		{map[string]interface{}{"id": 2.5}, "id must be an integer"},
+		{map[string]interface{}{"id": 2.0, "priority": "high"}, "priority must be an integer"},
+	}
+	for _, tt := range tests {
+		_, _, err := tool.Execute(context.Background(), "devops_update_workitem", tt.args)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("args %v: error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestCreateWorkItemDefaults(t *testing.T) {
+	var path string
+	var patch []map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&patch)
+		w.Write([]byte(`{"id":7,"fields":{"System.Title":"Broken login"}}`))
+	})
+	tool := NewTool(c)
+	tool.SetCreateDefaults("Bug", 3)
+
+	result, _, err := tool.Execute(context.Background(), "devops_create_workitem", map[string]interface{}{"title": "Broken login"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if path != "/_apis/wit/workitems/$Bug" {
+		t.Errorf("path = %q, expected the configured default type", path)
+	}
+	var priority interface{}
+	for _, op := range patch {
+		if op["path"] == "/fields/Microsoft.VSTS.Common.Priority" {
+			priority = op["value"]
+		}
+	}
+	if priority != 3.0 {
+		t.Errorf("priority = %v, expected the configured default 3", priority)
+	}
+	if expected := "Created work item #7: Broken login (Type: Bug, Priority: 3)"; result != expected {
+		t.Errorf("result = %q, expected %q", result, expected)
+	}
+
+	path = ""
+	_, _, err = tool.Execute(context.Background(), "devops_create_workitem", map[string]interface{}{"title": "x", "type": "Incident"})
+	if err == nil || !strings.Contains(err.Error(), "invalid work item type") {
+		t.Errorf("err = %v, expected an invalid type error", err)
+	}
+	if path != "" {
+		t.Errorf("invalid type reached the API (%s)", path)
+	}
+}
+
+func TestCommitChangesShowsDiffs(t *testing.T) {
+	files := map[string]string{
+		"/src/login.go@0a1b2c3d": "package src\n\nfunc login() {\n\tredirect(\"/home\")\n}\n",
+		"/src/auth.go@3f2a9c1b":  "package src\n\nfunc login() {\n\tclearState()\n\tredirect(\"/home\")\n}\n",
+		"/README.md@0a1b2c3d":    "# API\n",
+		"/README.md@3f2a9c1b":    "# API\n" + strings.Repeat("line\n", 200),
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_apis/git/repositories/api/commits/3f2a9c1b/changes":
+			w.Write([]byte(`{"changes":[
+				{"item":{"path":"/src/auth.go"},"changeType":"rename, edit","sourceServerItem":"/src/login.go"},
+				{"item":{"path":"/README.md"},"changeType":"edit"},
+				{"item":{"path":"/logo.png"},"changeType":"add"}
+			]}`))
+		case "/_apis/git/repositories/api/commits/3f2a9c1b":
+			w.Write([]byte(`{"commitId":"3f2a9c1b","parents":["0a1b2c3d"]}`))
+		case "/_apis/git/repositories/api/items":
+			q := r.URL.Query()
+			if q.Get("versionDescriptor.versionType") != "commit" || q.Get("$format") != "json" {
+				t.Errorf("items query = %v", q)
+			}
+			if q.Get("path") == "/logo.png" {
+				w.Write([]byte(`{"content":"","contentMetadata":{"isBinary":true}}`))
+				return
+			}
+			content, ok := files[q.Get("path")+"@"+q.Get("versionDescriptor.version")]
+			if !ok {
+				t.Errorf("unexpected file %s at %s", q.Get("path"), q.Get("versionDescriptor.version"))
+			}
+			json.NewEncoder(w).Encode(map[string]string{"content": content})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_commit_changes", map[string]interface{}{
+		"repo":      "api",
+		"commit_id": "3f2a9c1b",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	for _, want := range []string{
+		"/src/auth.go (rename, edit):\n```diff\n@@ -1,5 +1,6 @@\n package src\n \n func login() {\n+\tclearState()\n \tredirect(\"/home\")\n }\n```",
+		"/README.md (edit):\n```diff\n@@ -1,1 +1,201 @@\n # API\n+line\n",
+		"(diff truncated after 80 lines)",
+		"/logo.png (add): binary file",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+}
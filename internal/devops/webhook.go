@@ -0,0 +1,136 @@
+package devops
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+// WebhookSecretHeader is the custom header configured on the service hook
+// subscription to carry the shared secret
+const WebhookSecretHeader = "X-Webhook-Secret"
+
+// ServiceHookEvent is the payload Azure DevOps service hooks post to a
+// webhook. Resource layouts differ between event types, so the resource is
+// kept as a generic map and read defensively.
+type ServiceHookEvent struct {
+	ID          string                 `json:"id"`
+	EventType   string                 `json:"eventType"` // workitem.updated, build.complete, ...
+	PublisherID string                 `json:"publisherId"`
+	Message     *ServiceHookMessage    `json:"message,omitempty"`
+	Resource    map[string]interface{} `json:"resource"`
+	CreatedDate string                 `json:"createdDate"`
+}
+
+// ServiceHookMessage is the human-readable summary Azure DevOps includes in events
+type ServiceHookMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown"`
+}
+
+// VerifyWebhookSecret compares a received secret with the configured one in constant time
+func VerifyWebhookSecret(received, secret string) bool {
+	if secret == "" || received == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(received), []byte(secret)) == 1
+}
+
+// FormatServiceHookEvent renders an event as a short chat notification
+func FormatServiceHookEvent(e ServiceHookEvent) string {
+	r := e.Resource
+
+	switch e.EventType {
+	case "workitem.updated":
+		id := lookupInt(r, "workItemId")
+		title := lookupString(r, "revision", "fields", "System.Title")
+		itemType := lookupString(r, "revision", "fields", "System.WorkItemType")
+		if itemType == "" {
+			itemType = "Work item"
+		}
+		who := lookupString(r, "revisedBy", "displayName")
+		if who == "" {
+			who = "Alguém"
+		}
+
+		text := fmt.Sprintf("✏️ %s atualizou %s #%d: %s", who, itemType, id, title)
+		if newState := lookupString(r, "fields", "System.State", "newValue"); newState != "" {
+			oldState := lookupString(r, "fields", "System.State", "oldValue")
+			text += fmt.Sprintf("\nEstado: %s → %s", oldState, newState)
+		}
+		if assigned := lookupString(r, "fields", "System.AssignedTo", "newValue"); assigned != "" {
+			text += fmt.Sprintf("\nAtribuído a: %s", assigned)
+		}
+		return text
+
+	case "workitem.created":
+		return fmt.Sprintf("🆕 %s #%d criado: %s",
+			lookupString(r, "fields", "System.WorkItemType"),
+			lookupInt(r, "id"),
+			lookupString(r, "fields", "System.Title"),
+		)
+
+	case "build.complete":
+		icon := "❌"
+		result := lookupString(r, "result")
+		if result == "" {
+			result = lookupString(r, "status")
+		}
+		if result == "succeeded" {
+			icon = "✅"
+		} else if result == "partiallySucceeded" {
+			icon = "⚠️"
+		}
+
+		text := fmt.Sprintf("%s Build %s (%s): %s",
+			icon,
+			lookupString(r, "buildNumber"),
+			lookupString(r, "definition", "name"),
+			result,
+		)
+		if who := lookupString(r, "requestedFor", "displayName"); who != "" {
+			text += fmt.Sprintf("\nSolicitado por: %s", who)
+		}
+		if link := lookupString(r, "_links", "web", "href"); link != "" {
+			text += "\n" + link
+		}
+		return text
+	}
+
+	if e.Message != nil && e.Message.Text != "" {
+		return e.Message.Text
+	}
+	return fmt.Sprintf("Evento do Azure DevOps recebido: %s", e.EventType)
+}
+
+// lookup walks nested JSON objects, returning nil when any key is missing
+func lookup(m map[string]interface{}, path ...string) interface{} {
+	var current interface{} = m
+	for _, key := range path {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = obj[key]
+	}
+	return current
+}
+
+func lookupString(m map[string]interface{}, path ...string) string {
+	switch v := lookup(m, path...).(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		// Identity fields may be objects instead of "Name <email>" strings
+		if name, ok := v["displayName"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+func lookupInt(m map[string]interface{}, path ...string) int {
+	if v, ok := lookup(m, path...).(float64); ok {
+		return int(v)
+	}
+	return 0
+}
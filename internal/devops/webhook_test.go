@@ -0,0 +1,117 @@
+package devops
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const workItemUpdatedPayload = `{
+  "id": "27646e0e-b520-4d2b-9411-bba7524947cd",
+  "eventType": "workitem.updated",
+  "publisherId": "tfs",
+  "message": {"text": "Bug #5 (Some great new idea!) updated by Jamal Hartnett."},
+  "resource": {
+    "id": 2,
+    "workItemId": 5,
+    "rev": 2,
+    "revisedBy": {"id": "e5a5f7f8", "displayName": "Jamal Hartnett", "uniqueName": "fabrikamfiber4@hotmail.com"},
+    "fields": {
+      "System.Rev": {"oldValue": 1, "newValue": 2},
+      "System.State": {"oldValue": "New", "newValue": "Approved"},
+      "System.AssignedTo": {"newValue": {"displayName": "Ana Souza", "uniqueName": "ana@example.com"}}
+    },
+    "revision": {
+      "id": 5,
+      "rev": 2,
+      "fields": {
+        "System.WorkItemType": "Bug",
+        "System.Title": "Some great new idea!",
+        "System.State": "Approved"
+      }
+    }
+  },
+  "createdDate": "2014-07-15T17:42:44.663Z"
+}`
+
+const buildCompletePayload = `{
+  "id": "4a5d99d6-1c75-4e53-91b9-ee80057d4ce3",
+  "eventType": "build.complete",
+  "publisherId": "tfs",
+  "message": {"text": "Build ConsumerAddressModule_20150407.2 succeeded"},
+  "resource": {
+    "id": 2,
+    "buildNumber": "ConsumerAddressModule_20150407.2",
+    "status": "completed",
+    "result": "succeeded",
+    "definition": {"id": 1, "name": "ConsumerAddressModule"},
+    "requestedFor": {"displayName": "Normal Paulk", "uniqueName": "fabrikamfiber16@hotmail.com"},
+    "_links": {"web": {"href": "https://dev.azure.com/org/proj/_build/results?buildId=2"}}
+  },
+  "createdDate": "2015-04-07T18:06:43.541Z"
+}`
+
+func TestFormatServiceHookEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected []string
+	}{
+		{
+			name:    "Work item updated",
+			payload: workItemUpdatedPayload,
+			expected: []string{
+				"Jamal Hartnett atualizou Bug #5: Some great new idea!",
+				"Estado: New → Approved",
+				"Atribuído a: Ana Souza",
+			},
+		},
+		{
+			name:    "Build complete",
+			payload: buildCompletePayload,
+			expected: []string{
+				"✅ Build ConsumerAddressModule_20150407.2 (ConsumerAddressModule): succeeded",
+				"Solicitado por: Normal Paulk",
+				"https://dev.azure.com/org/proj/_build/results?buildId=2",
+			},
+		},
+		{
+			name:     "Unknown event falls back to message text",
+			payload:  `{"eventType":"git.push","message":{"text":"Jamal pushed 2 commits"},"resource":{"refUpdates":[]}}`,
+			expected: []string{"Jamal pushed 2 commits"},
+		},
+		{
+			name:     "Missing resource fields",
+			payload:  `{"eventType":"workitem.updated","resource":{"workItemId":"not-a-number"}}`,
+			expected: []string{"Alguém atualizou Work item #0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var event ServiceHookEvent
+			if err := json.Unmarshal([]byte(tt.payload), &event); err != nil {
+				t.Fatalf("failed to parse payload: %v", err)
+			}
+
+			result := FormatServiceHookEvent(event)
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("FormatServiceHookEvent() missing %q:\n%s", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyWebhookSecret(t *testing.T) {
+	if !VerifyWebhookSecret("s3cret-value", "s3cret-value") {
+		t.Error("VerifyWebhookSecret() rejected matching secret")
+	}
+	if VerifyWebhookSecret("wrong", "s3cret-value") {
+		t.Error("VerifyWebhookSecret() accepted wrong secret")
+	}
+	if VerifyWebhookSecret("", "") {
+		t.Error("VerifyWebhookSecret() accepted empty secret")
+	}
+}
@@ -0,0 +1,75 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// WhoAmI returns the identity the PAT authenticates as. The result is
+// cached, since it cannot change for the lifetime of the client.
+func (c *Client) WhoAmI(ctx context.Context) (*Identity, error) {
+	c.meMu.Lock()
+	defer c.meMu.Unlock()
+	if c.me != nil {
+		return c.me, nil
+	}
+
+	// connectionData is only available as a preview version
+	version := c.apiVersion
+	if !strings.Contains(version, "preview") {
+		version += "-preview"
+	}
+	endpoint := fmt.Sprintf("%s/_apis/connectionData?api-version=%s", c.orgURL, version)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AuthenticatedUser struct {
+			ID                  string `json:"id"`
+			ProviderDisplayName string `json:"providerDisplayName"`
+			Properties          struct {
+				Account struct {
+					Value string `json:"$value"`
+				} `json:"Account"`
+			} `json:"properties"`
+		} `json:"authenticatedUser"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode connection data: %w", err)
+	}
+
+	user := result.AuthenticatedUser
+	if user.Properties.Account.Value == "" {
+		return nil, fmt.Errorf("azure devops did not report the authenticated user's account")
+	}
+	c.me = &Identity{
+		ID:          user.ID,
+		DisplayName: user.ProviderDisplayName,
+		UniqueName:  user.Properties.Account.Value,
+	}
+	return c.me, nil
+}
+
+func (t *Tool) assignToMe(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, err := requireInt(args, "id")
+	if err != nil {
+		return "", err
+	}
+
+	me, err := t.client.WhoAmI(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the authenticated user: %w", err)
+	}
+
+	item, err := t.client.UpdateWorkItem(ctx, id, WorkItemUpdateRequest{AssignedTo: &me.UniqueName})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ Work item #%d (%v) assigned to %s", item.ID, item.Fields["System.Title"], me.DisplayName), nil
+}
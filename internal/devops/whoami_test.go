@@ -0,0 +1,51 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+const connectionData = `{"authenticatedUser":{"id":"6d3b-4a1f","descriptor":"Microsoft.IdentityModel.Claims.ClaimsIdentity;ana@contoso.com",` +
+	`"providerDisplayName":"Ana Silva","isActive":true,"properties":{"Account":{"$type":"System.String","$value":"ana@contoso.com"}}},` +
+	`"instanceId":"1c2d","deploymentType":"hosted"}`
+
+func TestAssignToMeUsesAuthenticatedUser(t *testing.T) {
+	var lookups int
+	var patch []map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/_apis/connectionData":
+			lookups++
+			if v := r.URL.Query().Get("api-version"); v != "7.0-preview" {
+				t.Errorf("api-version = %q", v)
+			}
+			w.Write([]byte(connectionData))
+		case r.Method == "PATCH" && r.URL.Path == "/_apis/wit/workitems/42":
+			json.NewDecoder(r.Body).Decode(&patch)
+			w.Write([]byte(`{"id":42,"fields":{"System.Title":"Fix login"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	tool := NewTool(c)
+	for i := 0; i < 2; i++ {
+		result, _, err := tool.Execute(context.Background(), "devops_assign_to_me", map[string]interface{}{"id": 42.0})
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		if want := "✅ Work item #42 (Fix login) assigned to Ana Silva"; result != want {
+			t.Errorf("result = %q, want %q", result, want)
+		}
+	}
+
+	if len(patch) != 1 || patch[0]["path"] != "/fields/System.AssignedTo" || patch[0]["value"] != "ana@contoso.com" {
+		t.Errorf("patch = %v", patch)
+	}
+	if lookups != 1 {
+		t.Errorf("identity looked up %d times, want it cached after the first", lookups)
+	}
+}
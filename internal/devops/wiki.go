@@ -0,0 +1,149 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/abelclopes/nomad-iabot/internal/redact"
+)
+
+// maxWikiPageChars bounds the page content handed to the LLM
+const maxWikiPageChars = 20000
+
+// ErrWikiPageNotFound is returned by GetWikiPage for a path the wiki does not have
+var ErrWikiPageNotFound = errors.New("wiki page not found")
+
+// Wiki is a project or code wiki
+type Wiki struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Type   string `json:"type"` // projectWiki or codeWiki
+	WebURL string `json:"remoteUrl"`
+}
+
+// ListWikis lists the wikis of the project
+func (c *Client) ListWikis(ctx context.Context) ([]Wiki, error) {
+	endpoint := fmt.Sprintf("%s/_apis/wiki/wikis?api-version=%s", c.baseURL, c.apiVersion)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Count int    `json:"count"`
+		Value []Wiki `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode wikis: %w", err)
+	}
+
+	return result.Value, nil
+}
+
+// GetWikiPage returns the Markdown content of the page at path (e.g.
+// /Onboarding/Setup) in the wiki with the given ID or name
+func (c *Client) GetWikiPage(ctx context.Context, wikiID, path string) (string, error) {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	endpoint := fmt.Sprintf("%s/_apis/wiki/wikis/%s/pages?path=%s&includeContent=true&api-version=%s",
+		c.baseURL, url.PathEscape(wikiID), url.QueryEscape(path), c.apiVersion)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Basic "+c.basicAuth())
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", redact.Error(err, c.pat, c.basicAuth()))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("%w: %s", ErrWikiPageNotFound, path)
+		}
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, c.redact(string(bodyBytes)))
+	}
+
+	var page struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return "", fmt.Errorf("failed to decode wiki page: %w", err)
+	}
+
+	return page.Content, nil
+}
+
+func (t *Tool) getWikiPage(ctx context.Context, args map[string]interface{}) (string, error) {
+	path := strings.TrimSpace(getString(args, "path"))
+	if path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	wiki := getString(args, "wiki")
+	if wiki == "" {
+		wikis, err := t.client.ListWikis(ctx)
+		if err != nil {
+			return "", err
+		}
+		w := defaultWiki(wikis)
+		if w == nil {
+			return "", fmt.Errorf("the project has no wiki")
+		}
+		wiki = w.ID
+	}
+
+	content, err := t.client.GetWikiPage(ctx, wiki, path)
+	if errors.Is(err, ErrWikiPageNotFound) {
+		return fmt.Sprintf("No wiki page at %s. Check the path; pages are addressed like /Parent/Page.", path), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return formatWikiPage(path, content), nil
+}
+
+// defaultWiki picks the project wiki, or the first code wiki when there is none
+func defaultWiki(wikis []Wiki) *Wiki {
+	for i := range wikis {
+		if wikis[i].Type == "projectWiki" {
+			return &wikis[i]
+		}
+	}
+	if len(wikis) > 0 {
+		return &wikis[0]
+	}
+	return nil
+}
+
+func formatWikiPage(path, content string) string {
+	if strings.TrimSpace(content) == "" {
+		return fmt.Sprintf("📄 %s is empty", path)
+	}
+
+	result := fmt.Sprintf("📄 %s\n\n", path)
+	if n := utf8.RuneCountInString(content); n > maxWikiPageChars {
+		runes := []rune(content)
+		return result + string(runes[:maxWikiPageChars]) +
+			fmt.Sprintf("\n\n… (truncated, %d more characters)", n-maxWikiPageChars)
+	}
+	return result + content
+}
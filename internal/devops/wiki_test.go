@@ -0,0 +1,75 @@
+package devops
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const projectWikis = `{"count":2,"value":[
+  {"id":"c0de","name":"Web.code","type":"codeWiki","remoteUrl":"https://dev.azure.com/org/proj/_wiki/wikis/Web.code"},
+  {"id":"9f1b","name":"proj.wiki","type":"projectWiki","remoteUrl":"https://dev.azure.com/org/proj/_wiki/wikis/proj.wiki"}
+]}`
+
+const wikiPage = `{"path":"/Onboarding/Dev Setup","order":0,"gitItemPath":"/Onboarding/Dev-Setup.md",` +
+	`"isParentPage":false,"remoteUrl":"https://dev.azure.com/org/proj/_wiki/wikis/proj.wiki?pagePath=%2FOnboarding%2FDev+Setup",` +
+	`"content":"# Dev Setup\n\n1. Install Go 1.22\n2. Run ` + "`make dev`" + `"}`
+
+func TestGetWikiPageUsesProjectWiki(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_apis/wiki/wikis":
+			w.Write([]byte(projectWikis))
+		case "/_apis/wiki/wikis/9f1b/pages":
+			if got := r.URL.Query().Get("path"); got != "/Onboarding/Dev Setup" {
+				t.Errorf("path = %q", got)
+			}
+			if r.URL.Query().Get("includeContent") != "true" {
+				t.Error("page requested without content")
+			}
+			w.Write([]byte(wikiPage))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_get_wiki_page", map[string]interface{}{
+		"path": "Onboarding/Dev Setup",
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if want := "📄 /Onboarding/Dev Setup\n\n# Dev Setup\n\n1. Install Go 1.22\n2. Run `make dev`"; result != want {
+		t.Errorf("result = %q, want %q", result, want)
+	}
+}
+
+func TestGetWikiPageNotFound(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"The page '/Missing' specified in the add operation doesn't exist in the wiki.","typeKey":"WikiPageNotFoundException"}`))
+	})
+
+	_, err := c.GetWikiPage(context.Background(), "proj.wiki", "/Missing")
+	if !errors.Is(err, ErrWikiPageNotFound) {
+		t.Fatalf("GetWikiPage() error = %v, want ErrWikiPageNotFound", err)
+	}
+
+	result, _, err := NewTool(c).Execute(context.Background(), "devops_get_wiki_page", map[string]interface{}{
+		"path": "/Missing",
+		"wiki": "proj.wiki",
+	})
+	if err != nil || !strings.Contains(result, "No wiki page at /Missing") {
+		t.Errorf("Execute() = %q, %v", result, err)
+	}
+}
+
+func TestFormatWikiPageTruncates(t *testing.T) {
+	result := formatWikiPage("/Big", strings.Repeat("é", maxWikiPageChars+10))
+	if !strings.HasSuffix(result, "… (truncated, 10 more characters)") {
+		t.Errorf("result ends with %q", result[len(result)-60:])
+	}
+}
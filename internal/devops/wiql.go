@@ -0,0 +1,66 @@
+package devops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/abelclopes/nomad-iabot/internal/redact"
+)
+
+// WIQLError is a query Azure DevOps rejected, with its explanation
+type WIQLError struct {
+	Message string
+}
+
+func (e *WIQLError) Error() string {
+	return "invalid WIQL: " + e.Message
+}
+
+// ValidateQuery has Azure DevOps parse and run query without fetching any
+// work item. With count set it returns how many work items match (Azure
+// DevOps stops at 20000); otherwise only one reference is requested and the
+// count is -1. A query Azure DevOps rejects returns a *WIQLError.
+func (c *Client) ValidateQuery(ctx context.Context, query string, count bool) (int, error) {
+	endpoint := fmt.Sprintf("%s/_apis/wit/wiql?api-version=%s", c.baseURL, c.apiVersion)
+	if !count {
+		endpoint += "&$top=1"
+	}
+
+	jsonBody, _ := json.Marshal(map[string]string{"query": query})
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Basic "+c.basicAuth())
+
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", redact.Error(err, c.pat, c.basicAuth()))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if resp.StatusCode == http.StatusBadRequest && json.Unmarshal(bodyBytes, &apiErr) == nil && apiErr.Message != "" {
+			return 0, &WIQLError{Message: c.redact(apiErr.Message)}
+		}
+		return 0, fmt.Errorf("API error (status %d): %s", resp.StatusCode, c.redact(string(bodyBytes)))
+	}
+
+	var result WorkItemQueryResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode query result: %w", err)
+	}
+	if !count {
+		return -1, nil
+	}
+	return len(result.WorkItems), nil
+}
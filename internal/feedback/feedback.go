@@ -0,0 +1,129 @@
+package feedback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/abelclopes/nomad-iabot/internal/devops"
+	"github.com/abelclopes/nomad-iabot/internal/trello"
+)
+
+// Tag marks every work item or card filed through the feedback mechanism
+const Tag = "bot-feedback"
+
+// maxTitleLength keeps generated titles readable in boards and backlogs
+const maxTitleLength = 80
+
+// ErrDisabled is returned when no feedback target is configured
+var ErrDisabled = errors.New("feedback is not configured")
+
+// WorkItemCreator is the subset of the Azure DevOps client used for feedback
+type WorkItemCreator interface {
+	CreateWorkItem(ctx context.Context, req devops.WorkItemCreateRequest) (*devops.WorkItem, error)
+	WorkItemWebURL(id int) string
+}
+
+// CardCreator is the subset of the Trello client used for feedback
+type CardCreator interface {
+	CreateCard(ctx context.Context, req trello.CreateCardRequest) (*trello.Card, error)
+}
+
+// Report is a piece of feedback about the bot itself
+type Report struct {
+	UserID  string
+	Channel string
+	Text    string
+}
+
+// Result describes the item created for a report
+type Result struct {
+	Target string `json:"target"` // "devops" or "trello"
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+}
+
+// Service files feedback reports into the configured integration
+type Service struct {
+	devops       WorkItemCreator
+	workItemType string
+	trello       CardCreator
+	listID       string
+}
+
+// NewDevOpsService creates a service that files feedback as work items
+func NewDevOpsService(client WorkItemCreator, workItemType string) *Service {
+	if workItemType == "" {
+		workItemType = "Bug"
+	}
+	return &Service{devops: client, workItemType: workItemType}
+}
+
+// NewTrelloService creates a service that files feedback as cards on listID
+func NewTrelloService(client CardCreator, listID string) *Service {
+	return &Service{trello: client, listID: listID}
+}
+
+// Submit files a report and returns a link to the created item
+func (s *Service) Submit(ctx context.Context, report Report) (*Result, error) {
+	if s == nil || (s.devops == nil && s.trello == nil) {
+		return nil, ErrDisabled
+	}
+
+	text := strings.TrimSpace(report.Text)
+	if text == "" {
+		return nil, fmt.Errorf("feedback text is required")
+	}
+
+	title := "Feedback: " + summarize(text)
+
+	if s.devops != nil {
+		desc := fmt.Sprintf("<p>%s</p><p>Reported by user <b>%s</b> via <b>%s</b>.</p>",
+			strings.ReplaceAll(html.EscapeString(text), "\n", "<br>"),
+			html.EscapeString(report.UserID),
+			html.EscapeString(report.Channel),
+		)
+		item, err := s.devops.CreateWorkItem(ctx, devops.WorkItemCreateRequest{
+			Type:        s.workItemType,
+			Title:       title,
+			Description: desc,
+			Tags:        []string{Tag},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create feedback work item: %w", err)
+		}
+		return &Result{
+			Target: "devops",
+			ID:     fmt.Sprintf("%d", item.ID),
+			URL:    s.devops.WorkItemWebURL(item.ID),
+		}, nil
+	}
+
+	card, err := s.trello.CreateCard(ctx, trello.CreateCardRequest{
+		ListID: s.listID,
+		Name:   "[" + Tag + "] " + title,
+		Desc:   fmt.Sprintf("%s\n\n---\nReported by user **%s** via **%s**.", text, report.UserID, report.Channel),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create feedback card: %w", err)
+	}
+	return &Result{
+		Target: "trello",
+		ID:     card.ID,
+		URL:    card.ShortURL,
+	}, nil
+}
+
+// summarize returns the first line of text, truncated for use as a title
+func summarize(text string) string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) > maxTitleLength {
+		return string(runes[:maxTitleLength-1]) + "…"
+	}
+	return string(runes)
+}
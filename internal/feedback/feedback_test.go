@@ -0,0 +1,110 @@
+package feedback
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/devops"
+	"github.com/abelclopes/nomad-iabot/internal/trello"
+)
+
+type fakeDevOps struct {
+	calls []devops.WorkItemCreateRequest
+}
+
+func (f *fakeDevOps) CreateWorkItem(ctx context.Context, req devops.WorkItemCreateRequest) (*devops.WorkItem, error) {
+	f.calls = append(f.calls, req)
+	return &devops.WorkItem{ID: 321}, nil
+}
+
+func (f *fakeDevOps) WorkItemWebURL(id int) string {
+	return "https://dev.azure.com/org/proj/_workitems/edit/321"
+}
+
+type fakeTrello struct {
+	calls []trello.CreateCardRequest
+}
+
+func (f *fakeTrello) CreateCard(ctx context.Context, req trello.CreateCardRequest) (*trello.Card, error) {
+	f.calls = append(f.calls, req)
+	return &trello.Card{ID: "card1", ShortURL: "https://trello.com/c/abc"}, nil
+}
+
+func TestSubmitDevOps(t *testing.T) {
+	client := &fakeDevOps{}
+	svc := NewDevOpsService(client, "")
+
+	result, err := svc.Submit(context.Background(), Report{
+		UserID:  "12345",
+		Channel: "telegram",
+		Text:    "The bot ignores my /workitems command",
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	if len(client.calls) != 1 {
+		t.Fatalf("expected 1 create call, got %d", len(client.calls))
+	}
+	req := client.calls[0]
+	if req.Type != "Bug" {
+		t.Errorf("Type = %q, expected Bug", req.Type)
+	}
+	if req.Title != "Feedback: The bot ignores my /workitems command" {
+		t.Errorf("Title = %q", req.Title)
+	}
+	if len(req.Tags) != 1 || req.Tags[0] != Tag {
+		t.Errorf("Tags = %v, expected [%s]", req.Tags, Tag)
+	}
+	if !strings.Contains(req.Description, "12345") || !strings.Contains(req.Description, "telegram") {
+		t.Errorf("Description does not capture user and channel: %q", req.Description)
+	}
+	if result.URL != "https://dev.azure.com/org/proj/_workitems/edit/321" || result.ID != "321" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestSubmitTrello(t *testing.T) {
+	client := &fakeTrello{}
+	svc := NewTrelloService(client, "list-feedback")
+
+	result, err := svc.Submit(context.Background(), Report{
+		UserID:  "anonymous",
+		Channel: "api",
+		Text:    "Please add dark mode",
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	if len(client.calls) != 1 {
+		t.Fatalf("expected 1 create call, got %d", len(client.calls))
+	}
+	req := client.calls[0]
+	if req.ListID != "list-feedback" {
+		t.Errorf("ListID = %q, expected list-feedback", req.ListID)
+	}
+	if !strings.Contains(req.Name, Tag) {
+		t.Errorf("Name = %q, expected %s tag", req.Name, Tag)
+	}
+	if result.URL != "https://trello.com/c/abc" {
+		t.Errorf("URL = %q", result.URL)
+	}
+}
+
+func TestSubmitDisabled(t *testing.T) {
+	var svc *Service
+	if _, err := svc.Submit(context.Background(), Report{Text: "hi"}); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Submit() error = %v, expected ErrDisabled", err)
+	}
+}
+
+func TestSummarizeTruncates(t *testing.T) {
+	long := strings.Repeat("a", 200) + "\nsecond line"
+	result := summarize(long)
+	if len([]rune(result)) != maxTitleLength {
+		t.Errorf("summarize() length = %d, expected %d", len([]rune(result)), maxTitleLength)
+	}
+}
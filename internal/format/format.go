@@ -0,0 +1,47 @@
+// Package format converts the Markdown the LLM writes into the markup each
+// channel renders. Channels look up their formatter by name, so a new
+// channel only needs to Register one.
+package format
+
+import "sync"
+
+// Formatter converts a Markdown response into a channel's markup
+type Formatter func(markdown string) string
+
+var (
+	mu         sync.RWMutex
+	formatters = map[string]Formatter{
+		"telegram": TelegramMarkdownV2,
+		"slack":    SlackMrkdwn,
+		"webchat":  Plain,
+	}
+)
+
+// Register sets the formatter for channel, replacing any existing one
+func Register(channel string, f Formatter) {
+	mu.Lock()
+	defer mu.Unlock()
+	formatters[channel] = f
+}
+
+// For returns the formatter registered for channel, or Plain for channels
+// without one
+func For(channel string) Formatter {
+	mu.RLock()
+	defer mu.RUnlock()
+	if f, ok := formatters[channel]; ok {
+		return f
+	}
+	return Plain
+}
+
+// Response formats markdown for channel
+func Response(channel, markdown string) string {
+	return For(channel)(markdown)
+}
+
+// Plain returns markdown unchanged, for channels that render Markdown
+// themselves (the webchat does it in the browser)
+func Plain(markdown string) string {
+	return markdown
+}
@@ -0,0 +1,59 @@
+package format
+
+import "testing"
+
+const sample = "## Sprint 14 (2 items)\n\n" +
+	"- **#42** Fix login_page crash — _high_ priority\n" +
+	"- ~~#43~~ done, see [the board](https://dev.azure.com/org/proj/_boards?a=1&b=2)\n\n" +
+	"Run `make test` first:\n\n" +
+	"```bash\ngo test ./... > out.txt\n```\n" +
+	"> Note: 1 + 1 = 2!"
+
+func TestTelegramMarkdownV2(t *testing.T) {
+	want := "*Sprint 14 \\(2 items\\)*\n\n" +
+		"• *\\#42* Fix login\\_page crash — _high_ priority\n" +
+		"• ~\\#43~ done, see [the board](https://dev.azure.com/org/proj/_boards?a=1&b=2)\n\n" +
+		"Run `make test` first:\n\n" +
+		"```bash\ngo test ./... > out.txt\n```\n" +
+		">Note: 1 \\+ 1 \\= 2\\!"
+	if got := TelegramMarkdownV2(sample); got != want {
+		t.Errorf("TelegramMarkdownV2() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSlackMrkdwn(t *testing.T) {
+	want := "*Sprint 14 (2 items)*\n\n" +
+		"• *#42* Fix login_page crash — _high_ priority\n" +
+		"• ~#43~ done, see <https://dev.azure.com/org/proj/_boards?a=1&b=2|the board>\n\n" +
+		"Run `make test` first:\n\n" +
+		"```\ngo test ./... &gt; out.txt\n```\n" +
+		">Note: 1 + 1 = 2!"
+	if got := SlackMrkdwn(sample); got != want {
+		t.Errorf("SlackMrkdwn() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTelegramClosesUnterminatedCodeBlock(t *testing.T) {
+	if got, want := TelegramMarkdownV2("```\nx := `a`"), "```\nx := \\`a\\`\n```"; got != want {
+		t.Errorf("TelegramMarkdownV2() = %q, want %q", got, want)
+	}
+}
+
+func TestForFallsBackToPlain(t *testing.T) {
+	if got := Response("webchat", "**bold**"); got != "**bold**" {
+		t.Errorf("webchat = %q, want Markdown unchanged", got)
+	}
+	if got := Response("teams", "**bold**"); got != "**bold**" {
+		t.Errorf("unregistered channel = %q, want Markdown unchanged", got)
+	}
+
+	Register("teams", func(md string) string { return "<b>" + md + "</b>" })
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(formatters, "teams")
+		mu.Unlock()
+	})
+	if got := Response("teams", "x"); got != "<b>x</b>" {
+		t.Errorf("registered channel = %q", got)
+	}
+}
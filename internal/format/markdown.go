@@ -0,0 +1,286 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// spanKind is the kind of an inline Markdown element
+type spanKind int
+
+const (
+	spanText spanKind = iota
+	spanCode
+	spanBold
+	spanItalic
+	spanStrike
+	spanLink
+)
+
+// span is an inline Markdown element; bold, italic, strike and link text
+// hold their content in inner
+type span struct {
+	kind  spanKind
+	text  string
+	url   string
+	inner []span
+}
+
+// renderer writes the elements of the Markdown subset the LLM uses in a
+// channel's markup
+type renderer interface {
+	text(s string) string
+	code(s string) string
+	bold(inner string) string
+	italic(inner string) string
+	strike(inner string) string
+	link(text, url string) string
+	heading(inner string) string
+	bullet(indent, inner string) string
+	quote(inner string) string
+	fence(lang string) string // opening line of a code block
+	codeLine(s string) string
+}
+
+var (
+	fenceLine   = regexp.MustCompile("^\\s*```\\s*([\\w+#.-]*)\\s*$")
+	headingLine = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*\s*$`)
+	bulletLine  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	quoteLine   = regexp.MustCompile(`^>\s?(.*)$`)
+	linkPrefix  = regexp.MustCompile(`^\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// convert renders markdown line by line with r. Code blocks keep their
+// content verbatim; one left open at the end is closed.
+func convert(markdown string, r renderer) string {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	inCode := false
+	for _, line := range lines {
+		if m := fenceLine.FindStringSubmatch(line); m != nil {
+			if inCode {
+				out = append(out, "```")
+			} else {
+				out = append(out, r.fence(m[1]))
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			out = append(out, r.codeLine(line))
+			continue
+		}
+
+		switch {
+		case headingLine.MatchString(line):
+			out = append(out, r.heading(renderSpans(parseInline(headingLine.FindStringSubmatch(line)[1]), r)))
+		case bulletLine.MatchString(line):
+			m := bulletLine.FindStringSubmatch(line)
+			out = append(out, r.bullet(m[1], renderSpans(parseInline(m[2]), r)))
+		case quoteLine.MatchString(line):
+			out = append(out, r.quote(renderSpans(parseInline(quoteLine.FindStringSubmatch(line)[1]), r)))
+		default:
+			out = append(out, renderSpans(parseInline(line), r))
+		}
+	}
+	if inCode {
+		out = append(out, "```")
+	}
+	return strings.Join(out, "\n")
+}
+
+func renderSpans(spans []span, r renderer) string {
+	var b strings.Builder
+	for _, s := range spans {
+		switch s.kind {
+		case spanText:
+			b.WriteString(r.text(s.text))
+		case spanCode:
+			b.WriteString(r.code(s.text))
+		case spanBold:
+			b.WriteString(r.bold(renderSpans(s.inner, r)))
+		case spanItalic:
+			b.WriteString(r.italic(renderSpans(s.inner, r)))
+		case spanStrike:
+			b.WriteString(r.strike(renderSpans(s.inner, r)))
+		case spanLink:
+			b.WriteString(r.link(renderSpans(s.inner, r), s.url))
+		}
+	}
+	return b.String()
+}
+
+// parseInline splits a line into code spans, links, emphasis and plain
+// text. Unmatched delimiters are kept as text.
+func parseInline(s string) []span {
+	var spans []span
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			spans = append(spans, span{kind: spanText, text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		rest := s[i:]
+
+		if rest[0] == '`' {
+			if end := strings.IndexByte(rest[1:], '`'); end >= 0 {
+				flush()
+				spans = append(spans, span{kind: spanCode, text: rest[1 : end+1]})
+				i += end + 2
+				continue
+			}
+		}
+
+		if rest[0] == '[' {
+			if m := linkPrefix.FindStringSubmatch(rest); m != nil {
+				flush()
+				spans = append(spans, span{kind: spanLink, url: m[2], inner: parseInline(m[1])})
+				i += len(m[0])
+				continue
+			}
+		}
+
+		if kind, delim := emphasis(s, i); delim != "" {
+			if end := closingDelimiter(rest[len(delim):], delim); end > 0 {
+				flush()
+				inner := rest[len(delim) : len(delim)+end]
+				spans = append(spans, span{kind: kind, inner: parseInline(inner)})
+				i += len(delim)*2 + end
+				continue
+			}
+		}
+
+		_, size := utf8.DecodeRuneInString(rest)
+		text.WriteString(rest[:size])
+		i += size
+	}
+	flush()
+	return spans
+}
+
+// emphasis returns the emphasis delimiter opening at s[i], if any. Single
+// underscores only count at a word start, so snake_case stays text.
+func emphasis(s string, i int) (spanKind, string) {
+	rest := s[i:]
+	switch {
+	case strings.HasPrefix(rest, "**"):
+		return spanBold, "**"
+	case strings.HasPrefix(rest, "__"):
+		return spanBold, "__"
+	case strings.HasPrefix(rest, "~~"):
+		return spanStrike, "~~"
+	case rest[0] == '*':
+		return spanItalic, "*"
+	case rest[0] == '_':
+		if i > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(s[:i])
+			if unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+				return 0, ""
+			}
+		}
+		return spanItalic, "_"
+	}
+	return 0, ""
+}
+
+// closingDelimiter returns the index in s of the delimiter closing an
+// emphasis, or -1. The content must not start or end with a space.
+func closingDelimiter(s, delim string) int {
+	if s == "" || s[0] == ' ' {
+		return -1
+	}
+	for from := 0; from < len(s); {
+		end := strings.Index(s[from:], delim)
+		if end < 0 {
+			return -1
+		}
+		end += from
+		if end > 0 && s[end-1] != ' ' && !(len(delim) == 1 && strings.HasPrefix(s[end:], delim+delim)) {
+			if delim != "_" || end+1 >= len(s) || !isWordByte(s[end+1]) {
+				return end
+			}
+		}
+		from = end + len(delim)
+	}
+	return -1
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// TelegramMarkdownV2 converts markdown to Telegram's MarkdownV2, escaping
+// every reserved character outside the formatting it produces
+func TelegramMarkdownV2(markdown string) string {
+	return convert(markdown, telegramRenderer{})
+}
+
+// telegramReserved are the characters MarkdownV2 requires escaping in text
+const telegramReserved = "_*[]()~`>#+-=|{}.!\\"
+
+type telegramRenderer struct{}
+
+func (telegramRenderer) text(s string) string       { return escapeWith(s, telegramReserved) }
+func (telegramRenderer) code(s string) string       { return "`" + escapeWith(s, "`\\") + "`" }
+func (telegramRenderer) bold(inner string) string   { return "*" + inner + "*" }
+func (telegramRenderer) italic(inner string) string { return "_" + inner + "_" }
+func (telegramRenderer) strike(inner string) string { return "~" + inner + "~" }
+func (telegramRenderer) link(text, url string) string {
+	return "[" + text + "](" + escapeWith(url, ")\\") + ")"
+}
+func (telegramRenderer) heading(inner string) string { return "*" + inner + "*" }
+func (telegramRenderer) bullet(indent, inner string) string {
+	return indent + "• " + inner
+}
+func (telegramRenderer) quote(inner string) string { return ">" + inner }
+func (telegramRenderer) fence(lang string) string  { return "```" + lang }
+func (telegramRenderer) codeLine(s string) string  { return escapeWith(s, "`\\") }
+
+// SlackMrkdwn converts markdown to Slack's mrkdwn
+func SlackMrkdwn(markdown string) string {
+	return convert(markdown, slackRenderer{})
+}
+
+type slackRenderer struct{}
+
+func (slackRenderer) text(s string) string       { return escapeSlack(s) }
+func (slackRenderer) code(s string) string       { return "`" + escapeSlack(s) + "`" }
+func (slackRenderer) bold(inner string) string   { return "*" + inner + "*" }
+func (slackRenderer) italic(inner string) string { return "_" + inner + "_" }
+func (slackRenderer) strike(inner string) string { return "~" + inner + "~" }
+func (slackRenderer) link(text, url string) string {
+	return "<" + url + "|" + text + ">"
+}
+func (slackRenderer) heading(inner string) string { return "*" + inner + "*" }
+func (slackRenderer) bullet(indent, inner string) string {
+	return indent + "• " + inner
+}
+func (slackRenderer) quote(inner string) string { return ">" + inner }
+
+// Slack code blocks have no language tag
+func (slackRenderer) fence(lang string) string { return "```" }
+func (slackRenderer) codeLine(s string) string { return escapeSlack(s) }
+
+// escapeWith prefixes each character of s found in reserved with a backslash
+func escapeWith(s, reserved string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(reserved, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// slackEscaper escapes the characters Slack treats as control sequences
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeSlack(s string) string {
+	return slackEscaper.Replace(s)
+}
@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+)
+
+// adminMiddleware guards the admin API with ADMIN_TOKEN, sent in the
+// X-Admin-Token header so it does not clash with the user's bearer token.
+// Without a configured token the admin API does not exist.
+func (g *Gateway) adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.cfg.Security.AdminToken == "" {
+			respondError(w, http.StatusNotFound, "admin API is disabled")
+			return
+		}
+		token := r.Header.Get("X-Admin-Token")
+		if token == "" {
+			respondError(w, http.StatusUnauthorized, "missing admin token")
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(g.cfg.Security.AdminToken)) != 1 {
+			g.logger.Warn("invalid admin token", "remote", r.RemoteAddr)
+			respondError(w, http.StatusForbidden, "invalid admin token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ToggleToolRequest enables or disables a tool
+type ToggleToolRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+func (g *Gateway) handleAdminListTools(w http.ResponseWriter, r *http.Request) {
+	states := g.agent.ToolStates()
+	if states == nil {
+		states = []agent.ToolState{}
+	}
+	respondJSON(w, http.StatusOK, states)
+}
+
+func (g *Gateway) handleAdminToggleTool(w http.ResponseWriter, r *http.Request) {
+	var req ToggleToolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Enabled == nil {
+		respondError(w, http.StatusBadRequest, "enabled is required")
+		return
+	}
+
+	state, err := g.agent.SetToolEnabled(chi.URLParam(r, "name"), *req.Enabled)
+	if errors.Is(err, agent.ErrUnknownTool) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		g.logger.Error("failed to toggle tool", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to save tool override")
+		return
+	}
+	respondJSON(w, http.StatusOK, state)
+}
+
+func (g *Gateway) handleAdminToolStats(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, g.agent.ToolStats())
+}
+
+// handleAdminGetUsage returns one user's usage (?user_id=) or everyone's
+func (g *Gateway) handleAdminGetUsage(w http.ResponseWriter, r *http.Request) {
+	tracker := g.agent.GetUsageTracker()
+
+	if userID := r.URL.Query().Get("user_id"); userID != "" {
+		respondJSON(w, http.StatusOK, tracker.Get(userID))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"daily_token_limit": tracker.DailyTokenLimit(),
+		"users":             tracker.All(),
+	})
+}
+
+// handleAdminResetUsage clears one user's counters (?user_id=) or everyone's (?all=true)
+func (g *Gateway) handleAdminResetUsage(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" && r.URL.Query().Get("all") != "true" {
+		respondError(w, http.StatusBadRequest, "user_id or all=true is required")
+		return
+	}
+
+	g.agent.GetUsageTracker().Reset(userID)
+	w.WriteHeader(http.StatusNoContent)
+}
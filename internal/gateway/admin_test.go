@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+	"github.com/abelclopes/nomad-iabot/internal/usage"
+)
+
+func adminRequest(g *Gateway, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("X-Admin-Token", token)
+	}
+	rec := httptest.NewRecorder()
+	g.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminToolsRequiresToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		sent       string
+		want       int
+	}{
+		{"admin API disabled", "", "anything", http.StatusNotFound},
+		{"missing token", "s3cret", "", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "guess", http.StatusForbidden},
+		{"valid token", "s3cret", "s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGateway(t, withAgent(), withTrello(), withAdminToken(tt.configured))
+			if rec := adminRequest(g, "GET", "/api/v1/admin/tools", tt.sent, ""); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminToggleTool(t *testing.T) {
+	g := newTestGateway(t, withAgent(), withTrello(), withAdminToken("s3cret"))
+
+	rec := adminRequest(g, "POST", "/api/v1/admin/tools/trello_list_boards", "s3cret", `{"enabled": false}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var state agent.ToolState
+	json.NewDecoder(rec.Body).Decode(&state)
+	if state.Name != "trello_list_boards" || state.Enabled {
+		t.Errorf("state = %+v", state)
+	}
+
+	var tools []Tool
+	json.NewDecoder(adminRequest(g, "GET", "/api/v1/admin/tools", "s3cret", "").Body).Decode(&tools)
+	found := false
+	for _, tool := range tools {
+		if tool.Name == "trello_list_boards" {
+			found = true
+			if tool.Enabled {
+				t.Error("disabled tool listed as enabled")
+			}
+		} else if !tool.Enabled {
+			t.Errorf("%s was disabled too", tool.Name)
+		}
+	}
+	if !found {
+		t.Error("disabled tool missing from the list")
+	}
+
+	if rec := adminRequest(g, "POST", "/api/v1/admin/tools/rm_rf", "s3cret", `{"enabled": false}`); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown tool status = %d, want 404", rec.Code)
+	}
+	if rec := adminRequest(g, "POST", "/api/v1/admin/tools/trello_list_boards", "s3cret", `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing enabled status = %d, want 400", rec.Code)
+	}
+}
+
+func TestAdminUsageCoversEveryUser(t *testing.T) {
+	g := newTestGateway(t, withAgent(), withTrello(), withAdminToken("s3cret"))
+	tracker := g.agent.GetUsageTracker()
+	tracker.Record("alice", llm.Usage{TotalTokens: 10})
+	tracker.Record("bob", llm.Usage{TotalTokens: 20})
+
+	if rec := adminRequest(g, "GET", "/api/v1/admin/usage", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status without admin token = %d, want 401", rec.Code)
+	}
+
+	var summary struct {
+		Users map[string]usage.Stats `json:"users"`
+	}
+	json.NewDecoder(adminRequest(g, "GET", "/api/v1/admin/usage", "s3cret", "").Body).Decode(&summary)
+	if len(summary.Users) != 2 || summary.Users["bob"].TotalTokens != 20 {
+		t.Errorf("users = %+v", summary.Users)
+	}
+
+	if rec := adminRequest(g, "DELETE", "/api/v1/admin/usage?user_id=bob", "s3cret", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("reset status = %d", rec.Code)
+	}
+	if got := tracker.Get("bob").TotalTokens; got != 0 {
+		t.Errorf("bob's tokens after reset = %d", got)
+	}
+	if got := tracker.Get("alice").TotalTokens; got != 10 {
+		t.Errorf("alice's tokens = %d, reset should only touch bob", got)
+	}
+}
@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"time"
@@ -8,6 +9,21 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// contextKey namespaces values the gateway stores in request contexts
+type contextKey string
+
+// userIDKey holds the authenticated user, taken from the token's subject
+const userIDKey contextKey = "user_id"
+
+// requestUserID returns the authenticated caller, or "anonymous" when the
+// request was not authenticated
+func requestUserID(r *http.Request) string {
+	if id, ok := r.Context().Value(userIDKey).(string); ok && id != "" {
+		return id
+	}
+	return "anonymous"
+}
+
 // authMiddleware validates JWT tokens
 func (g *Gateway) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -48,7 +64,7 @@ func (g *Gateway) authMiddleware(next http.Handler) http.Handler {
 				return nil, jwt.ErrSignatureInvalid
 			}
 			return []byte(g.cfg.Security.JWTSecret), nil
-		})
+		}, jwt.WithTimeFunc(g.clock.Now))
 
 		if err != nil || !token.Valid {
 			g.logger.Warn("invalid token", "error", err)
@@ -56,14 +72,19 @@ func (g *Gateway) authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Token is valid, proceed
-		next.ServeHTTP(w, r)
+		// Token is valid, proceed as the token's subject
+		sub, _ := token.Claims.GetSubject()
+		if sub == "" {
+			respondError(w, http.StatusUnauthorized, "token has no subject")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userIDKey, sub)))
 	})
 }
 
 // GenerateToken generates a JWT token (for CLI/admin use)
 func (g *Gateway) GenerateToken(userID string, expiresIn int64) (string, error) {
-	now := time.Now()
+	now := g.clock.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub": userID,
 		"iat": jwt.NewNumericDate(now),
@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+	"github.com/abelclopes/nomad-iabot/internal/usage"
+)
+
+func TestTokenExpiresWithClock(t *testing.T) {
+	g := newTestGateway(t, withJWT("test-jwt-secret"))
+	fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	g.SetClock(fake)
+
+	token, err := g.GenerateToken("admin", 3600)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	status := func() int {
+		req := httptest.NewRequest("GET", "/api/v1/config", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		g.router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	fake.Advance(59 * time.Minute)
+	if got := status(); got != http.StatusOK {
+		t.Fatalf("status before expiry = %d, want 200", got)
+	}
+
+	fake.Advance(2 * time.Minute)
+	if got := status(); got != http.StatusUnauthorized {
+		t.Errorf("status after expiry = %d, want 401", got)
+	}
+}
+
+func TestUsageIsScopedToTheTokenSubject(t *testing.T) {
+	g := newTestGateway(t, withJWT("test-jwt-secret"), withLLM(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(llm.ChatResponse{
+			Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "ok"}}},
+			Usage:   llm.Usage{TotalTokens: 10},
+		})
+	}))
+	g.agent.GetUsageTracker().Record("api:bob", llm.Usage{TotalTokens: 20})
+
+	token, err := g.GenerateToken("alice", 3600)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		g.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request("POST", "/api/v1/chat", `{"message":"oi"}`); rec.Code != http.StatusOK {
+		t.Fatalf("chat status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := request("GET", "/api/v1/usage", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var stats usage.Stats
+	json.NewDecoder(rec.Body).Decode(&stats)
+	if stats.TotalTokens != 10 || stats.Requests != 1 {
+		t.Errorf("usage = %+v, want alice's one chat of 10 tokens", stats)
+	}
+
+	// Asking for another user is ignored
+	stats = usage.Stats{}
+	json.NewDecoder(request("GET", "/api/v1/usage?user_id=api:bob", "").Body).Decode(&stats)
+	if stats.TotalTokens != 10 {
+		t.Errorf("total tokens with ?user_id=api:bob = %d, want alice's 10", stats.TotalTokens)
+	}
+
+	// Callers cannot reset their own daily cap
+	if rec := request("DELETE", "/api/v1/usage", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE /api/v1/usage status = %d, want 405", rec.Code)
+	}
+}
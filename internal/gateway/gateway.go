@@ -13,7 +13,10 @@ import (
 	"github.com/go-chi/httprate"
 	"github.com/abelclopes/nomad-iabot/internal/agent"
 	"github.com/abelclopes/nomad-iabot/internal/channels"
+	"github.com/abelclopes/nomad-iabot/internal/clock"
 	"github.com/abelclopes/nomad-iabot/internal/config"
+	"github.com/abelclopes/nomad-iabot/internal/idempotency"
+	"github.com/abelclopes/nomad-iabot/internal/redact"
 )
 
 // Gateway is the main HTTP/WS server for Nomad Agent
@@ -24,15 +27,26 @@ type Gateway struct {
 	router     *chi.Mux
 	agent      *agent.Agent
 	webchat    *channels.WebChatChannel
+	devopsHTTP *http.Client // overrides the Azure DevOps HTTP client (tests)
+	clock      clock.Clock  // issues and validates token lifetimes
+
+	// idempotency is shared by the per-request Azure DevOps clients
+	idempotency *idempotency.Store
+
+	trelloHandlers []TrelloWebhookHandler
+	devopsHandlers []DevOpsWebhookHandler
 }
 
 // New creates a new Gateway instance
 func New(cfg *config.Config, logger *slog.Logger, ag *agent.Agent) (*Gateway, error) {
 	g := &Gateway{
 		cfg:    cfg,
-		logger: logger,
+		logger: redact.Logger(logger),
 		router: chi.NewRouter(),
 		agent:  ag,
+		clock:  clock.Real(),
+
+		idempotency: idempotency.NewStore(idempotency.DefaultTTL),
 	}
 
 	g.setupMiddleware()
@@ -41,6 +55,21 @@ func New(cfg *config.Config, logger *slog.Logger, ag *agent.Agent) (*Gateway, er
 	return g, nil
 }
 
+// SetClock replaces the clock used to issue and validate tokens
+func (g *Gateway) SetClock(c clock.Clock) {
+	g.clock = c
+}
+
+// OnTrelloEvent registers a handler for verified Trello webhook events
+func (g *Gateway) OnTrelloEvent(handler TrelloWebhookHandler) {
+	g.trelloHandlers = append(g.trelloHandlers, handler)
+}
+
+// OnDevOpsEvent registers a handler for verified Azure DevOps service hook events
+func (g *Gateway) OnDevOpsEvent(handler DevOpsWebhookHandler) {
+	g.devopsHandlers = append(g.devopsHandlers, handler)
+}
+
 // RegisterWebChat registers the WebChat channel
 func (g *Gateway) RegisterWebChat(wc *channels.WebChatChannel) {
 	g.webchat = wc
@@ -77,7 +106,7 @@ func (g *Gateway) setupMiddleware() {
 	g.router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   g.cfg.Gateway.CORSOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", "Idempotency-Key"},
 		ExposedHeaders:   []string{"X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -97,17 +126,27 @@ func (g *Gateway) setupRoutes() {
 	// Health check (no auth required)
 	g.router.Get("/health", g.handleHealth)
 	g.router.Get("/ready", g.handleReady)
+	g.router.Get("/health/detail", g.handleHealthDetail)
+
+	// Machine-readable API description
+	if g.cfg.Gateway.OpenAPI {
+		g.router.Get("/openapi.json", g.handleOpenAPI)
+	}
 
 	// API routes (with auth)
 	g.router.Route("/api/v1", func(r chi.Router) {
 		// Auth middleware for API routes
-		if g.cfg.Security.AuthMode == "token" {
+		if g.cfg.Security.AuthMode == "jwt" {
 			r.Use(g.authMiddleware)
 		}
 
 		// Chat/Agent endpoints
 		r.Post("/chat", g.handleChat)
 		r.Post("/chat/stream", g.handleChatStream)
+		r.Post("/chat/batch", g.handleChatBatch)
+
+		// Feedback about the bot itself
+		r.Post("/feedback", g.handleFeedback)
 
 		// Sessions
 		r.Get("/sessions", g.handleListSessions)
@@ -121,21 +160,45 @@ func (g *Gateway) setupRoutes() {
 		// Azure DevOps (if enabled)
 		r.Route("/devops", func(r chi.Router) {
 			r.Get("/workitems", g.handleListWorkItems)
-			r.Post("/workitems", g.handleCreateWorkItem)
+			r.With(g.readOnlyMiddleware).Post("/workitems", g.handleCreateWorkItem)
 			r.Get("/workitems/{id}", g.handleGetWorkItem)
-			r.Patch("/workitems/{id}", g.handleUpdateWorkItem)
+			r.With(g.readOnlyMiddleware).Patch("/workitems/{id}", g.handleUpdateWorkItem)
+			r.Get("/workitems/{id}/comments", g.handleGetWorkItemComments)
 			r.Get("/pipelines", g.handleListPipelines)
-			r.Post("/pipelines/{id}/run", g.handleRunPipeline)
+			r.With(g.readOnlyMiddleware).Post("/pipelines/{id}/run", g.handleRunPipeline)
 			r.Get("/repos", g.handleListRepos)
 			r.Get("/boards", g.handleListBoards)
+			r.Post("/wiql/validate", g.handleValidateWIQL)
 		})
 
+		// The caller's own usage
+		r.Get("/usage", g.handleGetUsage)
+
+		// LLM response cache metrics
+		r.Get("/llm/cache", g.handleLLMCacheStats)
+
 		// Config
 		r.Get("/config", g.handleGetConfig)
+
+		// Runtime administration (ADMIN_TOKEN)
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(g.adminMiddleware)
+			r.Get("/tools", g.handleAdminListTools)
+			r.Post("/tools/{name}", g.handleAdminToggleTool)
+			r.Get("/tool-stats", g.handleAdminToolStats)
+			r.Get("/usage", g.handleAdminGetUsage)
+			r.Delete("/usage", g.handleAdminResetUsage)
+			r.Get("/routes", g.handleListRoutes)
+		})
 	})
 
+	// Webhooks authenticate with signatures rather than API tokens
+	g.router.Head("/api/v1/trello/webhook", g.handleTrelloWebhookPing)
+	g.router.Post("/api/v1/trello/webhook", g.handleTrelloWebhook)
+	g.router.Post("/api/v1/devops/webhook", g.handleDevOpsWebhook)
+
 	// WebChat static files
-	g.router.Handle("/webchat/*", http.StripPrefix("/webchat/", http.FileServer(http.Dir("./web/dist"))))
+	g.router.Handle("/webchat/*", g.webchatStatic())
 
 	// WebSocket for real-time chat
 	g.router.Get("/ws", g.handleWebSocket)
@@ -157,12 +220,16 @@ func (g *Gateway) Start(ctx context.Context) error {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	g.logRoutes()
 	g.logger.Info("HTTP server starting", "addr", addr)
 	return g.httpServer.ListenAndServe()
 }
 
 // Shutdown gracefully shuts down the server
 func (g *Gateway) Shutdown(ctx context.Context) error {
+	if g.httpServer == nil {
+		return nil
+	}
 	shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 	return g.httpServer.Shutdown(shutdownCtx)
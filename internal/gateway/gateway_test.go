@@ -0,0 +1,136 @@
+package gateway
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+	"github.com/abelclopes/nomad-iabot/internal/config"
+)
+
+// testSetup collects what newTestGateway builds a gateway from
+type testSetup struct {
+	cfg    *config.Config
+	agent  bool
+	llm    http.HandlerFunc
+	devops http.HandlerFunc
+}
+
+// testOption customizes the gateway built by newTestGateway
+type testOption func(*testSetup)
+
+// withConfig adjusts the configuration before the gateway is built
+func withConfig(fn func(cfg *config.Config)) testOption {
+	return func(s *testSetup) { fn(s.cfg) }
+}
+
+// withAgent gives the gateway an agent built from its configuration
+func withAgent() testOption {
+	return func(s *testSetup) { s.agent = true }
+}
+
+// withLLM gives the gateway an agent whose LLM requests handler answers
+func withLLM(handler http.HandlerFunc) testOption {
+	return func(s *testSetup) {
+		s.agent = true
+		s.llm = handler
+	}
+}
+
+// withDevOps enables Azure DevOps, with a client that talks to handler
+func withDevOps(handler http.HandlerFunc, customFields ...string) testOption {
+	return func(s *testSetup) {
+		s.devops = handler
+		s.cfg.AzureDevOps = config.AzureDevOpsConfig{
+			Enabled:      true,
+			Organization: "org",
+			Project:      "proj",
+			PAT:          "test-pat-secret",
+			APIVersion:   "7.0",
+			CustomFields: customFields,
+		}
+	}
+}
+
+// withTrello enables Trello with placeholder credentials
+func withTrello() testOption {
+	return withConfig(func(cfg *config.Config) {
+		cfg.Trello = config.TrelloConfig{Enabled: true, APIKey: "key", Token: "token"}
+	})
+}
+
+// withJWT requires API tokens signed with secret
+func withJWT(secret string) testOption {
+	return withConfig(func(cfg *config.Config) {
+		cfg.Security.AuthMode = "jwt"
+		cfg.Security.JWTSecret = secret
+	})
+}
+
+// withAdminToken enables the admin API
+func withAdminToken(token string) testOption {
+	return withConfig(func(cfg *config.Config) {
+		cfg.Security.AdminToken = token
+	})
+}
+
+// newTestGateway builds a gateway without authentication, integrations or
+// an agent, unless opts add them
+func newTestGateway(t *testing.T, opts ...testOption) *Gateway {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Security.AuthMode = "none"
+	cfg.Security.RateLimitRPS = 100
+	cfg.Tools.OverridesFile = filepath.Join(t.TempDir(), "tool-overrides.json")
+	s := &testSetup{cfg: cfg}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.llm != nil {
+		srv := httptest.NewServer(s.llm)
+		t.Cleanup(srv.Close)
+		cfg.LLM.BaseURL = srv.URL
+		cfg.LLM.Model = "test-model"
+		cfg.LLM.TimeoutSec = 5
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var a *agent.Agent
+	if s.agent {
+		var err error
+		if a, err = agent.New(cfg, logger); err != nil {
+			t.Fatalf("agent.New() error = %v", err)
+		}
+	}
+	g, err := New(cfg, logger, a)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if s.devops != nil {
+		srv := httptest.NewServer(s.devops)
+		t.Cleanup(srv.Close)
+		target, _ := url.Parse(srv.URL)
+		g.devopsHTTP = &http.Client{Transport: &rewriteTransport{target: target}}
+	}
+	return g
+}
+
+// rewriteTransport sends every request to the test server instead of dev.azure.com
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
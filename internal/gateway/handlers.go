@@ -1,8 +1,20 @@
 package gateway
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+	"github.com/abelclopes/nomad-iabot/internal/breaker"
+	"github.com/abelclopes/nomad-iabot/internal/config"
+	"github.com/abelclopes/nomad-iabot/internal/feedback"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+	"github.com/abelclopes/nomad-iabot/internal/skills"
 )
 
 // Health check handlers
@@ -13,8 +25,48 @@ func (g *Gateway) handleHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// HealthDetail reports the state of the LLM and of each guarded integration
+type HealthDetail struct {
+	Status       string                     `json:"status"` // "healthy", or "degraded" while the LLM is unreachable, a breaker is not closed or credentials are rejected
+	Model        string                     `json:"model,omitempty"`
+	UptimeSec    int64                      `json:"uptime_sec,omitempty"`
+	LLM          *agent.LLMHealth           `json:"llm,omitempty"`
+	Integrations []breaker.Status           `json:"integrations"`
+	Concurrency  *agent.ConcurrencyStats    `json:"concurrency,omitempty"`
+	Setup        []config.IntegrationStatus `json:"setup"`
+	Credentials  []agent.CredentialStatus   `json:"credentials"` // integrations whose credentials were rejected
+}
+
+func (g *Gateway) handleHealthDetail(w http.ResponseWriter, r *http.Request) {
+	detail := HealthDetail{
+		Status:       "healthy",
+		Integrations: []breaker.Status{},
+		Setup:        g.cfg.Integrations(),
+		Credentials:  []agent.CredentialStatus{},
+	}
+	if g.agent != nil {
+		h := g.agent.Health(r.Context())
+		detail.Status = h.Status
+		detail.Model = h.Model
+		detail.UptimeSec = int64(h.Uptime.Seconds())
+		detail.LLM = &h.LLM
+		detail.Integrations = h.Breakers
+		detail.Concurrency = &h.Concurrency
+		detail.Credentials = h.Credentials
+	}
+	respondJSON(w, http.StatusOK, detail)
+}
+
 func (g *Gateway) handleReady(w http.ResponseWriter, r *http.Request) {
-	// TODO: Check LLM connectivity
+	if g.agent != nil {
+		if ready, reason := g.agent.Ready(); !ready {
+			respondJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"status": "not ready",
+				"reason": reason,
+			})
+			return
+		}
+	}
 	respondJSON(w, http.StatusOK, map[string]string{
 		"status": "ready",
 	})
@@ -33,14 +85,43 @@ func (g *Gateway) handleChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user ID from context (set by auth middleware) or use default
-	userID := "anonymous"
-	if id, ok := r.Context().Value("user_id").(string); ok {
-		userID = id
+	if err := g.cfg.InputLimits().Check(req.Message); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	attachments, err := g.decodeAttachments(req.Attachments)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := llm.ValidateStop(req.Stop); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Set by the auth middleware; "anonymous" without authentication
+	userID := requestUserID(r)
+
+	ctx := r.Context()
+	if len(req.Stop) > 0 {
+		ctx = agent.WithLLMOptions(ctx, llm.WithStop(req.Stop))
+	}
+	switch {
+	case req.PlanOnly:
+		ctx = agent.WithPlanMode(ctx, agent.PlanOnly)
+	case req.Plan:
+		ctx = agent.WithPlanMode(ctx, agent.PlanShow)
 	}
 
 	// Process message with agent
-	response, err := g.agent.ProcessMessage(r.Context(), userID, "api", req.Message)
+	res, err := g.agent.ProcessMessageWithAttachments(ctx, userID, "api", req.Message, attachments)
+	if errors.Is(err, agent.ErrBusy) {
+		w.Header().Set("Retry-After", "5")
+		respondError(w, http.StatusServiceUnavailable, "server busy, try again shortly")
+		return
+	}
 	if err != nil {
 		g.logger.Error("failed to process chat message", "error", err)
 		respondError(w, http.StatusInternalServerError, "failed to process message")
@@ -48,8 +129,11 @@ func (g *Gateway) handleChat(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respondJSON(w, http.StatusOK, ChatResponse{
-		ID:      req.SessionID,
-		Message: response,
+		ID:         req.SessionID,
+		Message:    res.Response,
+		Plan:       res.Plan,
+		ToolCalls:  res.ToolCalls,
+		TokensUsed: res.Usage.TotalTokens,
 	})
 }
 
@@ -72,6 +156,43 @@ func (g *Gateway) handleChatStream(w http.ResponseWriter, r *http.Request) {
 	flusher.Flush()
 }
 
+// Feedback handler
+func (g *Gateway) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	fb := g.agent.GetFeedbackService()
+	if fb == nil {
+		respondError(w, http.StatusNotFound, "feedback is not configured")
+		return
+	}
+
+	var req struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Message == "" {
+		respondError(w, http.StatusBadRequest, "message is required")
+		return
+	}
+
+	userID := requestUserID(r)
+
+	result, err := fb.Submit(r.Context(), feedback.Report{
+		UserID:  userID,
+		Channel: "api",
+		Text:    req.Message,
+	})
+	if err != nil {
+		g.logger.Error("failed to submit feedback", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to submit feedback")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, result)
+}
+
 // Session handlers
 func (g *Gateway) handleListSessions(w http.ResponseWriter, r *http.Request) {
 	// TODO: Implement session listing
@@ -90,23 +211,20 @@ func (g *Gateway) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
 
 // Tools handlers
 func (g *Gateway) handleListTools(w http.ResponseWriter, r *http.Request) {
-	var tools []Tool
-
-	// Add DevOps tools if available
-	if devopsTool := g.agent.GetDevOpsTool(); devopsTool != nil {
-		for _, def := range devopsTool.GetToolDefinitions() {
-			tools = append(tools, Tool{
-				Name:        def.Function.Name,
-				Description: def.Function.Description,
-				Enabled:     true,
-			})
-		}
+	tools := []Tool{}
+	for _, state := range g.agent.ToolStates() {
+		tools = append(tools, Tool(state))
 	}
 
 	respondJSON(w, http.StatusOK, tools)
 }
 
 func (g *Gateway) handleExecuteTool(w http.ResponseWriter, r *http.Request) {
+	if g.cfg.Security.ReadOnly && skills.IsMutatingCommand(chi.URLParam(r, "name")) {
+		respondError(w, http.StatusForbidden, "this instance is read-only")
+		return
+	}
+
 	// TODO: Implement tool execution
 	respondJSON(w, http.StatusOK, map[string]string{"status": "executed"})
 }
@@ -136,12 +254,55 @@ func (g *Gateway) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, safeConfig)
 }
 
+// handleGetUsage returns the caller's own usage. Other users' usage, and
+// resetting counters, are in the admin API.
+func (g *Gateway) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, g.agent.UsageFor("api", requestUserID(r)))
+}
+
+func (g *Gateway) handleLLMCacheStats(w http.ResponseWriter, r *http.Request) {
+	stats, ok := g.agent.GetLLMClient().CacheStats()
+	if !ok {
+		respondError(w, http.StatusNotFound, "LLM response cache is not enabled")
+		return
+	}
+	respondJSON(w, http.StatusOK, stats)
+}
+
 // WebSocket handler
 func (g *Gateway) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// TODO: Implement WebSocket handling
 	http.Error(w, "WebSocket not implemented yet", http.StatusNotImplemented)
 }
 
+// decodeAttachments validates chat attachments against the configured limits
+func (g *Gateway) decodeAttachments(in []ChatAttachment) ([]agent.Attachment, error) {
+	limits := g.cfg.AttachmentLimits()
+	if err := limits.CheckCount(len(in)); err != nil {
+		return nil, err
+	}
+
+	out := make([]agent.Attachment, 0, len(in))
+	for _, att := range in {
+		if att.Name == "" {
+			return nil, fmt.Errorf("attachment name is required")
+		}
+		// Reject oversized payloads before decoding them
+		if limits.MaxBytes > 0 && base64.StdEncoding.DecodedLen(len(att.DataBase64)) > limits.MaxBytes+2 {
+			return nil, fmt.Errorf("attachment %q too large (max %d bytes)", att.Name, limits.MaxBytes)
+		}
+		data, err := base64.StdEncoding.DecodeString(att.DataBase64)
+		if err != nil {
+			return nil, fmt.Errorf("attachment %q: invalid base64 data", att.Name)
+		}
+		if err := limits.Check(att.Name, att.ContentType, len(data)); err != nil {
+			return nil, err
+		}
+		out = append(out, agent.Attachment{Name: att.Name, ContentType: att.ContentType, Data: data})
+	}
+	return out, nil
+}
+
 // Helper functions
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -155,16 +316,27 @@ func respondError(w http.ResponseWriter, status int, message string) {
 
 // Types
 type ChatRequest struct {
-	Message   string `json:"message"`
-	SessionID string `json:"session_id,omitempty"`
-	Stream    bool   `json:"stream,omitempty"`
+	Message     string           `json:"message"`
+	SessionID   string           `json:"session_id,omitempty"`
+	Stream      bool             `json:"stream,omitempty"`
+	Attachments []ChatAttachment `json:"attachments,omitempty"`
+	Stop        []string         `json:"stop,omitempty"`
+	Plan        bool             `json:"plan,omitempty"`      // describe intended tool calls before running them
+	PlanOnly    bool             `json:"plan_only,omitempty"` // describe them and stop, for the caller to approve
+}
+
+type ChatAttachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	DataBase64  string `json:"data_base64"`
 }
 
 type ChatResponse struct {
-	ID         string   `json:"id"`
-	Message    string   `json:"message"`
-	ToolCalls  []string `json:"tool_calls,omitempty"`
-	TokensUsed int      `json:"tokens_used,omitempty"`
+	ID         string                `json:"id"`
+	Message    string                `json:"message"`
+	Plan       string                `json:"plan,omitempty"`
+	ToolCalls  []agent.ToolCallTrace `json:"tool_calls,omitempty"`
+	TokensUsed int                   `json:"tokens_used,omitempty"`
 }
 
 type Session struct {
@@ -0,0 +1,114 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/abelclopes/nomad-iabot/internal/agent"
+)
+
+// ChatBatchRequest is a list of independent prompts, processed without the
+// user's memory. When SessionID is set the items belong to one conversation
+// of that name, kept apart from the user's main one, and are processed in
+// order.
+type ChatBatchRequest struct {
+	Items     []ChatBatchItem `json:"items"`
+	SessionID string          `json:"session_id,omitempty"`
+}
+
+// ChatBatchItem is one prompt of a batch, identified by a caller-chosen ID
+type ChatBatchItem struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// ChatBatchResult is the outcome of one batch item; Error is set instead
+// of Message when the item failed
+type ChatBatchResult struct {
+	ID         string `json:"id"`
+	Message    string `json:"message,omitempty"`
+	Error      string `json:"error,omitempty"`
+	TokensUsed int    `json:"tokens_used,omitempty"`
+}
+
+// ChatBatchResponse holds one result per item, in request order
+type ChatBatchResponse struct {
+	Results []ChatBatchResult `json:"results"`
+}
+
+func (g *Gateway) handleChatBatch(w http.ResponseWriter, r *http.Request) {
+	var req ChatBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if len(req.Items) == 0 {
+		respondError(w, http.StatusBadRequest, "items is required")
+		return
+	}
+	if limit := g.cfg.Gateway.BatchMaxItems; limit > 0 && len(req.Items) > limit {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("batch has %d items, the maximum is %d", len(req.Items), limit))
+		return
+	}
+
+	userID := requestUserID(r)
+
+	concurrency := g.cfg.Gateway.BatchConcurrency
+	if concurrency < 1 || req.SessionID != "" {
+		concurrency = 1
+	}
+
+	// Parallel items must not interleave in the user's memory
+	ctx := agent.WithConversation(r.Context(), req.SessionID)
+
+	results := make([]ChatBatchResult, len(req.Items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, item := range req.Items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, item ChatBatchItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = g.processBatchItem(ctx, userID, item)
+		}(i, item)
+	}
+	wg.Wait()
+
+	respondJSON(w, http.StatusOK, ChatBatchResponse{Results: results})
+}
+
+// processBatchItem validates and runs one batch item, reporting failures in
+// the result rather than failing the whole batch
+func (g *Gateway) processBatchItem(ctx context.Context, userID string, item ChatBatchItem) ChatBatchResult {
+	result := ChatBatchResult{ID: item.ID}
+
+	if item.Message == "" {
+		result.Error = "message is required"
+		return result
+	}
+	if err := g.cfg.InputLimits().Check(item.Message); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	res, err := g.agent.ProcessMessageWithAttachments(ctx, userID, "api", item.Message, nil)
+	if errors.Is(err, agent.ErrBusy) {
+		result.Error = "server busy, try again shortly"
+		return result
+	}
+	if err != nil {
+		g.logger.Error("failed to process batch item", "id", item.ID, "error", err)
+		result.Error = "failed to process message"
+		return result
+	}
+
+	result.Message = res.Response
+	result.TokensUsed = res.Usage.TotalTokens
+	return result
+}
@@ -0,0 +1,83 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/config"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+)
+
+// withBatchLimit accepts batches of up to maxItems prompts, two at a time
+func withBatchLimit(maxItems int) testOption {
+	return withConfig(func(cfg *config.Config) {
+		cfg.Gateway.BatchMaxItems = maxItems
+		cfg.Gateway.BatchConcurrency = 2
+	})
+}
+
+func postBatch(t *testing.T, g *Gateway, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	g.router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/chat/batch", strings.NewReader(body)))
+	return rec
+}
+
+func TestChatBatchMixedResults(t *testing.T) {
+	g := newTestGateway(t, withBatchLimit(10), withLLM(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt := req.Messages[len(req.Messages)-1].Content
+		if strings.Contains(prompt, "boom") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(llm.ChatResponse{
+			Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "label: " + prompt}}},
+			Usage:   llm.Usage{TotalTokens: 7},
+		})
+	}))
+
+	rec := postBatch(t, g, `{"items":[
+		{"id":"a","message":"refund request"},
+		{"id":"b","message":"boom"},
+		{"id":"c","message":""},
+		{"id":"d","message":"login issue"}
+	]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	var resp ChatBatchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []ChatBatchResult{
+		{ID: "a", Message: "label: refund request", TokensUsed: 7},
+		{ID: "b", Error: "failed to process message"},
+		{ID: "c", Error: "message is required"},
+		{ID: "d", Message: "label: login issue", TokensUsed: 7},
+	}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("results = %+v", resp.Results)
+	}
+	for i := range want {
+		if resp.Results[i] != want[i] {
+			t.Errorf("results[%d] = %+v, want %+v", i, resp.Results[i], want[i])
+		}
+	}
+}
+
+func TestChatBatchRejectsOversizedBatch(t *testing.T) {
+	g := newTestGateway(t, withBatchLimit(2), withLLM(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the LLM must not be called for a rejected batch")
+	}))
+
+	rec := postBatch(t, g, `{"items":[{"id":"1","message":"a"},{"id":"2","message":"b"},{"id":"3","message":"c"}]}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "maximum is 2") {
+		t.Errorf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
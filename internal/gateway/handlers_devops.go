@@ -1,28 +1,69 @@
 package gateway
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/abelclopes/nomad-iabot/internal/devops"
+	"github.com/abelclopes/nomad-iabot/internal/idempotency"
+	"github.com/abelclopes/nomad-iabot/internal/skills"
 )
 
 // Azure DevOps handlers
 
-func (g *Gateway) handleListWorkItems(w http.ResponseWriter, r *http.Request) {
-	if !g.cfg.AzureDevOps.Enabled {
-		respondError(w, http.StatusNotFound, "Azure DevOps integration is not enabled")
-		return
-	}
-
+// devopsClient creates an Azure DevOps client from the gateway configuration
+func (g *Gateway) devopsClient() *devops.Client {
 	client := devops.NewClient(
 		g.cfg.AzureDevOps.Organization,
 		g.cfg.AzureDevOps.Project,
 		g.cfg.AzureDevOps.PAT,
 		g.cfg.AzureDevOps.APIVersion,
 	)
+	client.SetPipelineBranches(g.cfg.AzureDevOps.PipelineBranches)
+	client.SetIdempotencyStore(g.idempotency)
+	if g.agent != nil {
+		client.SetBreaker(g.agent.GetDevOpsBreaker())
+	}
+	if g.devopsHTTP != nil {
+		client.SetHTTPClient(g.devopsHTTP)
+	}
+	return client
+}
+
+// validateCustomFields checks custom field names against the configured allowlist
+func (g *Gateway) validateCustomFields(fields map[string]interface{}) error {
+	for name := range fields {
+		if !skills.ValidateDevOpsFieldName(name) {
+			return fmt.Errorf("invalid custom field name: %s", name)
+		}
+		allowed := false
+		for _, f := range g.cfg.AzureDevOps.CustomFields {
+			if f == name {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("custom field not allowed: %s", name)
+		}
+	}
+	return nil
+}
+
+func (g *Gateway) handleListWorkItems(w http.ResponseWriter, r *http.Request) {
+	if !g.cfg.AzureDevOps.Enabled {
+		respondError(w, http.StatusNotFound, "Azure DevOps integration is not enabled")
+		return
+	}
+
+	client := g.devopsClient()
 
 	// Check for query parameter
 	query := r.URL.Query().Get("query")
@@ -31,6 +72,10 @@ func (g *Gateway) handleListWorkItems(w http.ResponseWriter, r *http.Request) {
 	var err error
 	
 	if query != "" {
+		if err := skills.ValidateWIQL(query); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		items, err = client.QueryWorkItems(r.Context(), query)
 	} else {
 		// Default: get my work items
@@ -46,6 +91,59 @@ func (g *Gateway) handleListWorkItems(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, items)
 }
 
+// WIQLValidateRequest is a query to check without running it in full
+type WIQLValidateRequest struct {
+	Query string `json:"query"`
+	Count bool   `json:"count,omitempty"` // also count the matching work items
+}
+
+// WIQLValidateResponse reports whether a query is valid
+type WIQLValidateResponse struct {
+	Valid bool   `json:"valid"`
+	Count *int   `json:"count,omitempty"` // matching work items, when requested
+	Error string `json:"error,omitempty"` // why the query is invalid
+}
+
+func (g *Gateway) handleValidateWIQL(w http.ResponseWriter, r *http.Request) {
+	if !g.cfg.AzureDevOps.Enabled {
+		respondError(w, http.StatusNotFound, "Azure DevOps integration is not enabled")
+		return
+	}
+
+	var req WIQLValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		respondError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	if err := skills.ValidateWIQL(req.Query); err != nil {
+		respondJSON(w, http.StatusOK, WIQLValidateResponse{Error: err.Error()})
+		return
+	}
+
+	count, err := g.devopsClient().ValidateQuery(r.Context(), req.Query, req.Count)
+	var wiqlErr *devops.WIQLError
+	if errors.As(err, &wiqlErr) {
+		respondJSON(w, http.StatusOK, WIQLValidateResponse{Error: wiqlErr.Message})
+		return
+	}
+	if err != nil {
+		g.logger.Error("failed to validate WIQL query", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to validate query")
+		return
+	}
+
+	resp := WIQLValidateResponse{Valid: true}
+	if req.Count {
+		resp.Count = &count
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
 func (g *Gateway) handleGetWorkItem(w http.ResponseWriter, r *http.Request) {
 	if !g.cfg.AzureDevOps.Enabled {
 		respondError(w, http.StatusNotFound, "Azure DevOps integration is not enabled")
@@ -59,12 +157,7 @@ func (g *Gateway) handleGetWorkItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client := devops.NewClient(
-		g.cfg.AzureDevOps.Organization,
-		g.cfg.AzureDevOps.Project,
-		g.cfg.AzureDevOps.PAT,
-		g.cfg.AzureDevOps.APIVersion,
-	)
+	client := g.devopsClient()
 
 	item, err := client.GetWorkItem(r.Context(), id)
 	if err != nil {
@@ -73,9 +166,45 @@ func (g *Gateway) handleGetWorkItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Descriptions are returned as raw HTML unless plain text is requested
+	if r.URL.Query().Get("format") == "text" {
+		if desc, ok := item.Fields["System.Description"].(string); ok {
+			item.Fields["System.Description"] = devops.HTMLToText(desc)
+		}
+	}
+
 	respondJSON(w, http.StatusOK, item)
 }
 
+func (g *Gateway) handleGetWorkItemComments(w http.ResponseWriter, r *http.Request) {
+	if !g.cfg.AzureDevOps.Enabled {
+		respondError(w, http.StatusNotFound, "Azure DevOps integration is not enabled")
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid work item ID")
+		return
+	}
+
+	comments, err := g.devopsClient().GetWorkItemComments(r.Context(), id)
+	if err != nil {
+		g.logger.Error("failed to get work item comments", "error", err, "id", id)
+		respondError(w, http.StatusInternalServerError, "failed to get work item comments")
+		return
+	}
+
+	// Comment text is HTML unless plain text is requested
+	if r.URL.Query().Get("format") == "text" {
+		for i := range comments {
+			comments[i].Text = devops.HTMLToText(comments[i].Text)
+		}
+	}
+
+	respondJSON(w, http.StatusOK, comments)
+}
+
 func (g *Gateway) handleCreateWorkItem(w http.ResponseWriter, r *http.Request) {
 	if !g.cfg.AzureDevOps.Enabled {
 		respondError(w, http.StatusNotFound, "Azure DevOps integration is not enabled")
@@ -90,6 +219,8 @@ func (g *Gateway) handleCreateWorkItem(w http.ResponseWriter, r *http.Request) {
 		Priority    int      `json:"priority,omitempty"`
 		Tags        []string `json:"tags,omitempty"`
 		ParentID    int      `json:"parent_id,omitempty"`
+
+		CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -102,12 +233,21 @@ func (g *Gateway) handleCreateWorkItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client := devops.NewClient(
-		g.cfg.AzureDevOps.Organization,
-		g.cfg.AzureDevOps.Project,
-		g.cfg.AzureDevOps.PAT,
-		g.cfg.AzureDevOps.APIVersion,
-	)
+	if err := g.validateCustomFields(req.CustomFields); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Retried requests with the same key return the work item created first
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if idempotencyKey != "" {
+		if err := idempotency.ValidateKey(idempotencyKey); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	client := g.devopsClient()
 
 	createReq := devops.WorkItemCreateRequest{
 		Type:        req.Type,
@@ -117,6 +257,10 @@ func (g *Gateway) handleCreateWorkItem(w http.ResponseWriter, r *http.Request) {
 		Priority:    req.Priority,
 		Tags:        req.Tags,
 		ParentID:    req.ParentID,
+
+		CustomFields: req.CustomFields,
+
+		IdempotencyKey: idempotencyKey,
 	}
 
 	item, err := client.CreateWorkItem(r.Context(), createReq)
@@ -148,6 +292,8 @@ func (g *Gateway) handleUpdateWorkItem(w http.ResponseWriter, r *http.Request) {
 		State       *string `json:"state,omitempty"`
 		AssignedTo  *string `json:"assigned_to,omitempty"`
 		Priority    *int    `json:"priority,omitempty"`
+
+		CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -155,12 +301,12 @@ func (g *Gateway) handleUpdateWorkItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client := devops.NewClient(
-		g.cfg.AzureDevOps.Organization,
-		g.cfg.AzureDevOps.Project,
-		g.cfg.AzureDevOps.PAT,
-		g.cfg.AzureDevOps.APIVersion,
-	)
+	if err := g.validateCustomFields(req.CustomFields); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	client := g.devopsClient()
 
 	updateReq := devops.WorkItemUpdateRequest{
 		Title:       req.Title,
@@ -168,6 +314,8 @@ func (g *Gateway) handleUpdateWorkItem(w http.ResponseWriter, r *http.Request) {
 		State:       req.State,
 		AssignedTo:  req.AssignedTo,
 		Priority:    req.Priority,
+
+		CustomFields: req.CustomFields,
 	}
 
 	item, err := client.UpdateWorkItem(r.Context(), id, updateReq)
@@ -186,12 +334,7 @@ func (g *Gateway) handleListPipelines(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client := devops.NewClient(
-		g.cfg.AzureDevOps.Organization,
-		g.cfg.AzureDevOps.Project,
-		g.cfg.AzureDevOps.PAT,
-		g.cfg.AzureDevOps.APIVersion,
-	)
+	client := g.devopsClient()
 
 	pipelines, err := client.ListPipelines(r.Context())
 	if err != nil {
@@ -217,27 +360,22 @@ func (g *Gateway) handleRunPipeline(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Branch    string            `json:"branch"`
-		Variables map[string]string `json:"variables,omitempty"`
+		Branch          string            `json:"branch"`
+		Variables       map[string]string `json:"variables,omitempty"`
+		StrictVariables bool              `json:"strict_variables,omitempty"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		// Body is optional
-		req.Branch = "refs/heads/main"
-	}
+	// Body is optional; without a branch the pipeline's default branch is used
+	json.NewDecoder(r.Body).Decode(&req)
 
-	if req.Branch == "" {
-		req.Branch = "refs/heads/main"
-	}
+	client := g.devopsClient()
 
-	client := devops.NewClient(
-		g.cfg.AzureDevOps.Organization,
-		g.cfg.AzureDevOps.Project,
-		g.cfg.AzureDevOps.PAT,
-		g.cfg.AzureDevOps.APIVersion,
-	)
-
-	run, err := client.RunPipeline(r.Context(), id, req.Branch, req.Variables)
+	run, err := client.RunPipelineChecked(r.Context(), id, req.Branch, req.Variables, req.StrictVariables)
+	var unknown *devops.UnknownVariablesError
+	if errors.As(err, &unknown) {
+		respondError(w, http.StatusBadRequest, unknown.Error())
+		return
+	}
 	if err != nil {
 		g.logger.Error("failed to run pipeline", "error", err, "id", id)
 		respondError(w, http.StatusInternalServerError, "failed to run pipeline")
@@ -253,12 +391,7 @@ func (g *Gateway) handleListRepos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client := devops.NewClient(
-		g.cfg.AzureDevOps.Organization,
-		g.cfg.AzureDevOps.Project,
-		g.cfg.AzureDevOps.PAT,
-		g.cfg.AzureDevOps.APIVersion,
-	)
+	client := g.devopsClient()
 
 	repos, err := client.ListRepositories(r.Context())
 	if err != nil {
@@ -278,12 +411,7 @@ func (g *Gateway) handleListBoards(w http.ResponseWriter, r *http.Request) {
 
 	team := r.URL.Query().Get("team")
 
-	client := devops.NewClient(
-		g.cfg.AzureDevOps.Organization,
-		g.cfg.AzureDevOps.Project,
-		g.cfg.AzureDevOps.PAT,
-		g.cfg.AzureDevOps.APIVersion,
-	)
+	client := g.devopsClient()
 
 	boards, err := client.ListBoards(r.Context(), team)
 	if err != nil {
@@ -294,3 +422,49 @@ func (g *Gateway) handleListBoards(w http.ResponseWriter, r *http.Request) {
 
 	respondJSON(w, http.StatusOK, boards)
 }
+
+// DevOpsWebhookHandler receives verified Azure DevOps service hook events
+type DevOpsWebhookHandler func(ctx context.Context, event devops.ServiceHookEvent)
+
+// handleDevOpsWebhook receives service hook events. With ?dry_run=true the
+// formatted notification is echoed back instead of being forwarded, which
+// helps when testing a new subscription.
+func (g *Gateway) handleDevOpsWebhook(w http.ResponseWriter, r *http.Request) {
+	if g.cfg.AzureDevOps.WebhookSecret == "" {
+		respondError(w, http.StatusNotFound, "Azure DevOps webhooks are not configured")
+		return
+	}
+
+	secret := r.Header.Get(devops.WebhookSecretHeader)
+	if secret == "" {
+		_, secret, _ = r.BasicAuth()
+	}
+	if !devops.VerifyWebhookSecret(secret, g.cfg.AzureDevOps.WebhookSecret) {
+		g.logger.Warn("rejected Azure DevOps webhook with invalid secret", "remote", r.RemoteAddr)
+		respondError(w, http.StatusUnauthorized, "invalid webhook secret")
+		return
+	}
+
+	var event devops.ServiceHookEvent
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxWebhookBodySize)).Decode(&event); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid webhook payload")
+		return
+	}
+
+	g.logger.Info("received Azure DevOps webhook", "event_type", event.EventType, "id", event.ID)
+
+	if r.URL.Query().Get("dry_run") == "true" {
+		respondJSON(w, http.StatusOK, map[string]string{
+			"event_type":   event.EventType,
+			"notification": devops.FormatServiceHookEvent(event),
+		})
+		return
+	}
+
+	ctx := context.WithoutCancel(r.Context())
+	for _, handler := range g.devopsHandlers {
+		go handler(ctx, event)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,268 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/config"
+	"github.com/abelclopes/nomad-iabot/internal/devops"
+)
+
+func TestUpdateWorkItemCustomFields(t *testing.T) {
+	var ops []map[string]interface{}
+	g := newTestGateway(t, withDevOps(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+			t.Errorf("failed to decode patch document: %v", err)
+		}
+		w.Write([]byte(`{"id":42,"rev":2,"fields":{"Custom.ReleaseNotes":"Fixed login"}}`))
+	}, "Custom.ReleaseNotes"))
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/devops/workitems/42",
+		strings.NewReader(`{"custom_fields":{"Custom.ReleaseNotes":"Fixed login"}}`))
+	rec := httptest.NewRecorder()
+	g.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	found := false
+	for _, op := range ops {
+		if op["path"] == "/fields/Custom.ReleaseNotes" && op["value"] == "Fixed login" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("patch document missing custom field: %v", ops)
+	}
+}
+
+func TestCustomFieldsRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{
+			name:   "Update with field not in allowlist",
+			method: http.MethodPatch,
+			path:   "/api/v1/devops/workitems/42",
+			body:   `{"custom_fields":{"System.AreaPath":"proj\\Secret"}}`,
+		},
+		{
+			name:   "Update with malformed field name",
+			method: http.MethodPatch,
+			path:   "/api/v1/devops/workitems/42",
+			body:   `{"custom_fields":{"ReleaseNotes":"x"}}`,
+		},
+		{
+			name:   "Create with field not in allowlist",
+			method: http.MethodPost,
+			path:   "/api/v1/devops/workitems",
+			body:   `{"type":"Task","title":"Test","custom_fields":{"Custom.Other":"x"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGateway(t, withDevOps(func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("unexpected request to Azure DevOps: %s %s", r.Method, r.URL.Path)
+			}, "Custom.ReleaseNotes"))
+
+			rec := httptest.NewRecorder()
+			g.router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, expected %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestDevOpsWebhook(t *testing.T) {
+	const payload = `{"eventType":"build.complete","resource":{"buildNumber":"20240101.1","result":"failed","definition":{"name":"CI"}}}`
+
+	newGateway := func(t *testing.T) *Gateway {
+		return newTestGateway(t, withJWT("test-jwt-secret"), withConfig(func(cfg *config.Config) {
+			cfg.AzureDevOps.WebhookSecret = "hook-secret"
+		}))
+	}
+
+	t.Run("Invalid secret is rejected", func(t *testing.T) {
+		g := newGateway(t)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/devops/webhook", strings.NewReader(payload))
+		req.Header.Set(devops.WebhookSecretHeader, "wrong")
+		rec := httptest.NewRecorder()
+		g.router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, expected %d", rec.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("Basic auth secret dispatches event", func(t *testing.T) {
+		g := newGateway(t)
+		events := make(chan devops.ServiceHookEvent, 1)
+		g.OnDevOpsEvent(func(ctx context.Context, event devops.ServiceHookEvent) {
+			events <- event
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/devops/webhook", strings.NewReader(payload))
+		req.SetBasicAuth("azure", "hook-secret")
+		rec := httptest.NewRecorder()
+		g.router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+		}
+		select {
+		case event := <-events:
+			if event.EventType != "build.complete" {
+				t.Errorf("EventType = %q", event.EventType)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("handler was not called")
+		}
+	})
+
+	t.Run("Dry run echoes notification", func(t *testing.T) {
+		g := newGateway(t)
+		g.OnDevOpsEvent(func(ctx context.Context, event devops.ServiceHookEvent) {
+			t.Error("handler called in dry-run mode")
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/devops/webhook?dry_run=true", strings.NewReader(payload))
+		req.Header.Set(devops.WebhookSecretHeader, "hook-secret")
+		rec := httptest.NewRecorder()
+		g.router.ServeHTTP(rec, req)
+
+		var resp map[string]string
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if rec.Code != http.StatusOK || !strings.Contains(resp["notification"], "Build 20240101.1 (CI): failed") {
+			t.Errorf("status = %d, response = %v", rec.Code, resp)
+		}
+	})
+}
+
+func TestDevOpsBreakerShortCircuits(t *testing.T) {
+	var calls int
+	g := newTestGateway(t, withDevOps(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}), withAgent(), withConfig(func(cfg *config.Config) {
+		cfg.Breaker = config.BreakerConfig{FailureThreshold: 2, CooldownSec: 60}
+	}))
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		g.router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/devops/workitems/1", nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("request %d: status = %d", i, rec.Code)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Azure DevOps called %d times, want 2 before the breaker opens", calls)
+	}
+
+	rec := httptest.NewRecorder()
+	g.router.ServeHTTP(rec, httptest.NewRequest("GET", "/health/detail", nil))
+	var detail HealthDetail
+	if err := json.NewDecoder(rec.Body).Decode(&detail); err != nil {
+		t.Fatalf("decode health detail: %v", err)
+	}
+	if detail.Status != "degraded" {
+		t.Errorf("status = %q, want degraded", detail.Status)
+	}
+	var found bool
+	for _, st := range detail.Integrations {
+		if st.Name == "azure_devops" {
+			found = true
+			if st.State != "open" || st.Rejected != 1 {
+				t.Errorf("azure_devops breaker = %+v, want open with 1 rejected call", st)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("integrations = %+v, missing azure_devops", detail.Integrations)
+	}
+}
+
+func TestValidateWIQL(t *testing.T) {
+	g := newTestGateway(t, withDevOps(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if strings.Contains(body["query"], "[System.Stat]") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"TF51005: The query references a field that does not exist. The error is caused by «[System.Stat]».","typeKey":"WorkItemTrackingQueryInvalidFieldException"}`))
+			return
+		}
+		if r.URL.Query().Get("$top") != "" {
+			t.Errorf("count requested but $top = %q", r.URL.Query().Get("$top"))
+		}
+		w.Write([]byte(`{"queryType":"flat","workItems":[{"id":1},{"id":2},{"id":3}]}`))
+	}))
+
+	validate := func(body string) (int, WIQLValidateResponse) {
+		rec := httptest.NewRecorder()
+		g.router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/devops/wiql/validate", strings.NewReader(body)))
+		var resp WIQLValidateResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp
+	}
+
+	code, resp := validate(`{"query":"SELECT [System.Id] FROM WorkItems WHERE [System.State] = 'Active; or not'","count":true}`)
+	if code != http.StatusOK || !resp.Valid || resp.Count == nil || *resp.Count != 3 {
+		t.Errorf("valid query: status = %d, response = %+v", code, resp)
+	}
+
+	code, resp = validate(`{"query":"SELECT [System.Id] FROM WorkItems WHERE [System.Stat] = 'Active'"}`)
+	if code != http.StatusOK || resp.Valid || !strings.HasPrefix(resp.Error, "TF51005: The query references a field that does not exist") {
+		t.Errorf("invalid field: status = %d, response = %+v", code, resp)
+	}
+
+	// Rejected before reaching Azure DevOps
+	code, resp = validate(`{"query":"SELECT [System.Id] FROM WorkItems; DROP"}`)
+	if code != http.StatusOK || resp.Valid || resp.Error != "query must be a single statement" {
+		t.Errorf("two statements: status = %d, response = %+v", code, resp)
+	}
+
+	if code, _ = validate(`{}`); code != http.StatusBadRequest {
+		t.Errorf("missing query: status = %d, want 400", code)
+	}
+}
+
+func TestReadOnlyRejectsMutatingEndpoints(t *testing.T) {
+	var calls int
+	g := newTestGateway(t, withDevOps(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"count":1,"value":[{"id":1,"name":"web-ci"}]}`))
+	}))
+	g.cfg.Security.ReadOnly = true
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPost, "/api/v1/devops/workitems", `{"type":"Task","title":"x"}`},
+		{http.MethodPatch, "/api/v1/devops/workitems/42", `{"title":"x"}`},
+		{http.MethodPost, "/api/v1/devops/pipelines/1/run", `{}`},
+	} {
+		rec := httptest.NewRecorder()
+		g.router.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s status = %d, want 403", tc.method, tc.path, rec.Code)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("read-only mode sent %d requests to Azure DevOps", calls)
+	}
+
+	rec := httptest.NewRecorder()
+	g.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/devops/pipelines", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET pipelines status = %d, want 200 in read-only mode", rec.Code)
+	}
+}
@@ -0,0 +1,73 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/abelclopes/nomad-iabot/internal/trello"
+)
+
+// maxWebhookBodySize bounds webhook payloads (Trello actions are a few KB)
+const maxWebhookBodySize = 1 << 20
+
+// TrelloWebhookHandler receives verified Trello webhook events
+type TrelloWebhookHandler func(ctx context.Context, event trello.WebhookEvent)
+
+// handleTrelloWebhookPing answers the HEAD request Trello sends when a webhook is created
+func (g *Gateway) handleTrelloWebhookPing(w http.ResponseWriter, r *http.Request) {
+	if g.cfg.Trello.WebhookSecret == "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (g *Gateway) handleTrelloWebhook(w http.ResponseWriter, r *http.Request) {
+	if g.cfg.Trello.WebhookSecret == "" {
+		respondError(w, http.StatusNotFound, "Trello webhooks are not configured")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	if !trello.VerifyWebhookSignature(body, g.trelloCallbackURL(r), g.cfg.Trello.WebhookSecret, r.Header.Get(trello.WebhookSignatureHeader)) {
+		g.logger.Warn("rejected Trello webhook with invalid signature", "remote", r.RemoteAddr)
+		respondError(w, http.StatusUnauthorized, "invalid webhook signature")
+		return
+	}
+
+	var event trello.WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid webhook payload")
+		return
+	}
+
+	g.logger.Info("received Trello webhook", "action", event.Action.Type, "model", event.Model.ID)
+
+	// Trello expects a quick 200, so handlers run after the response
+	ctx := context.WithoutCancel(r.Context())
+	for _, handler := range g.trelloHandlers {
+		go handler(ctx, event)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// trelloCallbackURL returns the callback URL Trello signed. The configured
+// URL wins because proxies may rewrite the scheme or host.
+func (g *Gateway) trelloCallbackURL(r *http.Request) string {
+	if g.cfg.Trello.WebhookCallbackURL != "" {
+		return g.cfg.Trello.WebhookCallbackURL
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
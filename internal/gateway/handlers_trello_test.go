@@ -0,0 +1,87 @@
+package gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/config"
+	"github.com/abelclopes/nomad-iabot/internal/trello"
+)
+
+const testCallbackURL = "https://bot.example.com/api/v1/trello/webhook"
+
+// withTrelloWebhook verifies Trello webhook signatures for testCallbackURL
+func withTrelloWebhook() testOption {
+	return withConfig(func(cfg *config.Config) {
+		cfg.Trello.WebhookSecret = "app-secret"
+		cfg.Trello.WebhookCallbackURL = testCallbackURL
+	})
+}
+
+func TestTrelloWebhookPing(t *testing.T) {
+	g := newTestGateway(t, withJWT("test-jwt-secret"), withTrelloWebhook())
+
+	rec := httptest.NewRecorder()
+	g.router.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/api/v1/trello/webhook", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("HEAD status = %d, expected %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestTrelloWebhookSignature(t *testing.T) {
+	body := `{"action":{"type":"commentCard","data":{"text":"LGTM","card":{"id":"c1","name":"Fix login"}}},"model":{"id":"b1"}}`
+
+	mac := hmac.New(sha1.New, []byte("app-secret"))
+	mac.Write([]byte(body + testCallbackURL))
+	valid := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	t.Run("Valid signature dispatches event", func(t *testing.T) {
+		g := newTestGateway(t, withJWT("test-jwt-secret"), withTrelloWebhook())
+		events := make(chan trello.WebhookEvent, 1)
+		g.OnTrelloEvent(func(ctx context.Context, event trello.WebhookEvent) {
+			events <- event
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/trello/webhook", strings.NewReader(body))
+		req.Header.Set(trello.WebhookSignatureHeader, valid)
+		rec := httptest.NewRecorder()
+		g.router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+		}
+
+		select {
+		case event := <-events:
+			if event.Action.Type != "commentCard" || event.Action.Data.Text != "LGTM" {
+				t.Errorf("unexpected event: %+v", event.Action)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("handler was not called")
+		}
+	})
+
+	t.Run("Invalid signature is rejected", func(t *testing.T) {
+		g := newTestGateway(t, withJWT("test-jwt-secret"), withTrelloWebhook())
+		g.OnTrelloEvent(func(ctx context.Context, event trello.WebhookEvent) {
+			t.Error("handler called for unverified request")
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/trello/webhook", strings.NewReader(body))
+		req.Header.Set(trello.WebhookSignatureHeader, "bm90LXRoZS1zaWduYXR1cmU=")
+		rec := httptest.NewRecorder()
+		g.router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, expected %d", rec.Code, http.StatusUnauthorized)
+		}
+	})
+}
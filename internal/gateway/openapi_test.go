@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/abelclopes/nomad-iabot/internal/channels"
+)
+
+type openAPIDocument struct {
+	OpenAPI string                                `json:"openapi"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	g := newTestGateway(t)
+	// Rebuild the routes with the OpenAPI endpoint enabled
+	g.cfg.Gateway.OpenAPI = true
+	g.router = chi.NewRouter()
+	g.setupRoutes()
+	g.RegisterWebChat(channels.NewWebChatChannel(slog.New(slog.NewTextHandler(io.Discard, nil)), nil))
+
+	rec := httptest.NewRecorder()
+	g.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json status = %d", rec.Code)
+	}
+
+	var doc openAPIDocument
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("openapi.json does not parse: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, expected an OpenAPI 3 document", doc.OpenAPI)
+	}
+
+	documented := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method := range item {
+			if method != "parameters" {
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+
+	registered := make(map[string]bool)
+	err := chi.Walk(g.router, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		// Static WebChat assets are not part of the API
+		if route == "/webchat/*" {
+			return nil
+		}
+		registered[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("chi.Walk() error = %v", err)
+	}
+
+	if len(registered) == 0 {
+		t.Fatal("no routes registered")
+	}
+	for route := range registered {
+		if !documented[route] {
+			t.Errorf("route %s is registered but missing from openapi.json", route)
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			t.Errorf("openapi.json documents %s, which is not registered", route)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package gateway
+
+import "net/http"
+
+// readOnlyMiddleware refuses endpoints that change Azure DevOps or Trello
+// when READ_ONLY is set
+func (g *Gateway) readOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.cfg.Security.ReadOnly {
+			respondError(w, http.StatusForbidden, "this instance is read-only")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Route is a registered method and path pattern
+type Route struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// Routes returns every route registered on the router, sorted by path then method
+func (g *Gateway) Routes() []Route {
+	var routes []Route
+	chi.Walk(g.router, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		routes = append(routes, Route{Method: method, Path: route})
+		return nil
+	})
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// logRoutes logs the route table at startup so a missing or renamed route
+// shows up in the logs before anyone calls it
+func (g *Gateway) logRoutes() {
+	routes := g.Routes()
+	for _, rt := range routes {
+		g.logger.Debug("route registered", "method", rt.Method, "path", rt.Path)
+	}
+	g.logger.Info("HTTP routes registered", "count", len(routes))
+}
+
+func (g *Gateway) handleListRoutes(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, g.Routes())
+}
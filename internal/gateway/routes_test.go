@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/channels"
+)
+
+// expectedRoutes is the full route table. Update it deliberately when a
+// route is added, renamed or removed.
+var expectedRoutes = []Route{
+	{"GET", "/api/v1/admin/routes"},
+	{"GET", "/api/v1/admin/tool-stats"},
+	{"GET", "/api/v1/admin/tools"},
+	{"POST", "/api/v1/admin/tools/{name}"},
+	{"DELETE", "/api/v1/admin/usage"},
+	{"GET", "/api/v1/admin/usage"},
+	{"POST", "/api/v1/chat"},
+	{"POST", "/api/v1/chat/batch"},
+	{"POST", "/api/v1/chat/stream"},
+	{"GET", "/api/v1/config"},
+	{"GET", "/api/v1/devops/boards"},
+	{"GET", "/api/v1/devops/pipelines"},
+	{"POST", "/api/v1/devops/pipelines/{id}/run"},
+	{"GET", "/api/v1/devops/repos"},
+	{"POST", "/api/v1/devops/webhook"},
+	{"POST", "/api/v1/devops/wiql/validate"},
+	{"GET", "/api/v1/devops/workitems"},
+	{"POST", "/api/v1/devops/workitems"},
+	{"GET", "/api/v1/devops/workitems/{id}"},
+	{"PATCH", "/api/v1/devops/workitems/{id}"},
+	{"GET", "/api/v1/devops/workitems/{id}/comments"},
+	{"POST", "/api/v1/feedback"},
+	{"GET", "/api/v1/llm/cache"},
+	{"GET", "/api/v1/sessions"},
+	{"DELETE", "/api/v1/sessions/{id}"},
+	{"GET", "/api/v1/sessions/{id}"},
+	{"GET", "/api/v1/tools"},
+	{"POST", "/api/v1/tools/{name}/execute"},
+	{"HEAD", "/api/v1/trello/webhook"},
+	{"POST", "/api/v1/trello/webhook"},
+	{"GET", "/api/v1/usage"},
+	{"GET", "/health"},
+	{"GET", "/health/detail"},
+	{"GET", "/ready"},
+	{"GET", "/webchat/*"},
+	{"POST", "/webchat/api/sessions"},
+	{"DELETE", "/webchat/api/sessions/{id}"},
+	{"GET", "/webchat/api/sessions/{id}"},
+	{"POST", "/webchat/api/sessions/{id}/link"},
+	{"GET", "/webchat/api/sessions/{id}/messages"},
+	{"POST", "/webchat/api/sessions/{id}/messages"},
+	{"POST", "/webchat/api/sessions/{id}/messages/stream"},
+	{"GET", "/ws"},
+}
+
+func TestRoutes(t *testing.T) {
+	g := newTestGateway(t, withAdminToken("admin-secret"))
+	g.RegisterWebChat(channels.NewWebChatChannel(slog.New(slog.NewTextHandler(io.Discard, nil)), nil))
+
+	rec := adminRequest(g, http.MethodGet, "/api/v1/admin/routes", "admin-secret", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/admin/routes status = %d", rec.Code)
+	}
+
+	var got []Route
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode routes: %v", err)
+	}
+
+	registered := make(map[Route]bool, len(got))
+	for _, rt := range got {
+		registered[rt] = true
+	}
+	expected := make(map[Route]bool, len(expectedRoutes))
+	for _, rt := range expectedRoutes {
+		expected[rt] = true
+		if !registered[rt] {
+			t.Errorf("route %s %s is not registered", rt.Method, rt.Path)
+		}
+	}
+	for _, rt := range got {
+		// Static WebChat assets are mounted for every method
+		if rt.Path == "/webchat/*" {
+			continue
+		}
+		if !expected[rt] {
+			t.Errorf("unexpected route %s %s; add it to expectedRoutes", rt.Method, rt.Path)
+		}
+	}
+}
+
+func TestRoutesNeedTheAdminToken(t *testing.T) {
+	g := newTestGateway(t, withAdminToken("admin-secret"))
+
+	if rec := adminRequest(g, http.MethodGet, "/api/v1/admin/routes", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /api/v1/admin/routes without a token status = %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,25 @@
+package gateway
+
+import (
+	"net/http"
+	"os"
+)
+
+// webchatStatic serves the WebChat frontend from the configured directory.
+// When the directory is missing it logs once and answers 404 with a clear
+// message instead of an empty file listing.
+func (g *Gateway) webchatStatic() http.Handler {
+	dir := g.cfg.Gateway.WebChatStaticDir
+	if dir == "" {
+		dir = "./web/dist"
+	}
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		g.logger.Warn("WebChat static directory not found; set WEBCHAT_STATIC_DIR", "dir", dir)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			respondError(w, http.StatusNotFound, "WebChat assets are not installed")
+		})
+	}
+
+	return http.StripPrefix("/webchat/", http.FileServer(http.Dir(dir)))
+}
@@ -0,0 +1,49 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/config"
+)
+
+// withStaticDir serves the WebChat files from dir
+func withStaticDir(dir string) testOption {
+	return withConfig(func(cfg *config.Config) {
+		cfg.Gateway.WebChatStaticDir = dir
+	})
+}
+
+func TestWebChatServesCustomStaticDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("console.log('nomad')"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g := newTestGateway(t, withStaticDir(dir))
+
+	rec := httptest.NewRecorder()
+	g.router.ServeHTTP(rec, httptest.NewRequest("GET", "/webchat/app.js", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "nomad") {
+		t.Errorf("body = %q, want the file from the custom dir", rec.Body.String())
+	}
+}
+
+func TestWebChatMissingStaticDir(t *testing.T) {
+	g := newTestGateway(t, withStaticDir(filepath.Join(t.TempDir(), "missing")))
+
+	rec := httptest.NewRecorder()
+	g.router.ServeHTTP(rec, httptest.NewRequest("GET", "/webchat/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "not installed") {
+		t.Errorf("body = %q, want an explanation", rec.Body.String())
+	}
+}
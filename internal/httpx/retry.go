@@ -0,0 +1,178 @@
+// Package httpx holds the HTTP plumbing shared by the API clients
+package httpx
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy controls which responses RetryTransport retries and how long it
+// waits between attempts
+type Policy struct {
+	MaxRetries int           // retries after the first attempt
+	BaseDelay  time.Duration // backoff before the first retry, doubled after each one
+	MaxDelay   time.Duration // cap on any wait, including a server's Retry-After
+	Statuses   []int         // response codes worth retrying
+}
+
+// DefaultPolicy retries throttled (429) and temporarily unavailable (503)
+// responses, which both mean the request was not processed
+var DefaultPolicy = Policy{
+	MaxRetries: 3,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+	Statuses:   []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+}
+
+// IdempotencyKeyHeader marks a request the server deduplicates, which makes
+// it safe to retry whatever its method
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Limiter paces outbound requests
+type Limiter interface {
+	// Wait blocks until a request may be sent or ctx is done
+	Wait(ctx context.Context) error
+}
+
+// LimiterFunc adapts a function to Limiter
+type LimiterFunc func(ctx context.Context) error
+
+// Wait implements Limiter
+func (f LimiterFunc) Wait(ctx context.Context) error {
+	return f(ctx)
+}
+
+// RetryTransport is an http.RoundTripper that retries the responses its
+// policy lists. It waits for the Retry-After the server asks for, or a
+// jittered exponential backoff, and gives up early when the request's
+// context ends. Requests whose body cannot be replayed are sent once.
+//
+// A 429 means the request was not processed, so it is retried for any
+// method. Other statuses are only retried for idempotent methods or
+// requests carrying an IdempotencyKeyHeader: a POST that failed with a 503
+// may have been applied anyway.
+type RetryTransport struct {
+	Base   http.RoundTripper
+	Policy Policy
+	// Limiter, when set, is waited on before every attempt, so retries are
+	// paced like first attempts
+	Limiter Limiter
+}
+
+// NewRetryTransport wraps base (http.DefaultTransport when nil) with policy
+func NewRetryTransport(base http.RoundTripper, policy Policy) *RetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RetryTransport{Base: base, Policy: policy}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if t.Limiter != nil {
+			if err := t.Limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := t.Base.RoundTrip(req)
+		if err != nil || !t.retryable(req, resp.StatusCode) || attempt >= t.Policy.MaxRetries {
+			return resp, err
+		}
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, nil
+		}
+
+		wait := t.delay(resp, attempt)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err := Sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			// The transport must not modify the caller's request
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+func (t *RetryTransport) retryable(req *http.Request, status int) bool {
+	for _, s := range t.Policy.Statuses {
+		if s == status {
+			return status == http.StatusTooManyRequests || idempotent(req)
+		}
+	}
+	return false
+}
+
+// idempotent reports whether sending req twice has the same effect as
+// sending it once
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// delay returns how long to wait before retrying resp: its Retry-After, or
+// a backoff of BaseDelay doubled per attempt with up to half of it jittered
+// away so clients throttled together do not retry together
+func (t *RetryTransport) delay(resp *http.Response, attempt int) time.Duration {
+	wait, ok := RetryAfter(resp)
+	if !ok {
+		wait = t.Policy.BaseDelay << attempt
+		if wait > 0 {
+			wait -= time.Duration(rand.Int63n(int64(wait)/2 + 1))
+		}
+	}
+	if t.Policy.MaxDelay > 0 && wait > t.Policy.MaxDelay {
+		wait = t.Policy.MaxDelay
+	}
+	return wait
+}
+
+// RetryAfter parses resp's Retry-After header (seconds or HTTP date); ok is
+// false when it is missing or malformed
+func RetryAfter(resp *http.Response) (wait time.Duration, ok bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		wait = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		wait = time.Until(t)
+	} else {
+		return 0, false
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+// Sleep waits for d or until ctx is done, returning ctx's error in that case
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
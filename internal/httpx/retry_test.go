@@ -0,0 +1,192 @@
+package httpx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testPolicy retries quickly so tests do not wait out real backoffs
+var testPolicy = Policy{
+	MaxRetries: 3,
+	BaseDelay:  time.Millisecond,
+	MaxDelay:   50 * time.Millisecond,
+	Statuses:   []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+}
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) (*http.Client, string) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &http.Client{Transport: NewRetryTransport(nil, testPolicy)}, srv.URL
+}
+
+func TestRetriesTooManyRequestsWithBody(t *testing.T) {
+	var calls int32
+	client, url := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"name":"x"}` {
+			t.Errorf("attempt %d body = %q", atomic.LoadInt32(&calls)+1, body)
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	resp, err := client.Post(url, "application/json", strings.NewReader(`{"name":"x"}`))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("status = %d after %d requests, expected 200 after 2", resp.StatusCode, calls)
+	}
+}
+
+func TestRetriesServiceUnavailableUntilLimit(t *testing.T) {
+	var calls int32
+	client, url := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, expected the last 503", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(&calls); got != int32(testPolicy.MaxRetries+1) {
+		t.Errorf("expected %d requests, got %d", testPolicy.MaxRetries+1, got)
+	}
+}
+
+func TestDoesNotRetryOtherStatuses(t *testing.T) {
+	var calls int32
+	client, url := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("500 sent %d requests, expected 1", got)
+	}
+}
+
+func TestRetriesNonIdempotentRequestsOnlyWhenSafe(t *testing.T) {
+	var calls int32
+	client, url := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	// A POST that got a 503 may have been applied, so it is sent once
+	resp, err := client.Post(url, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	resp.Body.Close()
+	if got := atomic.SwapInt32(&calls, 0); got != 1 {
+		t.Errorf("POST sent %d requests on 503, expected 1", got)
+	}
+
+	// unless the server deduplicates it
+	req, _ := http.NewRequest("PATCH", url, strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "req-1")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+	if got := atomic.LoadInt32(&calls); got != int32(testPolicy.MaxRetries+1) {
+		t.Errorf("PATCH with an idempotency key sent %d requests, expected %d", got, testPolicy.MaxRetries+1)
+	}
+}
+
+func TestLimiterPacesEveryAttempt(t *testing.T) {
+	var calls int32
+	client, url := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	var waits int32
+	client.Transport.(*RetryTransport).Limiter = LimiterFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&waits, 1)
+		return nil
+	})
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if got := atomic.LoadInt32(&waits); got != 2 {
+		t.Errorf("limiter waited %d times, expected once per attempt (2)", got)
+	}
+}
+
+func TestRetryStopsWhenContextEnds(t *testing.T) {
+	client, url := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	client.Transport.(*RetryTransport).Policy.MaxDelay = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+
+	start := time.Now()
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("expected the context error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited %v, expected to stop with the context", elapsed)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	rt := NewRetryTransport(nil, Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second})
+
+	header := func(v string) *http.Response {
+		resp := &http.Response{Header: http.Header{}}
+		if v != "" {
+			resp.Header.Set("Retry-After", v)
+		}
+		return resp
+	}
+
+	if got := rt.delay(header("1"), 0); got != time.Second {
+		t.Errorf("Retry-After: 1 waits %v, expected 1s", got)
+	}
+	if got := rt.delay(header("120"), 0); got != 2*time.Second {
+		t.Errorf("Retry-After: 120 waits %v, expected the 2s cap", got)
+	}
+	if got := rt.delay(header(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)), 0); got != 0 {
+		t.Errorf("past Retry-After date waits %v, expected 0", got)
+	}
+	for attempt := 0; attempt < 3; attempt++ {
+		backoff := 100 * time.Millisecond << attempt
+		if got := rt.delay(header(""), attempt); got < backoff/2 || got > backoff {
+			t.Errorf("attempt %d backoff = %v, expected between %v and %v", attempt, got, backoff/2, backoff)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package httpx
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// DefaultUserAgent is sent until SetUserAgent is called
+const DefaultUserAgent = "nomad-iabot"
+
+var userAgent atomic.Value
+
+// SetUserAgent sets the User-Agent of every outbound request made through
+// a UserAgentTransport. An empty value restores DefaultUserAgent.
+func SetUserAgent(ua string) {
+	if ua == "" {
+		ua = DefaultUserAgent
+	}
+	userAgent.Store(ua)
+}
+
+// UserAgent returns the User-Agent sent on outbound requests
+func UserAgent() string {
+	if ua, ok := userAgent.Load().(string); ok {
+		return ua
+	}
+	return DefaultUserAgent
+}
+
+// UserAgentTransport sets the process-wide User-Agent on requests that do
+// not carry one, so APIs and proxies can tell this service's traffic apart
+type UserAgentTransport struct {
+	Base http.RoundTripper
+}
+
+// NewUserAgentTransport wraps base (http.DefaultTransport when nil)
+func NewUserAgentTransport(base http.RoundTripper) *UserAgentTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &UserAgentTransport{Base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *UserAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		// The transport must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", UserAgent())
+	}
+	return t.Base.RoundTrip(req)
+}
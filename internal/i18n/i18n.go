@@ -0,0 +1,278 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported locales
+const (
+	PtBR = "pt-BR"
+	En   = "en"
+)
+
+// Fallback is used when neither the user nor the configuration selects a supported locale
+const Fallback = PtBR
+
+// messages holds user-facing strings keyed by locale, then by message key.
+// Every key must exist in the fallback locale.
+var messages = map[string]map[string]string{
+	PtBR: {
+		"start": "👋 Olá! Eu sou o Nomad Agent. Como posso ajudar?",
+		"help": `🤖 *Nomad Agent*
+
+Comandos disponíveis:
+/start - Iniciar conversa
+/help - Mostrar esta ajuda
+/status - Ver status do sistema
+/workitems - Listar work items (Azure DevOps)
+/feedback <texto> - Enviar feedback sobre o bot
+/newitem - Criar um work item passo a passo
+/cancel - Cancelar a criação em andamento
+/usage - Ver seu consumo de tokens
+/export [md|txt] - Exportar o histórico da conversa
+/plan - Ligar/desligar o modo plano (explica as ações antes de executar)
+/link - Gerar um código para vincular o WebChat a esta conta
+
+Envie qualquer mensagem para conversar com o agente.`,
+		"help.setup":         "ℹ️ Nenhuma integração está ativa, então por enquanto eu só converso. Para Azure DevOps, defina `AZURE_DEVOPS_ORGANIZATION` e `AZURE_DEVOPS_PAT`; para Trello, `TRELLO_ENABLED=true`, `TRELLO_API_KEY` e `TRELLO_TOKEN`. Depois reinicie o bot.",
+		"start.capabilities": "Posso ajudar com:",
+		"capability.chat":    "Responder perguntas",
+		"capability.code":    "Programação e desenvolvimento",
+		"capability.devops":  "Azure DevOps: work items, pipelines e repositórios",
+		"capability.trello":  "Trello: boards, listas e cards",
+
+		"status.ok":          "✅ Sistema operacional",
+		"status.degraded":    "⚠️ *Sistema degradado*",
+		"status.llm_ok":      "🤖 LLM `%s`: ✅ acessível",
+		"status.llm_down":    "🤖 LLM `%s`: ❌ inacessível",
+		"status.uptime":      "⏱ Em execução há %s",
+		"status.service_ok":  "%s: ✅",
+		"status.credentials": "%s: ❌ credenciais rejeitadas",
+		"status.circuit":     "%s: ⚠️ indisponível (circuito aberto)",
+		"error.unauthorized": "❌ Você não tem permissão para usar este bot.",
+		"error.too_long":     "❌ Mensagem muito longa. Por favor, envie um texto menor.",
+		"error.processing":   "❌ Desculpe, ocorreu um erro ao processar sua mensagem.",
+		"error.truncated":    "⚠️ Não consegui enviar o restante da resposta. Tente novamente em instantes.",
+		"error.busy":         "⏳ Estou atendendo muitas mensagens agora. Tente novamente em alguns segundos.",
+		"error.timeout":      "⌛ Sua mensagem levou tempo demais para ser processada. Tente uma pergunta mais simples ou tente novamente.",
+		"error.shutdown":     "⚠️ Estou reiniciando e não consegui terminar sua solicitação. Envie novamente em instantes.",
+		"error.empty":        "🤔 Sua mensagem chegou sem texto. Como posso ajudar?",
+		"progress.working":   "⏳ Ainda estou trabalhando nisso…",
+
+		"usage.disabled":    "ℹ️ O controle de uso não está habilitado.",
+		"usage.summary":     "📊 *Seu uso*\n\nHoje: %d requisições, %d tokens\nTotal: %d requisições, %d tokens",
+		"usage.limit":       "\nLimite diário: %d tokens",
+		"usage.daily_limit": "⏳ Você atingiu o limite diário de uso. Tente novamente amanhã.",
+
+		"feedback.disabled": "ℹ️ O envio de feedback não está configurado.",
+		"feedback.usage":    "Uso: /feedback <descreva o problema ou sugestão>",
+		"feedback.failed":   "❌ Não foi possível registrar seu feedback.",
+		"feedback.done":     "✅ Obrigado! Feedback registrado: %s",
+
+		"export.disabled":  "ℹ️ A exportação de conversas não está habilitada.",
+		"export.usage":     "Uso: /export [md|txt]",
+		"export.empty":     "ℹ️ Ainda não há histórico de conversa para exportar.",
+		"export.failed":    "❌ Não foi possível exportar a conversa.",
+		"export.caption":   "📄 Histórico da sua conversa",
+		"export.private":   "🔒 Por privacidade, use /export em uma conversa privada comigo.",
+		"export.title":     "Conversa com o Nomad Agent — %s",
+		"export.user":      "Você",
+		"export.assistant": "Nomad Agent",
+
+		"plan.disabled": "ℹ️ O modo plano não está habilitado.",
+		"plan.on":       "📋 Modo plano ligado: vou explicar o que pretendo fazer antes de usar ferramentas. Envie /plan para desligar.",
+		"plan.off":      "Modo plano desligado.",
+		"plan.header":   "📋 Plano:\n%s\n\n",
+
+		"link.disabled": "ℹ️ A vinculação de contas não está habilitada.",
+		"link.code":     "🔗 Seu código de vinculação: `%s`\n\nInforme-o no WebChat em até %d minutos para compartilhar o histórico e o consumo com esta conta.",
+		"link.failed":   "❌ Não foi possível gerar o código de vinculação.",
+
+		"stream.thinking": "⏳ Pensando…",
+		"stream.tool":     "🔧 Consultando %s…",
+
+		"newitem.disabled":       "ℹ️ A criação de work items não está configurada.",
+		"newitem.ask_type":       "🆕 Qual o tipo do work item? (/cancel para cancelar)",
+		"newitem.none":           "Nenhum /newitem em andamento.",
+		"newitem.invalid_type":   "Tipo inválido.",
+		"newitem.ask_title":      "📝 %s: qual o título?",
+		"newitem.pick_type":      "Escolha o tipo nos botões acima ou use /cancel.",
+		"newitem.empty_title":    "O título não pode ficar vazio.",
+		"newitem.ask_desc":       "📄 Envie uma descrição, ou /skip para criar sem descrição.",
+		"newitem.nothing_skip":   "Nada para pular.",
+		"newitem.cancelled":      "🚫 Criação do work item cancelada.",
+		"newitem.nothing_cancel": "Nada para cancelar.",
+		"newitem.failed":         "❌ Não foi possível criar o work item.",
+		"newitem.created":        "✅ %s criado: %s",
+
+		"cmd.start":     "Iniciar conversa",
+		"cmd.help":      "Mostrar ajuda",
+		"cmd.status":    "Ver status do sistema",
+		"cmd.workitems": "Listar meus work items",
+		"cmd.newitem":   "Criar um work item passo a passo",
+		"cmd.feedback":  "Enviar feedback sobre o bot",
+		"cmd.usage":     "Ver seu consumo de tokens",
+		"cmd.export":    "Exportar o histórico da conversa",
+		"cmd.plan":      "Ligar/desligar o modo plano",
+		"cmd.link":      "Vincular o WebChat a esta conta",
+
+		"agent.post_tool": "Use os resultados das ferramentas acima para responder ao usuário. Resuma o que importa em linguagem natural; não copie a saída bruta das ferramentas.",
+	},
+	En: {
+		"start": "👋 Hi! I'm Nomad Agent. How can I help?",
+		"help": `🤖 *Nomad Agent*
+
+Available commands:
+/start - Start a conversation
+/help - Show this help
+/status - Show system status
+/workitems - List work items (Azure DevOps)
+/feedback <text> - Send feedback about the bot
+/newitem - Create a work item step by step
+/cancel - Cancel the creation in progress
+/usage - Show your token usage
+/export [md|txt] - Export the conversation history
+/plan - Turn plan mode on/off (explains actions before running them)
+/link - Get a code to link WebChat to this account
+
+Send any message to chat with the agent.`,
+		"help.setup":         "ℹ️ No integration is active, so for now I can only chat. For Azure DevOps, set `AZURE_DEVOPS_ORGANIZATION` and `AZURE_DEVOPS_PAT`; for Trello, `TRELLO_ENABLED=true`, `TRELLO_API_KEY` and `TRELLO_TOKEN`. Then restart the bot.",
+		"start.capabilities": "I can help with:",
+		"capability.chat":    "Answering questions",
+		"capability.code":    "Programming and development",
+		"capability.devops":  "Azure DevOps: work items, pipelines and repositories",
+		"capability.trello":  "Trello: boards, lists and cards",
+
+		"status.ok":          "✅ System operational",
+		"status.degraded":    "⚠️ *System degraded*",
+		"status.llm_ok":      "🤖 LLM `%s`: ✅ reachable",
+		"status.llm_down":    "🤖 LLM `%s`: ❌ unreachable",
+		"status.uptime":      "⏱ Up for %s",
+		"status.service_ok":  "%s: ✅",
+		"status.credentials": "%s: ❌ credentials rejected",
+		"status.circuit":     "%s: ⚠️ unavailable (circuit open)",
+		"error.unauthorized": "❌ You are not allowed to use this bot.",
+		"error.too_long":     "❌ Message too long. Please send a shorter text.",
+		"error.processing":   "❌ Sorry, something went wrong while processing your message.",
+		"error.truncated":    "⚠️ I couldn't send the rest of the reply. Please try again in a moment.",
+		"error.busy":         "⏳ I'm handling a lot of messages right now. Please try again in a few seconds.",
+		"error.timeout":      "⌛ Your message took too long to process. Try a simpler request or try again.",
+		"error.shutdown":     "⚠️ I'm restarting and couldn't finish your request. Please send it again in a moment.",
+		"error.empty":        "🤔 Your message arrived without any text. How can I help?",
+		"progress.working":   "⏳ Still working on it…",
+
+		"usage.disabled":    "ℹ️ Usage tracking is not enabled.",
+		"usage.summary":     "📊 *Your usage*\n\nToday: %d requests, %d tokens\nTotal: %d requests, %d tokens",
+		"usage.limit":       "\nDaily limit: %d tokens",
+		"usage.daily_limit": "⏳ You have reached your daily usage limit. Please try again tomorrow.",
+
+		"feedback.disabled": "ℹ️ Feedback is not configured.",
+		"feedback.usage":    "Usage: /feedback <describe the problem or suggestion>",
+		"feedback.failed":   "❌ Could not record your feedback.",
+		"feedback.done":     "✅ Thanks! Feedback recorded: %s",
+
+		"export.disabled":  "ℹ️ Conversation export is not enabled.",
+		"export.usage":     "Usage: /export [md|txt]",
+		"export.empty":     "ℹ️ There is no conversation history to export yet.",
+		"export.failed":    "❌ Could not export the conversation.",
+		"export.caption":   "📄 Your conversation history",
+		"export.private":   "🔒 For privacy, use /export in a private chat with me.",
+		"export.title":     "Conversation with Nomad Agent — %s",
+		"export.user":      "You",
+		"export.assistant": "Nomad Agent",
+
+		"plan.disabled": "ℹ️ Plan mode is not enabled.",
+		"plan.on":       "📋 Plan mode on: I'll explain what I intend to do before using tools. Send /plan to turn it off.",
+		"plan.off":      "Plan mode off.",
+		"plan.header":   "📋 Plan:\n%s\n\n",
+
+		"link.disabled": "ℹ️ Account linking is not enabled.",
+		"link.code":     "🔗 Your link code: `%s`\n\nEnter it in WebChat within %d minutes to share history and usage with this account.",
+		"link.failed":   "❌ Could not create a link code.",
+
+		"stream.thinking": "⏳ Thinking…",
+		"stream.tool":     "🔧 Checking %s…",
+
+		"newitem.disabled":       "ℹ️ Work item creation is not configured.",
+		"newitem.ask_type":       "🆕 Which work item type? (/cancel to cancel)",
+		"newitem.none":           "No /newitem in progress.",
+		"newitem.invalid_type":   "Invalid type.",
+		"newitem.ask_title":      "📝 %s: what is the title?",
+		"newitem.pick_type":      "Pick a type from the buttons above or use /cancel.",
+		"newitem.empty_title":    "The title cannot be empty.",
+		"newitem.ask_desc":       "📄 Send a description, or /skip to create it without one.",
+		"newitem.nothing_skip":   "Nothing to skip.",
+		"newitem.cancelled":      "🚫 Work item creation cancelled.",
+		"newitem.nothing_cancel": "Nothing to cancel.",
+		"newitem.failed":         "❌ Could not create the work item.",
+		"newitem.created":        "✅ %s created: %s",
+
+		"cmd.start":     "Start a conversation",
+		"cmd.help":      "Show help",
+		"cmd.status":    "Show system status",
+		"cmd.workitems": "List my work items",
+		"cmd.newitem":   "Create a work item step by step",
+		"cmd.feedback":  "Send feedback about the bot",
+		"cmd.usage":     "Show your token usage",
+		"cmd.export":    "Export the conversation history",
+		"cmd.plan":      "Turn plan mode on/off",
+		"cmd.link":      "Link WebChat to this account",
+
+		"agent.post_tool": "Use the tool results above to answer the user. Summarize what matters in plain language; do not copy the raw tool output.",
+	},
+}
+
+// Locales returns the supported locales
+func Locales() []string {
+	return []string{PtBR, En}
+}
+
+// LanguageCode returns the two-letter ISO 639-1 code for a locale, as used by Telegram
+func LanguageCode(locale string) string {
+	lang, _, _ := strings.Cut(Normalize(locale), "-")
+	return lang
+}
+
+// T returns the message for key in locale, formatted with args. Missing
+// translations fall back to the fallback locale, then to the key itself.
+func T(locale, key string, args ...interface{}) string {
+	msg, ok := messages[Normalize(locale)][key]
+	if !ok {
+		msg, ok = messages[Fallback][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Normalize maps a language tag such as "en-US" or "pt" to a supported
+// locale, returning "" when the language is not supported
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	lang, _, _ := strings.Cut(tag, "-")
+
+	switch lang {
+	case "pt":
+		return PtBR
+	case "en":
+		return En
+	}
+	return ""
+}
+
+// Resolve picks the locale for a user: their own language when supported,
+// otherwise the configured default, otherwise Fallback
+func Resolve(userLanguage, configured string) string {
+	if l := Normalize(userLanguage); l != "" {
+		return l
+	}
+	if l := Normalize(configured); l != "" {
+		return l
+	}
+	return Fallback
+}
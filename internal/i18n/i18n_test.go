@@ -0,0 +1,54 @@
+package i18n
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name         string
+		userLanguage string
+		configured   string
+		expected     string
+	}{
+		{"User language wins", "en", PtBR, En},
+		{"Regional tag", "en-US", PtBR, En},
+		{"Portuguese variant", "pt-PT", En, PtBR},
+		{"Unsupported user language uses config", "de", En, En},
+		{"No user language uses config", "", En, En},
+		{"Nothing supported uses fallback", "fr", "es", Fallback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Resolve(tt.userLanguage, tt.configured); result != tt.expected {
+				t.Errorf("Resolve(%q, %q) = %q, expected %q", tt.userLanguage, tt.configured, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	if result := T(En, "feedback.done", "https://x"); result != "✅ Thanks! Feedback recorded: https://x" {
+		t.Errorf("T(en, feedback.done) = %q", result)
+	}
+	if result := T("fr", "status.ok"); result != messages[Fallback]["status.ok"] {
+		t.Errorf("T(fr, status.ok) = %q, expected fallback", result)
+	}
+	if result := T(En, "missing.key"); result != "missing.key" {
+		t.Errorf("T(en, missing.key) = %q, expected key", result)
+	}
+}
+
+func TestLocalesHaveSameKeys(t *testing.T) {
+	for locale, table := range messages {
+		for key := range messages[Fallback] {
+			if _, ok := table[key]; !ok {
+				t.Errorf("locale %s is missing key %q", locale, key)
+			}
+		}
+		for key := range table {
+			if _, ok := messages[Fallback][key]; !ok {
+				t.Errorf("locale %s has key %q not present in %s", locale, key, Fallback)
+			}
+		}
+	}
+}
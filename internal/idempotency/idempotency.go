@@ -0,0 +1,99 @@
+package idempotency
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+// DefaultTTL is how long a completed result is remembered
+const DefaultTTL = 24 * time.Hour
+
+// keyPattern keeps keys safe to embed in tags and queries
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// ValidateKey reports whether key can be used as an idempotency key
+func ValidateKey(key string) error {
+	if !keyPattern.MatchString(key) {
+		return fmt.Errorf("invalid idempotency key %q: use up to 64 letters, digits, '.', '_', ':' or '-'", key)
+	}
+	return nil
+}
+
+// Store remembers the results of create operations by idempotency key so a
+// retried request returns the first result instead of creating a duplicate.
+// Failed operations are not remembered.
+type Store struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*entry
+	clock   clock.Clock
+}
+
+type entry struct {
+	done    chan struct{} // closed once the operation finished
+	value   interface{}
+	err     error
+	expires time.Time
+}
+
+// NewStore creates a store whose results live for ttl
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		ttl:     ttl,
+		entries: make(map[string]*entry),
+		clock:   clock.Real(),
+	}
+}
+
+// Do runs create once per key. Later calls with the same key, including
+// concurrent ones, get the first call's result with reused set. An empty
+// key always runs create.
+func (s *Store) Do(key string, create func() (interface{}, error)) (value interface{}, reused bool, err error) {
+	if key == "" {
+		v, err := create()
+		return v, false, err
+	}
+
+	s.mu.Lock()
+	now := s.clock.Now()
+	for k, e := range s.entries {
+		if isDone(e) && now.After(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+	if e, ok := s.entries[key]; ok {
+		s.mu.Unlock()
+		<-e.done
+		return e.value, e.err == nil, e.err
+	}
+
+	e := &entry{done: make(chan struct{})}
+	s.entries[key] = e
+	s.mu.Unlock()
+
+	e.value, e.err = create()
+
+	s.mu.Lock()
+	if e.err != nil {
+		delete(s.entries, key)
+	} else {
+		e.expires = s.clock.Now().Add(s.ttl)
+	}
+	s.mu.Unlock()
+	close(e.done)
+
+	return e.value, false, e.err
+}
+
+func isDone(e *entry) bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,60 @@
+package idempotency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+func TestStoreDo(t *testing.T) {
+	s := NewStore(time.Minute)
+	clk := clock.NewFake(time.Now())
+	s.clock = clk
+
+	calls := 0
+	create := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	v, reused, err := s.Do("k1", create)
+	if err != nil || reused || v != 1 {
+		t.Fatalf("first Do() = %v, %v, %v", v, reused, err)
+	}
+	v, reused, err = s.Do("k1", create)
+	if err != nil || !reused || v != 1 {
+		t.Errorf("second Do() = %v, %v, %v; expected the first result", v, reused, err)
+	}
+	if _, reused, _ = s.Do("", create); reused {
+		t.Error("empty key must never be reused")
+	}
+
+	// Expired results are forgotten
+	clk.Advance(2 * time.Minute)
+	if v, reused, _ = s.Do("k1", create); reused || v != 3 {
+		t.Errorf("Do() after TTL = %v, %v; expected a new result", v, reused)
+	}
+
+	// Failures are not remembered
+	if _, _, err = s.Do("k2", func() (interface{}, error) { return nil, errors.New("boom") }); err == nil {
+		t.Fatal("expected an error")
+	}
+	if v, reused, err = s.Do("k2", create); err != nil || reused {
+		t.Errorf("Do() after failure = %v, %v, %v; expected a retry", v, reused, err)
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"abc", "req-123_x.y:z"} {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("ValidateKey(%q) error = %v", key, err)
+		}
+	}
+	for _, key := range []string{"", "has space", "semi;colon", "quote'", string(make([]byte, 65))} {
+		if err := ValidateKey(key); err == nil {
+			t.Errorf("ValidateKey(%q) expected an error", key)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package identity links the IDs a person has on each channel to one
+// canonical user, so memory and usage follow them from Telegram to WebChat.
+package identity
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/atomicfile"
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+// CodeTTL is how long a link code can be redeemed
+const CodeTTL = 10 * time.Minute
+
+// codeAlphabet leaves out characters that are easily confused (0/O, 1/I/L)
+const (
+	codeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+	codeLength   = 6
+)
+
+var (
+	// ErrInvalidCode is returned for unknown, expired or already used codes
+	ErrInvalidCode = errors.New("invalid or expired link code")
+	// ErrAnonymous is returned when linking a user that has no identity
+	ErrAnonymous = errors.New("anonymous users cannot be linked")
+)
+
+// Store maps channel user IDs to canonical users. Static links come from
+// configuration; links made with codes are kept in memory and, with a
+// file, across restarts. Only the latter are saved, so a link removed from
+// configuration is gone after a restart.
+type Store struct {
+	mu     sync.Mutex
+	static map[string]string // "channel:id" -> canonical user, from configuration
+	links  map[string]string // "channel:id" -> canonical user, made with codes
+	codes  map[string]pendingCode
+	path   string // JSON file for links made at runtime ("" = not persisted)
+	clock  clock.Clock
+}
+
+type pendingCode struct {
+	canonical string
+	expires   time.Time
+}
+
+// NewStore creates a store with the given static links, keyed by
+// "channel:id", and loads the links saved in path, if any
+func NewStore(static map[string]string, path string) (*Store, error) {
+	s := &Store{
+		static: make(map[string]string, len(static)),
+		links:  make(map[string]string),
+		codes:  make(map[string]pendingCode),
+		path:   path,
+		clock:  clock.Real(),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	for key, canonical := range static {
+		s.static[strings.ToLower(key)] = canonical
+	}
+	return s, nil
+}
+
+// ParseLinks parses static links written as "channel:id=canonical",
+// comma-separated
+func ParseLinks(spec string) (map[string]string, error) {
+	links := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, canonical, ok := strings.Cut(entry, "=")
+		channel, id, hasChannel := strings.Cut(strings.TrimSpace(key), ":")
+		canonical = strings.TrimSpace(canonical)
+		if !ok || !hasChannel || channel == "" || id == "" || canonical == "" {
+			return nil, fmt.Errorf("invalid identity link %q: want channel:id=user", entry)
+		}
+		links[linkKey(channel, id)] = canonical
+	}
+	return links, nil
+}
+
+// Resolve returns the canonical user for userID on channel. Unlinked users
+// are "channel:id", so the same ID on two channels is two users until they
+// are linked. Anonymous users are returned unchanged.
+func (s *Store) Resolve(channel, userID string) string {
+	if isAnonymous(userID) {
+		return userID
+	}
+	key := linkKey(channel, userID)
+	if s == nil {
+		return key
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Configuration wins over links made at runtime
+	if canonical, ok := s.static[key]; ok {
+		return canonical
+	}
+	if canonical, ok := s.links[key]; ok {
+		return canonical
+	}
+	return key
+}
+
+// NewCode issues a short single-use code that links another channel's
+// identity to this user when redeemed within CodeTTL
+func (s *Store) NewCode(channel, userID string) (string, error) {
+	if isAnonymous(userID) {
+		return "", ErrAnonymous
+	}
+
+	code := make([]byte, codeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(codeAlphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate link code: %w", err)
+		}
+		code[i] = codeAlphabet[n.Int64()]
+	}
+
+	canonical := s.Resolve(channel, userID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	for c, p := range s.codes {
+		if now.After(p.expires) {
+			delete(s.codes, c)
+		}
+	}
+	s.codes[string(code)] = pendingCode{canonical: canonical, expires: now.Add(CodeTTL)}
+	return string(code), nil
+}
+
+// Redeem links userID on channel to the user that issued code and returns
+// that canonical user
+func (s *Store) Redeem(code, channel, userID string) (string, error) {
+	if isAnonymous(userID) {
+		return "", ErrAnonymous
+	}
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending, ok := s.codes[code]
+	if !ok || s.clock.Now().After(pending.expires) {
+		return "", ErrInvalidCode
+	}
+	delete(s.codes, code)
+
+	key := linkKey(channel, userID)
+	previous, existed := s.links[key]
+	s.links[key] = pending.canonical
+	if err := s.save(); err != nil {
+		if existed {
+			s.links[key] = previous
+		} else {
+			delete(s.links, key)
+		}
+		return "", err
+	}
+	return pending.canonical, nil
+}
+
+func linkKey(channel, userID string) string {
+	return strings.ToLower(strings.TrimSpace(channel)) + ":" + strings.TrimSpace(userID)
+}
+
+func isAnonymous(userID string) bool {
+	return userID == "" || userID == "anonymous"
+}
+
+// load reads the links made at runtime from the store's file. A missing file means
+// nothing has been linked yet.
+func (s *Store) load() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read identity links: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.links); err != nil {
+		return fmt.Errorf("failed to parse identity links %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// save writes the links made at runtime to the store's file, replacing it atomically.
+// Callers hold s.mu.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.links, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(s.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to save identity links: %w", err)
+	}
+	return nil
+}
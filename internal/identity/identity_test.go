@@ -0,0 +1,133 @@
+package identity
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+func TestParseLinks(t *testing.T) {
+	links, err := ParseLinks(" telegram:42=ana, WebChat:ana.silva=ana ,")
+	if err != nil {
+		t.Fatalf("ParseLinks() error = %v", err)
+	}
+	if len(links) != 2 || links["telegram:42"] != "ana" || links["webchat:ana.silva"] != "ana" {
+		t.Errorf("ParseLinks() = %v", links)
+	}
+
+	for _, spec := range []string{"telegram:42", "42=ana", "telegram:=ana", "telegram:42="} {
+		if _, err := ParseLinks(spec); err == nil {
+			t.Errorf("ParseLinks(%q) expected an error", spec)
+		}
+	}
+}
+
+func TestResolveStaticLinks(t *testing.T) {
+	s, err := NewStore(map[string]string{"telegram:42": "ana"}, "")
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if got := s.Resolve("telegram", "42"); got != "ana" {
+		t.Errorf("Resolve(telegram, 42) = %q, want ana", got)
+	}
+	if got := s.Resolve("webchat", "42"); got != "webchat:42" {
+		t.Errorf("Resolve(webchat, 42) = %q, links are per channel", got)
+	}
+
+	var none *Store
+	if got := none.Resolve("telegram", "42"); got != "telegram:42" {
+		t.Errorf("nil store Resolve() = %q", got)
+	}
+	if got := s.Resolve("api", "anonymous"); got != "anonymous" {
+		t.Errorf("Resolve(api, anonymous) = %q, want anonymous", got)
+	}
+}
+
+func TestLinkCodeIsSingleUseAndExpires(t *testing.T) {
+	s, _ := NewStore(nil, "")
+	clk := clock.NewFake(time.Now())
+	s.clock = clk
+
+	code, err := s.NewCode("telegram", "42")
+	if err != nil {
+		t.Fatalf("NewCode() error = %v", err)
+	}
+	if len(code) != codeLength {
+		t.Errorf("code %q has %d characters, want %d", code, len(code), codeLength)
+	}
+
+	linked, err := s.Redeem(" "+code+" ", "webchat", "ana")
+	if err != nil || linked != "telegram:42" {
+		t.Fatalf("Redeem() = %q, %v", linked, err)
+	}
+	if got := s.Resolve("webchat", "ana"); got != "telegram:42" {
+		t.Errorf("Resolve(webchat, ana) = %q after linking, want telegram:42", got)
+	}
+	if _, err := s.Redeem(code, "webchat", "bob"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("second Redeem() error = %v, want ErrInvalidCode", err)
+	}
+
+	code, _ = s.NewCode("telegram", "42")
+	clk.Advance(CodeTTL + time.Second)
+	if _, err := s.Redeem(code, "webchat", "bob"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Redeem() after TTL error = %v, want ErrInvalidCode", err)
+	}
+
+	if _, err := s.NewCode("telegram", "anonymous"); !errors.Is(err, ErrAnonymous) {
+		t.Errorf("NewCode(anonymous) error = %v, want ErrAnonymous", err)
+	}
+	code, _ = s.NewCode("telegram", "42")
+	if _, err := s.Redeem(code, "webchat", ""); !errors.Is(err, ErrAnonymous) {
+		t.Errorf("Redeem() for an anonymous user error = %v, want ErrAnonymous", err)
+	}
+}
+
+func TestLinksPersistAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identity-links.json")
+	s, err := NewStore(nil, path)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	code, _ := s.NewCode("telegram", "42")
+	if _, err := s.Redeem(code, "webchat", "ana"); err != nil {
+		t.Fatalf("Redeem() error = %v", err)
+	}
+
+	reloaded, err := NewStore(map[string]string{"telegram:7": "bob"}, path)
+	if err != nil {
+		t.Fatalf("NewStore() reload error = %v", err)
+	}
+	if got := reloaded.Resolve("webchat", "ana"); got != "telegram:42" {
+		t.Errorf("Resolve(webchat, ana) after restart = %q, want telegram:42", got)
+	}
+	if got := reloaded.Resolve("telegram", "7"); got != "bob" {
+		t.Errorf("static link lost when loading the file: %q", got)
+	}
+}
+
+func TestStaticLinksAreNotSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identity-links.json")
+	s, err := NewStore(map[string]string{"telegram:7": "bob"}, path)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	code, _ := s.NewCode("telegram", "42")
+	if _, err := s.Redeem(code, "webchat", "ana"); err != nil {
+		t.Fatalf("Redeem() error = %v", err)
+	}
+
+	// The link was dropped from the configuration
+	reloaded, err := NewStore(nil, path)
+	if err != nil {
+		t.Fatalf("NewStore() reload error = %v", err)
+	}
+	if got := reloaded.Resolve("telegram", "7"); got != "telegram:7" {
+		t.Errorf("Resolve(telegram, 7) = %q, want the removed static link gone", got)
+	}
+	if got := reloaded.Resolve("webchat", "ana"); got != "telegram:42" {
+		t.Errorf("Resolve(webchat, ana) = %q, want the runtime link kept", got)
+	}
+}
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+// ResponseCache stores chat responses for identical deterministic requests.
+// Requests with tools, streaming or a temperature above the configured
+// maximum are never cached.
+type ResponseCache struct {
+	mu             sync.Mutex
+	ttl            time.Duration
+	maxTemperature float64
+	entries        map[string]cacheEntry
+	hits           uint64
+	misses         uint64
+	clock          clock.Clock
+}
+
+type cacheEntry struct {
+	resp    ChatResponse
+	expires time.Time
+}
+
+// CacheStats reports cache effectiveness
+type CacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+// NewResponseCache creates a cache whose entries live for ttl
+func NewResponseCache(ttl time.Duration, maxTemperature float64) *ResponseCache {
+	return &ResponseCache{
+		ttl:            ttl,
+		maxTemperature: maxTemperature,
+		entries:        make(map[string]cacheEntry),
+		clock:          clock.Real(),
+	}
+}
+
+// Stats returns hit/miss counters and the current number of entries
+func (rc *ResponseCache) Stats() CacheStats {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return CacheStats{Hits: rc.hits, Misses: rc.misses, Entries: len(rc.entries)}
+}
+
+// cacheable reports whether a request is deterministic enough to cache
+func (rc *ResponseCache) cacheable(req ChatRequest) bool {
+	return len(req.Tools) == 0 && !req.Stream && req.Temperature <= rc.maxTemperature
+}
+
+// key hashes the parts of a request that determine the response
+func (rc *ResponseCache) key(req ChatRequest) string {
+	data, _ := json.Marshal(struct {
+		Model       string    `json:"model"`
+		Messages    []Message `json:"messages"`
+		Temperature float64   `json:"temperature"`
+		MaxTokens   int       `json:"max_tokens"`
+		Stop        []string  `json:"stop"`
+	}{req.Model, req.Messages, req.Temperature, req.MaxTokens, req.Stop})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (rc *ResponseCache) get(key string) (*ChatResponse, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if ok && rc.clock.Now().After(entry.expires) {
+		delete(rc.entries, key)
+		ok = false
+	}
+	if !ok {
+		rc.misses++
+		return nil, false
+	}
+
+	rc.hits++
+	resp := entry.resp
+	resp.Choices = append([]Choice(nil), entry.resp.Choices...)
+	return &resp, true
+}
+
+func (rc *ResponseCache) put(key string, resp *ChatResponse) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	// Drop expired entries opportunistically so the map doesn't grow unbounded
+	now := rc.clock.Now()
+	for k, e := range rc.entries {
+		if now.After(e.expires) {
+			delete(rc.entries, k)
+		}
+	}
+
+	stored := *resp
+	stored.Choices = append([]Choice(nil), resp.Choices...)
+	rc.entries[key] = cacheEntry{resp: stored, expires: now.Add(rc.ttl)}
+}
@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+func TestResponseCache(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"id":"1","choices":[{"message":{"role":"assistant","content":"42"},"finish_reason":"stop"}]}`))
+	})
+	cache := NewResponseCache(time.Minute, 0.3)
+	c.SetCache(cache)
+
+	messages := []Message{{Role: "user", Content: "What is the answer?"}}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.Chat(ctx, messages, WithTemperature(0.1))
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		if resp.Choices[0].Message.Content != "42" {
+			t.Errorf("Chat() content = %q", resp.Choices[0].Message.Content)
+		}
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("HTTP calls = %d, expected 1 (second request should hit the cache)", n)
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("Stats() = %+v, expected 1 hit, 1 miss, 1 entry", stats)
+	}
+
+	// Different prompts, tools and high temperatures bypass the cached entry
+	c.Chat(ctx, []Message{{Role: "user", Content: "Something else"}}, WithTemperature(0.1))
+	c.Chat(ctx, messages, WithTemperature(0.9))
+	c.Chat(ctx, messages, WithTemperature(0.1), WithTools([]Tool{{Type: "function", Function: ToolFunction{Name: "x"}}}))
+
+	if n := atomic.LoadInt32(&calls); n != 4 {
+		t.Errorf("HTTP calls = %d, expected 4", n)
+	}
+}
+
+func TestResponseCacheExpiry(t *testing.T) {
+	cache := NewResponseCache(time.Minute, 1)
+	clk := clock.NewFake(time.Now())
+	cache.clock = clk
+
+	req := ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}
+	key := cache.key(req)
+	cache.put(key, &ChatResponse{ID: "cached"})
+
+	if resp, ok := cache.get(key); !ok || resp.ID != "cached" {
+		t.Fatalf("get() = %v, %v; expected cached response", resp, ok)
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, ok := cache.get(key); ok {
+		t.Error("get() returned an expired entry")
+	}
+}
@@ -7,7 +7,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/breaker"
+	"github.com/abelclopes/nomad-iabot/internal/httpx"
+	"github.com/abelclopes/nomad-iabot/internal/redact"
 )
 
 // Client is a generic LLM client that supports OpenAI-compatible APIs
@@ -17,12 +22,19 @@ type Client struct {
 	model      string
 	apiKey     string
 	httpClient *http.Client
+	transport  *http.Transport // pooled connections to the LLM server
+	cache      *ResponseCache
+	breaker    *breaker.Breaker
+	latency    *LatencyTracker // chat request latencies, see LatencyP95
 }
 
 // Message represents a chat message
 type Message struct {
 	Role    string `json:"role"`    // "system", "user", "assistant"
 	Content string `json:"content"`
+
+	// Parts replaces Content with a multimodal content array when set
+	Parts []ContentPart `json:"-"`
 }
 
 // ChatRequest represents a chat completion request
@@ -33,6 +45,12 @@ type ChatRequest struct {
 	Temperature float64   `json:"temperature,omitempty"`
 	Stream      bool      `json:"stream,omitempty"`
 	Tools       []Tool    `json:"tools,omitempty"`
+	Stop        []string  `json:"stop,omitempty"`
+
+	ToolChoice ToolChoice `json:"tool_choice,omitempty"`
+
+	// onDelta receives the content of a streamed response as it arrives
+	onDelta func(string)
 }
 
 // Tool represents a tool/function the LLM can call
@@ -93,6 +111,7 @@ type StreamChunk struct {
 	Created int64          `json:"created"`
 	Model   string         `json:"model"`
 	Choices []StreamChoice `json:"choices"`
+	Usage   *Usage         `json:"usage,omitempty"` // final chunk, when the server reports it
 }
 
 // StreamChoice represents a streaming choice
@@ -104,19 +123,51 @@ type StreamChoice struct {
 
 // StreamDelta represents the delta content in streaming
 type StreamDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
 }
 
+// Connection pool defaults. Every request goes to the same host, so unlike
+// http.DefaultTransport (2 idle connections per host) most of the pool may
+// be kept for it.
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 32
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
 // NewClient creates a new LLM client
 func NewClient(baseURL, model, apiKey string, timeoutSec int) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = DefaultMaxIdleConns
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = DefaultIdleConnTimeout
+
 	return &Client{
 		baseURL: baseURL,
 		model:   model,
 		apiKey:  apiKey,
 		httpClient: &http.Client{
-			Timeout: time.Duration(timeoutSec) * time.Second,
+			Timeout:   time.Duration(timeoutSec) * time.Second,
+			Transport: httpx.NewUserAgentTransport(httpx.NewRetryTransport(transport, httpx.DefaultPolicy)),
 		},
+		transport: transport,
+		latency:   NewLatencyTracker(DefaultLatencyWindow),
+	}
+}
+
+// SetConnectionPool tunes how many keep-alive connections to the LLM server
+// are kept and for how long. Zero values keep the current setting.
+func (c *Client) SetConnectionPool(maxIdle, maxIdlePerHost int, idleTimeout time.Duration) {
+	if maxIdle > 0 {
+		c.transport.MaxIdleConns = maxIdle
+	}
+	if maxIdlePerHost > 0 {
+		c.transport.MaxIdleConnsPerHost = maxIdlePerHost
+	}
+	if idleTimeout > 0 {
+		c.transport.IdleConnTimeout = idleTimeout
 	}
 }
 
@@ -132,13 +183,76 @@ func (c *Client) Chat(ctx context.Context, messages []Message, opts ...ChatOptio
 		opt(&req)
 	}
 
+	if err := ValidateStop(req.Stop); err != nil {
+		return nil, err
+	}
+
+	if c.cache == nil || !c.cache.cacheable(req) {
+		return c.chat(ctx, req, messages, opts...)
+	}
+
+	key := c.cache.key(req)
+	if resp, ok := c.cache.get(key); ok {
+		return resp, nil
+	}
+
+	resp, err := c.chat(ctx, req, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.put(key, resp)
+	return resp, nil
+}
+
+// SetCache enables response caching for deterministic requests
+func (c *Client) SetCache(cache *ResponseCache) {
+	c.cache = cache
+}
+
+// SetBreaker guards chat requests with a circuit breaker
+func (c *Client) SetBreaker(b *breaker.Breaker) {
+	c.breaker = b
+}
+
+// SetLatencyWindow sets how long chat request latencies count toward
+// LatencyP95
+func (c *Client) SetLatencyWindow(window time.Duration) {
+	c.latency = NewLatencyTracker(window)
+}
+
+// LatencyP95 returns the 95th percentile latency of the chat requests
+// answered within the latency window, and how many there were
+func (c *Client) LatencyP95() (time.Duration, int) {
+	return c.latency.P95()
+}
+
+// CacheStats returns response cache counters, or false when caching is disabled
+func (c *Client) CacheStats() (CacheStats, bool) {
+	if c.cache == nil {
+		return CacheStats{}, false
+	}
+	return c.cache.Stats(), true
+}
+
+// chat sends a prepared request to the provider
+func (c *Client) chat(ctx context.Context, req ChatRequest, messages []Message, opts ...ChatOption) (*ChatResponse, error) {
 	// Determine endpoint based on provider
 	endpoint := c.baseURL + "/v1/chat/completions"
 	
 	// Ollama uses a different endpoint
 	if isOllamaURL(c.baseURL) {
 		endpoint = c.baseURL + "/api/chat"
-		return c.chatOllama(ctx, messages, opts...)
+		resp, err := c.chatOllama(ctx, messages, opts...)
+		if err == nil && req.onDelta != nil {
+			// Ollama answers in one piece
+			deliverContent(resp, req.onDelta)
+		}
+		return resp, err
+	}
+
+	// Providers reject tool_choice on requests without tools
+	if len(req.Tools) == 0 {
+		req.ToolChoice = ""
 	}
 
 	body, err := json.Marshal(req)
@@ -158,25 +272,47 @@ func (c *Client) Chat(ctx context.Context, messages []Message, opts ...ChatOptio
 		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", redact.Error(err, c.apiKey))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, redact.String(string(bodyBytes), c.apiKey))
+	}
+
+	// Servers that don't support streaming answer in one piece
+	if req.Stream && !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return readStream(resp.Body, req.onDelta)
 	}
 
 	var chatResp ChatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	if req.onDelta != nil {
+		deliverContent(&chatResp, req.onDelta)
+	}
 
 	return &chatResp, nil
 }
 
+// do sends a chat request through the circuit breaker
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	c.breaker.Observe(resp, err)
+	if err == nil {
+		c.latency.Observe(time.Since(start))
+	}
+	return resp, err
+}
+
 // chatOllama handles Ollama-specific API format
 func (c *Client) chatOllama(ctx context.Context, messages []Message, opts ...ChatOption) (*ChatResponse, error) {
 	req := ChatRequest{
@@ -190,18 +326,23 @@ func (c *Client) chatOllama(ctx context.Context, messages []Message, opts ...Cha
 	}
 
 	// Ollama format
+	options := map[string]interface{}{
+		"temperature": req.Temperature,
+		"num_predict": req.MaxTokens,
+	}
+	if len(req.Stop) > 0 {
+		options["stop"] = req.Stop
+	}
+
 	ollamaReq := map[string]interface{}{
 		"model":    req.Model,
-		"messages": req.Messages,
+		"messages": toOllamaMessages(req.Messages),
 		"stream":   false,
-		"options": map[string]interface{}{
-			"temperature": req.Temperature,
-			"num_predict": req.MaxTokens,
-		},
+		"options":  options,
 	}
 
-	if len(req.Tools) > 0 {
-		ollamaReq["tools"] = req.Tools
+	if tools := req.ToolChoice.filter(req.Tools); len(tools) > 0 {
+		ollamaReq["tools"] = tools
 	}
 
 	body, err := json.Marshal(ollamaReq)
@@ -217,15 +358,15 @@ func (c *Client) chatOllama(ctx context.Context, messages []Message, opts ...Cha
 
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", redact.Error(err, c.apiKey))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, redact.String(string(bodyBytes), c.apiKey))
 	}
 
 	// Parse Ollama response
@@ -235,6 +376,7 @@ func (c *Client) chatOllama(ctx context.Context, messages []Message, opts ...Cha
 		Done      bool    `json:"done"`
 		TotalDuration int64 `json:"total_duration"`
 		EvalCount int     `json:"eval_count"`
+		PromptEvalCount int `json:"prompt_eval_count"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
@@ -252,7 +394,9 @@ func (c *Client) chatOllama(ctx context.Context, messages []Message, opts ...Cha
 			},
 		},
 		Usage: Usage{
+			PromptTokens:     ollamaResp.PromptEvalCount,
 			CompletionTokens: ollamaResp.EvalCount,
+			TotalTokens:      ollamaResp.PromptEvalCount + ollamaResp.EvalCount,
 		},
 	}, nil
 }
@@ -281,6 +425,38 @@ func WithStream() ChatOption {
 	}
 }
 
+// WithStreamHandler streams the response, passing each piece of content to
+// onDelta as it arrives. Chat still returns the assembled response.
+func WithStreamHandler(onDelta func(delta string)) ChatOption {
+	return func(r *ChatRequest) {
+		r.Stream = true
+		r.onDelta = onDelta
+	}
+}
+
+// MaxStopSequences is the most stop sequences OpenAI-compatible providers accept
+const MaxStopSequences = 4
+
+// WithStop ends generation as soon as the model produces one of sequences
+func WithStop(sequences []string) ChatOption {
+	return func(r *ChatRequest) {
+		r.Stop = sequences
+	}
+}
+
+// ValidateStop checks stop sequences against provider limits
+func ValidateStop(sequences []string) error {
+	if len(sequences) > MaxStopSequences {
+		return fmt.Errorf("at most %d stop sequences are allowed, got %d", MaxStopSequences, len(sequences))
+	}
+	for _, s := range sequences {
+		if s == "" {
+			return fmt.Errorf("stop sequences must not be empty")
+		}
+	}
+	return nil
+}
+
 // WithTools adds tools/functions
 func WithTools(tools []Tool) ChatOption {
 	return func(r *ChatRequest) {
@@ -288,6 +464,55 @@ func WithTools(tools []Tool) ChatOption {
 	}
 }
 
+// Tool choices understood by every provider; any other ToolChoice names the
+// function the model must call
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+)
+
+// ToolChoice controls whether the model may call the request's tools
+type ToolChoice string
+
+// MarshalJSON encodes a function name as OpenAI's {"type":"function",...} object
+func (tc ToolChoice) MarshalJSON() ([]byte, error) {
+	switch tc {
+	case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		return json.Marshal(string(tc))
+	}
+	return json.Marshal(map[string]interface{}{
+		"type":     "function",
+		"function": map[string]string{"name": string(tc)},
+	})
+}
+
+// filter applies the choice to tools for providers without tool_choice
+// (Ollama): "none" sends no tools and a function name sends only that one.
+// "required" cannot be enforced and leaves tools as they are.
+func (tc ToolChoice) filter(tools []Tool) []Tool {
+	switch tc {
+	case "", ToolChoiceAuto, ToolChoiceRequired:
+		return tools
+	case ToolChoiceNone:
+		return nil
+	}
+	for _, t := range tools {
+		if t.Function.Name == string(tc) {
+			return []Tool{t}
+		}
+	}
+	return tools
+}
+
+// WithToolChoice sets tool_choice: "auto", "none", "required" or the name of
+// a function the model must call. It only applies to requests with tools.
+func WithToolChoice(choice string) ChatOption {
+	return func(r *ChatRequest) {
+		r.ToolChoice = ToolChoice(choice)
+	}
+}
+
 // ListModels lists available models
 func (c *Client) ListModels(ctx context.Context) ([]string, error) {
 	endpoint := c.baseURL + "/api/tags" // Ollama
@@ -334,6 +559,76 @@ func (c *Client) ListModels(ctx context.Context) ([]string, error) {
 	return models, nil
 }
 
+// HasModel reports whether model is available on the server. Ollama tags
+// without an explicit version match their ":latest" variant.
+func (c *Client) HasModel(ctx context.Context, model string) (bool, error) {
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, m := range models {
+		if m == model || m == model+":latest" || strings.TrimSuffix(m, ":latest") == model {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PullProgress reports download progress while an Ollama model is pulled
+type PullProgress struct {
+	Status    string `json:"status"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PullModel downloads a model through Ollama's /api/pull, calling progress
+// for every status line streamed by the server
+func (c *Client) PullModel(ctx context.Context, model string, progress func(PullProgress)) error {
+	body, err := json.Marshal(map[string]interface{}{"name": model, "stream": true})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Pulls can take far longer than a chat request; rely on ctx instead
+	httpClient := &http.Client{Transport: c.httpClient.Transport}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/pull", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", redact.Error(err, c.apiKey))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, redact.String(string(bodyBytes), c.apiKey))
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var p PullProgress
+		if err := decoder.Decode(&p); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to decode pull progress: %w", err)
+		}
+
+		if p.Error != "" {
+			return fmt.Errorf("pull failed: %s", p.Error)
+		}
+		if progress != nil {
+			progress(p)
+		}
+	}
+}
+
 // Ping checks if the LLM server is reachable
 func (c *Client) Ping(ctx context.Context) error {
 	endpoint := c.baseURL + "/api/tags" // Ollama
@@ -355,6 +650,9 @@ func (c *Client) Ping(ctx context.Context) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("LLM server returned status %d", resp.StatusCode)
+	}
 	return nil
 }
 
@@ -0,0 +1,280 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client whose requests are served by handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL, "test-model", "", 5)
+}
+
+func TestHasModel(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"models":[{"name":"llama3.2:latest"},{"name":"qwen3:8b"}]}`))
+	})
+
+	tests := []struct {
+		model    string
+		expected bool
+	}{
+		{"llama3.2", true},
+		{"llama3.2:latest", true},
+		{"qwen3:8b", true},
+		{"qwen3", false},
+		{"mistral", false},
+	}
+
+	for _, tt := range tests {
+		found, err := c.HasModel(context.Background(), tt.model)
+		if err != nil {
+			t.Fatalf("HasModel(%q) error = %v", tt.model, err)
+		}
+		if found != tt.expected {
+			t.Errorf("HasModel(%q) = %v, expected %v", tt.model, found, tt.expected)
+		}
+	}
+}
+
+func TestPullModelStreamsProgress(t *testing.T) {
+	var pulled string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/pull" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		pulled = r.URL.Path
+		w.Write([]byte(`{"status":"pulling manifest"}
+{"status":"downloading","total":100,"completed":50}
+{"status":"success"}
+`))
+	})
+
+	var statuses []string
+	if err := c.PullModel(context.Background(), "llama3.2", func(p PullProgress) {
+		statuses = append(statuses, p.Status)
+	}); err != nil {
+		t.Fatalf("PullModel() error = %v", err)
+	}
+
+	if pulled == "" || len(statuses) != 3 || statuses[2] != "success" {
+		t.Errorf("statuses = %v, expected three progress updates ending in success", statuses)
+	}
+}
+
+func TestPullModelReportsStreamedError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"pulling manifest"}
+{"error":"pull model manifest: file does not exist"}
+`))
+	})
+
+	if err := c.PullModel(context.Background(), "nope", nil); err == nil {
+		t.Fatal("expected an error for a failed pull")
+	}
+}
+
+// captureTransport records request bodies and answers with a fixed response
+type captureTransport struct {
+	body     []byte
+	response string
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.body, _ = io.ReadAll(req.Body)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(t.response)),
+		Request:    req,
+	}, nil
+}
+
+func TestChatSendsStopSequences(t *testing.T) {
+	stop := []string{"\n\n", "END"}
+
+	tests := []struct {
+		name     string
+		baseURL  string
+		response string
+		stopPath func(map[string]interface{}) interface{}
+	}{
+		{
+			name:     "openai",
+			baseURL:  "http://llm.example.com",
+			response: `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`,
+			stopPath: func(body map[string]interface{}) interface{} { return body["stop"] },
+		},
+		{
+			name:     "ollama",
+			baseURL:  "http://localhost:11434",
+			response: `{"message":{"role":"assistant","content":"ok"},"done":true}`,
+			stopPath: func(body map[string]interface{}) interface{} {
+				options, _ := body["options"].(map[string]interface{})
+				return options["stop"]
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &captureTransport{response: tt.response}
+			c := NewClient(tt.baseURL, "test-model", "", 5)
+			c.httpClient.Transport = transport
+
+			if _, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, WithStop(stop)); err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(transport.body, &body); err != nil {
+				t.Fatalf("request body is not JSON: %v", err)
+			}
+			got, _ := json.Marshal(tt.stopPath(body))
+			if string(got) != `["\n\n","END"]` {
+				t.Errorf("stop = %s, want [\"\\n\\n\",\"END\"]", got)
+			}
+		})
+	}
+}
+
+func TestChatRejectsTooManyStopSequences(t *testing.T) {
+	c := NewClient("http://llm.example.com", "test-model", "", 5)
+	c.httpClient.Transport = &captureTransport{}
+
+	_, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, WithStop([]string{"a", "b", "c", "d", "e"}))
+	if err == nil || !strings.Contains(err.Error(), "at most 4") {
+		t.Errorf("Chat() error = %v, want a stop sequence limit error", err)
+	}
+}
+
+func TestChatSendsToolChoice(t *testing.T) {
+	tools := []Tool{
+		{Type: "function", Function: ToolFunction{Name: "get_weather"}},
+		{Type: "function", Function: ToolFunction{Name: "get_time"}},
+	}
+
+	// The stub only emits tool calls while tool_choice allows them
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["tool_choice"] == "none" || body["tools"] == nil {
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"done"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant"},"tool_calls":[{"id":"1","type":"function","function":{"name":"get_weather","arguments":"{}"}}]}]}`))
+	})
+	msgs := []Message{{Role: "user", Content: "weather?"}}
+
+	resp, err := c.Chat(context.Background(), msgs, WithTools(tools))
+	if err != nil || len(resp.Choices[0].ToolCalls) != 1 {
+		t.Fatalf("Chat() = %+v, %v; expected a tool call", resp, err)
+	}
+	resp, err = c.Chat(context.Background(), msgs, WithTools(tools), WithToolChoice(ToolChoiceNone))
+	if err != nil || len(resp.Choices[0].ToolCalls) != 0 || resp.Choices[0].Message.Content != "done" {
+		t.Errorf("Chat() with tool_choice none = %+v, %v; expected a plain answer", resp, err)
+	}
+
+	tests := []struct {
+		name    string
+		baseURL string
+		choice  string
+		noTools bool
+		field   string // tool_choice as sent to OpenAI-compatible providers
+		sent    string // tool names sent to Ollama
+	}{
+		{name: "auto", baseURL: "http://llm.example.com", choice: "auto", field: `"auto"`},
+		{name: "none", baseURL: "http://llm.example.com", choice: "none", field: `"none"`},
+		{name: "function", baseURL: "http://llm.example.com", choice: "get_time", field: `{"function":{"name":"get_time"},"type":"function"}`},
+		{name: "without tools", baseURL: "http://llm.example.com", choice: "none", noTools: true, field: "null"},
+		{name: "ollama none", baseURL: "http://localhost:11434", choice: "none", sent: ""},
+		{name: "ollama function", baseURL: "http://localhost:11434", choice: "get_time", sent: "get_time"},
+		{name: "ollama auto", baseURL: "http://localhost:11434", choice: "auto", sent: "get_weather,get_time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &captureTransport{response: `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`}
+			if strings.Contains(tt.baseURL, "11434") {
+				transport.response = `{"message":{"role":"assistant","content":"ok"},"done":true}`
+			}
+			c := NewClient(tt.baseURL, "test-model", "", 5)
+			c.httpClient.Transport = transport
+
+			opts := []ChatOption{WithToolChoice(tt.choice)}
+			if !tt.noTools {
+				opts = append(opts, WithTools(tools))
+			}
+			if _, err := c.Chat(context.Background(), msgs, opts...); err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+
+			var body struct {
+				ToolChoice json.RawMessage `json:"tool_choice"`
+				Tools      []Tool          `json:"tools"`
+			}
+			if err := json.Unmarshal(transport.body, &body); err != nil {
+				t.Fatalf("request body is not JSON: %v", err)
+			}
+
+			if strings.Contains(tt.baseURL, "11434") {
+				names := make([]string, len(body.Tools))
+				for i, tool := range body.Tools {
+					names[i] = tool.Function.Name
+				}
+				if got := strings.Join(names, ","); got != tt.sent {
+					t.Errorf("tools sent to Ollama = %q, want %q", got, tt.sent)
+				}
+				return
+			}
+
+			got := string(body.ToolChoice)
+			if got == "" {
+				got = "null"
+			}
+			if got != tt.field {
+				t.Errorf("tool_choice = %s, want %s", got, tt.field)
+			}
+		})
+	}
+}
+
+func TestChatReusesConnections(t *testing.T) {
+	var newConns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}` + "\n"))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			newConns.Add(1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	c := NewClient(srv.URL, "test-model", "", 5)
+	c.SetConnectionPool(10, 4, time.Minute)
+	for i := 0; i < 5; i++ {
+		if _, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+	}
+
+	if n := newConns.Load(); n != 1 {
+		t.Errorf("opened %d connections for 5 sequential requests, want 1", n)
+	}
+	if c.transport.MaxIdleConnsPerHost != 4 || c.transport.IdleConnTimeout != time.Minute {
+		t.Errorf("pool settings not applied: %d, %s", c.transport.MaxIdleConnsPerHost, c.transport.IdleConnTimeout)
+	}
+}
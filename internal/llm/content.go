@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ContentPart is one element of a multimodal message, as accepted by
+// OpenAI-compatible vision models
+type ContentPart struct {
+	Type     string    `json:"type"` // "text" or "image_url"
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL points at an image, usually a base64 data URL
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+// TextPart returns a text content part
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: "text", Text: text}
+}
+
+// ImagePart returns an image content part carrying base64 data inline
+func ImagePart(contentType, dataBase64 string) ContentPart {
+	return ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: "data:" + contentType + ";base64," + dataBase64}}
+}
+
+// MarshalJSON sends Parts as the content array when set, and the plain
+// Content string otherwise
+func (m Message) MarshalJSON() ([]byte, error) {
+	type plain Message
+	if len(m.Parts) == 0 {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		Role    string        `json:"role"`
+		Content []ContentPart `json:"content"`
+	}{m.Role, m.Parts})
+}
+
+// ollamaMessage is the native Ollama message format, which carries images
+// as a separate list of base64 strings instead of content parts
+type ollamaMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"`
+}
+
+// toOllamaMessages converts multimodal messages to the native Ollama format
+func toOllamaMessages(messages []Message) []ollamaMessage {
+	out := make([]ollamaMessage, 0, len(messages))
+	for _, m := range messages {
+		om := ollamaMessage{Role: m.Role, Content: m.Content}
+		if len(m.Parts) > 0 {
+			var text []string
+			for _, p := range m.Parts {
+				switch {
+				case p.Type == "text":
+					text = append(text, p.Text)
+				case p.ImageURL != nil:
+					if _, data, ok := strings.Cut(p.ImageURL.URL, ";base64,"); ok {
+						om.Images = append(om.Images, data)
+					}
+				}
+			}
+			om.Content = strings.Join(text, "\n")
+		}
+		out = append(out, om)
+	}
+	return out
+}
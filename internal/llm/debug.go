@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/redact"
+)
+
+// debugBodyLimit caps how much of each request and response body is logged
+const debugBodyLimit = 64 << 10
+
+// SetDebugLogger logs every request and raw response body sent to the
+// provider at debug level, with secrets redacted. Bodies are captured at
+// the transport, so the log shows exactly what went over the wire.
+func (c *Client) SetDebugLogger(logger *slog.Logger) {
+	base := c.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c.httpClient.Transport = &debugTransport{base: base, logger: logger, apiKey: c.apiKey}
+}
+
+// debugTransport logs request and response bodies passing through it
+type debugTransport struct {
+	base   http.RoundTripper
+	logger *slog.Logger
+	apiKey string
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = b
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+
+	url := t.redact(req.URL.String())
+	t.logger.Debug("llm request", "method", req.Method, "url", url, "body", t.redact(truncateBody(reqBody)))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.logger.Debug("llm request failed",
+			"url", url,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"error", t.redact(err.Error()),
+		)
+		return nil, err
+	}
+
+	// Log when the body is closed so streamed responses keep streaming
+	resp.Body = &debugBody{
+		ReadCloser: resp.Body,
+		done: func(body []byte) {
+			attrs := []any{
+				"url", url,
+				"status", resp.StatusCode,
+				"duration_ms", time.Since(start).Milliseconds(),
+				"body", t.redact(string(body)),
+			}
+			if u, ok := parseUsage(body); ok {
+				attrs = append(attrs,
+					"prompt_tokens", u.PromptTokens,
+					"completion_tokens", u.CompletionTokens,
+					"total_tokens", u.TotalTokens,
+				)
+			}
+			t.logger.Debug("llm response", attrs...)
+		},
+	}
+	return resp, nil
+}
+
+func (t *debugTransport) redact(s string) string {
+	return redact.String(s, t.apiKey)
+}
+
+// debugBody records up to debugBodyLimit bytes as they are read and hands
+// them to done once, on Close
+type debugBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func([]byte)
+}
+
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := debugBodyLimit - b.buf.Len(); room > 0 && n > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	return n, err
+}
+
+func (b *debugBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.buf.Bytes()) })
+	return err
+}
+
+func truncateBody(b []byte) string {
+	if len(b) > debugBodyLimit {
+		return string(b[:debugBodyLimit]) + "…(truncated)"
+	}
+	return string(b)
+}
+
+// parseUsage reads token counts from an OpenAI-style or Ollama response body
+func parseUsage(body []byte) (Usage, bool) {
+	var r struct {
+		Usage           *Usage `json:"usage"`
+		PromptEvalCount int    `json:"prompt_eval_count"`
+		EvalCount       int    `json:"eval_count"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return Usage{}, false
+	}
+	if r.Usage != nil {
+		return *r.Usage, true
+	}
+	if r.PromptEvalCount > 0 || r.EvalCount > 0 {
+		return Usage{
+			PromptTokens:     r.PromptEvalCount,
+			CompletionTokens: r.EvalCount,
+			TotalTokens:      r.PromptEvalCount + r.EvalCount,
+		}, true
+	}
+	return Usage{}, false
+}
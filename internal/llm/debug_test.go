@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugLoggerLogsRedactedBodies(t *testing.T) {
+	const apiKey = "sk-test-debug-secret"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"key was ` + apiKey + `"}}],` +
+			`"usage":{"prompt_tokens":12,"completion_tokens":5,"total_tokens":17}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var logs bytes.Buffer
+	c := NewClient(srv.URL, "test-model", apiKey, 5)
+	c.SetDebugLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	resp, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "why no tool calls? " + apiKey}})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if got := resp.Choices[0].Message.Content; !strings.Contains(got, apiKey) {
+		t.Errorf("response content = %q, logging must not alter the body", got)
+	}
+
+	out := logs.String()
+	for _, want := range []string{"llm request", "why no tool calls?", "llm response", "duration_ms=", "total_tokens=17"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, apiKey) {
+		t.Errorf("log leaks the API key:\n%s", out)
+	}
+}
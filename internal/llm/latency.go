@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+// DefaultLatencyWindow is how long a latency sample counts toward the p95
+const DefaultLatencyWindow = 5 * time.Minute
+
+// maxLatencySamples caps the samples kept, dropping the oldest first
+const maxLatencySamples = 500
+
+type latencySample struct {
+	at time.Time
+	d  time.Duration
+}
+
+// LatencyTracker keeps the latencies of recent requests to report their
+// 95th percentile. Samples older than the window are dropped, so the p95
+// recovers once slow requests stop even if few requests follow them.
+type LatencyTracker struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	window  time.Duration
+	samples []latencySample // oldest first
+}
+
+// NewLatencyTracker returns a tracker counting samples from the last window
+func NewLatencyTracker(window time.Duration) *LatencyTracker {
+	if window <= 0 {
+		window = DefaultLatencyWindow
+	}
+	return &LatencyTracker{clock: clock.Real(), window: window}
+}
+
+// SetClock replaces the clock samples are timestamped with, for tests
+func (t *LatencyTracker) SetClock(c clock.Clock) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clock = c
+}
+
+// Observe records the latency of one request
+func (t *LatencyTracker) Observe(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now()
+	t.expire(now)
+	if len(t.samples) >= maxLatencySamples {
+		t.samples = t.samples[1:]
+	}
+	t.samples = append(t.samples, latencySample{at: now, d: d})
+}
+
+// P95 returns the 95th percentile of the samples in the window and how many
+// there are; the percentile is zero without samples
+func (t *LatencyTracker) P95() (time.Duration, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expire(t.clock.Now())
+	if len(t.samples) == 0 {
+		return 0, 0
+	}
+	sorted := make([]time.Duration, len(t.samples))
+	for i, s := range t.samples {
+		sorted[i] = s.d
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	// Nearest rank: the smallest sample at or above 95% of them
+	rank := (len(sorted)*95 + 99) / 100
+	return sorted[rank-1], len(sorted)
+}
+
+// expire drops the samples older than the window. Callers hold t.mu.
+func (t *LatencyTracker) expire(now time.Time) {
+	cutoff := now.Add(-t.window)
+	i := 0
+	for i < len(t.samples) && t.samples[i].at.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		t.samples = append(t.samples[:0], t.samples[i:]...)
+	}
+}
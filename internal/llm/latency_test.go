@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+func TestLatencyP95(t *testing.T) {
+	tr := NewLatencyTracker(time.Minute)
+	tr.SetClock(clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+
+	if p95, n := tr.P95(); p95 != 0 || n != 0 {
+		t.Errorf("empty P95() = %v, %d, want 0, 0", p95, n)
+	}
+	for i := 1; i <= 100; i++ {
+		tr.Observe(time.Duration(i) * time.Millisecond)
+	}
+	if p95, n := tr.P95(); p95 != 95*time.Millisecond || n != 100 {
+		t.Errorf("P95() = %v, %d, want 95ms over 100 samples", p95, n)
+	}
+}
+
+func TestLatencyRecoversWhenSamplesExpire(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	tr := NewLatencyTracker(time.Minute)
+	tr.SetClock(fake)
+
+	for i := 0; i < 10; i++ {
+		tr.Observe(5 * time.Second)
+	}
+	fake.Advance(45 * time.Second)
+	tr.Observe(100 * time.Millisecond)
+	if p95, _ := tr.P95(); p95 != 5*time.Second {
+		t.Errorf("P95() = %v, want the slow 5s", p95)
+	}
+
+	fake.Advance(30 * time.Second)
+	if p95, n := tr.P95(); p95 != 100*time.Millisecond || n != 1 {
+		t.Errorf("after the window P95() = %v, %d, want 100ms over 1 sample", p95, n)
+	}
+}
@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ToolCallDelta is a fragment of a tool call in a streaming chunk. The
+// first fragment of a call carries its ID and name; the arguments arrive
+// split across any number of later fragments with the same Index.
+type ToolCallDelta struct {
+	Index    int              `json:"index"`
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// toolCallAccumulator reassembles streamed tool calls by index
+type toolCallAccumulator struct {
+	calls map[int]*ToolCall
+}
+
+func (a *toolCallAccumulator) add(deltas []ToolCallDelta) {
+	if a.calls == nil {
+		a.calls = make(map[int]*ToolCall)
+	}
+	for _, d := range deltas {
+		tc, ok := a.calls[d.Index]
+		if !ok {
+			tc = &ToolCall{Type: "function"}
+			a.calls[d.Index] = tc
+		}
+		if d.ID != "" {
+			tc.ID = d.ID
+		}
+		if d.Type != "" {
+			tc.Type = d.Type
+		}
+		tc.Function.Name += d.Function.Name
+		tc.Function.Arguments += d.Function.Arguments
+	}
+}
+
+// complete returns the assembled calls in index order, failing when a call
+// has no name or its arguments are not a complete JSON document
+func (a *toolCallAccumulator) complete() ([]ToolCall, error) {
+	if len(a.calls) == 0 {
+		return nil, nil
+	}
+
+	indexes := make([]int, 0, len(a.calls))
+	for i := range a.calls {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	calls := make([]ToolCall, 0, len(indexes))
+	for _, i := range indexes {
+		tc := *a.calls[i]
+		if tc.Function.Name == "" {
+			return nil, fmt.Errorf("streamed tool call %d has no function name", i)
+		}
+		if strings.TrimSpace(tc.Function.Arguments) == "" {
+			tc.Function.Arguments = "{}"
+		}
+		if !json.Valid([]byte(tc.Function.Arguments)) {
+			return nil, fmt.Errorf("streamed tool call %q has incomplete arguments", tc.Function.Name)
+		}
+		calls = append(calls, tc)
+	}
+	return calls, nil
+}
+
+// readStream assembles a server-sent event stream of chat completion chunks
+// into a single response, passing content to onDelta, when set, as it
+// arrives. Tool calls are only returned once the stream has finished, so
+// callers never execute a call with partial arguments.
+func readStream(r io.Reader, onDelta func(string)) (*ChatResponse, error) {
+	var (
+		resp     ChatResponse
+		content  strings.Builder
+		role     = "assistant"
+		finish   string
+		toolAcc  toolCallAccumulator
+		finished bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue // blank separators, comments and other SSE fields
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			finished = true
+			break
+		}
+
+		var chunk StreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if resp.ID == "" {
+			resp.ID, resp.Model, resp.Created = chunk.ID, chunk.Model, chunk.Created
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+
+		for _, ch := range chunk.Choices {
+			if ch.Index != 0 {
+				continue
+			}
+			if ch.Delta.Role != "" {
+				role = ch.Delta.Role
+			}
+			content.WriteString(ch.Delta.Content)
+			if onDelta != nil && ch.Delta.Content != "" {
+				onDelta(ch.Delta.Content)
+			}
+			toolAcc.add(ch.Delta.ToolCalls)
+			if ch.FinishReason != "" {
+				finish = ch.FinishReason
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+	if !finished && finish == "" {
+		return nil, fmt.Errorf("stream ended before the response was complete")
+	}
+
+	toolCalls, err := toolAcc.complete()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Object = "chat.completion"
+	resp.Choices = []Choice{{
+		Message:      Message{Role: role, Content: content.String()},
+		FinishReason: finish,
+		ToolCalls:    toolCalls,
+	}}
+	return &resp, nil
+}
+
+// deliverContent passes the content of a response that was not streamed to
+// onDelta in one piece
+func deliverContent(resp *ChatResponse, onDelta func(string)) {
+	if len(resp.Choices) > 0 && resp.Choices[0].Message.Content != "" {
+		onDelta(resp.Choices[0].Message.Content)
+	}
+}
@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestChatStreamAssemblesSplitToolCallArguments(t *testing.T) {
+	chunks := []string{
+		`{"id":"c1","model":"test-model","choices":[{"index":0,"delta":{"role":"assistant","content":"Vou "}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"content":"verificar."}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"devops_get_workitem","arguments":""}}]}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"i"}}]}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"trello_list_boards","arguments":""}}]}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"d\": 4"}}]}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"2}"}}]}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"id":"c1","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":8,"total_tokens":20}}`,
+	}
+
+	var req ChatRequest
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	})
+
+	resp, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "status do #42"}}, WithStream())
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if !req.Stream {
+		t.Error("request did not ask for a stream")
+	}
+
+	choice := resp.Choices[0]
+	if choice.Message.Content != "Vou verificar." || choice.FinishReason != "tool_calls" {
+		t.Errorf("choice = %+v", choice)
+	}
+	if len(choice.ToolCalls) != 2 {
+		t.Fatalf("got %d tool calls, want 2: %+v", len(choice.ToolCalls), choice.ToolCalls)
+	}
+
+	first := choice.ToolCalls[0]
+	if first.ID != "call_a" || first.Function.Name != "devops_get_workitem" || first.Function.Arguments != `{"id": 42}` {
+		t.Errorf("first tool call = %+v", first)
+	}
+	if second := choice.ToolCalls[1]; second.Function.Name != "trello_list_boards" || second.Function.Arguments != "{}" {
+		t.Errorf("second tool call = %+v", second)
+	}
+	if resp.Usage.TotalTokens != 20 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestChatStreamRejectsTruncatedToolCall(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_a","function":{"name":"devops_get_workitem","arguments":"{\"id\": 4"}}]}}]}` + "\n\n"))
+	})
+
+	_, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, WithStream())
+	if err == nil || !strings.Contains(err.Error(), "before the response was complete") {
+		t.Errorf("Chat() error = %v, want an incomplete stream error", err)
+	}
+}
+
+func TestChatStreamHandlerReceivesContentAsItArrives(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, piece := range []string{"Você tem ", "", "2 itens"} {
+			w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"content":"` + piece + `"}}]}` + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	})
+
+	var deltas []string
+	resp, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, WithStreamHandler(func(delta string) {
+		deltas = append(deltas, delta)
+	}))
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if strings.Join(deltas, "|") != "Você tem |2 itens" {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if resp.Choices[0].Message.Content != "Você tem 2 itens" {
+		t.Errorf("content = %q", resp.Choices[0].Message.Content)
+	}
+}
+
+func TestChatStreamHandlerGetsUnstreamedAnswersInOnePiece(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Olá!"}}]}`))
+	})
+
+	var deltas []string
+	_, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, WithStreamHandler(func(delta string) {
+		deltas = append(deltas, delta)
+	}))
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(deltas) != 1 || deltas[0] != "Olá!" {
+		t.Errorf("deltas = %q, want the whole answer once", deltas)
+	}
+}
@@ -0,0 +1,177 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Mask is the placeholder that replaces a redacted secret
+const Mask = "[REDACTED]"
+
+// minSecretLength avoids masking trivially short values that would
+// corrupt unrelated text
+const minSecretLength = 4
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// Register adds secrets (PATs, tokens, API keys) to the global redaction list
+func Register(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		if len(v) < minSecretLength {
+			continue
+		}
+		found := false
+		for _, s := range secrets {
+			if s == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			secrets = append(secrets, v)
+		}
+	}
+}
+
+// Reset clears the global redaction list
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	secrets = nil
+}
+
+// String masks every registered secret, plus any extra values, found in s
+func String(s string, extra ...string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, v := range extra {
+		if len(v) >= minSecretLength {
+			s = strings.ReplaceAll(s, v, Mask)
+		}
+	}
+	for _, v := range secrets {
+		s = strings.ReplaceAll(s, v, Mask)
+	}
+	return s
+}
+
+// Error wraps err so that its message is redacted while errors.Is/As
+// still see the original error
+func Error(err error, extra ...string) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err, extra: extra}
+}
+
+type redactedError struct {
+	err   error
+	extra []string
+}
+
+func (e *redactedError) Error() string {
+	return String(e.err.Error(), e.extra...)
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// Handler is a slog.Handler that redacts secrets from messages and attributes
+type Handler struct {
+	inner slog.Handler
+}
+
+// NewHandler wraps a slog.Handler with secret redaction
+func NewHandler(h slog.Handler) slog.Handler {
+	if _, ok := h.(*Handler); ok {
+		return h
+	}
+	return &Handler{inner: h}
+}
+
+// Logger returns a logger whose output is redacted
+func Logger(logger *slog.Logger) *slog.Logger {
+	if _, ok := logger.Handler().(*Handler); ok {
+		return logger
+	}
+	return slog.New(NewHandler(logger.Handler()))
+}
+
+// Enabled implements slog.Handler
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, String(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.inner.Handle(ctx, out)
+}
+
+// WithAttrs implements slog.Handler
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &Handler{inner: h.inner.WithAttrs(redacted)}
+}
+
+// WithGroup implements slog.Handler
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{inner: h.inner.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, String(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		attrs := make([]any, len(group))
+		for i, ga := range group {
+			attrs[i] = redactAttr(ga)
+		}
+		return slog.Group(a.Key, attrs...)
+	case slog.KindAny:
+		// Handlers would print these through Error or String, e.g. a
+		// *url.URL carrying a token in its query
+		switch x := v.Any().(type) {
+		case error:
+			if str, ok := safeString(x.Error); ok {
+				return slog.String(a.Key, String(str))
+			}
+		case fmt.Stringer:
+			if str, ok := safeString(x.String); ok {
+				return slog.String(a.Key, String(str))
+			}
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+// safeString calls f, reporting false if it panics, as Error and String
+// methods on nil pointers do
+func safeString(f func() string) (s string, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return f(), true
+}
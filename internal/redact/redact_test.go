@@ -0,0 +1,106 @@
+package redact
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	Reset()
+	defer Reset()
+	Register("global-secret-123", "ab") // too short to register
+
+	tests := []struct {
+		name     string
+		input    string
+		extra    []string
+		expected string
+	}{
+		{
+			name:     "No secrets",
+			input:    "nothing to hide",
+			expected: "nothing to hide",
+		},
+		{
+			name:     "Global secret",
+			input:    "token=global-secret-123",
+			expected: "token=" + Mask,
+		},
+		{
+			name:     "Extra secret",
+			input:    `{"message":"invalid PAT my-pat-value"}`,
+			extra:    []string{"my-pat-value"},
+			expected: `{"message":"invalid PAT ` + Mask + `"}`,
+		},
+		{
+			name:     "Short values are ignored",
+			input:    "about abc",
+			extra:    []string{"abc"},
+			expected: "about abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := String(tt.input, tt.extra...)
+			if result != tt.expected {
+				t.Errorf("String(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestErrorPreservesChain(t *testing.T) {
+	sentinel := errors.New("boom")
+	err := Error(fmt.Errorf("call with token-xyz failed: %w", sentinel), "token-xyz")
+
+	if strings.Contains(err.Error(), "token-xyz") {
+		t.Errorf("Error() = %q, expected token to be masked", err.Error())
+	}
+	if !errors.Is(err, sentinel) {
+		t.Error("expected errors.Is to see the wrapped error")
+	}
+}
+
+func TestHandlerRedactsAttributes(t *testing.T) {
+	Reset()
+	defer Reset()
+	Register("super-secret-pat")
+
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewJSONHandler(&buf, nil)))
+	logger.With("pat", "super-secret-pat").Error("request failed",
+		"error", errors.New("API error (status 401): super-secret-pat rejected"),
+		"body", "echo super-secret-pat",
+	)
+	logger.InfoContext(context.Background(), "message with super-secret-pat")
+
+	if strings.Contains(buf.String(), "super-secret-pat") {
+		t.Errorf("log output leaked secret: %s", buf.String())
+	}
+}
+
+func TestHandlerRedactsStringers(t *testing.T) {
+	Reset()
+	defer Reset()
+	Register("super-secret-pat")
+
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewJSONHandler(&buf, nil)))
+	endpoint, _ := url.Parse("https://example.com/api?token=super-secret-pat")
+	var nilURL *url.URL
+	logger.Info("calling", "url", endpoint, "previous", nilURL)
+
+	if strings.Contains(buf.String(), "super-secret-pat") {
+		t.Errorf("log output leaked secret: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "token="+Mask) {
+		t.Errorf("log output = %s, want the URL with the token masked", buf.String())
+	}
+}
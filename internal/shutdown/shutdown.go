@@ -0,0 +1,44 @@
+// Package shutdown stops the application's components in order within one
+// time budget.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Stage is one step of the shutdown sequence
+type Stage struct {
+	Name string
+	Stop func(ctx context.Context) error
+}
+
+// Run runs stages in order under a shared timeout and logs each one. A stage
+// that fails or runs out of time does not stop the sequence: later stages
+// still release their resources, with whatever budget is left.
+func Run(ctx context.Context, logger *slog.Logger, timeout time.Duration, stages ...Stage) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var errs []error
+	for _, stage := range stages {
+		stageStart := time.Now()
+		logger.Info("shutdown stage started", "stage", stage.Name)
+
+		if err := stage.Stop(ctx); err != nil {
+			logger.Error("shutdown stage failed", "stage", stage.Name, "error", err,
+				"duration_ms", time.Since(stageStart).Milliseconds())
+			errs = append(errs, fmt.Errorf("%s: %w", stage.Name, err))
+			continue
+		}
+		logger.Info("shutdown stage finished", "stage", stage.Name,
+			"duration_ms", time.Since(stageStart).Milliseconds())
+	}
+
+	logger.Info("shutdown finished", "duration_ms", time.Since(start).Milliseconds(), "failed_stages", len(errs))
+	return errors.Join(errs...)
+}
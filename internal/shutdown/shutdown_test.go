@@ -0,0 +1,79 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunStopsStagesInOrderWithinBudget(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var order []string
+	stage := func(name string, stop func(ctx context.Context) error) Stage {
+		return Stage{Name: name, Stop: func(ctx context.Context) error {
+			order = append(order, name)
+			return stop(ctx)
+		}}
+	}
+	ok := func(ctx context.Context) error { return nil }
+
+	// The agent never drains on its own; the budget must cut it short and
+	// leave the later stages to run
+	hung := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	var storesCtxErr error
+	stores := func(ctx context.Context) error {
+		storesCtxErr = ctx.Err()
+		return nil
+	}
+
+	start := time.Now()
+	err := Run(context.Background(), logger, 50*time.Millisecond,
+		stage("gateway", ok),
+		stage("agent", hung),
+		stage("background", ok),
+		stage("telegram", ok),
+		stage("stores", stores),
+	)
+	elapsed := time.Since(start)
+
+	want := []string{"gateway", "agent", "background", "telegram", "stores"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("stages ran as %v, want %v", order, want)
+	}
+	if elapsed > time.Second {
+		t.Errorf("shutdown took %v, want it bounded by the 50ms budget", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "agent") {
+		t.Errorf("Run() error = %v, want the agent stage's deadline", err)
+	}
+	if storesCtxErr == nil {
+		t.Error("stores stage should see the exhausted budget")
+	}
+}
+
+func TestRunSucceeds(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	calls := 0
+	stop := func(ctx context.Context) error {
+		calls++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("stage context has no deadline")
+		}
+		return nil
+	}
+
+	if err := Run(context.Background(), logger, time.Second, Stage{"a", stop}, Stage{"b", stop}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("%d stages ran, want 2", calls)
+	}
+}
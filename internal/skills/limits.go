@@ -0,0 +1,70 @@
+package skills
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// charsPerToken is a rough, model-agnostic estimate used to reject
+// oversized input before it reaches the LLM
+const charsPerToken = 4
+
+// InputLimits bounds the size of a single user message (0 = no limit)
+type InputLimits struct {
+	MaxChars  int
+	MaxTokens int
+}
+
+// EstimateTokens returns an approximate token count for input
+func EstimateTokens(input string) int {
+	return (utf8.RuneCountInString(input) + charsPerToken - 1) / charsPerToken
+}
+
+// Check returns an error describing the violated limit, if any
+func (l InputLimits) Check(input string) error {
+	chars := utf8.RuneCountInString(input)
+	if l.MaxChars > 0 && chars > l.MaxChars {
+		return fmt.Errorf("message too long: %d characters (max %d)", chars, l.MaxChars)
+	}
+	if l.MaxTokens > 0 {
+		if tokens := EstimateTokens(input); tokens > l.MaxTokens {
+			return fmt.Errorf("message too long: ~%d tokens (max %d)", tokens, l.MaxTokens)
+		}
+	}
+	return nil
+}
+
+// AttachmentLimits bounds the files accepted alongside a chat message
+type AttachmentLimits struct {
+	MaxCount     int      // attachments per message (0 = no limit)
+	MaxBytes     int      // decoded size of a single attachment (0 = no limit)
+	AllowedTypes []string // accepted MIME types; empty rejects every attachment
+}
+
+// CheckCount returns an error when a message carries too many attachments
+func (l AttachmentLimits) CheckCount(n int) error {
+	if l.MaxCount > 0 && n > l.MaxCount {
+		return fmt.Errorf("too many attachments: %d (max %d)", n, l.MaxCount)
+	}
+	return nil
+}
+
+// Check returns an error when an attachment's type or size is not accepted
+func (l AttachmentLimits) Check(name, contentType string, size int) error {
+	mediaType, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(contentType)), ";")
+	allowed := false
+	for _, t := range l.AllowedTypes {
+		if strings.EqualFold(strings.TrimSpace(t), mediaType) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("attachment %q: content type %q is not allowed", name, contentType)
+	}
+	if l.MaxBytes > 0 && size > l.MaxBytes {
+		return fmt.Errorf("attachment %q too large: %d bytes (max %d)", name, size, l.MaxBytes)
+	}
+	return nil
+}
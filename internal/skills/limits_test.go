@@ -0,0 +1,64 @@
+package skills
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInputLimitsCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		limits      InputLimits
+		input       string
+		shouldError bool
+	}{
+		{"No limits", InputLimits{}, strings.Repeat("a", 100000), false},
+		{"At char limit", InputLimits{MaxChars: 10}, strings.Repeat("a", 10), false},
+		{"Over char limit", InputLimits{MaxChars: 10}, strings.Repeat("a", 11), true},
+		{"Multibyte counted as chars", InputLimits{MaxChars: 3}, "ção", false},
+		{"At token limit", InputLimits{MaxTokens: 2}, strings.Repeat("a", 8), false},
+		{"Over token limit", InputLimits{MaxTokens: 2}, strings.Repeat("a", 9), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Check(tt.input)
+			if (err != nil) != tt.shouldError {
+				t.Errorf("Check() error = %v, shouldError = %v", err, tt.shouldError)
+			}
+		})
+	}
+}
+
+func TestAttachmentLimitsCheck(t *testing.T) {
+	limits := AttachmentLimits{MaxCount: 2, MaxBytes: 100, AllowedTypes: []string{"image/png", "text/plain"}}
+
+	tests := []struct {
+		name        string
+		contentType string
+		size        int
+		shouldError bool
+	}{
+		{"Allowed type", "image/png", 100, false},
+		{"Type with parameters", "text/plain; charset=utf-8", 10, false},
+		{"Type is case insensitive", "IMAGE/PNG", 10, false},
+		{"Disallowed type", "application/x-msdownload", 10, true},
+		{"Too large", "image/png", 101, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.Check("file", tt.contentType, tt.size)
+			if (err != nil) != tt.shouldError {
+				t.Errorf("Check() error = %v, shouldError = %v", err, tt.shouldError)
+			}
+		})
+	}
+
+	if err := limits.CheckCount(2); err != nil {
+		t.Errorf("CheckCount(2) error = %v", err)
+	}
+	if err := limits.CheckCount(3); err == nil {
+		t.Error("CheckCount(3) expected an error")
+	}
+}
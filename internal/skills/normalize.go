@@ -0,0 +1,56 @@
+package skills
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// botMention matches a Telegram bot mention (bot usernames end in "bot")
+// pasted at the start of a message
+var botMention = regexp.MustCompile(`(?i)^@\w*bot\b[\s,:]*`)
+
+// NormalizeInput cleans up user input before it reaches the LLM: it trims
+// surrounding whitespace and trailing spaces, drops control and zero-width
+// characters, collapses runs of blank lines and removes a leading bot
+// mention. Fenced code blocks are left as written.
+func NormalizeInput(input string) string {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	inCode, blank := false, false
+	for _, line := range lines {
+		if !inCode {
+			line = strings.TrimRightFunc(strings.Map(dropInvisible, line), unicode.IsSpace)
+			if line == "" {
+				if blank {
+					continue
+				}
+				blank = true
+			} else {
+				blank = false
+			}
+		}
+		out = append(out, line)
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+	}
+
+	normalized := strings.TrimSpace(strings.Join(out, "\n"))
+	return botMention.ReplaceAllString(normalized, "")
+}
+
+// dropInvisible maps control and zero-width characters to -1 so strings.Map
+// removes them. The zero-width joiner is kept because emoji sequences need it.
+func dropInvisible(r rune) rune {
+	switch r {
+	case '\t', '\n':
+		return r
+	case '\u200b', '\u200c', '\u200e', '\u200f', '\u2060', '\ufeff', '\u00ad':
+		return -1
+	}
+	if unicode.IsControl(r) {
+		return -1
+	}
+	return r
+}
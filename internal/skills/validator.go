@@ -3,38 +3,82 @@ package skills
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 )
 
-// Validator validates operations against skill definitions
+// Validator validates operations against skill definitions. Registered
+// commands can be disabled and re-enabled at runtime.
 type Validator struct {
+	mu              sync.RWMutex
 	allowedCommands map[string]bool
+	disabled        map[string]bool
 }
 
 // NewValidator creates a new skills validator
 func NewValidator() *Validator {
 	return &Validator{
 		allowedCommands: make(map[string]bool),
+		disabled:        make(map[string]bool),
 	}
 }
 
 // RegisterCommand registers a command as allowed
 func (v *Validator) RegisterCommand(command string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 	v.allowedCommands[command] = true
 }
 
 // RegisterCommands registers multiple commands as allowed
 func (v *Validator) RegisterCommands(commands []string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 	for _, cmd := range commands {
 		v.allowedCommands[cmd] = true
 	}
 }
 
-// IsCommandAllowed checks if a command is in the allowlist
+// IsCommandAllowed checks if a command is in the allowlist and not disabled
 func (v *Validator) IsCommandAllowed(command string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.allowedCommands[command] && !v.disabled[command]
+}
+
+// IsRegistered reports whether a command is in the allowlist, enabled or not
+func (v *Validator) IsRegistered(command string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 	return v.allowedCommands[command]
 }
 
+// SetEnabled disables or re-enables a command without removing it from the
+// allowlist. Commands that are not registered yet can be disabled too, so
+// overrides can be applied before the integration registers them.
+func (v *Validator) SetEnabled(command string, enabled bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if enabled {
+		delete(v.disabled, command)
+	} else {
+		v.disabled[command] = true
+	}
+}
+
+// Disabled returns the disabled commands, sorted
+func (v *Validator) Disabled() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	list := make([]string, 0, len(v.disabled))
+	for cmd := range v.disabled {
+		list = append(list, cmd)
+	}
+	sort.Strings(list)
+	return list
+}
+
 // ValidateCommand validates a command against the allowlist
 func (v *Validator) ValidateCommand(command string) error {
 	if !v.IsCommandAllowed(command) {
@@ -106,14 +150,102 @@ func GetAllowedDevOpsCommands() []string {
 		"devops_get_workitem",
 		"devops_create_workitem",
 		"devops_update_workitem",
+		"devops_close_workitem_by_title",
 		"devops_query_workitems",
+		"devops_search_workitems",
+		"devops_workitems_in_range",
+		"devops_state_summary",
+		"devops_list_templates",
+		"devops_run_template",
 		"devops_list_pipelines",
 		"devops_run_pipeline",
+		"devops_list_artifacts",
+		"devops_list_projects",
 		"devops_list_repos",
+		"devops_list_commits",
+		"devops_commit_changes",
 		"devops_list_boards",
+		"devops_board_status",
+		"devops_list_team_members",
+		"devops_reassign_workitems",
+		"devops_bulk_comment",
+		"devops_assign_to_sprint",
+		"devops_get_wiki_page",
+		"devops_assign_to_me",
+		"devops_set_effort",
+		"devops_get_pipeline_yaml",
+		"devops_get_children",
+		"devops_get_parent",
+		"devops_my_mentions",
+		"devops_create_subtasks",
+	}
+}
+
+// GetAllowedTrelloCommands returns the list of allowed Trello commands
+func GetAllowedTrelloCommands() []string {
+	return []string{
+		"trello_list_boards",
+		"trello_get_board",
+		"trello_get_lists",
+		"trello_board_overview",
+		"trello_create_list",
+		"trello_create_card",
+		"trello_copy_card",
+		"trello_get_card",
+		"trello_get_cards_on_list",
+		"trello_get_cards_on_board",
+		"trello_update_card",
+		"trello_add_comment",
+		"trello_get_board_members",
+		"trello_add_member",
+		"trello_remove_member",
+		"trello_set_custom_field",
+		"trello_set_reminder",
+		"trello_my_notifications",
+		"trello_mark_notifications_read",
+		"trello_my_cards",
+	}
+}
+
+// GetAllowedBridgeCommands returns the tools that need both Azure DevOps and Trello
+func GetAllowedBridgeCommands() []string {
+	return []string{
+		"bridge_workitem_to_card",
 	}
 }
 
+// mutatingCommands are the tools that create, change or run something in
+// an integration, refused in read-only mode
+var mutatingCommands = map[string]bool{
+	"devops_create_workitem":         true,
+	"devops_update_workitem":         true,
+	"devops_close_workitem_by_title": true,
+	"devops_run_pipeline":            true,
+	"devops_reassign_workitems":      true,
+	"devops_bulk_comment":            true,
+	"devops_assign_to_sprint":        true,
+	"devops_assign_to_me":            true,
+	"devops_set_effort":              true,
+	"devops_create_subtasks":         true,
+	"trello_create_list":             true,
+	"trello_create_card":             true,
+	"trello_copy_card":               true,
+	"trello_update_card":             true,
+	"trello_add_comment":             true,
+	"trello_add_member":              true,
+	"trello_remove_member":           true,
+	"trello_set_custom_field":        true,
+	"trello_set_reminder":            true,
+	"trello_mark_notifications_read": true,
+	"bridge_workitem_to_card":        true,
+}
+
+// IsMutatingCommand reports whether a tool changes data in an integration.
+// Tools that only list, get or query are not mutating.
+func IsMutatingCommand(command string) bool {
+	return mutatingCommands[command]
+}
+
 // GetAllowedTelegramCommands returns the list of allowed Telegram commands
 func GetAllowedTelegramCommands() []string {
 	return []string{
@@ -121,6 +253,11 @@ func GetAllowedTelegramCommands() []string {
 		"/help",
 		"/status",
 		"/workitems",
+		"/feedback",
+		"/newitem",
+		"/skip",
+		"/cancel",
+		"/usage",
 	}
 }
 
@@ -151,3 +288,41 @@ func ValidateDevOpsState(state string) bool {
 	}
 	return allowedStates[state]
 }
+
+// fieldReferencePattern matches Azure DevOps field reference names such as
+// "Microsoft.VSTS.Common.Priority" or "Custom.ReleaseNotes"
+var fieldReferencePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z][A-Za-z0-9_]*)+$`)
+
+// ValidateDevOpsFieldName validates a work item field reference name
+func ValidateDevOpsFieldName(name string) bool {
+	return fieldReferencePattern.MatchString(name)
+}
+
+// maxWIQLLength is the longest query Azure DevOps accepts
+const maxWIQLLength = 32000
+
+// wiqlPattern matches a single read-only WIQL statement
+var wiqlPattern = regexp.MustCompile(`(?is)^\s*SELECT\s.+\sFROM\s+(WorkItems|WorkItemLinks)\b`)
+
+// wiqlLiteral matches a WIQL string in single or double quotes, where a
+// doubled quote escapes one
+var wiqlLiteral = regexp.MustCompile(`'(?:[^']|'')*'|"(?:[^"]|"")*"`)
+
+// ValidateWIQL rejects queries that are not a single WIQL SELECT statement,
+// before they are sent to Azure DevOps. String literals may contain anything.
+func ValidateWIQL(query string) error {
+	query = wiqlLiteral.ReplaceAllString(strings.TrimSpace(query), "''")
+	switch {
+	case query == "":
+		return fmt.Errorf("query is required")
+	case len(query) > maxWIQLLength:
+		return fmt.Errorf("query is too long: %d characters (max %d)", len(query), maxWIQLLength)
+	case !wiqlPattern.MatchString(query):
+		return fmt.Errorf("query must be a SELECT ... FROM WorkItems or WorkItemLinks statement")
+	case strings.Contains(query, ";"):
+		return fmt.Errorf("query must be a single statement")
+	case strings.Contains(query, "--") || strings.Contains(query, "/*"):
+		return fmt.Errorf("query must not contain comments")
+	}
+	return nil
+}
@@ -197,11 +197,34 @@ func TestGetAllowedDevOpsCommands(t *testing.T) {
 		"devops_get_workitem",
 		"devops_create_workitem",
 		"devops_update_workitem",
+		"devops_close_workitem_by_title",
 		"devops_query_workitems",
+		"devops_search_workitems",
+		"devops_workitems_in_range",
+		"devops_state_summary",
+		"devops_list_templates",
+		"devops_run_template",
 		"devops_list_pipelines",
 		"devops_run_pipeline",
+		"devops_list_artifacts",
+		"devops_list_projects",
 		"devops_list_repos",
+		"devops_list_commits",
+		"devops_commit_changes",
 		"devops_list_boards",
+		"devops_board_status",
+		"devops_list_team_members",
+		"devops_reassign_workitems",
+		"devops_bulk_comment",
+		"devops_assign_to_sprint",
+		"devops_get_wiki_page",
+		"devops_assign_to_me",
+		"devops_set_effort",
+		"devops_get_pipeline_yaml",
+		"devops_get_children",
+		"devops_get_parent",
+		"devops_my_mentions",
+		"devops_create_subtasks",
 	}
 
 	if len(commands) != len(expectedCommands) {
@@ -220,3 +243,78 @@ func TestGetAllowedDevOpsCommands(t *testing.T) {
 		}
 	}
 }
+
+func TestMutatingCommandsAreRegistered(t *testing.T) {
+	registered := make(map[string]bool)
+	for _, list := range [][]string{GetAllowedDevOpsCommands(), GetAllowedTrelloCommands(), GetAllowedBridgeCommands()} {
+		for _, cmd := range list {
+			registered[cmd] = true
+		}
+	}
+
+	for cmd := range mutatingCommands {
+		if !registered[cmd] {
+			t.Errorf("mutating command %q is not a registered tool", cmd)
+		}
+	}
+	for _, cmd := range []string{"devops_get_workitem", "devops_query_workitems", "devops_list_pipelines", "trello_get_card"} {
+		if IsMutatingCommand(cmd) {
+			t.Errorf("%s should be read-only", cmd)
+		}
+	}
+}
+
+func TestValidateDevOpsFieldName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"Custom.ReleaseNotes", true},
+		{"Microsoft.VSTS.Common.Priority", true},
+		{"System.Title", true},
+		{"ReleaseNotes", false},
+		{"Custom.", false},
+		{".Field", false},
+		{"Custom.Release Notes", false},
+		{"Custom/ReleaseNotes", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := ValidateDevOpsFieldName(tt.name); result != tt.expected {
+				t.Errorf("ValidateDevOpsFieldName(%q) = %v, expected %v", tt.name, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateWIQL(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{"SELECT [System.Id] FROM WorkItems WHERE [System.State] = 'Active'", true},
+		{"select [System.Id] from workitemlinks where [Source].[System.Id] = 1", true},
+		{"SELECT [System.Id] FROM WorkItems WHERE [System.Title] CONTAINS 'a; b -- c'", true},
+		{"SELECT [System.Id] FROM WorkItems WHERE [System.Title] = 'it''s; fine'", true},
+		{`SELECT [System.Id] FROM WorkItems WHERE [System.Title] CONTAINS "a; b -- c"`, true},
+		{`SELECT [System.Id] FROM WorkItems WHERE [System.Title] = "say ""hi""; it's /* fine */"`, true},
+		{`SELECT [System.Id] FROM WorkItems WHERE [System.Title] = "x"; SELECT [System.Id] FROM WorkItems`, false},
+		{`SELECT [System.Id] FROM WorkItems WHERE [System.Title] = "x" -- comment`, false},
+		{"", false},
+		{"DELETE FROM WorkItems", false},
+		{"SELECT [System.Id] FROM Users", false},
+		{"SELECT [System.Id] FROM WorkItems; SELECT [System.Id] FROM WorkItems", false},
+		{"SELECT [System.Id] FROM WorkItems -- comment", false},
+		{"SELECT [System.Id] FROM WorkItems /* comment */", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if err := ValidateWIQL(tt.query); (err == nil) != tt.expected {
+				t.Errorf("ValidateWIQL(%q) error = %v, expected valid = %v", tt.query, err, tt.expected)
+			}
+		})
+	}
+}
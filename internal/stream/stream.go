@@ -0,0 +1,26 @@
+// Package stream defines the progress events reported while a message is
+// processed, shared by the agent that emits them and the channels that
+// show them.
+package stream
+
+// Event types. A client can expect any number of tool_start/tool_end
+// pairs, content events carrying pieces of the answer as the model writes
+// it, then error when processing failed, and always done last. Content
+// sent before a tool_start is the model commenting on the call it is about
+// to make; the answer is the content after the last tool_end.
+const (
+	ToolStart = "tool_start"
+	ToolEnd   = "tool_end"
+	Content   = "content"
+	Error     = "error"
+	Done      = "done"
+)
+
+// Event is a progress event emitted while a message is processed
+type Event struct {
+	Type       string `json:"type"`
+	Tool       string `json:"tool,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Content    string `json:"content,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
@@ -1,33 +1,82 @@
 package trello
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 	"encoding/json"
+
+	"github.com/abelclopes/nomad-iabot/internal/breaker"
+	"github.com/abelclopes/nomad-iabot/internal/httpx"
+	"github.com/abelclopes/nomad-iabot/internal/idempotency"
+	"github.com/abelclopes/nomad-iabot/internal/redact"
 )
 
+// ErrAuthExpired is returned when Trello rejects the API key or token,
+// usually because the token expired or was revoked
+var ErrAuthExpired = errors.New("trello rejected the API key or token")
+
 // Client is a Trello REST API client
 type Client struct {
 	apiKey      string
 	token       string
 	httpClient  *http.Client
 	baseURL     string
+	limiter     *rateLimiter
+	breaker     *breaker.Breaker
+	idempotency *idempotency.Store
 }
 
 // NewClient creates a new Trello client
 func NewClient(apiKey, token string) *Client {
-	return &Client{
-		apiKey: apiKey,
-		token:  token,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+	c := &Client{
+		apiKey:  apiKey,
+		token:   token,
 		baseURL: "https://api.trello.com/1",
+		limiter: newRateLimiter(defaultRateLimitRequests, defaultRateLimitInterval),
+
+		idempotency: idempotency.NewStore(idempotency.DefaultTTL),
+	}
+
+	// The transport waits on the limiter before every attempt, so retries
+	// count against the rate limit too
+	retry := httpx.NewRetryTransport(nil, httpx.DefaultPolicy)
+	retry.Limiter = httpx.LimiterFunc(c.waitTurn)
+	c.httpClient = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: httpx.NewUserAgentTransport(retry),
 	}
+	return c
+}
+
+// waitTurn paces a request with the current rate limit
+func (c *Client) waitTurn(ctx context.Context) error {
+	return c.limiter.Wait(ctx)
+}
+
+// SetHTTPClient replaces the HTTP client used for API requests. Its
+// transport is responsible for retries and pacing; SetRateLimit only paces
+// the default one.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetBreaker guards API requests with a circuit breaker
+func (c *Client) SetBreaker(b *breaker.Breaker) {
+	c.breaker = b
+}
+
+// SetRateLimit paces outbound requests to at most requests per interval.
+// A non-positive value disables client-side pacing.
+func (c *Client) SetRateLimit(requests int, interval time.Duration) {
+	c.limiter = newRateLimiter(requests, interval)
 }
 
 // ========================================
@@ -151,6 +200,7 @@ type Card struct {
 	URL         string   `json:"url"`
 	ShortURL    string   `json:"shortUrl"`
 	Due         string   `json:"due,omitempty"`
+	DueComplete bool     `json:"dueComplete,omitempty"`
 	Labels      []Label  `json:"labels,omitempty"`
 }
 
@@ -170,10 +220,32 @@ type CreateCardRequest struct {
 	DueDate   string   // ISO 8601 date format
 	MemberIDs []string
 	LabelIDs  []string
+
+	IdempotencyKey string // repeated creates with the same key return the first card
 }
 
-// CreateCard creates a new card on a list
+// CreateCard creates a new card on a list. With an IdempotencyKey, repeated
+// calls return the card created by the first one.
 func (c *Client) CreateCard(ctx context.Context, req CreateCardRequest) (*Card, error) {
+	if req.IdempotencyKey == "" {
+		return c.createCard(ctx, req)
+	}
+	if err := idempotency.ValidateKey(req.IdempotencyKey); err != nil {
+		return nil, err
+	}
+
+	v, _, err := c.idempotency.Do("trello:"+req.IdempotencyKey, func() (interface{}, error) {
+		return c.createCard(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	card := *v.(*Card)
+	return &card, nil
+}
+
+func (c *Client) createCard(ctx context.Context, req CreateCardRequest) (*Card, error) {
 	endpoint := fmt.Sprintf("%s/cards", c.baseURL)
 	
 	params := url.Values{}
@@ -214,6 +286,45 @@ func (c *Client) CreateCard(ctx context.Context, req CreateCardRequest) (*Card,
 	return &card, nil
 }
 
+// CopyCard creates a copy of sourceCardID on targetListID. keep lists the
+// attributes to carry over (e.g. "checklists", "labels", "attachments");
+// when empty, everything is kept. An empty name keeps the source card's name.
+func (c *Client) CopyCard(ctx context.Context, sourceCardID, targetListID, name string, keep ...string) (*Card, error) {
+	endpoint := fmt.Sprintf("%s/cards", c.baseURL)
+
+	keepFromSource := "all"
+	if len(keep) > 0 {
+		keepFromSource = strings.Join(keep, ",")
+	}
+
+	params := url.Values{}
+	params.Set("idCardSource", sourceCardID)
+	params.Set("idList", targetListID)
+	params.Set("keepFromSource", keepFromSource)
+	if name != "" {
+		params.Set("name", name)
+	}
+
+	resp, err := c.doRequestWithParams(ctx, "POST", endpoint, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var card Card
+	if err := json.NewDecoder(resp.Body).Decode(&card); err != nil {
+		return nil, fmt.Errorf("failed to decode card: %w", err)
+	}
+
+	// Copies of archived cards inherit the closed flag; templates should be usable
+	if card.Closed {
+		open := false
+		return c.UpdateCard(ctx, card.ID, UpdateCardRequest{Closed: &open})
+	}
+
+	return &card, nil
+}
+
 // GetCard retrieves a specific card by ID
 func (c *Client) GetCard(ctx context.Context, cardID string) (*Card, error) {
 	endpoint := fmt.Sprintf("%s/cards/%s", c.baseURL, cardID)
@@ -350,6 +461,190 @@ func (c *Client) GetBoardMembers(ctx context.Context, boardID string) ([]Member,
 	return members, nil
 }
 
+// AddMemberToCard assigns one member to a card and returns the card's members
+func (c *Client) AddMemberToCard(ctx context.Context, cardID, memberID string) ([]Member, error) {
+	endpoint := fmt.Sprintf("%s/cards/%s/idMembers", c.baseURL, cardID)
+
+	params := url.Values{}
+	params.Set("value", memberID)
+
+	resp, err := c.doRequestWithParams(ctx, "POST", endpoint, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var members []Member
+	if err := json.NewDecoder(resp.Body).Decode(&members); err != nil {
+		return nil, fmt.Errorf("failed to decode members: %w", err)
+	}
+
+	return members, nil
+}
+
+// RemoveMemberFromCard unassigns a member from a card and returns the
+// members left on it
+func (c *Client) RemoveMemberFromCard(ctx context.Context, cardID, memberID string) ([]Member, error) {
+	endpoint := fmt.Sprintf("%s/cards/%s/idMembers/%s", c.baseURL, cardID, url.PathEscape(memberID))
+
+	resp, err := c.doRequest(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var members []Member
+	if err := json.NewDecoder(resp.Body).Decode(&members); err != nil {
+		return nil, fmt.Errorf("failed to decode members: %w", err)
+	}
+
+	return members, nil
+}
+
+// ResolveBoardMember finds a board member by ID or username (with or
+// without a leading '@', case-insensitive)
+func (c *Client) ResolveBoardMember(ctx context.Context, boardID, member string) (*Member, error) {
+	member = strings.TrimPrefix(strings.TrimSpace(member), "@")
+	if member == "" {
+		return nil, fmt.Errorf("member is required")
+	}
+
+	members, err := c.GetBoardMembers(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		if members[i].ID == member || strings.EqualFold(members[i].Username, member) {
+			return &members[i], nil
+		}
+	}
+	return nil, fmt.Errorf("member %q not found on board %s", member, boardID)
+}
+
+// ========================================
+// Custom Fields
+// ========================================
+
+// CustomField is a custom field defined on a board (Custom Fields Power-Up)
+type CustomField struct {
+	ID      string              `json:"id"`
+	Name    string              `json:"name"`
+	Type    string              `json:"type"` // text, number, checkbox, date or list
+	Options []CustomFieldOption `json:"options,omitempty"`
+}
+
+// CustomFieldOption is one choice of a list custom field
+type CustomFieldOption struct {
+	ID    string `json:"id"`
+	Value struct {
+		Text string `json:"text"`
+	} `json:"value"`
+}
+
+// GetCustomFields returns the custom fields defined on a board
+func (c *Client) GetCustomFields(ctx context.Context, boardID string) ([]CustomField, error) {
+	endpoint := fmt.Sprintf("%s/boards/%s/customFields", c.baseURL, boardID)
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var fields []CustomField
+	if err := json.NewDecoder(resp.Body).Decode(&fields); err != nil {
+		return nil, fmt.Errorf("failed to decode custom fields: %w", err)
+	}
+
+	return fields, nil
+}
+
+// ResolveCustomField finds a board custom field by ID or case-insensitive name
+func (c *Client) ResolveCustomField(ctx context.Context, boardID, field string) (*CustomField, error) {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return nil, fmt.Errorf("field is required")
+	}
+
+	fields, err := c.GetCustomFields(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range fields {
+		if fields[i].ID == field || strings.EqualFold(fields[i].Name, field) {
+			return &fields[i], nil
+		}
+	}
+	return nil, fmt.Errorf("custom field %q not found on board %s", field, boardID)
+}
+
+// Value converts raw into the body SetCardCustomField expects for this
+// field's type. An empty raw value clears the field.
+func (f *CustomField) Value(raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	if f.Type == "list" {
+		if raw == "" {
+			return map[string]string{"idValue": ""}, nil
+		}
+		for _, opt := range f.Options {
+			if opt.ID == raw || strings.EqualFold(opt.Value.Text, raw) {
+				return map[string]string{"idValue": opt.ID}, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not an option of %s", raw, f.Name)
+	}
+
+	if raw == "" {
+		return map[string]string{"value": ""}, nil
+	}
+
+	var key string
+	switch f.Type {
+	case "text":
+		key = "text"
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("%s expects a number, got %q", f.Name, raw)
+		}
+		key = "number"
+	case "checkbox":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects true or false, got %q", f.Name, raw)
+		}
+		key, raw = "checked", strconv.FormatBool(b)
+	case "date":
+		if _, err := time.Parse(time.RFC3339, raw); err != nil {
+			if _, err := time.Parse("2006-01-02", raw); err != nil {
+				return nil, fmt.Errorf("%s expects an ISO 8601 date, got %q", f.Name, raw)
+			}
+		}
+		key = "date"
+	default:
+		return nil, fmt.Errorf("unsupported custom field type %q", f.Type)
+	}
+	// Trello takes every value as a string, keyed by type
+	return map[string]map[string]string{"value": {key: raw}}, nil
+}
+
+// SetCardCustomField sets a custom field on a card. value is the request
+// body, as built by CustomField.Value.
+func (c *Client) SetCardCustomField(ctx context.Context, cardID, fieldID string, value interface{}) error {
+	endpoint := fmt.Sprintf("%s/cards/%s/customField/%s/item", c.baseURL, cardID, url.PathEscape(fieldID))
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal custom field value: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "PUT", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
 // ========================================
 // Comments
 // ========================================
@@ -416,16 +711,42 @@ func (c *Client) doRequestWithParams(ctx context.Context, method, endpoint strin
 		req.Header.Set("Content-Type", "application/json")
 	}
 
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	// The client's transport paces requests and retries throttled ones
 	resp, err := c.httpClient.Do(req)
+	c.breaker.Observe(resp, err)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		// Transport errors include the full URL, which carries key and token
+		return nil, fmt.Errorf("request failed: %w", redact.Error(err, c.apiKey, c.token))
 	}
 
 	if resp.StatusCode >= 400 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+		if isAuthFailure(resp.StatusCode, string(bodyBytes)) {
+			return nil, fmt.Errorf("%w (status %d)", ErrAuthExpired, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, redact.String(string(bodyBytes), c.apiKey, c.token))
 	}
 
 	return resp, nil
 }
+
+// isAuthFailure reports whether a Trello error response rejects the
+// credentials themselves. Trello also answers 401 when a valid token lacks
+// access to a board or card, so the body has to be checked.
+func isAuthFailure(status int, body string) bool {
+	if status != http.StatusUnauthorized && status != http.StatusForbidden {
+		return false
+	}
+	body = strings.ToLower(body)
+	for _, marker := range []string{"invalid token", "expired token", "invalid key", "invalid app key"} {
+		if strings.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
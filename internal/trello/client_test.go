@@ -0,0 +1,147 @@
+package trello
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/breaker"
+	"github.com/abelclopes/nomad-iabot/internal/httpx"
+)
+
+// newTestClient returns a client whose requests are served by handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c := NewClient("test-key", "test-token")
+	c.baseURL = srv.URL
+	return c
+}
+
+func TestRetriesAfterTooManyRequests(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"API_TOKEN_LIMIT_EXCEEDED"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"card1","name":"Created after retry"}`))
+	})
+
+	card, err := c.CreateCard(context.Background(), CreateCardRequest{ListID: "list1", Name: "Created after retry"})
+	if err != nil {
+		t.Fatalf("CreateCard() error = %v", err)
+	}
+	if card.ID != "card1" {
+		t.Errorf("card.ID = %q, expected card1", card.ID)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("expected 2 requests (429 then success), got %d", got)
+	}
+}
+
+func TestGivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	if _, err := c.GetCard(context.Background(), "card1"); err == nil {
+		t.Fatal("expected an error after exhausting retries")
+	}
+	if got := atomic.LoadInt32(&calls); got != int32(httpx.DefaultPolicy.MaxRetries+1) {
+		t.Errorf("expected %d requests, got %d", httpx.DefaultPolicy.MaxRetries+1, got)
+	}
+}
+
+func TestRateLimiterPacesRequests(t *testing.T) {
+	l := newRateLimiter(2, 100*time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+
+	// The third token needs half an interval to refill
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected the third request to be delayed, elapsed %v", elapsed)
+	}
+}
+
+func TestBreakerShortCircuitsFailingTrello(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	b := breaker.New("trello", 2, time.Minute)
+	c.SetBreaker(b)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetCard(context.Background(), "card1"); err == nil {
+			t.Fatalf("request %d succeeded against a failing Trello", i)
+		} else if i == 2 && !errors.Is(err, breaker.ErrOpen) {
+			t.Errorf("third request error = %v, want breaker.ErrOpen", err)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("Trello called %d times, want 2 before the breaker opens", got)
+	}
+	if st := b.Status(); st.State != "open" || st.Trips != 1 || st.Rejected != 1 {
+		t.Errorf("breaker = %+v, want open after 1 trip with 1 rejected call", st)
+	}
+}
+
+func TestCreateCardIdempotencyKey(t *testing.T) {
+	var creates int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&creates, 1)
+		w.Write([]byte(`{"id":"c1","name":"Revisar PR"}`))
+	})
+
+	req := CreateCardRequest{ListID: "l1", Name: "Revisar PR", IdempotencyKey: "req-1"}
+	for i := 0; i < 2; i++ {
+		card, err := c.CreateCard(context.Background(), req)
+		if err != nil {
+			t.Fatalf("CreateCard() error = %v", err)
+		}
+		if card.ID != "c1" {
+			t.Errorf("CreateCard() returned %q", card.ID)
+		}
+	}
+
+	if n := atomic.LoadInt32(&creates); n != 1 {
+		t.Errorf("create calls = %d, expected 1", n)
+	}
+}
+
+func TestInvalidTokenYieldsErrAuthExpired(t *testing.T) {
+	body := "invalid token"
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(body))
+	})
+
+	if _, err := c.GetCard(context.Background(), "abc"); !errors.Is(err, ErrAuthExpired) {
+		t.Fatalf("GetCard() error = %v, want ErrAuthExpired", err)
+	}
+
+	// A valid token without access to a board is not an expired token
+	body = "unauthorized permission requested"
+	if _, err := c.GetCard(context.Background(), "abc"); err == nil || errors.Is(err, ErrAuthExpired) {
+		t.Fatalf("GetCard() error = %v, want a plain API error", err)
+	}
+}
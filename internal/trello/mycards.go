@@ -0,0 +1,164 @@
+package trello
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// MyCard is a card assigned to the token's member, with the names of the
+// board and list it is on
+type MyCard struct {
+	Card
+	BoardName string
+	ListName  string
+}
+
+// GetMyCards returns the open cards assigned to the token's member on every
+// board. Board and list names come from one extra request for the member's
+// boards with their lists, however many boards the cards are spread over.
+func (c *Client) GetMyCards(ctx context.Context) ([]MyCard, error) {
+	params := url.Values{}
+	params.Set("filter", "open")
+	params.Set("fields", "name,idBoard,idList,due,dueComplete,shortUrl,url,closed")
+
+	resp, err := c.doRequestWithParams(ctx, "GET", c.baseURL+"/members/me/cards", params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var cards []Card
+	if err := json.NewDecoder(resp.Body).Decode(&cards); err != nil {
+		return nil, fmt.Errorf("failed to decode cards: %w", err)
+	}
+	if len(cards) == 0 {
+		return nil, nil
+	}
+
+	params = url.Values{}
+	params.Set("fields", "name")
+	params.Set("lists", "all")
+	params.Set("list_fields", "name")
+
+	resp, err = c.doRequestWithParams(ctx, "GET", c.baseURL+"/members/me/boards", params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var boards []struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Lists []List `json:"lists"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&boards); err != nil {
+		return nil, fmt.Errorf("failed to decode boards: %w", err)
+	}
+
+	boardNames := make(map[string]string, len(boards))
+	listNames := make(map[string]string)
+	for _, b := range boards {
+		boardNames[b.ID] = b.Name
+		for _, l := range b.Lists {
+			listNames[l.ID] = l.Name
+		}
+	}
+
+	mine := make([]MyCard, len(cards))
+	for i, card := range cards {
+		mine[i] = MyCard{Card: card, BoardName: boardNames[card.IDBoard], ListName: listNames[card.IDList]}
+	}
+	return mine, nil
+}
+
+func (t *Tool) myCards(ctx context.Context, args map[string]interface{}) (string, error) {
+	// A negative window lists every card
+	days := -1
+	if v, ok := getInt(args, "due_within_days"); ok && v >= 0 {
+		days = v
+	}
+
+	cards, err := t.client.GetMyCards(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	now := t.clock.Now().In(t.location)
+	if days >= 0 {
+		cards = dueBy(cards, endOfDay(now).AddDate(0, 0, days))
+	}
+	return formatMyCards(cards, now, days), nil
+}
+
+// dueBy keeps the unfinished cards due up to limit, overdue ones included
+func dueBy(cards []MyCard, limit time.Time) []MyCard {
+	var kept []MyCard
+	for _, c := range cards {
+		if c.DueComplete {
+			continue
+		}
+		if due, err := time.Parse(time.RFC3339, c.Due); err == nil && !due.After(limit) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+func endOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 23, 59, 59, 0, t.Location())
+}
+
+// formatMyCards groups cards by board, ordering each board's cards by due
+// date with undated ones last. days is the due-soon window, or negative.
+func formatMyCards(cards []MyCard, now time.Time, days int) string {
+	if len(cards) == 0 {
+		if days >= 0 {
+			return fmt.Sprintf("No cards assigned to you are due in the next %d days.", days)
+		}
+		return "No open cards are assigned to you."
+	}
+
+	sort.SliceStable(cards, func(i, j int) bool {
+		if cards[i].BoardName != cards[j].BoardName {
+			return cards[i].BoardName < cards[j].BoardName
+		}
+		if (cards[i].Due == "") != (cards[j].Due == "") {
+			return cards[j].Due == ""
+		}
+		return cards[i].Due < cards[j].Due
+	})
+
+	result := fmt.Sprintf("You have %d cards assigned:\n", len(cards))
+	if days >= 0 {
+		result = fmt.Sprintf("You have %d cards due in the next %d days:\n", len(cards), days)
+	}
+	board := "\x00"
+	for _, c := range cards {
+		if c.BoardName != board {
+			board = c.BoardName
+			name := board
+			if name == "" {
+				name = c.IDBoard
+			}
+			result += fmt.Sprintf("\n📋 %s\n", name)
+		}
+		result += fmt.Sprintf("- %s", c.Name)
+		if c.ListName != "" {
+			result += fmt.Sprintf(" [%s]", c.ListName)
+		}
+		if due, err := time.Parse(time.RFC3339, c.Due); err == nil {
+			due = due.In(now.Location())
+			result += " — due " + due.Format("2006-01-02 15:04")
+			if !c.DueComplete && due.Before(now) {
+				result += " ⚠️ overdue"
+			}
+		}
+		result += fmt.Sprintf(" %s (ID: %s)\n", c.ShortURL, c.ID)
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package trello
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+// recordedMyCards and recordedMyBoards are trimmed responses from
+// GET /1/members/me/cards and GET /1/members/me/boards?lists=all
+const (
+	recordedMyCards = `[
+  {"id":"c1","name":"Fix login","idBoard":"b1","idList":"l2","due":"2026-10-15T17:00:00.000Z","dueComplete":false,"shortUrl":"https://trello.com/c/AbCd1234","closed":false},
+  {"id":"c2","name":"Write release notes","idBoard":"b1","idList":"l1","due":null,"dueComplete":false,"shortUrl":"https://trello.com/c/Ef5678","closed":false},
+  {"id":"c3","name":"Renew domain","idBoard":"b2","idList":"l3","due":"2026-10-10T12:00:00.000Z","dueComplete":false,"shortUrl":"https://trello.com/c/Gh9012","closed":false},
+  {"id":"c4","name":"Plan Q1","idBoard":"b2","idList":"l3","due":"2026-11-30T12:00:00.000Z","dueComplete":false,"shortUrl":"https://trello.com/c/Ij3456","closed":false},
+  {"id":"c5","name":"Old task","idBoard":"b2","idList":"l3","due":"2026-10-01T12:00:00.000Z","dueComplete":true,"shortUrl":"https://trello.com/c/Kl7890","closed":false}
+]`
+	recordedMyBoards = `[
+  {"id":"b1","name":"Sprint","lists":[{"id":"l1","name":"To Do"},{"id":"l2","name":"Doing"}]},
+  {"id":"b2","name":"Personal","lists":[{"id":"l3","name":"Inbox"}]}
+]`
+)
+
+func newMyCardsTool(t *testing.T) *Tool {
+	t.Helper()
+	var boardRequests int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/members/me/cards":
+			if got := r.URL.Query().Get("filter"); got != "open" {
+				t.Errorf("filter = %q, want open", got)
+			}
+			w.Write([]byte(recordedMyCards))
+		case "/members/me/boards":
+			if boardRequests++; boardRequests > 1 {
+				t.Errorf("boards requested %d times, want once per call", boardRequests)
+			}
+			w.Write([]byte(recordedMyBoards))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	tool := NewTool(c)
+	tool.SetClock(clock.NewFake(time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)))
+	tool.SetLocation(time.UTC)
+	return tool
+}
+
+func TestMyCardsFormatsRecordedResponse(t *testing.T) {
+	result, _, err := newMyCardsTool(t).Execute(context.Background(), "trello_my_cards", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	for _, want := range []string{
+		"You have 5 cards assigned",
+		"📋 Personal\n- Old task [Inbox] — due 2026-10-01 12:00 https://trello.com/c/Kl7890 (ID: c5)\n- Renew domain [Inbox] — due 2026-10-10 12:00 ⚠️ overdue",
+		"📋 Sprint\n- Fix login [Doing] — due 2026-10-15 17:00 https://trello.com/c/AbCd1234 (ID: c1)\n- Write release notes [To Do] https://trello.com/c/Ef5678 (ID: c2)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestMyCardsDueSoon(t *testing.T) {
+	// Models send the window as a JSON number or as a numeric string
+	for _, days := range []interface{}{1.0, "1"} {
+		result, _, err := newMyCardsTool(t).Execute(context.Background(), "trello_my_cards", map[string]interface{}{"due_within_days": days})
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+
+		if !strings.Contains(result, "You have 2 cards due in the next 1 days") {
+			t.Errorf("unexpected header:\n%s", result)
+		}
+		for _, want := range []string{"Fix login", "Renew domain"} {
+			if !strings.Contains(result, want) {
+				t.Errorf("result missing %q:\n%s", want, result)
+			}
+		}
+		for _, unwanted := range []string{"Write release notes", "Plan Q1", "Old task"} {
+			if strings.Contains(result, unwanted) {
+				t.Errorf("result should not list %q:\n%s", unwanted, result)
+			}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package trello
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxNotifications caps how many notifications are fetched at once
+const maxNotifications = 50
+
+// Notification is an entry in a member's Trello notification feed. Trello
+// has dozens of notification types and the shape of Data varies between
+// them, so every field is optional.
+type Notification struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Date   string `json:"date"`
+	Unread bool   `json:"unread"`
+	Data   struct {
+		Text  string `json:"text"`
+		Board *struct {
+			ID        string `json:"id"`
+			Name      string `json:"name"`
+			ShortLink string `json:"shortLink"`
+		} `json:"board"`
+		Card *struct {
+			ID        string `json:"id"`
+			Name      string `json:"name"`
+			ShortLink string `json:"shortLink"`
+			Due       string `json:"due"`
+		} `json:"card"`
+		ListAfter *struct {
+			Name string `json:"name"`
+		} `json:"listAfter"`
+	} `json:"data"`
+	MemberCreator *struct {
+		FullName string `json:"fullName"`
+		Username string `json:"username"`
+	} `json:"memberCreator"`
+}
+
+// GetMyNotifications returns the newest notifications of the token's
+// member, optionally only the unread ones
+func (c *Client) GetMyNotifications(ctx context.Context, unreadOnly bool) ([]Notification, error) {
+	endpoint := fmt.Sprintf("%s/members/me/notifications", c.baseURL)
+
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(maxNotifications))
+	params.Set("memberCreator_fields", "fullName,username")
+	if unreadOnly {
+		params.Set("read_filter", "unread")
+	}
+
+	resp, err := c.doRequestWithParams(ctx, "GET", endpoint, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var notifications []Notification
+	if err := json.NewDecoder(resp.Body).Decode(&notifications); err != nil {
+		return nil, fmt.Errorf("failed to decode notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationRead marks one notification as read
+func (c *Client) MarkNotificationRead(ctx context.Context, id string) error {
+	endpoint := fmt.Sprintf("%s/notifications/%s/unread", c.baseURL, url.PathEscape(id))
+
+	params := url.Values{}
+	params.Set("value", "false")
+
+	resp, err := c.doRequestWithParams(ctx, "PUT", endpoint, params, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// MarkAllNotificationsRead marks every notification of the token's member
+// as read
+func (c *Client) MarkAllNotificationsRead(ctx context.Context) error {
+	endpoint := fmt.Sprintf("%s/notifications/all/read", c.baseURL)
+
+	resp, err := c.doRequest(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (t *Tool) myNotifications(ctx context.Context, args map[string]interface{}) (string, error) {
+	unreadOnly := true
+	if v, ok := args["unread_only"].(bool); ok {
+		unreadOnly = v
+	}
+
+	notifications, err := t.client.GetMyNotifications(ctx, unreadOnly)
+	if err != nil {
+		return "", err
+	}
+	return formatNotifications(notifications, unreadOnly), nil
+}
+
+func (t *Tool) markNotificationsRead(ctx context.Context, args map[string]interface{}) (string, error) {
+	var ids []string
+	if list, ok := args["notification_ids"].([]interface{}); ok {
+		for _, v := range list {
+			if id, ok := v.(string); ok && id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	all, _ := args["all"].(bool)
+	switch {
+	case len(ids) > 0 && all:
+		return "", fmt.Errorf("pass either notification_ids or all, not both")
+	case len(ids) == 0 && !all:
+		// Clearing every notification must be asked for, not a default
+		return "", fmt.Errorf("notification_ids is required; pass all: true to mark every notification as read")
+	case all:
+		if err := t.client.MarkAllNotificationsRead(ctx); err != nil {
+			return "", err
+		}
+		return "Marked all notifications as read.", nil
+	}
+
+	for i, id := range ids {
+		if err := t.client.MarkNotificationRead(ctx, id); err != nil {
+			return "", fmt.Errorf("marked %d of %d notifications as read, then failed on %s: %w", i, len(ids), id, err)
+		}
+	}
+	return fmt.Sprintf("Marked %d notifications as read.", len(ids)), nil
+}
+
+func formatNotifications(notifications []Notification, unreadOnly bool) string {
+	if len(notifications) == 0 {
+		if unreadOnly {
+			return "No unread Trello notifications."
+		}
+		return "No Trello notifications."
+	}
+
+	kind := "notifications"
+	if unreadOnly {
+		kind = "unread notifications"
+	}
+	result := fmt.Sprintf("You have %d %s:\n\n", len(notifications), kind)
+	for _, n := range notifications {
+		date := n.Date
+		if len(date) >= 10 {
+			date = date[:10]
+		}
+		result += fmt.Sprintf("- %s %s", date, describeNotification(n))
+		if n.Data.Card != nil && n.Data.Card.ShortLink != "" {
+			result += " https://trello.com/c/" + n.Data.Card.ShortLink
+		}
+		result += fmt.Sprintf(" (ID: %s)\n", n.ID)
+	}
+	return result
+}
+
+// describeNotification renders the common notification types as a
+// sentence and falls back to the raw type for the rest
+func describeNotification(n Notification) string {
+	who := "Someone"
+	if n.MemberCreator != nil && n.MemberCreator.FullName != "" {
+		who = n.MemberCreator.FullName
+	}
+	card := "a card"
+	if n.Data.Card != nil && n.Data.Card.Name != "" {
+		card = fmt.Sprintf("%q", n.Data.Card.Name)
+	}
+	board := "a board"
+	if n.Data.Board != nil && n.Data.Board.Name != "" {
+		board = fmt.Sprintf("%q", n.Data.Board.Name)
+	}
+	text := strings.TrimSpace(n.Data.Text)
+	// Cut by runes so a multi-byte character isn't split in half
+	if runes := []rune(text); len(runes) > 200 {
+		text = string(runes[:200]) + "…"
+	}
+
+	switch n.Type {
+	case "mentionedOnCard":
+		return fmt.Sprintf("💬 %s mentioned you on %s: %s", who, card, text)
+	case "commentCard":
+		return fmt.Sprintf("💬 %s commented on %s: %s", who, card, text)
+	case "addedToCard":
+		return fmt.Sprintf("➕ %s added you to %s", who, card)
+	case "removedFromCard":
+		return fmt.Sprintf("➖ %s removed you from %s", who, card)
+	case "cardDueSoon":
+		due := ""
+		if n.Data.Card != nil && len(n.Data.Card.Due) >= 16 {
+			due = " (" + strings.Replace(n.Data.Card.Due[:16], "T", " ", 1) + " UTC)"
+		}
+		return fmt.Sprintf("⏰ %s is due soon%s", card, due)
+	case "changeCard":
+		if n.Data.ListAfter != nil && n.Data.ListAfter.Name != "" {
+			return fmt.Sprintf("🔀 %s moved %s to %q", who, card, n.Data.ListAfter.Name)
+		}
+		return fmt.Sprintf("✏️ %s changed %s", who, card)
+	case "createdCard":
+		return fmt.Sprintf("🆕 %s created %s", who, card)
+	case "addAttachmentToCard":
+		return fmt.Sprintf("📎 %s attached a file to %s", who, card)
+	case "addedToBoard", "makeAdminOfBoard":
+		return fmt.Sprintf("📋 %s added you to %s", who, board)
+	case "removedFromBoard":
+		return fmt.Sprintf("📋 %s removed you from %s", who, board)
+	}
+
+	desc := fmt.Sprintf("🔔 %s (%s)", n.Type, who)
+	if n.Data.Card != nil && n.Data.Card.Name != "" {
+		desc += " on " + card
+	} else if n.Data.Board != nil && n.Data.Board.Name != "" {
+		desc += " on " + board
+	}
+	return desc
+}
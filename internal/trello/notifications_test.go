@@ -0,0 +1,105 @@
+package trello
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// recordedNotifications is a trimmed response from
+// GET /1/members/me/notifications, including a type the formatter does
+// not know about
+const recordedNotifications = `[
+  {"id":"n1","unread":true,"type":"mentionedOnCard","date":"2026-10-14T09:12:03.000Z",
+   "data":{"text":"@ana can you review this?","card":{"id":"c1","name":"Fix login","shortLink":"AbCd1234","idShort":42},
+           "board":{"id":"b1","name":"Sprint","shortLink":"Bo4rd"}},
+   "memberCreator":{"id":"m2","fullName":"Bruno Lima","username":"bruno"}},
+  {"id":"n2","unread":true,"type":"cardDueSoon","date":"2026-10-14T08:00:00.000Z",
+   "data":{"card":{"id":"c2","name":"Release notes","shortLink":"Ef5678","due":"2026-10-15T17:00:00.000Z"},
+           "board":{"id":"b1","name":"Sprint","shortLink":"Bo4rd"}}},
+  {"id":"n3","unread":true,"type":"addedToCard","date":"2026-10-13T15:40:00.000Z",
+   "data":{"card":{"id":"c3","name":"Deploy","shortLink":"Gh9012"},"board":{"id":"b1","name":"Sprint"}},
+   "memberCreator":{"id":"m3","fullName":"Carla Souza","username":"carla"}},
+  {"id":"n4","unread":true,"type":"reactionAdded","date":"2026-10-13T10:00:00.000Z",
+   "data":{"actionType":"commentCard","emoji":{"unified":"1F44D"},"board":{"id":"b1","name":"Sprint"}},
+   "memberCreator":{"id":"m2","fullName":"Bruno Lima","username":"bruno"}},
+  {"id":"n5","unread":true,"type":"memberJoinedTrello","date":"2026-10-12T10:00:00.000Z","data":{}}
+]`
+
+func TestMyNotificationsFormatsRecordedResponse(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/members/me/notifications" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("read_filter"); got != "unread" {
+			t.Errorf("read_filter = %q, want unread", got)
+		}
+		w.Write([]byte(recordedNotifications))
+	})
+
+	result, _, err := NewTool(c).Execute(context.Background(), "trello_my_notifications", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	for _, want := range []string{
+		"You have 5 unread notifications",
+		`Bruno Lima mentioned you on "Fix login": @ana can you review this?`,
+		"https://trello.com/c/AbCd1234",
+		`"Release notes" is due soon (2026-10-15 17:00 UTC)`,
+		`Carla Souza added you to "Deploy"`,
+		`reactionAdded (Bruno Lima) on "Sprint"`,
+		"memberJoinedTrello (Someone)",
+		"(ID: n5)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestMarkNotificationsRead(t *testing.T) {
+	var calls []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path+" "+r.URL.Query().Get("value"))
+		w.Write([]byte(`{}`))
+	})
+	tool := NewTool(c)
+
+	if _, _, err := tool.Execute(context.Background(), "trello_mark_notifications_read", map[string]interface{}{
+		"notification_ids": []interface{}{"n1", "n2"},
+	}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	// Without IDs nothing is marked unless all is explicit
+	if _, _, err := tool.Execute(context.Background(), "trello_mark_notifications_read", map[string]interface{}{}); err == nil {
+		t.Error("Execute without notification_ids or all succeeded")
+	}
+	if _, _, err := tool.Execute(context.Background(), "trello_mark_notifications_read", map[string]interface{}{"all": true}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	want := []string{
+		"PUT /notifications/n1/unread false",
+		"PUT /notifications/n2/unread false",
+		"POST /notifications/all/read ",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestNotificationTextIsCutByRunes(t *testing.T) {
+	n := Notification{Type: "commentCard"}
+	n.Data.Text = strings.Repeat("ç", 250)
+
+	line := describeNotification(n)
+	if !utf8.ValidString(line) {
+		t.Errorf("truncated text is not valid UTF-8: %q", line)
+	}
+	if !strings.HasSuffix(line, strings.Repeat("ç", 200)+"…") {
+		t.Errorf("text not cut at 200 characters: %q", line)
+	}
+}
@@ -0,0 +1,138 @@
+package trello
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// listCountConcurrency bounds the card count requests in flight at once,
+// so a board with many lists does not burst through the rate limit
+const listCountConcurrency = 4
+
+// ListCount is a list with the number of open cards on it
+type ListCount struct {
+	List
+	Cards int
+}
+
+// CountCardsOnLists returns lists, in order, with the number of open cards
+// on each
+func (c *Client) CountCardsOnLists(ctx context.Context, lists []List) ([]ListCount, error) {
+	counts := make([]ListCount, len(lists))
+	errs := make([]error, len(lists))
+	sem := make(chan struct{}, listCountConcurrency)
+	var wg sync.WaitGroup
+
+	for i, list := range lists {
+		counts[i].List = list
+		wg.Add(1)
+		go func(i int, listID string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			counts[i].Cards, errs[i] = c.countCardsOnList(ctx, listID)
+		}(i, list.ID)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to count cards on list %s: %w", lists[i].Name, err)
+		}
+	}
+	return counts, nil
+}
+
+// countCardsOnList counts the open cards on a list, fetching only their IDs
+func (c *Client) countCardsOnList(ctx context.Context, listID string) (int, error) {
+	endpoint := fmt.Sprintf("%s/lists/%s/cards", c.baseURL, listID)
+	params := url.Values{}
+	params.Set("fields", "id")
+
+	resp, err := c.doRequestWithParams(ctx, "GET", endpoint, params, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var cards []struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&cards); err != nil {
+		return 0, fmt.Errorf("failed to decode cards: %w", err)
+	}
+	return len(cards), nil
+}
+
+func (t *Tool) boardOverview(ctx context.Context, args map[string]interface{}) (string, error) {
+	boardID := getString(args, "board_id")
+	if boardID == "" {
+		return "", fmt.Errorf("board_id is required")
+	}
+
+	limit := t.wipLimit
+	if v, ok := getInt(args, "wip_limit"); ok && v >= 0 {
+		limit = v
+	}
+
+	board, err := t.client.GetBoard(ctx, boardID)
+	if err != nil {
+		return "", err
+	}
+	lists, err := t.client.GetLists(ctx, boardID)
+	if err != nil {
+		return "", err
+	}
+	counts, err := t.client.CountCardsOnLists(ctx, lists)
+	if err != nil {
+		return "", err
+	}
+	return formatBoardOverview(board, counts, limit), nil
+}
+
+// formatBoardOverview lists the board's lists with their card counts,
+// flagging those over the soft WIP limit (0 = no limit)
+func formatBoardOverview(board *Board, counts []ListCount, wipLimit int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Board: %s\n", board.Name)
+	if len(counts) == 0 {
+		sb.WriteString("No lists found.")
+		return sb.String()
+	}
+
+	total, over := 0, 0
+	for _, lc := range counts {
+		total += lc.Cards
+	}
+	fmt.Fprintf(&sb, "%d lists, %d open cards\n\n", len(counts), total)
+
+	for _, lc := range counts {
+		fmt.Fprintf(&sb, "- %s (%s)", lc.Name, cardCount(lc.Cards))
+		if wipLimit > 0 && lc.Cards > wipLimit {
+			fmt.Fprintf(&sb, " ⚠️ over WIP limit (%d/%d)", lc.Cards, wipLimit)
+			over++
+		}
+		sb.WriteString("\n")
+	}
+
+	if wipLimit > 0 {
+		fmt.Fprintf(&sb, "\nWIP limit: %d cards per list; %d list(s) over it.\n", wipLimit, over)
+	}
+	return sb.String()
+}
+
+func cardCount(n int) string {
+	if n == 1 {
+		return "1 card"
+	}
+	return fmt.Sprintf("%d cards", n)
+}
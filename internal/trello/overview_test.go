@@ -0,0 +1,111 @@
+package trello
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// boardServer serves a board with three lists holding 2, 0 and 7 cards and
+// records the most card count requests seen in flight at once
+func boardServer(t *testing.T, peak *int32) *Client {
+	cards := map[string]int{"l1": 2, "l2": 0, "l3": 7}
+	var inFlight int32
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/boards/b1":
+			w.Write([]byte(`{"id":"b1","name":"Sprint"}`))
+		case r.URL.Path == "/boards/b1/lists":
+			w.Write([]byte(`[{"id":"l1","name":"To Do"},{"id":"l2","name":"Doing"},{"id":"l3","name":"Review"}]`))
+		case strings.HasPrefix(r.URL.Path, "/lists/"):
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				p := atomic.LoadInt32(peak)
+				if n <= p || atomic.CompareAndSwapInt32(peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			if got := r.URL.Query().Get("fields"); got != "id" {
+				t.Errorf("fields = %q, want only card IDs", got)
+			}
+			listID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/lists/"), "/cards")
+			ids := make([]string, cards[listID])
+			for i := range ids {
+				ids[i] = fmt.Sprintf(`{"id":"%s-%d"}`, listID, i)
+			}
+			w.Write([]byte("[" + strings.Join(ids, ",") + "]"))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+}
+
+func TestGetListsIncludesCardCounts(t *testing.T) {
+	var peak int32
+	tool := NewTool(boardServer(t, &peak))
+
+	result, _, err := tool.Execute(context.Background(), "trello_get_lists", map[string]interface{}{"board_id": "b1"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for _, want := range []string{"To Do (2 cards)", "Doing (0 cards)", "Review (7 cards)"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestBoardOverviewFlagsWIPLimit(t *testing.T) {
+	var peak int32
+	tool := NewTool(boardServer(t, &peak))
+	tool.SetWIPLimit(5)
+
+	result, _, err := tool.Execute(context.Background(), "trello_board_overview", map[string]interface{}{"board_id": "b1"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for _, want := range []string{
+		"Board: Sprint",
+		"3 lists, 9 open cards",
+		"- To Do (2 cards)\n",
+		"- Review (7 cards) ⚠️ over WIP limit (7/5)",
+		"1 list(s) over it",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+
+	// The argument overrides the configured limit
+	result, _, _ = tool.Execute(context.Background(), "trello_board_overview", map[string]interface{}{"board_id": "b1", "wip_limit": float64(0)})
+	if strings.Contains(result, "WIP") {
+		t.Errorf("wip_limit 0 should disable the flags:\n%s", result)
+	}
+}
+
+func TestCountCardsOnListsBoundsConcurrency(t *testing.T) {
+	var peak int32
+	c := boardServer(t, &peak)
+
+	lists := make([]List, 12)
+	for i := range lists {
+		lists[i] = List{ID: "l3", Name: fmt.Sprintf("List %d", i)}
+	}
+	counts, err := c.CountCardsOnLists(context.Background(), lists)
+	if err != nil {
+		t.Fatalf("CountCardsOnLists: %v", err)
+	}
+	if len(counts) != 12 || counts[11].Cards != 7 || counts[11].Name != "List 11" {
+		t.Errorf("counts = %+v", counts)
+	}
+	if peak > listCountConcurrency {
+		t.Errorf("%d count requests in flight, want at most %d", peak, listCountConcurrency)
+	}
+}
@@ -0,0 +1,66 @@
+package trello
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/httpx"
+)
+
+// Trello allows roughly 100 requests per 10 seconds per token
+const (
+	defaultRateLimitRequests = 100
+	defaultRateLimitInterval = 10 * time.Second
+)
+
+// rateLimiter is a token bucket that paces outbound requests
+type rateLimiter struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	refill   float64 // tokens per second
+	last     time.Time
+}
+
+func newRateLimiter(requests int, interval time.Duration) *rateLimiter {
+	if requests <= 0 || interval <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		capacity: float64(requests),
+		tokens:   float64(requests),
+		refill:   float64(requests) / interval.Seconds(),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.refill
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - l.tokens) / l.refill * float64(time.Second))
+		l.mu.Unlock()
+
+		if err := httpx.Sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
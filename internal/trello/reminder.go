@@ -0,0 +1,194 @@
+package trello
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/caller"
+)
+
+// defaultReminderHour is used when a due date names a day but no time
+const defaultReminderHour = 9
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "domingo": time.Sunday,
+	"monday": time.Monday, "segunda": time.Monday, "segunda-feira": time.Monday,
+	"tuesday": time.Tuesday, "terça": time.Tuesday, "terca": time.Tuesday, "terça-feira": time.Tuesday,
+	"wednesday": time.Wednesday, "quarta": time.Wednesday, "quarta-feira": time.Wednesday,
+	"thursday": time.Thursday, "quinta": time.Thursday, "quinta-feira": time.Thursday,
+	"friday": time.Friday, "sexta": time.Friday, "sexta-feira": time.Friday,
+	"saturday": time.Saturday, "sábado": time.Saturday, "sabado": time.Saturday,
+}
+
+var (
+	// "in 3 hours", "em 2 dias"
+	relativeRe = regexp.MustCompile(`^(?:in|em)\s+(\d+)\s*(minutes?|mins?|minutos?|hours?|h|horas?|days?|dias?|weeks?|semanas?)$`)
+	// "5pm", "5:30 pm", "17:00", "17h", "17h30", optionally after "at"/"às"
+	clockRe = regexp.MustCompile(`^(?:at\s+|às\s+|as\s+)?(\d{1,2})(?:[:h](\d{2})?)?\s*(am|pm)?$`)
+)
+
+// isoLayouts are the absolute formats accepted for due dates
+var isoLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// parseISODue parses an absolute ISO 8601 due date, in loc unless it names
+// an offset. A date without a time means 09:00.
+func parseISODue(input string, loc *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	for _, layout := range isoLayouts {
+		if t, err := time.ParseInLocation(layout, input, loc); err == nil {
+			return t, nil
+		}
+	}
+	d, err := time.ParseInLocation("2006-01-02", input, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("due must be an ISO 8601 date such as 2024-03-15 or 2024-03-15T17:00, got %q", input)
+	}
+	return d.Add(defaultReminderHour * time.Hour), nil
+}
+
+// ParseDue parses an ISO 8601 date or a relative expression such as
+// "tomorrow 5pm", "amanhã 17h", "friday 10:00" or "in 2 hours", relative
+// to now and in now's location. A day without a time means 09:00.
+func ParseDue(input string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	if s == "" {
+		return time.Time{}, fmt.Errorf("due date is required")
+	}
+	loc := now.Location()
+
+	if t, err := parseISODue(input, loc); err == nil {
+		return t, nil
+	}
+
+	if m := relativeRe.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch unit := m[2]; {
+		case strings.HasPrefix(unit, "m"):
+			return now.Add(time.Duration(n) * time.Minute), nil
+		case strings.HasPrefix(unit, "h"):
+			return now.Add(time.Duration(n) * time.Hour), nil
+		case strings.HasPrefix(unit, "d"):
+			return now.AddDate(0, 0, n), nil
+		default:
+			return now.AddDate(0, 0, 7*n), nil
+		}
+	}
+
+	// A day word followed by an optional time of day
+	day, rest := s, ""
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		day, rest = s[:i], strings.TrimSpace(s[i+1:])
+	}
+	if day == "next" {
+		if i := strings.IndexByte(rest, ' '); i > 0 {
+			day, rest = rest[:i], strings.TrimSpace(rest[i+1:])
+		} else {
+			day, rest = rest, ""
+		}
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	var date time.Time
+	switch day {
+	case "today", "hoje":
+		date = today
+	case "tomorrow", "amanhã", "amanha":
+		date = today.AddDate(0, 0, 1)
+	default:
+		wd, ok := weekdayNames[day]
+		if !ok {
+			return time.Time{}, fmt.Errorf("could not understand due date %q; use ISO 8601 (2024-03-15T17:00) or e.g. 'tomorrow 5pm'", input)
+		}
+		ahead := (int(wd) - int(now.Weekday()) + 7) % 7
+		if ahead == 0 {
+			ahead = 7 // "friday" on a Friday means next week
+		}
+		date = today.AddDate(0, 0, ahead)
+	}
+
+	if rest == "" {
+		return date.Add(defaultReminderHour * time.Hour), nil
+	}
+	hour, minute, err := parseClock(rest)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not understand time %q in due date %q", rest, input)
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc), nil
+}
+
+// parseClock parses a time of day such as "5pm", "5:30 pm", "17:00" or "17h30"
+func parseClock(s string) (hour, minute int, err error) {
+	m := clockRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, fmt.Errorf("invalid time %q", s)
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	if m[3] != "" && (hour < 1 || hour > 12) {
+		return 0, 0, fmt.Errorf("invalid time %q", s)
+	}
+	switch m[3] {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid time %q", s)
+	}
+	return hour, minute, nil
+}
+
+func (t *Tool) setReminder(ctx context.Context, args map[string]interface{}) (string, error) {
+	cardID := getString(args, "card_id")
+	input := getString(args, "due")
+	if cardID == "" || input == "" {
+		return "", fmt.Errorf("card_id and due are required")
+	}
+
+	now := t.clock.Now().In(t.location)
+	due, err := ParseDue(input, now)
+	if err != nil {
+		return "", err
+	}
+	if !due.After(now) {
+		return "", fmt.Errorf("due date %s is not in the future", due.Format("2006-01-02 15:04"))
+	}
+
+	dueStr := due.UTC().Format(time.RFC3339)
+	card, err := t.client.UpdateCard(ctx, cardID, UpdateCardRequest{Due: &dueStr})
+	if err != nil {
+		return "", err
+	}
+
+	// The comment is visible to everyone on the board, so it names the
+	// channel but not the internal user ID
+	requested := "requested"
+	if who, ok := caller.From(ctx); ok && who.Channel != "" {
+		requested += " via " + who.Channel
+	}
+	text := fmt.Sprintf("⏰ Reminder: due %s, %s on %s.",
+		due.Format("2006-01-02 15:04 MST"), requested, now.Format("2006-01-02 15:04 MST"))
+	if note := strings.TrimSpace(getString(args, "note")); note != "" {
+		text += "\n\n" + note
+	}
+	if _, err := t.client.AddComment(ctx, cardID, text); err != nil {
+		return "", fmt.Errorf("due date set to %s but the reminder comment failed: %w", due.Format("2006-01-02 15:04 MST"), err)
+	}
+
+	return fmt.Sprintf("Set due date of %q to %s and added the comment:\n%s", card.Name, due.Format("Mon 2006-01-02 15:04 MST"), text), nil
+}
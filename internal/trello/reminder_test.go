@@ -0,0 +1,102 @@
+package trello
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/caller"
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+)
+
+func TestParseDueRelative(t *testing.T) {
+	loc := time.FixedZone("BRT", -3*60*60)
+	// Wednesday 2024-03-13 10:30 BRT
+	now := time.Date(2024, 3, 13, 10, 30, 0, 0, loc)
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"tomorrow 5pm", time.Date(2024, 3, 14, 17, 0, 0, 0, loc)},
+		{"Tomorrow 5:30 PM", time.Date(2024, 3, 14, 17, 30, 0, 0, loc)},
+		{"amanhã 17h", time.Date(2024, 3, 14, 17, 0, 0, 0, loc)},
+		{"hoje às 18h30", time.Date(2024, 3, 13, 18, 30, 0, 0, loc)},
+		{"tomorrow", time.Date(2024, 3, 14, 9, 0, 0, 0, loc)},
+		{"friday 10:00", time.Date(2024, 3, 15, 10, 0, 0, 0, loc)},
+		{"next monday 8am", time.Date(2024, 3, 18, 8, 0, 0, 0, loc)},
+		{"wednesday", time.Date(2024, 3, 20, 9, 0, 0, 0, loc)},
+		{"sexta 12pm", time.Date(2024, 3, 15, 12, 0, 0, 0, loc)},
+		{"in 2 hours", time.Date(2024, 3, 13, 12, 30, 0, 0, loc)},
+		{"em 3 dias", time.Date(2024, 3, 16, 10, 30, 0, 0, loc)},
+		{"2024-03-20T15:00", time.Date(2024, 3, 20, 15, 0, 0, 0, loc)},
+		{"2024-03-20", time.Date(2024, 3, 20, 9, 0, 0, 0, loc)},
+		{"2024-03-20T15:00:00Z", time.Date(2024, 3, 20, 15, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDue(tt.input, now)
+			if err != nil {
+				t.Fatalf("ParseDue() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDue(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{"someday", "tomorrow 25:00", "tomorrow 13pm", "in a while"} {
+		if got, err := ParseDue(bad, now); err == nil {
+			t.Errorf("ParseDue(%q) = %s, want an error", bad, got)
+		}
+	}
+}
+
+func TestSetReminder(t *testing.T) {
+	var due, comment string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/cards/card1":
+			due = r.URL.Query().Get("due")
+			w.Write([]byte(`{"id":"card1","name":"Renew certificate"}`))
+		case r.Method == "POST" && r.URL.Path == "/cards/card1/actions/comments":
+			comment = r.URL.Query().Get("text")
+			w.Write([]byte(`{"id":"a1"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	loc := time.FixedZone("BRT", -3*60*60)
+	tool := NewTool(c)
+	tool.SetClock(clock.NewFake(time.Date(2024, 3, 13, 10, 30, 0, 0, time.UTC)))
+	tool.SetLocation(loc)
+
+	ctx := caller.With(context.Background(), caller.Caller{UserID: "42", Channel: "telegram"})
+	result, handled, err := tool.Execute(ctx, "trello_set_reminder", map[string]interface{}{"card_id": "card1", "due": "tomorrow 5pm"})
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+
+	if due != "2024-03-14T20:00:00Z" {
+		t.Errorf("due = %q, want 17:00 BRT in UTC", due)
+	}
+	if !strings.Contains(comment, "requested via telegram on 2024-03-13 07:30 BRT") {
+		t.Errorf("comment = %q", comment)
+	}
+	if strings.Contains(comment, "42") {
+		t.Errorf("comment = %q, must not include the internal user ID", comment)
+	}
+	if !strings.Contains(result, "Thu 2024-03-14 17:00 BRT") || !strings.Contains(result, comment) {
+		t.Errorf("result = %q", result)
+	}
+
+	_, _, err = tool.Execute(ctx, "trello_set_reminder", map[string]interface{}{"card_id": "card1", "due": "2024-03-01"})
+	if err == nil || !strings.Contains(err.Error(), "not in the future") {
+		t.Errorf("past due date error = %v", err)
+	}
+}
@@ -4,18 +4,41 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/abelclopes/nomad-iabot/internal/clock"
 	"github.com/abelclopes/nomad-iabot/internal/llm"
 )
 
 // Tool represents a Trello tool for the LLM
 type Tool struct {
-	client *Client
+	client   *Client
+	clock    clock.Clock
+	location *time.Location // zone for relative due dates
+	wipLimit int            // soft cards-per-list limit flagged by the board overview (0 = none)
 }
 
 // NewTool creates a new Trello tool
 func NewTool(client *Client) *Tool {
-	return &Tool{client: client}
+	return &Tool{client: client, clock: clock.Real(), location: time.Local}
+}
+
+// SetClock replaces the clock used to resolve relative due dates
+func (t *Tool) SetClock(c clock.Clock) {
+	t.clock = c
+}
+
+// SetLocation sets the time zone relative due dates are interpreted in
+func (t *Tool) SetLocation(loc *time.Location) {
+	t.location = loc
+}
+
+// SetWIPLimit sets the soft cards-per-list limit the board overview flags
+func (t *Tool) SetWIPLimit(limit int) {
+	t.wipLimit = limit
 }
 
 // GetToolDefinitions returns the tool definitions for the LLM
@@ -54,7 +77,7 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 			Type: "function",
 			Function: llm.ToolFunction{
 				Name:        "trello_get_lists",
-				Description: "Get all lists from a Trello board",
+				Description: "Get all lists from a Trello board with the number of open cards on each",
 				Parameters: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -67,6 +90,27 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "trello_board_overview",
+				Description: "Overview of a Trello board: every list with its number of open cards, flagging lists over the WIP (work in progress) limit",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"board_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The board ID",
+						},
+						"wip_limit": map[string]interface{}{
+							"type":        "integer",
+							"description": "Cards per list above which a list is flagged (defaults to the configured limit; 0 disables)",
+						},
+					},
+					"required": []string{"board_id"},
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: llm.ToolFunction{
@@ -117,11 +161,45 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 							"type":        "string",
 							"description": "Due date in ISO 8601 format (e.g., 2024-12-31T23:59:59Z)",
 						},
+						"idempotency_key": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional unique key for this request (letters, digits, '.', '_', ':' or '-'). Retrying with the same key returns the card created the first time instead of a duplicate",
+						},
 					},
 					"required": []string{"list_id", "name"},
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "trello_copy_card",
+				Description: "Copy (clone) an existing Trello card, e.g. a template card, to a list",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"source_card_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The ID of the card to copy",
+						},
+						"list_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The list ID where the copy will be created",
+						},
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "Title of the new card (optional, defaults to the source card title)",
+						},
+						"keep": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string", "enum": copyableAttributes},
+							"description": "Attributes to keep from the source card (optional, defaults to all)",
+						},
+					},
+					"required": []string{"source_card_id", "list_id"},
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: llm.ToolFunction{
@@ -199,11 +277,11 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 						},
 						"list_id": map[string]interface{}{
 							"type":        "string",
-							"description": "Move card to a different list",
+							"description": "Move card to a different list on the same board",
 						},
 						"due": map[string]interface{}{
 							"type":        "string",
-							"description": "Due date in ISO 8601 format",
+							"description": "Due date in ISO 8601 format, e.g. 2024-03-15 or 2024-03-15T17:00",
 						},
 					},
 					"required": []string{"card_id"},
@@ -248,6 +326,162 @@ func (t *Tool) GetToolDefinitions() []llm.Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "trello_add_member",
+				Description: "Assign a member to a Trello card",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"card_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The card ID",
+						},
+						"member": map[string]interface{}{
+							"type":        "string",
+							"description": "Member ID or username (e.g. @maria) on the card's board",
+						},
+						"board_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Board used to resolve the username (optional, defaults to the card's board)",
+						},
+					},
+					"required": []string{"card_id", "member"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "trello_remove_member",
+				Description: "Remove a member from a Trello card",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"card_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The card ID",
+						},
+						"member": map[string]interface{}{
+							"type":        "string",
+							"description": "Member ID or username (e.g. @maria) on the card's board",
+						},
+						"board_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Board used to resolve the username (optional, defaults to the card's board)",
+						},
+					},
+					"required": []string{"card_id", "member"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "trello_set_custom_field",
+				Description: "Set a custom field (e.g. Story Points) on a Trello card. Works with text, number, checkbox, date and list fields",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"card_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The card ID",
+						},
+						"field": map[string]interface{}{
+							"type":        "string",
+							"description": "Custom field name or ID, e.g. 'Story Points'",
+						},
+						"value": map[string]interface{}{
+							"type":        "string",
+							"description": "New value: text, a number, true/false for checkboxes, an ISO 8601 date, or the option name for list fields. Empty clears the field",
+						},
+					},
+					"required": []string{"card_id", "field", "value"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "trello_set_reminder",
+				Description: "Set a Trello card's due date and add a comment recording who asked for the reminder and when",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"card_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The card ID",
+						},
+						"due": map[string]interface{}{
+							"type":        "string",
+							"description": "When it is due: ISO 8601 (2024-03-15T17:00) or relative, e.g. 'tomorrow 5pm', 'amanhã 17h', 'friday 10:00', 'in 2 hours'. Must be in the future",
+						},
+						"note": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional text added to the reminder comment",
+						},
+					},
+					"required": []string{"card_id", "due"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "trello_my_notifications",
+				Description: "Get the current user's Trello notifications (mentions, comments, cards due soon, being added to cards) with links to the cards",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"unread_only": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Only unread notifications (default true)",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "trello_my_cards",
+				Description: "List the open Trello cards assigned to the current user across all boards, with board, list and due date. Use it for 'what cards are assigned to me?' instead of asking for a board",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"due_within_days": map[string]interface{}{
+							"type":        "integer",
+							"description": "Only cards due within this many days, overdue ones included (0 = due today). Omit to list all",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        "trello_mark_notifications_read",
+				Description: "Mark Trello notifications as read, either specific ones or, only when the user asks for it, all of them",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"notification_ids": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string"},
+							"description": "IDs from trello_my_notifications",
+						},
+						"all": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Mark every notification as read instead of notification_ids",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
 	}
 }
 
@@ -263,12 +497,18 @@ func (t *Tool) Execute(ctx context.Context, name string, args map[string]interfa
 	case "trello_get_lists":
 		result, err := t.getLists(ctx, args)
 		return result, true, err
+	case "trello_board_overview":
+		result, err := t.boardOverview(ctx, args)
+		return result, true, err
 	case "trello_create_list":
 		result, err := t.createList(ctx, args)
 		return result, true, err
 	case "trello_create_card":
 		result, err := t.createCard(ctx, args)
 		return result, true, err
+	case "trello_copy_card":
+		result, err := t.copyCard(ctx, args)
+		return result, true, err
 	case "trello_get_card":
 		result, err := t.getCard(ctx, args)
 		return result, true, err
@@ -287,6 +527,27 @@ func (t *Tool) Execute(ctx context.Context, name string, args map[string]interfa
 	case "trello_get_board_members":
 		result, err := t.getBoardMembers(ctx, args)
 		return result, true, err
+	case "trello_add_member":
+		result, err := t.addMember(ctx, args)
+		return result, true, err
+	case "trello_remove_member":
+		result, err := t.removeMember(ctx, args)
+		return result, true, err
+	case "trello_set_custom_field":
+		result, err := t.setCustomField(ctx, args)
+		return result, true, err
+	case "trello_set_reminder":
+		result, err := t.setReminder(ctx, args)
+		return result, true, err
+	case "trello_my_notifications":
+		result, err := t.myNotifications(ctx, args)
+		return result, true, err
+	case "trello_mark_notifications_read":
+		result, err := t.markNotificationsRead(ctx, args)
+		return result, true, err
+	case "trello_my_cards":
+		result, err := t.myCards(ctx, args)
+		return result, true, err
 	default:
 		return "", false, nil
 	}
@@ -339,7 +600,11 @@ func (t *Tool) getLists(ctx context.Context, args map[string]interface{}) (strin
 	if err != nil {
 		return "", err
 	}
-	return formatLists(lists), nil
+	counts, err := t.client.CountCardsOnLists(ctx, lists)
+	if err != nil {
+		return "", err
+	}
+	return formatLists(counts), nil
 }
 
 func (t *Tool) createList(ctx context.Context, args map[string]interface{}) (string, error) {
@@ -376,6 +641,7 @@ func (t *Tool) createCard(ctx context.Context, args map[string]interface{}) (str
 	if due := getString(args, "due_date"); due != "" {
 		req.DueDate = due
 	}
+	req.IdempotencyKey = getString(args, "idempotency_key")
 
 	card, err := t.client.CreateCard(ctx, req)
 	if err != nil {
@@ -384,6 +650,59 @@ func (t *Tool) createCard(ctx context.Context, args map[string]interface{}) (str
 	return fmt.Sprintf("Created card '%s' (ID: %s, URL: %s)", card.Name, card.ID, card.ShortURL), nil
 }
 
+func (t *Tool) copyCard(ctx context.Context, args map[string]interface{}) (string, error) {
+	sourceID := getString(args, "source_card_id")
+	listID := getString(args, "list_id")
+
+	if sourceID == "" || listID == "" {
+		return "", fmt.Errorf("source_card_id and list_id are required")
+	}
+
+	var keep []string
+	if values, ok := args["keep"].([]interface{}); ok {
+		for _, v := range values {
+			attr, ok := v.(string)
+			if !ok {
+				continue
+			}
+			if !isCopyableAttribute(attr) {
+				return "", fmt.Errorf("invalid keep attribute: %s (allowed: %s)", attr, strings.Join(copyableAttributes, ", "))
+			}
+			keep = append(keep, attr)
+		}
+	}
+
+	source, err := t.client.GetCard(ctx, sourceID)
+	if err != nil {
+		return "", err
+	}
+
+	card, err := t.client.CopyCard(ctx, sourceID, listID, getString(args, "name"), keep...)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("Copied card '%s' to '%s' (ID: %s, URL: %s)", source.Name, card.Name, card.ID, card.ShortURL)
+	if source.Closed {
+		result += "\nNote: the source card is archived; the copy was created as an open card."
+	}
+	return result, nil
+}
+
+// copyableAttributes are the values Trello accepts in keepFromSource
+var copyableAttributes = []string{
+	"attachments", "checklists", "comments", "customFields", "due", "start", "labels", "members", "stickers",
+}
+
+func isCopyableAttribute(attr string) bool {
+	for _, a := range copyableAttributes {
+		if a == attr {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *Tool) getCard(ctx context.Context, args map[string]interface{}) (string, error) {
 	cardID := getString(args, "card_id")
 	if cardID == "" {
@@ -440,12 +759,22 @@ func (t *Tool) updateCard(ctx context.Context, args map[string]interface{}) (str
 	if closed, ok := args["closed"].(bool); ok {
 		req.Closed = &closed
 	}
+	// Invalid values are rejected here, where the error can say what is
+	// wrong, rather than by a bare Trello 400
+	if due := getString(args, "due"); due != "" {
+		dueAt, err := parseISODue(due, t.location)
+		if err != nil {
+			return "", err
+		}
+		formatted := dueAt.UTC().Format(time.RFC3339)
+		req.Due = &formatted
+	}
 	if listID := getString(args, "list_id"); listID != "" {
+		if err := t.checkListOnCardBoard(ctx, cardID, listID); err != nil {
+			return "", err
+		}
 		req.IDList = &listID
 	}
-	if due := getString(args, "due"); due != "" {
-		req.Due = &due
-	}
 
 	card, err := t.client.UpdateCard(ctx, cardID, req)
 	if err != nil {
@@ -454,6 +783,28 @@ func (t *Tool) updateCard(ctx context.Context, args map[string]interface{}) (str
 	return fmt.Sprintf("Updated card '%s' (ID: %s)", card.Name, card.ID), nil
 }
 
+// checkListOnCardBoard verifies that listID is an open list on the board
+// of the card, since cards are only moved between lists of one board
+func (t *Tool) checkListOnCardBoard(ctx context.Context, cardID, listID string) error {
+	card, err := t.client.GetCard(ctx, cardID)
+	if err != nil {
+		return err
+	}
+	lists, err := t.client.GetLists(ctx, card.IDBoard)
+	if err != nil {
+		return err
+	}
+	for _, l := range lists {
+		if l.ID == listID {
+			if l.Closed {
+				return fmt.Errorf("list %q (%s) is archived; move the card to an open list", l.Name, listID)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("list %s is not on the board of card %s; use trello_get_lists with board_id %s to pick one of its lists", listID, cardID, card.IDBoard)
+}
+
 func (t *Tool) addComment(ctx context.Context, args map[string]interface{}) (string, error) {
 	cardID := getString(args, "card_id")
 	text := getString(args, "text")
@@ -482,6 +833,102 @@ func (t *Tool) getBoardMembers(ctx context.Context, args map[string]interface{})
 	return formatMembers(members), nil
 }
 
+func (t *Tool) addMember(ctx context.Context, args map[string]interface{}) (string, error) {
+	cardID, member, err := t.resolveCardMember(ctx, args)
+	if err != nil {
+		return "", err
+	}
+
+	members, err := t.client.AddMemberToCard(ctx, cardID, member.ID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Added @%s to card %s.\n\n%s", member.Username, cardID, formatMembers(members)), nil
+}
+
+func (t *Tool) removeMember(ctx context.Context, args map[string]interface{}) (string, error) {
+	cardID, member, err := t.resolveCardMember(ctx, args)
+	if err != nil {
+		return "", err
+	}
+
+	members, err := t.client.RemoveMemberFromCard(ctx, cardID, member.ID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Removed @%s from card %s.\n\n%s", member.Username, cardID, formatMembers(members)), nil
+}
+
+func (t *Tool) setCustomField(ctx context.Context, args map[string]interface{}) (string, error) {
+	cardID := getString(args, "card_id")
+	fieldRef := getString(args, "field")
+	if cardID == "" || fieldRef == "" {
+		return "", fmt.Errorf("card_id and field are required")
+	}
+
+	var raw string
+	switch v := args["value"].(type) {
+	case string:
+		raw = v
+	case float64:
+		raw = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		raw = v.String()
+	case bool:
+		raw = strconv.FormatBool(v)
+	case nil:
+		return "", fmt.Errorf("value is required")
+	default:
+		return "", fmt.Errorf("value must be a string, number or boolean")
+	}
+
+	card, err := t.client.GetCard(ctx, cardID)
+	if err != nil {
+		return "", err
+	}
+	field, err := t.client.ResolveCustomField(ctx, card.IDBoard, fieldRef)
+	if err != nil {
+		return "", err
+	}
+	value, err := field.Value(raw)
+	if err != nil {
+		return "", err
+	}
+
+	if err := t.client.SetCardCustomField(ctx, cardID, field.ID, value); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(raw) == "" {
+		return fmt.Sprintf("Cleared %s on card %q.", field.Name, card.Name), nil
+	}
+	return fmt.Sprintf("Set %s to %s on card %q.", field.Name, raw, card.Name), nil
+}
+
+// resolveCardMember reads card_id and member from args and resolves the
+// member on board_id, or on the card's own board when board_id is omitted
+func (t *Tool) resolveCardMember(ctx context.Context, args map[string]interface{}) (string, *Member, error) {
+	cardID := getString(args, "card_id")
+	memberRef := getString(args, "member")
+	if cardID == "" || memberRef == "" {
+		return "", nil, fmt.Errorf("card_id and member are required")
+	}
+
+	boardID := getString(args, "board_id")
+	if boardID == "" {
+		card, err := t.client.GetCard(ctx, cardID)
+		if err != nil {
+			return "", nil, err
+		}
+		boardID = card.IDBoard
+	}
+
+	member, err := t.client.ResolveBoardMember(ctx, boardID, memberRef)
+	if err != nil {
+		return "", nil, err
+	}
+	return cardID, member, nil
+}
+
 // Helper functions
 func getString(args map[string]interface{}, key string) string {
 	if v, ok := args[key].(string); ok {
@@ -490,6 +937,30 @@ func getString(args map[string]interface{}, key string) string {
 	return ""
 }
 
+// getInt reads an integer argument. Providers send integers as JSON
+// numbers, json.Number or numeric strings, so all are accepted; ok is false
+// when the argument is missing or not a whole number.
+func getInt(args map[string]interface{}, key string) (int, bool) {
+	switch n := args[key].(type) {
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, false
+		}
+		return int(n), true
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		return i, err == nil
+	}
+	return 0, false
+}
+
 func formatBoards(boards []Board) string {
 	if len(boards) == 0 {
 		return "No boards found."
@@ -522,7 +993,7 @@ func formatBoard(board *Board) string {
 	return result
 }
 
-func formatLists(lists []List) string {
+func formatLists(lists []ListCount) string {
 	if len(lists) == 0 {
 		return "No lists found."
 	}
@@ -533,7 +1004,7 @@ func formatLists(lists []List) string {
 		if list.Closed {
 			status = "Closed"
 		}
-		result += fmt.Sprintf("- [%s] %s (ID: %s)\n", status, list.Name, list.ID)
+		result += fmt.Sprintf("- [%s] %s (%s) (ID: %s)\n", status, list.Name, cardCount(list.Cards), list.ID)
 	}
 	return result
 }
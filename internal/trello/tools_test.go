@@ -0,0 +1,255 @@
+package trello
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/abelclopes/nomad-iabot/internal/skills"
+)
+
+func TestToolDefinitionsAreAllowlisted(t *testing.T) {
+	allowed := make(map[string]bool)
+	for _, cmd := range skills.GetAllowedTrelloCommands() {
+		allowed[cmd] = true
+	}
+
+	for _, def := range NewTool(nil).GetToolDefinitions() {
+		if !allowed[def.Function.Name] {
+			t.Errorf("tool %q is not in GetAllowedTrelloCommands()", def.Function.Name)
+		}
+	}
+}
+
+func TestCopyCard(t *testing.T) {
+	var copyQuery map[string][]string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/cards/src1":
+			w.Write([]byte(`{"id":"src1","name":"Release checklist","closed":true}`))
+		case r.Method == "POST" && r.URL.Path == "/cards":
+			copyQuery = r.URL.Query()
+			w.Write([]byte(`{"id":"new1","name":"Release 2.0","shortUrl":"https://trello.com/c/new1"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	tool := NewTool(c)
+
+	result, handled, err := tool.Execute(context.Background(), "trello_copy_card", map[string]interface{}{
+		"source_card_id": "src1",
+		"list_id":        "list9",
+		"name":           "Release 2.0",
+		"keep":           []interface{}{"checklists", "labels"},
+	})
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+
+	if got := copyQuery["idCardSource"]; len(got) != 1 || got[0] != "src1" {
+		t.Errorf("idCardSource = %v, expected [src1]", got)
+	}
+	if got := copyQuery["idList"]; len(got) != 1 || got[0] != "list9" {
+		t.Errorf("idList = %v, expected [list9]", got)
+	}
+	if got := copyQuery["keepFromSource"]; len(got) != 1 || got[0] != "checklists,labels" {
+		t.Errorf("keepFromSource = %v, expected [checklists,labels]", got)
+	}
+	if !strings.Contains(result, "https://trello.com/c/new1") {
+		t.Errorf("result = %q, expected the new card URL", result)
+	}
+	if !strings.Contains(result, "archived") {
+		t.Errorf("result = %q, expected a note about the archived source", result)
+	}
+}
+
+func TestCopyCardRejectsUnknownAttribute(t *testing.T) {
+	tool := NewTool(NewClient("k", "t"))
+
+	_, _, err := tool.Execute(context.Background(), "trello_copy_card", map[string]interface{}{
+		"source_card_id": "src1",
+		"list_id":        "list9",
+		"keep":           []interface{}{"everything"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for an unknown keep attribute")
+	}
+}
+
+func TestRemoveMemberByUsername(t *testing.T) {
+	var deleted bool
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/cards/card1":
+			w.Write([]byte(`{"id":"card1","idBoard":"board1"}`))
+		case r.Method == "GET" && r.URL.Path == "/boards/board1/members":
+			w.Write([]byte(`[{"id":"m1","username":"maria","fullName":"Maria Silva"},{"id":"m2","username":"joao","fullName":"João Souza"}]`))
+		case r.Method == "DELETE" && r.URL.Path == "/cards/card1/idMembers/m1":
+			deleted = true
+			w.Write([]byte(`[{"id":"m2","username":"joao","fullName":"João Souza"}]`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	tool := NewTool(c)
+
+	result, handled, err := tool.Execute(context.Background(), "trello_remove_member", map[string]interface{}{
+		"card_id": "card1",
+		"member":  "@Maria",
+	})
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+	if !deleted {
+		t.Fatal("expected DELETE /cards/card1/idMembers/m1")
+	}
+	if !strings.Contains(result, "Removed @maria") || !strings.Contains(result, "@joao") {
+		t.Errorf("result = %q, expected the removal and the remaining members", result)
+	}
+}
+
+func TestRemoveMemberUnknownUsername(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`[{"id":"m1","username":"maria"}]`))
+	})
+	tool := NewTool(c)
+
+	_, _, err := tool.Execute(context.Background(), "trello_remove_member", map[string]interface{}{
+		"card_id":  "card1",
+		"board_id": "board1",
+		"member":   "ghost",
+	})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("error = %v, expected member not found", err)
+	}
+}
+
+func TestSetNumberCustomField(t *testing.T) {
+	var body map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/cards/card1":
+			w.Write([]byte(`{"id":"card1","name":"Checkout flow","idBoard":"board1"}`))
+		case r.Method == "GET" && r.URL.Path == "/boards/board1/customFields":
+			w.Write([]byte(`[
+				{"id":"cf-prio","name":"Priority","type":"list","options":[{"id":"opt-high","value":{"text":"High"}}]},
+				{"id":"cf-sp","name":"Story Points","type":"number"}
+			]`))
+		case r.Method == "PUT" && r.URL.Path == "/cards/card1/customField/cf-sp/item":
+			json.NewDecoder(r.Body).Decode(&body)
+			w.Write([]byte(`{"id":"item1","value":{"number":"5"},"idCustomField":"cf-sp"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	result, handled, err := NewTool(c).Execute(context.Background(), "trello_set_custom_field", map[string]interface{}{
+		"card_id": "card1",
+		"field":   "story points",
+		"value":   float64(5),
+	})
+	if !handled || err != nil {
+		t.Fatalf("Execute() handled = %v, error = %v", handled, err)
+	}
+
+	value, _ := body["value"].(map[string]interface{})
+	if value["number"] != "5" {
+		t.Errorf("request body = %v, want {\"value\":{\"number\":\"5\"}}", body)
+	}
+	if result != `Set Story Points to 5 on card "Checkout flow".` {
+		t.Errorf("result = %q", result)
+	}
+}
+
+func TestCustomFieldValueShapes(t *testing.T) {
+	list := &CustomField{Name: "Priority", Type: "list", Options: []CustomFieldOption{{ID: "opt-high"}}}
+	list.Options[0].Value.Text = "High"
+
+	tests := []struct {
+		field *CustomField
+		raw   string
+		want  string
+	}{
+		{&CustomField{Name: "Notes", Type: "text"}, "needs QA", `{"value":{"text":"needs QA"}}`},
+		{&CustomField{Name: "Done", Type: "checkbox"}, "TRUE", `{"value":{"checked":"true"}}`},
+		{list, "high", `{"idValue":"opt-high"}`},
+		{list, "", `{"idValue":""}`},
+		{&CustomField{Name: "Story Points", Type: "number"}, "", `{"value":""}`},
+	}
+	for _, tt := range tests {
+		v, err := tt.field.Value(tt.raw)
+		if err != nil {
+			t.Errorf("%s Value(%q) error = %v", tt.field.Type, tt.raw, err)
+			continue
+		}
+		if got, _ := json.Marshal(v); string(got) != tt.want {
+			t.Errorf("%s Value(%q) = %s, want %s", tt.field.Type, tt.raw, got, tt.want)
+		}
+	}
+
+	if _, err := (&CustomField{Name: "Story Points", Type: "number"}).Value("five"); err == nil {
+		t.Error("number field accepted a non-numeric value")
+	}
+}
+
+func TestUpdateCardRejectsInvalidDue(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+
+	_, _, err := NewTool(c).Execute(context.Background(), "trello_update_card", map[string]interface{}{
+		"card_id": "card1",
+		"due":     "next friday-ish",
+	})
+	if err == nil || !strings.Contains(err.Error(), "ISO 8601") {
+		t.Errorf("Execute() error = %v, expected an ISO 8601 error", err)
+	}
+}
+
+func TestUpdateCardSendsDueAsRFC3339(t *testing.T) {
+	var due string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		due = r.URL.Query().Get("due")
+		w.Write([]byte(`{"id":"card1","name":"Ship it"}`))
+	})
+
+	if _, _, err := NewTool(c).Execute(context.Background(), "trello_update_card", map[string]interface{}{
+		"card_id": "card1",
+		"due":     "2024-03-15T17:00:00-03:00",
+	}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if due != "2024-03-15T20:00:00Z" {
+		t.Errorf("due = %q, expected 2024-03-15T20:00:00Z", due)
+	}
+}
+
+func TestUpdateCardRejectsListFromAnotherBoard(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/cards/card1":
+			w.Write([]byte(`{"id":"card1","name":"Ship it","idBoard":"board1","idList":"todo"}`))
+		case r.Method == "GET" && r.URL.Path == "/boards/board1/lists":
+			w.Write([]byte(`[{"id":"todo","name":"To Do","idBoard":"board1"},{"id":"done","name":"Done","idBoard":"board1"}]`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	_, _, err := NewTool(c).Execute(context.Background(), "trello_update_card", map[string]interface{}{
+		"card_id": "card1",
+		"list_id": "other-board-list",
+	})
+	if err == nil || !strings.Contains(err.Error(), "not on the board of card card1") {
+		t.Errorf("Execute() error = %v, expected a cross-board list error", err)
+	}
+}
@@ -0,0 +1,132 @@
+package trello
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// WebhookSignatureHeader carries the HMAC signature of a Trello webhook request
+const WebhookSignatureHeader = "X-Trello-Webhook"
+
+// Webhook represents a registered Trello webhook
+type Webhook struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	IDModel     string `json:"idModel"`
+	CallbackURL string `json:"callbackURL"`
+	Active      bool   `json:"active"`
+}
+
+// WebhookEvent is the payload Trello posts to a webhook callback
+type WebhookEvent struct {
+	Action struct {
+		ID   string `json:"id"`
+		Type string `json:"type"` // createCard, updateCard, commentCard, ...
+		Date string `json:"date"`
+		Data struct {
+			Text       string    `json:"text,omitempty"`
+			Card       *EventRef `json:"card,omitempty"`
+			List       *EventRef `json:"list,omitempty"`
+			ListBefore *EventRef `json:"listBefore,omitempty"`
+			ListAfter  *EventRef `json:"listAfter,omitempty"`
+			Board      *EventRef `json:"board,omitempty"`
+		} `json:"data"`
+		MemberCreator struct {
+			ID       string `json:"id"`
+			FullName string `json:"fullName"`
+			Username string `json:"username"`
+		} `json:"memberCreator"`
+	} `json:"action"`
+	Model struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"model"`
+}
+
+// EventRef is a lightweight reference to a card, list or board in a webhook event
+type EventRef struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	ShortLink string `json:"shortLink,omitempty"`
+}
+
+// CreateWebhook registers callbackURL to receive events for modelID (a board, list or card)
+func (c *Client) CreateWebhook(ctx context.Context, callbackURL, modelID, description string) (*Webhook, error) {
+	endpoint := fmt.Sprintf("%s/webhooks", c.baseURL)
+
+	params := url.Values{}
+	params.Set("callbackURL", callbackURL)
+	params.Set("idModel", modelID)
+	if description != "" {
+		params.Set("description", description)
+	}
+
+	resp, err := c.doRequestWithParams(ctx, "POST", endpoint, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var webhook Webhook
+	if err := json.NewDecoder(resp.Body).Decode(&webhook); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+// VerifyWebhookSignature checks a Trello webhook signature, which is the
+// base64 HMAC-SHA1 of the raw body followed by the callback URL, keyed with
+// the application secret
+func VerifyWebhookSignature(body []byte, callbackURL, secret, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(body)
+	mac.Write([]byte(callbackURL))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// FormatWebhookEvent renders an event as a short chat notification. It
+// returns an empty string for action types that are not worth notifying.
+func FormatWebhookEvent(e WebhookEvent) string {
+	data := e.Action.Data
+	who := e.Action.MemberCreator.FullName
+	if who == "" {
+		who = "Alguém"
+	}
+
+	cardName := ""
+	if data.Card != nil {
+		cardName = data.Card.Name
+	}
+
+	switch e.Action.Type {
+	case "createCard":
+		list := ""
+		if data.List != nil {
+			list = data.List.Name
+		}
+		return fmt.Sprintf("🆕 %s criou o card \"%s\" em %s", who, cardName, list)
+	case "updateCard":
+		if data.ListBefore != nil && data.ListAfter != nil {
+			return fmt.Sprintf("📦 %s moveu \"%s\" de %s para %s", who, cardName, data.ListBefore.Name, data.ListAfter.Name)
+		}
+		return fmt.Sprintf("✏️ %s atualizou o card \"%s\"", who, cardName)
+	case "commentCard":
+		return fmt.Sprintf("💬 %s comentou em \"%s\":\n%s", who, cardName, data.Text)
+	case "addMemberToCard":
+		return fmt.Sprintf("👤 %s adicionou um membro ao card \"%s\"", who, cardName)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,68 @@
+package trello
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func sign(body, callbackURL, secret string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(body + callbackURL))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	const (
+		body        = `{"action":{"type":"commentCard"}}`
+		callbackURL = "https://bot.example.com/api/v1/trello/webhook"
+		secret      = "app-secret"
+	)
+	valid := sign(body, callbackURL, secret)
+
+	tests := []struct {
+		name        string
+		body        string
+		callbackURL string
+		secret      string
+		signature   string
+		expected    bool
+	}{
+		{"Valid signature", body, callbackURL, secret, valid, true},
+		{"Tampered body", `{"action":{"type":"deleteCard"}}`, callbackURL, secret, valid, false},
+		{"Different callback URL", body, "https://evil.example.com/hook", secret, valid, false},
+		{"Wrong secret", body, callbackURL, "other-secret", valid, false},
+		{"Missing signature", body, callbackURL, secret, "", false},
+		{"Missing secret", body, callbackURL, "", valid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := VerifyWebhookSignature([]byte(tt.body), tt.callbackURL, tt.secret, tt.signature)
+			if result != tt.expected {
+				t.Errorf("VerifyWebhookSignature() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatWebhookEvent(t *testing.T) {
+	var e WebhookEvent
+	e.Action.Type = "updateCard"
+	e.Action.MemberCreator.FullName = "Ana Souza"
+	e.Action.Data.Card = &EventRef{Name: "Fix login"}
+	e.Action.Data.ListBefore = &EventRef{Name: "Doing"}
+	e.Action.Data.ListAfter = &EventRef{Name: "Done"}
+
+	result := FormatWebhookEvent(e)
+	if !strings.Contains(result, `Ana Souza moveu "Fix login" de Doing para Done`) {
+		t.Errorf("FormatWebhookEvent() = %q", result)
+	}
+
+	e.Action.Type = "updateCheckItemStateOnCard"
+	if result := FormatWebhookEvent(e); result != "" {
+		t.Errorf("FormatWebhookEvent() = %q, expected empty for ignored action", result)
+	}
+}
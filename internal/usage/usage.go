@@ -0,0 +1,172 @@
+package usage
+
+import (
+	"sync"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+)
+
+// dayFormat identifies the day daily counters refer to
+const dayFormat = "2006-01-02"
+
+// Stats holds cumulative and daily counters for one user
+type Stats struct {
+	Requests         int64  `json:"requests"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	TotalTokens      int64  `json:"total_tokens"`
+	DayRequests      int64  `json:"day_requests"`
+	DayTokens        int64  `json:"day_tokens"`
+	Day              string `json:"day"` // YYYY-MM-DD the day counters refer to
+}
+
+// Store persists usage counters. MemoryStore is the default; other
+// implementations can keep counters across restarts.
+type Store interface {
+	// Add records one request's token usage for userID on day
+	Add(userID, day string, u llm.Usage)
+	// Get returns the counters for userID (zero Stats when unknown)
+	Get(userID string) Stats
+	// All returns the counters for every user
+	All() map[string]Stats
+	// Reset clears the counters for userID, or for everyone when userID is empty
+	Reset(userID string)
+}
+
+// MemoryStore keeps usage counters in memory
+type MemoryStore struct {
+	mu    sync.Mutex
+	stats map[string]Stats
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{stats: make(map[string]Stats)}
+}
+
+// Add implements Store
+func (s *MemoryStore) Add(userID, day string, u llm.Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.stats[userID]
+	if st.Day != day {
+		st.Day = day
+		st.DayRequests = 0
+		st.DayTokens = 0
+	}
+
+	total := int64(u.TotalTokens)
+	if total == 0 {
+		total = int64(u.PromptTokens + u.CompletionTokens)
+	}
+
+	st.Requests++
+	st.PromptTokens += int64(u.PromptTokens)
+	st.CompletionTokens += int64(u.CompletionTokens)
+	st.TotalTokens += total
+	st.DayRequests++
+	st.DayTokens += total
+	s.stats[userID] = st
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(userID string) Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats[userID]
+}
+
+// All implements Store
+func (s *MemoryStore) All() map[string]Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := make(map[string]Stats, len(s.stats))
+	for id, st := range s.stats {
+		all[id] = st
+	}
+	return all
+}
+
+// Reset implements Store
+func (s *MemoryStore) Reset(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if userID == "" {
+		s.stats = make(map[string]Stats)
+		return
+	}
+	delete(s.stats, userID)
+}
+
+// Tracker records usage per user and enforces an optional daily token cap
+type Tracker struct {
+	store           Store
+	dailyTokenLimit int64
+	clock           clock.Clock
+}
+
+// NewTracker creates a tracker. A dailyTokenLimit of 0 disables the cap.
+func NewTracker(store Store, dailyTokenLimit int) *Tracker {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Tracker{
+		store:           store,
+		dailyTokenLimit: int64(dailyTokenLimit),
+		clock:           clock.Real(),
+	}
+}
+
+// Record adds one request's usage for userID
+func (t *Tracker) Record(userID string, u llm.Usage) {
+	t.store.Add(userID, t.today(), u)
+}
+
+// Get returns the counters for userID, with day counters zeroed once the day is over
+func (t *Tracker) Get(userID string) Stats {
+	return t.current(t.store.Get(userID))
+}
+
+// All returns the counters for every user
+func (t *Tracker) All() map[string]Stats {
+	all := t.store.All()
+	for id, st := range all {
+		all[id] = t.current(st)
+	}
+	return all
+}
+
+// Reset clears the counters for userID, or for everyone when userID is empty
+func (t *Tracker) Reset(userID string) {
+	t.store.Reset(userID)
+}
+
+// DailyTokenLimit returns the configured cap (0 when disabled)
+func (t *Tracker) DailyTokenLimit() int64 {
+	return t.dailyTokenLimit
+}
+
+// LimitReached reports whether userID has used up today's token allowance
+func (t *Tracker) LimitReached(userID string) bool {
+	if t.dailyTokenLimit <= 0 {
+		return false
+	}
+	return t.Get(userID).DayTokens >= t.dailyTokenLimit
+}
+
+func (t *Tracker) today() string {
+	return t.clock.Now().Format(dayFormat)
+}
+
+func (t *Tracker) current(st Stats) Stats {
+	if today := t.today(); st.Day != today {
+		st.Day = today
+		st.DayRequests = 0
+		st.DayTokens = 0
+	}
+	return st
+}
@@ -0,0 +1,88 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abelclopes/nomad-iabot/internal/clock"
+	"github.com/abelclopes/nomad-iabot/internal/llm"
+)
+
+func newTestTracker(limit int, clk clock.Clock) *Tracker {
+	tr := NewTracker(NewMemoryStore(), limit)
+	tr.clock = clk
+	return tr
+}
+
+func TestTrackerAccumulates(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC))
+	tr := newTestTracker(0, clk)
+
+	tr.Record("alice", llm.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120})
+	tr.Record("alice", llm.Usage{PromptTokens: 50, CompletionTokens: 30}) // providers may omit the total
+	tr.Record("bob", llm.Usage{TotalTokens: 10})
+
+	st := tr.Get("alice")
+	if st.Requests != 2 || st.PromptTokens != 150 || st.CompletionTokens != 50 || st.TotalTokens != 200 {
+		t.Errorf("Get(alice) = %+v", st)
+	}
+	if st.DayRequests != 2 || st.DayTokens != 200 || st.Day != "2024-03-10" {
+		t.Errorf("Get(alice) day counters = %+v", st)
+	}
+
+	// Daily counters roll over while cumulative totals are kept
+	clk.Advance(24 * time.Hour)
+	st = tr.Get("alice")
+	if st.DayTokens != 0 || st.DayRequests != 0 || st.TotalTokens != 200 {
+		t.Errorf("Get(alice) next day = %+v", st)
+	}
+
+	tr.Record("alice", llm.Usage{TotalTokens: 5})
+	if st := tr.Get("alice"); st.DayTokens != 5 || st.TotalTokens != 205 || st.Requests != 3 {
+		t.Errorf("Get(alice) after next-day request = %+v", st)
+	}
+
+	if all := tr.All(); len(all) != 2 || all["bob"].TotalTokens != 10 {
+		t.Errorf("All() = %+v", all)
+	}
+
+	tr.Reset("alice")
+	if st := tr.Get("alice"); st.Requests != 0 {
+		t.Errorf("Get(alice) after Reset = %+v", st)
+	}
+	tr.Reset("")
+	if all := tr.All(); len(all) != 0 {
+		t.Errorf("All() after Reset(\"\") = %+v", all)
+	}
+}
+
+func TestTrackerDailyLimit(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC))
+	tr := newTestTracker(1000, clk)
+
+	tr.Record("alice", llm.Usage{TotalTokens: 999})
+	if tr.LimitReached("alice") {
+		t.Error("LimitReached() = true below the cap")
+	}
+
+	tr.Record("alice", llm.Usage{TotalTokens: 1})
+	if !tr.LimitReached("alice") {
+		t.Error("LimitReached() = false at the cap")
+	}
+	if tr.LimitReached("bob") {
+		t.Error("LimitReached() = true for a user with no usage")
+	}
+
+	clk.Advance(24 * time.Hour)
+	if tr.LimitReached("alice") {
+		t.Error("LimitReached() = true on the next day")
+	}
+}
+
+func TestTrackerNoLimit(t *testing.T) {
+	tr := newTestTracker(0, clock.Real())
+	tr.Record("alice", llm.Usage{TotalTokens: 1 << 30})
+	if tr.LimitReached("alice") {
+		t.Error("LimitReached() = true with the cap disabled")
+	}
+}